@@ -0,0 +1,214 @@
+// Command migrar convierte los archivos de transacciones escritos por
+// versiones anteriores del servidor al formato actual.
+//
+// Uso:
+//
+//	go run ./cmd/migrar -tarea montos -archivo ./transacciones.json
+//	go run ./cmd/migrar -tarea fechas -zona America/Mexico_City -archivo ./transacciones.json
+//	go run ./cmd/migrar -tarea partes -partes ./partes.json -tipos "Bancomer=banco,Banamex=banco" -tipo persona -archivo ./transacciones.json
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+)
+
+// registro es una transaccion tal como puede estar guardada en un archivo
+// anterior: la fecha se lee como texto para aceptar el formato dd/mm/aaaa, y
+// el estado se conserva vacio si el archivo aun no lo tiene.
+type registro struct {
+	transacciones.Transaccion
+	FechaTransaccion string `json:"fecha_transaccion"`
+	Estado           string `json:"estado,omitempty"`
+}
+
+// configuracion reune los parametros que usan las migraciones.
+type configuracion struct {
+	zona *time.Location
+	// partes es el registro de partes; tipos clasifica las partes nuevas por
+	// ClaveNombre y tipo es el de las que no aparecen en tipos, si se indico.
+	partes store.Store
+	tipos  map[string]partes.Tipo
+	tipo   partes.Tipo
+}
+
+// tareas contiene las migraciones disponibles por nombre.
+var tareas = map[string]func(lista []registro, config configuracion) ([]registro, error){
+	"montos": migrarMontos,
+	"fechas": migrarFechas,
+	"partes": migrarPartes,
+}
+
+func main() {
+	tarea := flag.String("tarea", "", "migracion a ejecutar: montos, fechas, partes")
+	archivo := flag.String("archivo", "./transacciones.json", "archivo json de transacciones")
+	nombreZona := flag.String("zona", os.Getenv("ZONA_HORARIA"), "zona horaria IANA de las fechas sin zona")
+	archivoPartes := flag.String("partes", "./partes.json", "archivo json del registro de partes")
+	textoTipos := flag.String("tipos", "", "tipo de cada parte nueva como nombre=tipo separados por comas, p. ej. Bancomer=banco,Pedrito=persona")
+	nombreTipo := flag.String("tipo", "", "tipo de las partes nuevas que no aparecen en -tipos; sin el, todas deben aparecer")
+	flag.Parse()
+
+	migrar, ok := tareas[*tarea]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: tarea %q no soportada\n", *tarea)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	zona, err := fecha.CargarZona(*nombreZona)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: zona horaria %q no valida\n", *nombreZona)
+		os.Exit(2)
+	}
+
+	var tipo partes.Tipo
+	if *nombreTipo != "" {
+		if tipo, err = partes.ParseTipo(*nombreTipo); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(2)
+		}
+	}
+	tipos, err := parseTipos(*textoTipos)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(2)
+	}
+
+	config := configuracion{zona: zona, partes: &store.JsonFileStore{FileName: *archivoPartes}, tipos: tipos, tipo: tipo}
+	if err := ejecutar(*archivo, config, migrar); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseTipos lee la clasificacion nombre=tipo,nombre=tipo de -tipos.
+func parseTipos(texto string) (map[string]partes.Tipo, error) {
+	tipos := map[string]partes.Tipo{}
+	for _, par := range strings.Split(texto, ",") {
+		if strings.TrimSpace(par) == "" {
+			continue
+		}
+		separador := strings.LastIndex(par, "=")
+		if separador < 0 || partes.ClaveNombre(par[:separador]) == "" {
+			return nil, fmt.Errorf("clasificacion %q no valida, se espera nombre=tipo", par)
+		}
+		tipo, err := partes.ParseTipo(par[separador+1:])
+		if err != nil {
+			return nil, err
+		}
+		tipos[partes.ClaveNombre(par[:separador])] = tipo
+	}
+	return tipos, nil
+}
+
+func ejecutar(archivo string, config configuracion, migrar func([]registro, configuracion) ([]registro, error)) error {
+	db := &store.JsonFileStore{FileName: archivo}
+	if err := db.Recover(); err != nil {
+		return err
+	}
+	if err := db.Lock(); err != nil {
+		return err
+	}
+	defer db.Unlock()
+
+	var lista []registro
+	if err := db.Read(&lista); err != nil {
+		return err
+	}
+
+	migrados, err := migrar(lista, config)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Write(migrados); err != nil {
+		return err
+	}
+	fmt.Printf("%d transacciones migradas en %s\n", len(migrados), archivo)
+	return nil
+}
+
+// migrarMontos reescribe los montos, que antes se guardaban como numeros
+// flotantes, como texto decimal exacto con los decimales de su moneda.
+func migrarMontos(lista []registro, _ configuracion) ([]registro, error) {
+	originales := make([]transacciones.Transaccion, len(lista))
+	for index, r := range lista {
+		originales[index] = r.Transaccion
+	}
+
+	normalizadas, err := transacciones.NormalizarMontos(originales)
+	if err != nil {
+		return nil, errors.New("no se logro convertir el monto de la " + err.Error())
+	}
+
+	for index := range lista {
+		lista[index].Monto = normalizadas[index].Monto
+	}
+	return lista, nil
+}
+
+// migrarFechas reescribe las fechas dd/mm/aaaa como RFC 3339, ubicandolas a
+// medianoche en la zona indicada. Las que ya tienen zona se conservan.
+func migrarFechas(lista []registro, config configuracion) ([]registro, error) {
+	for index, r := range lista {
+		valor, err := fecha.Parse(r.FechaTransaccion, config.zona)
+		if err != nil {
+			return nil, fmt.Errorf("no se logro convertir la fecha de la transaccion %d: %w", r.Id, err)
+		}
+		lista[index].FechaTransaccion = valor.Format(time.RFC3339Nano)
+	}
+	return lista, nil
+}
+
+// migrarPartes registra como partes los emisores y receptores escritos como
+// texto libre, tratando como una misma parte los nombres que solo difieren en
+// mayusculas o espacios, y asigna a cada transaccion emisor_id y receptor_id
+// con el nombre de la parte. Las partes que ya estan registradas se reutilizan,
+// por lo que la migracion se puede repetir. Si falta el tipo de alguna parte
+// nueva no se escribe nada.
+func migrarPartes(lista []registro, config configuracion) ([]registro, error) {
+	unlock, err := store.Lock(config.partes)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var registradas []partes.Parte
+	if err := config.partes.Read(&registradas); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		return nil, err
+	}
+
+	nombres := make([]string, 0, len(lista)*2)
+	for _, r := range lista {
+		nombres = append(nombres, r.Emisor, r.Receptor)
+	}
+	registro, porNombre, err := partes.Deduplicar(registradas, nombres, config.tipos, config.tipo)
+	if err != nil {
+		return nil, fmt.Errorf("%w; clasificalas con -tipos o indica -tipo", err)
+	}
+
+	for index, r := range lista {
+		emisor, okEmisor := porNombre[r.Emisor]
+		receptor, okReceptor := porNombre[r.Receptor]
+		if !okEmisor || !okReceptor {
+			return nil, fmt.Errorf("la transaccion %d no tiene emisor o receptor", r.Id)
+		}
+		lista[index].Emisor, lista[index].EmisorId = emisor.Nombre, emisor.Id
+		lista[index].Receptor, lista[index].ReceptorId = receptor.Nombre, receptor.Id
+	}
+
+	if err := config.partes.Write(registro); err != nil {
+		return nil, err
+	}
+	fmt.Printf("%d partes registradas a partir de %d nombres\n", len(registro)-len(registradas), len(porNombre))
+	return lista, nil
+}
@@ -0,0 +1,82 @@
+// Command mt940 lee estados de cuenta SWIFT MT940 y escribe sus movimientos
+// normalizados como JSON, una linea por movimiento, para usarlos en la
+// conciliacion.
+//
+// Uso:
+//
+//	go run ./cmd/mt940 -zona America/Mexico_City extracto.mt940 otro.sta
+//	go run ./cmd/mt940 -extractos < extracto.mt940
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/mt940"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+)
+
+func main() {
+	nombreZona := flag.String("zona", os.Getenv("ZONA_HORARIA"), "zona horaria IANA de las fechas del extracto")
+	extractos := flag.Bool("extractos", false, "escribe un extracto completo por linea en lugar de sus movimientos")
+	flag.Parse()
+
+	zona, err := fecha.CargarZona(*nombreZona)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: zona horaria %q no valida\n", *nombreZona)
+		os.Exit(2)
+	}
+
+	archivos := flag.Args()
+	if len(archivos) == 0 {
+		// Sin archivos se lee la entrada estandar.
+		archivos = []string{"-"}
+	}
+	salida := json.NewEncoder(os.Stdout)
+	for _, archivo := range archivos {
+		if err := convertir(archivo, zona, *extractos, salida); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %s\n", archivo, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// convertir lee un archivo, o la entrada estandar si archivo es "-", y escribe
+// sus extractos o sus movimientos.
+func convertir(archivo string, zona *time.Location, extractos bool, salida *json.Encoder) error {
+	var entrada io.Reader = os.Stdin
+	if archivo != "-" {
+		f, err := os.Open(archivo)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		entrada = f
+	}
+
+	leidos, err := mt940.Parse(entrada, zona)
+	if err != nil {
+		return err
+	}
+	for _, extracto := range leidos {
+		for _, advertencia := range extracto.Advertencias {
+			fmt.Fprintf(os.Stderr, "advertencia: %s: extracto %s: %s\n", archivo, extracto.Referencia, advertencia)
+		}
+		if extractos {
+			if err := salida.Encode(extracto); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, linea := range extracto.Lineas {
+			if err := salida.Encode(linea); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
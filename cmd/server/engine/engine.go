@@ -1,11 +1,23 @@
 package engine
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/BrandonICR/web_cl2_050422_8am/cmd/server/handler"
 	"github.com/BrandonICR/web_cl2_050422_8am/cmd/server/route"
 	"github.com/BrandonICR/web_cl2_050422_8am/docs"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/conciliacion"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/divisas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/idempotencia"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/libro"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/limites"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/riesgo"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
 	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -13,6 +25,19 @@ import (
 	"github.com/swaggo/gin-swagger/swaggerFiles"
 )
 
+const (
+	SQLITE_STORE_TYPE           = "sqlite"
+	DEFAULT_SQLITE_FILE         = "./transacciones.db"
+	DEFAULT_TIPOS_CAMBIO_FILE   = "./tipos_cambio.json"
+	DEFAULT_IDEMPOTENCIA_FILE   = "./idempotencia.json"
+	DEFAULT_IDEMPOTENCIA_TTL    = 24 * time.Hour
+	DEFAULT_LIBRO_FILE          = "./libro.json"
+	DEFAULT_PARTES_FILE         = "./partes.json"
+	DEFAULT_LIMITES_FILE        = "./limites.json"
+	DEFAULT_REGLAS_FILE         = "./reglas.txt"
+	DEFAULT_CONCILIACIONES_FILE = "./conciliaciones.json"
+)
+
 func copyFileStore(fileStore string, tempFileStore string) error {
 	data, err := os.ReadFile(fileStore)
 	if err != nil {
@@ -26,6 +51,131 @@ func copyFileStore(fileStore string, tempFileStore string) error {
 	return nil
 }
 
+// getZona regresa la zona horaria ZONA_HORARIA, o UTC si no esta definida.
+func getZona() *time.Location {
+	zona, err := fecha.CargarZona(os.Getenv("ZONA_HORARIA"))
+	if err != nil {
+		panic("error: la zona horaria no es valida")
+	}
+	return zona
+}
+
+// getRepository construye el repositorio de transacciones indicado por
+// STORE_TYPE: un store de archivo (jsonFile por defecto, o journalFile) sobre
+// fileStore, o una base sqlite en SQLITE_FILE.
+func getRepository(fileStore string, zona *time.Location) transacciones.Repository {
+	storeType := os.Getenv("STORE_TYPE")
+	if storeType == "" {
+		storeType = string(store.JsonFileType)
+	}
+
+	if storeType == SQLITE_STORE_TYPE {
+		sqliteFile := os.Getenv("SQLITE_FILE")
+		if sqliteFile == "" {
+			sqliteFile = DEFAULT_SQLITE_FILE
+		}
+		db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", sqliteFile))
+		if err != nil {
+			panic("error: no se logro abrir la base de datos")
+		}
+		if err := transacciones.MigrarSQL(db, zona); err != nil {
+			panic("error: no se lograron aplicar las migraciones")
+		}
+		return transacciones.NewSQLRepository(db)
+	}
+
+	db := store.NewStore(store.StoreType(storeType), fileStore)
+	if db == nil {
+		panic("error: tipo de store no soportado")
+	}
+	if err := store.Recover(db); err != nil {
+		panic("error: no se logro recuperar el file store")
+	}
+	return transacciones.NewRepository(db)
+}
+
+// getTiposCambio construye el repositorio de tipos de cambio sobre el archivo
+// TIPOS_CAMBIO_FILE.
+func getTiposCambio() divisas.Repository {
+	fileName := os.Getenv("TIPOS_CAMBIO_FILE")
+	if fileName == "" {
+		fileName = DEFAULT_TIPOS_CAMBIO_FILE
+	}
+	return divisas.NewRepository(store.NewStore(store.JsonFileType, fileName))
+}
+
+// getIdempotencia construye el repositorio de claves de idempotencia sobre el
+// archivo IDEMPOTENCIA_FILE; las claves vencen tras IDEMPOTENCIA_TTL.
+func getIdempotencia() idempotencia.Repository {
+	fileName := os.Getenv("IDEMPOTENCIA_FILE")
+	if fileName == "" {
+		fileName = DEFAULT_IDEMPOTENCIA_FILE
+	}
+
+	ventana := DEFAULT_IDEMPOTENCIA_TTL
+	if texto := os.Getenv("IDEMPOTENCIA_TTL"); texto != "" {
+		var err error
+		if ventana, err = time.ParseDuration(texto); err != nil || ventana <= 0 {
+			panic("error: IDEMPOTENCIA_TTL no es una duracion valida")
+		}
+	}
+	return idempotencia.NewRepository(store.NewStore(store.JsonFileType, fileName), ventana)
+}
+
+// getLibro construye el repositorio del libro mayor sobre el archivo
+// LIBRO_FILE.
+func getLibro() libro.Repository {
+	fileName := os.Getenv("LIBRO_FILE")
+	if fileName == "" {
+		fileName = DEFAULT_LIBRO_FILE
+	}
+	return libro.NewRepository(store.NewStore(store.JsonFileType, fileName))
+}
+
+// getPartes construye el registro de partes sobre el archivo PARTES_FILE.
+func getPartes() partes.Repository {
+	fileName := os.Getenv("PARTES_FILE")
+	if fileName == "" {
+		fileName = DEFAULT_PARTES_FILE
+	}
+	return partes.NewRepository(store.NewStore(store.JsonFileType, fileName))
+}
+
+// getLimites construye el repositorio de limites por emisor sobre el archivo
+// LIMITES_FILE.
+func getLimites() limites.Repository {
+	fileName := os.Getenv("LIMITES_FILE")
+	if fileName == "" {
+		fileName = DEFAULT_LIMITES_FILE
+	}
+	return limites.NewRepository(store.NewStore(store.JsonFileType, fileName))
+}
+
+// getReglas construye el repositorio de reglas de riesgo sobre el archivo
+// REGLAS_FILE. Las reglas se validan al arrancar para no descubrir un archivo
+// mal escrito hasta la primera transaccion.
+func getReglas() riesgo.Repository {
+	fileName := os.Getenv("REGLAS_FILE")
+	if fileName == "" {
+		fileName = DEFAULT_REGLAS_FILE
+	}
+	reglas := riesgo.NewRepository(fileName)
+	if _, err := reglas.GetAll(); err != nil {
+		panic(fmt.Sprintf("error: las reglas de riesgo no son validas: %v", err))
+	}
+	return reglas
+}
+
+// getConciliaciones construye el repositorio de reportes de conciliacion sobre
+// el archivo CONCILIACIONES_FILE.
+func getConciliaciones() conciliacion.Repository {
+	fileName := os.Getenv("CONCILIACIONES_FILE")
+	if fileName == "" {
+		fileName = DEFAULT_CONCILIACIONES_FILE
+	}
+	return conciliacion.NewRepository(store.NewStore(store.JsonFileType, fileName))
+}
+
 func GetEngine(fileStore string, tempFileStore string, fileEnv string) *gin.Engine {
 	if fileEnv != "" {
 		if err := godotenv.Load(fileEnv); err != nil {
@@ -44,7 +194,17 @@ func GetEngine(fileStore string, tempFileStore string, fileEnv string) *gin.Engi
 		fileStore = tempFileStore
 	}
 
-	store := store.NewStore(store.JsonFileType, fileStore)
+	zona := getZona()
+	repositories := route.Repositories{
+		Transacciones:  getRepository(fileStore, zona),
+		TiposCambio:    getTiposCambio(),
+		Idempotencia:   getIdempotencia(),
+		Libro:          getLibro(),
+		Partes:         getPartes(),
+		Limites:        getLimites(),
+		Reglas:         getReglas(),
+		Conciliaciones: getConciliaciones(),
+	}
 
 	router := gin.Default()
 
@@ -52,7 +212,7 @@ func GetEngine(fileStore string, tempFileStore string, fileEnv string) *gin.Engi
 	router.GET("docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	router.Use(handler.ValidarToken())
-	routes := route.NewRouter(router, &store)
+	routes := route.NewRouter(router, repositories, zona)
 	routes.MapRoutes()
 
 	return router
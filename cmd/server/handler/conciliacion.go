@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/conciliacion"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/mt940"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FUENTE_JSON  = "json"
+	FUENTE_MT940 = "mt940"
+)
+
+type lineaConciliacionRequest struct {
+	CodigoTransaccion string       `json:"codigo_transaccion" example:"ctr2"`
+	Moneda            string       `json:"moneda" example:"MXN"`
+	Monto             dinero.Monto `json:"monto" swaggertype:"string" example:"-4000.00"`
+	Fecha             string       `json:"fecha" example:"2022-04-21"`
+	Descripcion       string       `json:"descripcion"`
+}
+
+type conciliacionRequest struct {
+	ParteId         int                        `json:"parte_id"`
+	FechaDesde      string                     `json:"fecha_desde"`
+	FechaHasta      string                     `json:"fecha_hasta"`
+	ToleranciaMonto *dinero.Monto              `json:"tolerancia_monto" swaggertype:"string" example:"0.50"`
+	ToleranciaDias  int                        `json:"tolerancia_dias"`
+	Lineas          []lineaConciliacionRequest `json:"lineas"`
+}
+
+// resumenConciliacion es una conciliacion sin sus grupos, para listarlas.
+type resumenConciliacion struct {
+	Id         int                     `json:"id"`
+	Creada     time.Time               `json:"creada"`
+	Fuente     string                  `json:"fuente"`
+	ParteId    int                     `json:"parte_id,omitempty"`
+	Desde      time.Time               `json:"desde"`
+	Hasta      time.Time               `json:"hasta"`
+	Tolerancia conciliacion.Tolerancia `json:"tolerancia"`
+	Resumen    conciliacion.Resumen    `json:"resumen"`
+}
+
+type Conciliacion struct {
+	service conciliacion.Service
+	zona    *time.Location
+}
+
+// NewConciliacion crea el handler de conciliaciones; zona es la de las fechas
+// que se reciben sin zona.
+func NewConciliacion(s conciliacion.Service, zona *time.Location) *Conciliacion {
+	return &Conciliacion{service: s, zona: zona}
+}
+
+// statusConciliacion traduce los errores de la conciliacion a su codigo http.
+func statusConciliacion(err error) int {
+	switch {
+	case errors.Is(err, conciliacion.ErrConciliacionNoValida), errors.Is(err, mt940.ErrMT940NoValido):
+		return http.StatusBadRequest
+	case errors.Is(err, conciliacion.ErrConciliacionNoEncontrada):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// solicitudDe valida los parametros comunes al json y al MT940.
+func (c *Conciliacion) solicitudDe(request conciliacionRequest) (conciliacion.Solicitud, error) {
+	solicitud := conciliacion.Solicitud{
+		ParteId:    request.ParteId,
+		Tolerancia: conciliacion.Tolerancia{Monto: dinero.Nuevo(0, 2), Dias: request.ToleranciaDias},
+	}
+	if request.ToleranciaMonto != nil {
+		solicitud.Tolerancia.Monto = *request.ToleranciaMonto
+	}
+	if request.FechaDesde != "" {
+		desde, err := fecha.Parse(request.FechaDesde, c.zona)
+		if err != nil {
+			return conciliacion.Solicitud{}, fmt.Errorf("%w: fecha_desde: %v", conciliacion.ErrConciliacionNoValida, err)
+		}
+		solicitud.Desde = &desde
+	}
+	if request.FechaHasta != "" {
+		hasta, err := fecha.ParseHasta(request.FechaHasta, c.zona)
+		if err != nil {
+			return conciliacion.Solicitud{}, fmt.Errorf("%w: fecha_hasta: %v", conciliacion.ErrConciliacionNoValida, err)
+		}
+		solicitud.Hasta = &hasta
+	}
+	return solicitud, nil
+}
+
+// solicitudJSON lee el extracto y los parametros de un cuerpo json.
+func (c *Conciliacion) solicitudJSON(ctx *gin.Context) (conciliacion.Solicitud, error) {
+	var request conciliacionRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		return conciliacion.Solicitud{}, fmt.Errorf("%w: %v", conciliacion.ErrConciliacionNoValida, err)
+	}
+	solicitud, err := c.solicitudDe(request)
+	if err != nil {
+		return conciliacion.Solicitud{}, err
+	}
+	solicitud.Fuente = FUENTE_JSON
+	for index, linea := range request.Lineas {
+		externa := conciliacion.Externa{
+			Linea:             index + 1,
+			CodigoTransaccion: linea.CodigoTransaccion,
+			Moneda:            linea.Moneda,
+			Monto:             linea.Monto,
+			Descripcion:       linea.Descripcion,
+		}
+		if linea.Fecha != "" {
+			valor, err := fecha.Parse(linea.Fecha, c.zona)
+			if err != nil {
+				return conciliacion.Solicitud{}, fmt.Errorf("%w: linea %d: %v", conciliacion.ErrConciliacionNoValida, index+1, err)
+			}
+			externa.Fecha = valor
+		}
+		solicitud.Externas = append(solicitud.Externas, externa)
+	}
+	return solicitud, nil
+}
+
+// solicitudMT940 lee un extracto MT940 del cuerpo o del campo archivo, con
+// los parametros en la query. La referencia del cliente de cada linea, o si
+// no la trae la referencia extremo a extremo, se toma como codigo.
+func (c *Conciliacion) solicitudMT940(ctx *gin.Context) (conciliacion.Solicitud, error) {
+	request := conciliacionRequest{FechaDesde: ctx.Query("fecha_desde"), FechaHasta: ctx.Query("fecha_hasta")}
+	var err error
+	if texto := ctx.Query("parte_id"); texto != "" {
+		if request.ParteId, err = strconv.Atoi(texto); err != nil {
+			return conciliacion.Solicitud{}, fmt.Errorf("%w: parte_id %q no es un numero", conciliacion.ErrConciliacionNoValida, texto)
+		}
+	}
+	if texto := ctx.Query("tolerancia_dias"); texto != "" {
+		if request.ToleranciaDias, err = strconv.Atoi(texto); err != nil {
+			return conciliacion.Solicitud{}, fmt.Errorf("%w: tolerancia_dias %q no es un numero", conciliacion.ErrConciliacionNoValida, texto)
+		}
+	}
+	if texto := ctx.Query("tolerancia_monto"); texto != "" {
+		monto, err := dinero.Parse(texto)
+		if err != nil {
+			return conciliacion.Solicitud{}, fmt.Errorf("%w: tolerancia_monto %q no es un monto", conciliacion.ErrConciliacionNoValida, texto)
+		}
+		request.ToleranciaMonto = &monto
+	}
+	solicitud, err := c.solicitudDe(request)
+	if err != nil {
+		return conciliacion.Solicitud{}, err
+	}
+	solicitud.Fuente = FUENTE_MT940
+
+	archivo, _, _, err := archivoImportacion(ctx)
+	if err != nil {
+		return conciliacion.Solicitud{}, fmt.Errorf("%w: %v", conciliacion.ErrConciliacionNoValida, err)
+	}
+	defer archivo.Close()
+	extractos, err := mt940.Parse(archivo, c.zona)
+	if err != nil {
+		return conciliacion.Solicitud{}, err
+	}
+	for _, linea := range mt940.Lineas(extractos) {
+		codigo := linea.Referencia
+		if codigo == "" {
+			codigo = linea.ReferenciaExtremo
+		}
+		descripcion := linea.Concepto
+		if descripcion == "" {
+			descripcion = linea.Detalle
+		}
+		solicitud.Externas = append(solicitud.Externas, conciliacion.Externa{
+			Linea:             linea.LineaArchivo,
+			CodigoTransaccion: codigo,
+			Moneda:            linea.Moneda,
+			Monto:             linea.Monto,
+			Fecha:             linea.Fecha,
+			Descripcion:       descripcion,
+		})
+	}
+	return solicitud, nil
+}
+
+// Reconcile a statement
+// @Summary Reconcile statement
+// @Tags Reconciliation
+// @Description Reconcile an external statement against the stored transactions and keep the report. The statement is either a json body with its lines or an MT940 file, sent as the body or as the archivo field of a multipart form, with the parameters in the query.
+// @Description Lines are first matched by codigo_transaccion and then, when no transaction has their code, by currency, amount and date. The sign of a line amount is ignored. A pair within both tolerances is conciliada; a pair by code within the day tolerance but outside the amount tolerance is a diferencia_monto; the rest end up in solo_internas or solo_externas with the reason.
+// @Description Without a period, the transactions from the day of the first line to the day of the last one, widened by tolerancia_dias, are reconciled. Rejected transactions are never reconciled.
+// @Accept json
+// @Accept plain
+// @Accept mpfd
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param conciliacion body conciliacionRequest false "statement lines and parameters, when sending json"
+// @Param archivo formData file false "MT940 file when sending a multipart form"
+// @Param parte_id query int false "MT940 only: reconcile the transactions where this party is emisor or receptor"
+// @Param fecha_desde query string false "MT940 only: from date or timestamp, inclusive"
+// @Param fecha_hasta query string false "MT940 only: to date or timestamp, inclusive; a date includes the whole day"
+// @Param tolerancia_monto query string false "MT940 only: amount tolerance, e.g. 0.50; defaults to 0"
+// @Param tolerancia_dias query int false "MT940 only: date tolerance in calendar days; defaults to 0"
+// @Succes 200 {object} web.Response
+// @Router /conciliaciones [POST]
+func (c *Conciliacion) Store() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, IMPORTACION_MAXIMO_BYTES)
+		tipo, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+
+		var solicitud conciliacion.Solicitud
+		var err error
+		if tipo == "application/json" {
+			solicitud, err = c.solicitudJSON(ctx)
+		} else {
+			solicitud, err = c.solicitudMT940(ctx)
+		}
+		if err != nil {
+			status := statusConciliacion(err)
+			ctx.JSON(status, web.NewResponse(status, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		resultado, err := c.service.Conciliar(solicitud)
+		if err != nil {
+			status := statusConciliacion(err)
+			ctx.JSON(status, web.NewResponse(status, "Error al conciliar el extracto", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Extracto conciliado con exito", resultado, ""))
+	}
+}
+
+// Get all reconciliations
+// @Summary Get all reconciliations
+// @Tags Reconciliation
+// @Description Get the stored reconciliation reports with their totals, without the matched and unmatched items
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Succes 200 {object} web.Response
+// @Router /conciliaciones [GET]
+func (c *Conciliacion) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lista, err := c.service.GetAll()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al recuperar las conciliaciones", nil, err.Error()))
+			return
+		}
+
+		resumenes := make([]resumenConciliacion, len(lista))
+		for index, item := range lista {
+			resumenes[index] = resumenConciliacion{Id: item.Id, Creada: item.Creada, Fuente: item.Fuente, ParteId: item.ParteId,
+				Desde: item.Desde, Hasta: item.Hasta, Tolerancia: item.Tolerancia, Resumen: item.Resumen}
+		}
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Conciliaciones recuperadas con exito", resumenes, ""))
+	}
+}
+
+// conciliacionDe busca la conciliacion del parametro Id o responde el error.
+func (c *Conciliacion) conciliacionDe(ctx *gin.Context) (conciliacion.Conciliacion, bool) {
+	id, err := strconv.Atoi(ctx.Param("Id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "No se selecciono la conciliacion", nil, err.Error()))
+		return conciliacion.Conciliacion{}, false
+	}
+	resultado, err := c.service.Get(id)
+	if err != nil {
+		status := statusConciliacion(err)
+		ctx.JSON(status, web.NewResponse(status, "Error al recuperar la conciliacion", nil, err.Error()))
+		return conciliacion.Conciliacion{}, false
+	}
+	return resultado, true
+}
+
+// Get a reconciliation
+// @Summary Get reconciliation
+// @Tags Reconciliation
+// @Description Get a stored reconciliation report with its conciliadas, diferencias_monto, solo_internas and solo_externas
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param Id path int true "Id"
+// @Succes 200 {object} web.Response
+// @Router /conciliaciones/{Id} [GET]
+func (c *Conciliacion) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		resultado, ok := c.conciliacionDe(ctx)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Conciliacion recuperada con exito", resultado, ""))
+	}
+}
+
+// Export a reconciliation
+// @Summary Export reconciliation
+// @Tags Reconciliation
+// @Description Export a stored reconciliation report as a CSV or JSON Lines file, one row per pair or unmatched item
+// @Accept json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param authorization header string true "authorization"
+// @Param Id path int true "Id"
+// @Param formato query string false "csv (default) or ndjson"
+// @Success 200 {file} file
+// @Router /conciliaciones/{Id}/exportar [GET]
+func (c *Conciliacion) Exportar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		formato := strings.ToLower(strings.TrimSpace(ctx.DefaultQuery("formato", conciliacion.FORMATO_CSV)))
+		tipoContenido, err := conciliacion.TipoContenido(formato)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		resultado, ok := c.conciliacionDe(ctx)
+		if !ok {
+			return
+		}
+
+		ctx.Header("Content-Type", tipoContenido)
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="conciliacion_%d.%s"`, resultado.Id, formato))
+		ctx.Status(http.StatusOK)
+		if err := conciliacion.Exportar(ctx.Writer, formato, resultado); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/libro"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type Cuenta struct {
+	service libro.Service
+}
+
+func NewCuenta(s libro.Service) *Cuenta {
+	return &Cuenta{service: s}
+}
+
+// Get account balance
+// @Summary Get account balance
+// @Tags Account
+// @Description Get the ledger balance of a party for each currency it has moved
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param nombre path string true "party name"
+// @Succes 200 {object} web.Response
+// @Router /cuentas/{nombre}/saldo [GET]
+func (c *Cuenta) GetSaldo() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		saldos, err := c.service.Saldos(ctx.Param("nombre"))
+
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, libro.ErrCuentaNoEncontrada) {
+				status = http.StatusNotFound
+			}
+			ctx.JSON(status, web.NewResponse(status, "Error al recuperar el saldo", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Saldo recuperado con exito", saldos, ""))
+	}
+}
+
+// Get account statement
+// @Summary Get account statement
+// @Tags Account
+// @Description Get the ledger entries of a party ordered by date with the running balance per currency
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param nombre path string true "party name"
+// @Param moneda query string false "only entries in this currency"
+// @Succes 200 {object} web.Response
+// @Router /cuentas/{nombre}/movimientos [GET]
+func (c *Cuenta) GetMovimientos() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		movimientos, err := c.service.Movimientos(ctx.Param("nombre"), strings.ToUpper(ctx.Query("moneda")))
+
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, libro.ErrCuentaNoEncontrada) {
+				status = http.StatusNotFound
+			}
+			ctx.JSON(status, web.NewResponse(status, "Error al recuperar los movimientos", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Movimientos recuperados con exito", movimientos, ""))
+	}
+}
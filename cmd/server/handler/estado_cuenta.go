@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/estadocuenta"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type EstadoCuenta struct {
+	service estadocuenta.Service
+	zona    *time.Location
+}
+
+// NewEstadoCuenta crea el handler de estados de cuenta; las fechas sin zona se
+// leen en zona y el periodo termina hoy si no se indica fecha_hasta.
+func NewEstadoCuenta(s estadocuenta.Service, zona *time.Location) *EstadoCuenta {
+	return &EstadoCuenta{service: s, zona: zona}
+}
+
+// Get the account statement of a party
+// @Summary Get account statement
+// @Tags Statement
+// @Description Get every transaction where the party is emisor or receptor in the period, grouped by currency, with the opening balance, the running balance after each line and the closing balance.
+// @Description Transactions the party issued are charges (negative) and the ones it received are credits. Rejected transactions are listed but do not change the balance. Without fecha_desde the opening balance is zero.
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Produce text/html
+// @Param authorization header string true "authorization"
+// @Param nombre path string true "registered party name, case and spacing insensitive"
+// @Param fecha_desde query string false "from date or timestamp, inclusive"
+// @Param fecha_hasta query string false "to date or timestamp, inclusive; a date includes the whole day. Defaults to now"
+// @Param moneda query string false "only this currency"
+// @Param formato query string false "json (default), csv or html (printable)"
+// @Success 200 {object} web.Response{data=estadocuenta.EstadoCuenta}
+// @Router /estados-cuenta/{nombre} [GET]
+func (e *EstadoCuenta) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		formato, desde, hasta, err := e.parametros(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		estado, err := e.service.Generar(ctx.Param("nombre"), ctx.Query("moneda"), desde, hasta)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, partes.ErrParteNoEncontrada):
+				status = http.StatusNotFound
+			case errors.Is(err, estadocuenta.ErrEstadoCuentaNoValido):
+				status = http.StatusBadRequest
+			}
+			ctx.JSON(status, web.NewResponse(status, "Error al generar el estado de cuenta", nil, err.Error()))
+			return
+		}
+
+		switch formato {
+		case estadocuenta.FORMATO_CSV:
+			ctx.Header("Content-Type", "text/csv; charset=utf-8")
+			ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="estado_cuenta_%d.csv"`, estado.ParteId))
+			ctx.Status(http.StatusOK)
+			err = estadocuenta.EscribirCSV(ctx.Writer, estado)
+		case estadocuenta.FORMATO_HTML:
+			ctx.Header("Content-Type", "text/html; charset=utf-8")
+			ctx.Status(http.StatusOK)
+			err = estadocuenta.EscribirHTML(ctx.Writer, estado)
+		default:
+			ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Estado de cuenta generado con exito", estado, ""))
+		}
+		if err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
+
+// parametros lee el formato y el periodo de la consulta.
+func (e *EstadoCuenta) parametros(ctx *gin.Context) (string, *time.Time, time.Time, error) {
+	formato, err := estadocuenta.ParseFormato(ctx.DefaultQuery("formato", estadocuenta.FORMATO_JSON))
+	if err != nil {
+		return "", nil, time.Time{}, err
+	}
+	var desde *time.Time
+	if texto, ok := ctx.GetQuery("fecha_desde"); ok {
+		valor, err := fecha.Parse(texto, e.zona)
+		if err != nil {
+			return "", nil, time.Time{}, fmt.Errorf("fecha_desde: %w", err)
+		}
+		desde = &valor
+	}
+	hasta := time.Now().In(e.zona)
+	if texto, ok := ctx.GetQuery("fecha_hasta"); ok {
+		if hasta, err = fecha.ParseHasta(texto, e.zona); err != nil {
+			return "", nil, time.Time{}, fmt.Errorf("fecha_hasta: %w", err)
+		}
+	}
+	return formato, desde, hasta, nil
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/exportacion"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+// Export transactions to a file
+// @Summary Export transactions
+// @Tags Transaction
+// @Description Export the transactions that match the same filters as GET /transacciones/ as a CSV, JSON Lines or Excel file.
+// @Description Rows are streamed page by page; an error after the first byte ends the file early.
+// @Accept json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param authorization header string true "authorization"
+// @Param formato query string false "csv (default), ndjson or xlsx"
+// @Param columnas query string false "comma separated columns in order; defaults to id,codigo_transaccion,moneda,monto,emisor,receptor,fecha_transaccion,estado"
+// @Param locale query string false "number format of the csv amounts, e.g. es-MX or es-ES; a decimal comma also separates columns with ;"
+// @Param id query int false "id"
+// @Param codigo_transaccion query string false "codigo_transaccion"
+// @Param moneda query string false "one or more comma separated currencies, e.g. MXN,USD"
+// @Param monto query string false "exact amount, 0 included"
+// @Param monto_min query string false "minimum amount, inclusive"
+// @Param monto_max query string false "maximum amount, inclusive"
+// @Param emisor query string false "emisor"
+// @Param emisor_coincidencia query string false "how emisor is matched: exacta (default), prefijo or contiene"
+// @Param receptor query string false "receptor"
+// @Param fecha_transaccion query string false "day of the transaction: dd/mm/yyyy, yyyy-mm-dd or RFC 3339"
+// @Param fecha_desde query string false "from date or timestamp, inclusive"
+// @Param fecha_hasta query string false "to date or timestamp, inclusive; a date includes the whole day"
+// @Param estado query string false "comma separated states: pendiente, autorizada, liquidada, rechazada, revertida"
+// @Param sort query string false "comma separated fields, - for descending, e.g. -monto,fecha_transaccion"
+// @Success 200 {file} file
+// @Router /transacciones/exportar [GET]
+func (t *Transaccion) Exportar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		formato, err := exportacion.ParseFormato(ctx.DefaultQuery("formato", "csv"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		columnas, err := exportacion.ParseColumnas(ctx.Query("columnas"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		localizacion, err := exportacion.ParseLocalizacion(ctx.Query("locale"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		filtro, err := filtroDesdeQuery(ctx, t.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		orden, err := transacciones.ParseOrden(ctx.Query("sort"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		// La primera pagina se consulta antes de responder para que un error
+		// todavia pueda regresarse como json.
+		consulta := transacciones.Consulta{Filtro: filtro, Orden: orden, Limite: transacciones.LIMITE_MAXIMO}
+		pagina, err := t.service.Listar(consulta)
+		if errors.Is(err, transacciones.ErrSinResultados) {
+			err = nil
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al exportar las transacciones", nil, err.Error()))
+			return
+		}
+
+		nombreArchivo := fmt.Sprintf("transacciones_%s.%s", time.Now().In(t.zona).Format("20060102-150405"), formato.Extension)
+		ctx.Header("Content-Type", formato.TipoContenido)
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, nombreArchivo))
+		ctx.Status(http.StatusOK)
+
+		escritor := formato.NewEscritor(ctx.Writer, columnas, localizacion)
+		for {
+			for _, transaccion := range pagina.Transacciones {
+				if err := escritor.Escribir(transaccion); err != nil {
+					_ = ctx.Error(err)
+					return
+				}
+			}
+			ctx.Writer.Flush()
+			if pagina.SiguienteCursor == "" {
+				break
+			}
+
+			consulta.Cursor = pagina.SiguienteCursor
+			pagina, err = t.service.Listar(consulta)
+			if errors.Is(err, transacciones.ErrSinResultados) {
+				break
+			}
+			if err != nil {
+				_ = ctx.Error(err)
+				return
+			}
+		}
+		if err := escritor.Cerrar(); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/mt940"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type Extracto struct {
+	zona *time.Location
+}
+
+// NewExtracto crea el handler de extractos bancarios; zona es la de las
+// fechas del extracto, que no traen zona.
+func NewExtracto(zona *time.Location) *Extracto {
+	return &Extracto{zona: zona}
+}
+
+// Parse an MT940 statement
+// @Summary Parse MT940 statement
+// @Tags Statement
+// @Description Parse a SWIFT MT940 file, sent as the body or as the archivo field of a multipart form, into normalized statement lines for reconciliation. Nothing is stored.
+// @Description The common bank dialects are accepted: with or without the SWIFT envelope, CRLF line breaks, comma or dot decimals, optional entry date, :28: or :28C:, statements without the closing "-" and a free, ?NN or /KEY/ structured :86:.
+// @Description Amounts are negative for debits. Balances that do not add up are reported as advertencias of the statement.
+// @Accept plain
+// @Accept mpfd
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param archivo formData file false "file to parse when sending a multipart form"
+// @Succes 200 {object} web.Response
+// @Router /extractos/mt940 [POST]
+func (e *Extracto) MT940() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, IMPORTACION_MAXIMO_BYTES)
+		archivo, _, _, err := archivoImportacion(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		defer archivo.Close()
+
+		extractos, err := mt940.Parse(archivo, e.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Extracto leido con exito", extractos, ""))
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/gin-gonic/gin"
+)
+
+// filtroDesdeQuery arma el filtro con los parametros presentes en la query.
+// Un parametro presente siempre filtra, aunque su valor sea cero, y uno con
+// formato no valido es un error.
+func filtroDesdeQuery(ctx *gin.Context, zona *time.Location) (transacciones.Filtro, error) {
+	var filtro transacciones.Filtro
+
+	if texto, ok := ctx.GetQuery("id"); ok {
+		id, err := strconv.Atoi(texto)
+		if err != nil {
+			return filtro, fmt.Errorf("el parametro id no es un entero: %q", texto)
+		}
+		filtro.Id = &id
+	}
+	if codigo, ok := ctx.GetQuery("codigo_transaccion"); ok {
+		filtro.CodigoTransaccion = &codigo
+	}
+	if valores, ok := ctx.GetQueryArray("moneda"); ok {
+		for _, valor := range valores {
+			for _, moneda := range strings.Split(valor, ",") {
+				filtro.Monedas = append(filtro.Monedas, strings.ToUpper(strings.TrimSpace(moneda)))
+			}
+		}
+	}
+
+	var err error
+	if filtro.Monto, err = montoDesdeQuery(ctx, "monto"); err != nil {
+		return filtro, err
+	}
+	if filtro.MontoMin, err = montoDesdeQuery(ctx, "monto_min"); err != nil {
+		return filtro, err
+	}
+	if filtro.MontoMax, err = montoDesdeQuery(ctx, "monto_max"); err != nil {
+		return filtro, err
+	}
+
+	if emisor, ok := ctx.GetQuery("emisor"); ok {
+		coincidencia, err := transacciones.ParseCoincidencia(ctx.Query("emisor_coincidencia"))
+		if err != nil {
+			return filtro, err
+		}
+		filtro.Emisor = &transacciones.Texto{Valor: emisor, Coincidencia: coincidencia}
+	}
+	if receptor, ok := ctx.GetQuery("receptor"); ok {
+		filtro.Receptor = &receptor
+	}
+
+	// fecha_transaccion selecciona un dia completo; fecha_desde y fecha_hasta
+	// pueden acotarlo aun mas.
+	if texto, ok := ctx.GetQuery("fecha_transaccion"); ok {
+		dia, err := fecha.Parse(texto, zona)
+		if err != nil {
+			return filtro, err
+		}
+		inicio, fin := fecha.Dia(dia)
+		fin = fin.Add(-time.Nanosecond)
+		filtro.FechaDesde, filtro.FechaHasta = &inicio, &fin
+	}
+	if texto, ok := ctx.GetQuery("fecha_desde"); ok {
+		desde, err := fecha.Parse(texto, zona)
+		if err != nil {
+			return filtro, err
+		}
+		if filtro.FechaDesde == nil || desde.After(*filtro.FechaDesde) {
+			filtro.FechaDesde = &desde
+		}
+	}
+	if texto, ok := ctx.GetQuery("fecha_hasta"); ok {
+		hasta, err := fecha.ParseHasta(texto, zona)
+		if err != nil {
+			return filtro, err
+		}
+		if filtro.FechaHasta == nil || hasta.Before(*filtro.FechaHasta) {
+			filtro.FechaHasta = &hasta
+		}
+	}
+
+	if valores, ok := ctx.GetQueryArray("estado"); ok {
+		for _, valor := range valores {
+			for _, texto := range strings.Split(valor, ",") {
+				estado, err := transacciones.ParseEstado(texto)
+				if err != nil {
+					return filtro, err
+				}
+				filtro.Estados = append(filtro.Estados, estado)
+			}
+		}
+	}
+
+	return filtro, nil
+}
+
+func montoDesdeQuery(ctx *gin.Context, parametro string) (*dinero.Monto, error) {
+	texto, ok := ctx.GetQuery(parametro)
+	if !ok {
+		return nil, nil
+	}
+	monto, err := dinero.Parse(texto)
+	if err != nil {
+		return nil, fmt.Errorf("el parametro %s no es un monto valido: %q", parametro, texto)
+	}
+	return &monto, nil
+}
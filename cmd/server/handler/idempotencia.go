@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/idempotencia"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HEADER_IDEMPOTENCIA = "Idempotency-Key"
+	HEADER_REPETIDA     = "Idempotency-Replayed"
+)
+
+// grabadorRespuesta copia el cuerpo de la respuesta mientras se escribe.
+type grabadorRespuesta struct {
+	gin.ResponseWriter
+	cuerpo bytes.Buffer
+}
+
+func (g *grabadorRespuesta) Write(data []byte) (int, error) {
+	g.cuerpo.Write(data)
+	return g.ResponseWriter.Write(data)
+}
+
+func (g *grabadorRespuesta) WriteString(s string) (int, error) {
+	g.cuerpo.WriteString(s)
+	return g.ResponseWriter.WriteString(s)
+}
+
+// Idempotencia repite la respuesta original a los reintentos que traen el
+// mismo Idempotency-Key, la misma ruta y consulta, el mismo Accept y el mismo
+// cuerpo. Reusar la clave con otro cuerpo
+// responde 422 y reusarla mientras la primera peticion sigue en curso, 409.
+// Las respuestas 5xx no se guardan para que el reintento vuelva a procesarse.
+func Idempotencia(s idempotencia.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clave, ok := ctx.Request.Header[http.CanonicalHeaderKey(HEADER_IDEMPOTENCIA)]
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		cuerpo, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(cuerpo))
+
+		suma := sha256.New()
+		suma.Write([]byte(ctx.Request.Method + " " + ctx.Request.URL.Path + "?" + ctx.Request.URL.RawQuery + "\n"))
+		suma.Write([]byte(ctx.GetHeader("Accept") + "\n"))
+		suma.Write(cuerpo)
+		hash := hex.EncodeToString(suma.Sum(nil))
+
+		respuesta, err := s.Iniciar(clave[0], hash)
+		switch {
+		case errors.Is(err, idempotencia.ErrClaveNoValida):
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		case errors.Is(err, idempotencia.ErrClaveReutilizada):
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, web.NewResponse(http.StatusUnprocessableEntity, "Peticion no valida", nil, err.Error()))
+			return
+		case errors.Is(err, idempotencia.ErrEnCurso):
+			ctx.AbortWithStatusJSON(http.StatusConflict, web.NewResponse(http.StatusConflict, "Peticion en curso", nil, err.Error()))
+			return
+		case err != nil:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al verificar la clave de idempotencia", nil, err.Error()))
+			return
+		}
+
+		if respuesta != nil {
+			ctx.Header(HEADER_REPETIDA, "true")
+			ctx.Data(respuesta.Status, "application/json; charset=utf-8", respuesta.Cuerpo)
+			ctx.Abort()
+			return
+		}
+
+		grabador := &grabadorRespuesta{ResponseWriter: ctx.Writer}
+		ctx.Writer = grabador
+		ctx.Next()
+
+		if ctx.Writer.Status() >= http.StatusInternalServerError {
+			_ = s.Cancelar(clave[0])
+			return
+		}
+		if err := s.Completar(clave[0], hash, idempotencia.Respuesta{Status: ctx.Writer.Status(), Cuerpo: grabador.cuerpo.Bytes()}); err != nil {
+			_ = s.Cancelar(clave[0])
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FORMATO_CSV    = "csv"
+	FORMATO_NDJSON = "ndjson"
+
+	MODO_COMPLETO = "completo"
+	MODO_PARCIAL  = "parcial"
+
+	FILA_ACEPTADA  = "aceptada"
+	FILA_RECHAZADA = "rechazada"
+	FILA_CANCELADA = "cancelada"
+
+	// IMPORTACION_MAXIMO_BYTES acota el tamano del archivo que se importa.
+	IMPORTACION_MAXIMO_BYTES = 10 << 20
+)
+
+var ErrImportacionNoValida = errors.New("la importacion no es valida")
+
+// camposImportacion son los campos de request que se leen de cada fila.
+var camposImportacion = []string{"codigo_transaccion", "moneda", "monto", "emisor_id", "receptor_id", "fecha_transaccion"}
+
+// filaImportada es una fila leida del archivo con sus valores por campo.
+type filaImportada struct {
+	linea   int
+	valores map[string]string
+}
+
+type filaReporte struct {
+	Linea             int    `json:"linea"`
+	Estado            string `json:"estado" example:"aceptada"`
+	Id                int    `json:"id,omitempty"`
+	CodigoTransaccion string `json:"codigo_transaccion,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// reporteImportacion indica que paso con cada fila: aceptada si se guardo,
+// rechazada si no cumplio alguna regla y cancelada si en modo completo no se
+// guardo por culpa de otra fila.
+type reporteImportacion struct {
+	Modo       string        `json:"modo"`
+	Aceptadas  int           `json:"aceptadas"`
+	Rechazadas int           `json:"rechazadas"`
+	Canceladas int           `json:"canceladas"`
+	Filas      []filaReporte `json:"filas"`
+}
+
+func (r *reporteImportacion) agregar(fila filaReporte) {
+	switch fila.Estado {
+	case FILA_ACEPTADA:
+		r.Aceptadas++
+	case FILA_RECHAZADA:
+		r.Rechazadas++
+	default:
+		r.Canceladas++
+	}
+	r.Filas = append(r.Filas, fila)
+}
+
+// parseMapeo lee pares campo:columna separados por comas. Los campos que no se
+// mapean se buscan en la columna con su mismo nombre.
+func parseMapeo(texto string) (map[string]string, error) {
+	mapeo := make(map[string]string, len(camposImportacion))
+	for _, campo := range camposImportacion {
+		mapeo[campo] = campo
+	}
+	if strings.TrimSpace(texto) == "" {
+		return mapeo, nil
+	}
+	for _, par := range strings.Split(texto, ",") {
+		partes := strings.SplitN(par, ":", 2)
+		campo := strings.TrimSpace(partes[0])
+		var columna string
+		if len(partes) == 2 {
+			columna = strings.TrimSpace(partes[1])
+		}
+		if _, conocido := mapeo[campo]; !conocido || columna == "" {
+			return nil, fmt.Errorf("%w: mapeo %q, se espera campo:columna con campo entre %s", ErrImportacionNoValida, par, strings.Join(camposImportacion, ", "))
+		}
+		mapeo[campo] = columna
+	}
+	return mapeo, nil
+}
+
+// formatoImportacion regresa el formato indicado con el parametro formato o,
+// si no viene, el que corresponde al tipo de contenido o a la extension del
+// archivo.
+func formatoImportacion(formato, tipoContenido, nombreArchivo string) (string, error) {
+	if formato == "" {
+		tipo, _, _ := mime.ParseMediaType(tipoContenido)
+		switch {
+		case tipo == "text/csv":
+			formato = FORMATO_CSV
+		case tipo == "application/x-ndjson" || tipo == "application/jsonl" || tipo == "application/x-jsonlines":
+			formato = FORMATO_NDJSON
+		default:
+			formato = strings.TrimPrefix(filepath.Ext(nombreArchivo), ".")
+		}
+	}
+	switch strings.ToLower(formato) {
+	case FORMATO_CSV:
+		return FORMATO_CSV, nil
+	case FORMATO_NDJSON, "jsonl":
+		return FORMATO_NDJSON, nil
+	}
+	return "", fmt.Errorf("%w: formato %q, se espera csv o ndjson", ErrImportacionNoValida, formato)
+}
+
+// leerCSV lee un csv con encabezado; linea es la linea del archivo en que
+// empieza cada fila.
+func leerCSV(lector io.Reader, mapeo map[string]string) ([]filaImportada, error) {
+	csvReader := csv.NewReader(lector)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	encabezado, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportacionNoValida, err)
+	}
+	columnas := make(map[string]int, len(encabezado))
+	for index, columna := range encabezado {
+		columnas[strings.TrimSpace(strings.TrimPrefix(columna, "\ufeff"))] = index
+	}
+
+	var filas []filaImportada
+	for {
+		registro, err := csvReader.Read()
+		if err == io.EOF {
+			return filas, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportacionNoValida, err)
+		}
+		linea, _ := csvReader.FieldPos(0)
+		valores := make(map[string]string, len(mapeo))
+		for campo, columna := range mapeo {
+			if index, ok := columnas[columna]; ok && index < len(registro) {
+				valores[campo] = strings.TrimSpace(registro[index])
+			}
+		}
+		filas = append(filas, filaImportada{linea: linea, valores: valores})
+	}
+}
+
+// leerNDJSON lee un objeto json por linea; las lineas vacias se ignoran. Una
+// linea que no es un objeto se regresa con su error para reportarla sola.
+func leerNDJSON(lector io.Reader, mapeo map[string]string) ([]filaImportada, map[int]error, error) {
+	var filas []filaImportada
+	errores := map[int]error{}
+	scanner := bufio.NewScanner(lector)
+	scanner.Buffer(make([]byte, 64*1024), IMPORTACION_MAXIMO_BYTES)
+	for linea := 1; scanner.Scan(); linea++ {
+		texto := bytes.TrimSpace(scanner.Bytes())
+		if len(texto) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(texto))
+		decoder.UseNumber()
+		var objeto map[string]interface{}
+		if err := decoder.Decode(&objeto); err != nil {
+			errores[linea] = fmt.Errorf("la linea no es un objeto json: %v", err)
+			filas = append(filas, filaImportada{linea: linea})
+			continue
+		}
+
+		valores := make(map[string]string, len(mapeo))
+		for campo, columna := range mapeo {
+			switch valor := objeto[columna].(type) {
+			case string:
+				valores[campo] = strings.TrimSpace(valor)
+			case json.Number:
+				valores[campo] = valor.String()
+			case nil:
+			default:
+				errores[linea] = fmt.Errorf("el campo %s debe ser texto o numero", columna)
+			}
+		}
+		filas = append(filas, filaImportada{linea: linea, valores: valores})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrImportacionNoValida, err)
+	}
+	return filas, errores, nil
+}
+
+// aRequest convierte los valores de la fila en el request de una
+// transaccion; los campos vacios quedan en cero para que ValidarTransaccion
+// los reporte.
+func aRequest(valores map[string]string) (request, error) {
+	request := request{
+		CodigoTransaccion: valores["codigo_transaccion"],
+		Moneda:            valores["moneda"],
+		FechaTransaccion:  valores["fecha_transaccion"],
+	}
+	var err error
+	if texto := valores["monto"]; texto != "" {
+		if request.Monto, err = dinero.Parse(texto); err != nil {
+			return request, fmt.Errorf("el campo monto no es valido: %w", err)
+		}
+	}
+	if texto := valores["emisor_id"]; texto != "" {
+		if request.EmisorId, err = strconv.Atoi(texto); err != nil {
+			return request, fmt.Errorf("el campo emisor_id debe ser un entero: %q", texto)
+		}
+	}
+	if texto := valores["receptor_id"]; texto != "" {
+		if request.ReceptorId, err = strconv.Atoi(texto); err != nil {
+			return request, fmt.Errorf("el campo receptor_id debe ser un entero: %q", texto)
+		}
+	}
+	return request, nil
+}
+
+// nueva valida el request igual que Store y lo expresa como una transaccion
+// por dar de alta.
+func (t *Transaccion) nueva(request request) (transacciones.Nueva, error) {
+	if err := ValidarTransaccion(request); err != nil {
+		return transacciones.Nueva{}, err
+	}
+	moneda, err := t.monedas.Validar(request.Moneda)
+	if err != nil {
+		return transacciones.Nueva{}, err
+	}
+	fechaTransaccion, err := fecha.Parse(request.FechaTransaccion, t.zona)
+	if err != nil {
+		return transacciones.Nueva{}, err
+	}
+	return transacciones.Nueva{
+		CodigoTransaccion: request.CodigoTransaccion,
+		Moneda:            moneda.Codigo,
+		Monto:             request.Monto,
+		EmisorId:          request.EmisorId,
+		ReceptorId:        request.ReceptorId,
+		FechaTransaccion:  fechaTransaccion,
+	}, nil
+}
+
+// archivoImportacion regresa el archivo del campo archivo de un formulario
+// multipart o, si no es formulario, el cuerpo de la peticion.
+func archivoImportacion(ctx *gin.Context) (io.ReadCloser, string, string, error) {
+	tipo, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	if tipo != "multipart/form-data" {
+		return ctx.Request.Body, ctx.GetHeader("Content-Type"), "", nil
+	}
+	encabezado, err := ctx.FormFile("archivo")
+	if err != nil {
+		return nil, "", "", fmt.Errorf("%w: falta el campo archivo: %v", ErrImportacionNoValida, err)
+	}
+	archivo, err := encabezado.Open()
+	if err != nil {
+		return nil, "", "", err
+	}
+	return archivo, encabezado.Header.Get("Content-Type"), encabezado.Filename, nil
+}
+
+// modoImportacion lee el parametro modo; por omision es completo.
+func modoImportacion(ctx *gin.Context) (string, error) {
+	modo := ctx.DefaultQuery("modo", MODO_COMPLETO)
+	if modo != MODO_COMPLETO && modo != MODO_PARCIAL {
+		return "", fmt.Errorf("%w: modo %q, se espera completo o parcial", ErrImportacionNoValida, modo)
+	}
+	return modo, nil
+}
+
+// importarFilas da de alta las filas leidas y responde con el reporte.
+// errores trae, por linea, las filas que no se pudieron leer.
+func (t *Transaccion) importarFilas(ctx *gin.Context, modo string, filas []filaImportada, errores map[int]error) {
+	// Las filas que no pasan la validacion del request no llegan al
+	// servicio; en modo completo basta una para no guardar ninguna.
+	reporte := reporteImportacion{Modo: modo, Filas: []filaReporte{}}
+	rechazos := make([]error, len(filas))
+	var nuevas []transacciones.Nueva
+	var posiciones []int
+	for index, fila := range filas {
+		err := errores[fila.linea]
+		var request request
+		if err == nil {
+			request, err = aRequest(fila.valores)
+		}
+		var nueva transacciones.Nueva
+		if err == nil {
+			nueva, err = t.nueva(request)
+		}
+		if err != nil {
+			rechazos[index] = err
+			continue
+		}
+		nuevas = append(nuevas, nueva)
+		posiciones = append(posiciones, index)
+	}
+
+	resultados := make([]transacciones.ResultadoLote, len(filas))
+	if modo == MODO_PARCIAL || len(nuevas) == len(filas) {
+		guardadas, err := t.service.StoreLote(nuevas, modo == MODO_COMPLETO)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al guardar las transacciones", nil, err.Error()))
+			return
+		}
+		for index, resultado := range guardadas {
+			resultados[posiciones[index]] = resultado
+		}
+	} else {
+		for _, posicion := range posiciones {
+			resultados[posicion].Err = transacciones.ErrLoteCancelado
+		}
+	}
+
+	for index, fila := range filas {
+		filaReporte := filaReporte{Linea: fila.linea, CodigoTransaccion: fila.valores["codigo_transaccion"], Estado: FILA_ACEPTADA}
+		err := rechazos[index]
+		if err == nil {
+			err = resultados[index].Err
+			filaReporte.Id = resultados[index].Transaccion.Id
+		}
+		switch {
+		case errors.Is(err, transacciones.ErrLoteCancelado):
+			filaReporte.Estado = FILA_CANCELADA
+		case err != nil:
+			filaReporte.Estado = FILA_RECHAZADA
+		}
+		if err != nil {
+			filaReporte.Error = err.Error()
+		}
+		reporte.agregar(filaReporte)
+	}
+
+	if modo == MODO_COMPLETO && reporte.Rechazadas > 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, web.NewErrorResponse(http.StatusUnprocessableEntity, "No se importo ninguna transaccion", reporte,
+			fmt.Sprintf("%d filas rechazadas", reporte.Rechazadas)))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transacciones importadas", reporte, ""))
+}
+
+// Import transactions from a file
+// @Summary Import transactions
+// @Tags Transaction
+// @Description Import transactions from a CSV file with a header row or from JSON Lines, sent as the body or as the archivo field of a multipart form.
+// @Description Every row is validated like POST /transacciones and checked against the limits and risk rules.
+// @Description In completo mode (default) nothing is stored when any row fails and the report responds 422; in parcial mode the valid rows are stored.
+// @Accept mpfd
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param archivo formData file false "file to import when sending a multipart form"
+// @Param formato query string false "csv or ndjson; inferred from the content type or the file extension when omitted"
+// @Param modo query string false "completo (all or nothing, default) or parcial (best effort)"
+// @Param mapeo query string false "comma separated campo:columna pairs, e.g. codigo_transaccion:referencia,monto:importe"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/importar [POST]
+func (t *Transaccion) Importar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		modo, err := modoImportacion(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		mapeo, err := parseMapeo(ctx.Query("mapeo"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, IMPORTACION_MAXIMO_BYTES)
+		archivo, tipoContenido, nombreArchivo, err := archivoImportacion(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		defer archivo.Close()
+
+		formato, err := formatoImportacion(ctx.Query("formato"), tipoContenido, nombreArchivo)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		var filas []filaImportada
+		errores := map[int]error{}
+		if formato == FORMATO_CSV {
+			filas, err = leerCSV(archivo, mapeo)
+		} else {
+			filas, errores, err = leerNDJSON(archivo, mapeo)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		if len(filas) == 0 {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, fmt.Sprintf("%s: el archivo no tiene filas", ErrImportacionNoValida)))
+			return
+		}
+
+		t.importarFilas(ctx, modo, filas, errores)
+	}
+}
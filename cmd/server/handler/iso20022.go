@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/iso20022"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+const TIPO_CONTENIDO_XML = "application/xml"
+
+// listarTodas recorre todas las paginas de la consulta.
+func (t *Transaccion) listarTodas(consulta transacciones.Consulta) ([]transacciones.Transaccion, error) {
+	consulta.Limite = transacciones.LIMITE_MAXIMO
+	var lista []transacciones.Transaccion
+	for {
+		pagina, err := t.service.Listar(consulta)
+		if errors.Is(err, transacciones.ErrSinResultados) {
+			return lista, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		lista = append(lista, pagina.Transacciones...)
+		if pagina.SiguienteCursor == "" {
+			return lista, nil
+		}
+		consulta.Cursor = pagina.SiguienteCursor
+	}
+}
+
+// responderXML entrega el documento como archivo adjunto.
+func (t *Transaccion) responderXML(ctx *gin.Context, prefijo string, documento []byte) {
+	nombreArchivo := fmt.Sprintf("%s_%s.xml", prefijo, time.Now().In(t.zona).Format("20060102-150405"))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, nombreArchivo))
+	ctx.Data(http.StatusOK, TIPO_CONTENIDO_XML+"; charset=utf-8", documento)
+}
+
+// idMensaje identifica el documento generado; cabe en un Max35Text.
+func idMensaje(prefijo string, creado time.Time) string {
+	return prefijo + "-" + creado.UTC().Format("20060102150405.000000")
+}
+
+// Export an account statement as ISO 20022 camt.053
+// @Summary Export camt.053 statement
+// @Tags Transaction
+// @Description Export the transactions of a party in one currency as an ISO 20022 camt.053.001.02 bank to customer statement.
+// @Description Accounts are identified by the party id in Othr/Id; transactions stored before the party registry are matched by name. Rejected transactions are left out; every other one is a BOOK entry, so the balances match GET /cuentas/{nombre}/saldo and GET /estados-cuenta/{nombre}.
+// @Description The opening balance adds the transactions before fecha_desde.
+// @Produce application/xml
+// @Param authorization header string true "authorization"
+// @Param parte query int true "party id whose account is reported"
+// @Param moneda query string true "currency of the statement"
+// @Param fecha_desde query string false "from date or timestamp, inclusive"
+// @Param fecha_hasta query string false "to date or timestamp, inclusive; a date includes the whole day"
+// @Success 200 {file} file
+// @Router /transacciones/iso20022/camt053 [GET]
+func (t *Transaccion) ExportarCamt053() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parteId, err := strconv.Atoi(ctx.Query("parte"))
+		if err != nil || parteId <= 0 {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, "el parametro parte debe ser el id de una parte"))
+			return
+		}
+		moneda, err := t.monedas.Validar(ctx.Query("moneda"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		parte, err := t.partes.Get(parteId)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, partes.ErrParteNoEncontrada) {
+				status = http.StatusNotFound
+			}
+			ctx.JSON(status, web.NewResponse(status, "Error al exportar el estado de cuenta", nil, err.Error()))
+			return
+		}
+		var desde, hasta time.Time
+		if texto, ok := ctx.GetQuery("fecha_desde"); ok {
+			if desde, err = fecha.Parse(texto, t.zona); err != nil {
+				ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, "fecha_desde: "+err.Error()))
+				return
+			}
+		}
+		if texto, ok := ctx.GetQuery("fecha_hasta"); ok {
+			if hasta, err = fecha.ParseHasta(texto, t.zona); err != nil {
+				ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, "fecha_hasta: "+err.Error()))
+				return
+			}
+		}
+
+		filtro := transacciones.Filtro{Monedas: []string{moneda.Codigo}}
+		if !hasta.IsZero() {
+			filtro.FechaHasta = &hasta
+		}
+		orden, _ := transacciones.ParseOrden("fecha_transaccion")
+		lista, err := t.listarTodas(transacciones.Consulta{Filtro: filtro, Orden: orden})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al exportar el estado de cuenta", nil, err.Error()))
+			return
+		}
+
+		creado := time.Now().In(t.zona)
+		estado := iso20022.EstadoCuenta{
+			Id:     idMensaje("CAMT053", creado),
+			Creado: creado,
+			Parte:  parte,
+			Moneda: moneda.Codigo,
+			Desde:  desde,
+			Hasta:  hasta,
+		}
+		var anteriores []transacciones.Transaccion
+		for _, transaccion := range lista {
+			if !transaccion.EmitidaPor(parte) && !transaccion.RecibidaPor(parte) {
+				continue
+			}
+			if transaccion.FechaTransaccion.Before(desde) {
+				anteriores = append(anteriores, transaccion)
+				continue
+			}
+			estado.Transacciones = append(estado.Transacciones, transaccion)
+		}
+		if estado.Apertura, err = iso20022.Saldo(parte, moneda.Codigo, anteriores); err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al exportar el estado de cuenta", nil, err.Error()))
+			return
+		}
+
+		var documento bytes.Buffer
+		if err := iso20022.EscribirCamt053(&documento, estado); err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewResponse(http.StatusUnprocessableEntity, "No se logro generar el estado de cuenta", nil, err.Error()))
+			return
+		}
+		t.responderXML(ctx, fmt.Sprintf("camt053_%d_%s", parteId, moneda.Codigo), documento.Bytes())
+	}
+}
+
+// Export transactions as an ISO 20022 pain.001 credit transfer initiation
+// @Summary Export pain.001 initiation
+// @Tags Transaction
+// @Description Export the transactions that match the same filters as GET /transacciones/ as an ISO 20022 pain.001.001.03 customer credit transfer initiation.
+// @Description Transfers are grouped in one PmtInf per emisor and execution day; EndToEndId carries codigo_transaccion and accounts carry the party id in Othr/Id.
+// @Produce application/xml
+// @Param authorization header string true "authorization"
+// @Param iniciador query string false "name of the initiating party"
+// @Param id query int false "id"
+// @Param codigo_transaccion query string false "codigo_transaccion"
+// @Param moneda query string false "one or more comma separated currencies, e.g. MXN,USD"
+// @Param monto_min query string false "minimum amount, inclusive"
+// @Param monto_max query string false "maximum amount, inclusive"
+// @Param emisor query string false "emisor"
+// @Param receptor query string false "receptor"
+// @Param fecha_desde query string false "from date or timestamp, inclusive"
+// @Param fecha_hasta query string false "to date or timestamp, inclusive; a date includes the whole day"
+// @Param estado query string false "comma separated states, e.g. pendiente,autorizada"
+// @Param sort query string false "comma separated fields, - for descending, e.g. -monto,fecha_transaccion"
+// @Success 200 {file} file
+// @Router /transacciones/iso20022/pain001 [GET]
+func (t *Transaccion) ExportarPain001() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		filtro, err := filtroDesdeQuery(ctx, t.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		orden, err := transacciones.ParseOrden(ctx.Query("sort"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		lista, err := t.listarTodas(transacciones.Consulta{Filtro: filtro, Orden: orden})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al exportar las transacciones", nil, err.Error()))
+			return
+		}
+		if len(lista) == 0 {
+			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "No se encontraron transacciones", nil, transacciones.ErrSinResultados.Error()))
+			return
+		}
+
+		creado := time.Now().In(t.zona)
+		var documento bytes.Buffer
+		err = iso20022.EscribirPain001(&documento, iso20022.Iniciacion{
+			Id:            idMensaje("PAIN001", creado),
+			Creado:        creado,
+			Iniciador:     ctx.Query("iniciador"),
+			Transacciones: lista,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewResponse(http.StatusUnprocessableEntity, "No se logro generar la orden de transferencias", nil, err.Error()))
+			return
+		}
+		t.responderXML(ctx, "pain001", documento.Bytes())
+	}
+}
+
+// importarISO20022 lee el documento con leer y da de alta sus transacciones
+// igual que Importar; la linea del reporte es la posicion de la transaccion
+// en el documento.
+func (t *Transaccion) importarISO20022(leer func(io.Reader, *time.Location) ([]iso20022.Movimiento, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		modo, err := modoImportacion(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, IMPORTACION_MAXIMO_BYTES)
+		archivo, _, _, err := archivoImportacion(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		defer archivo.Close()
+
+		movimientos, err := leer(archivo, t.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		if len(movimientos) == 0 {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, fmt.Sprintf("%s: el documento no tiene transacciones", ErrImportacionNoValida)))
+			return
+		}
+
+		filas := make([]filaImportada, len(movimientos))
+		errores := map[int]error{}
+		for index, movimiento := range movimientos {
+			filas[index] = filaImportada{linea: movimiento.Posicion, valores: map[string]string{
+				"codigo_transaccion": movimiento.CodigoTransaccion,
+				"moneda":             movimiento.Moneda,
+				"monto":              movimiento.Monto.String(),
+				"emisor_id":          strconv.Itoa(movimiento.EmisorId),
+				"receptor_id":        strconv.Itoa(movimiento.ReceptorId),
+				"fecha_transaccion":  movimiento.Fecha.Format(time.RFC3339Nano),
+			}}
+			if movimiento.Err != nil {
+				errores[movimiento.Posicion] = movimiento.Err
+			}
+		}
+		t.importarFilas(ctx, modo, filas, errores)
+	}
+}
+
+// Import a camt.053 statement
+// @Summary Import camt.053 statement
+// @Tags Transaction
+// @Description Import the entries of an ISO 20022 camt.053 statement (any camt.053.001 version), sent as the body or as the archivo field of a multipart form.
+// @Description The document is validated against the schema structure first. Every transaction detail becomes a pending transaction: EndToEndId is its codigo_transaccion and the debtor and creditor accounts must carry a party id in Othr/Id; a missing side is the statement account.
+// @Description The report is the same as POST /transacciones/importar, with linea as the position of the transaction in the document.
+// @Accept application/xml
+// @Accept mpfd
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param archivo formData file false "file to import when sending a multipart form"
+// @Param modo query string false "completo (all or nothing, default) or parcial (best effort)"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/iso20022/camt053 [POST]
+func (t *Transaccion) ImportarCamt053() gin.HandlerFunc {
+	return t.importarISO20022(iso20022.LeerCamt053)
+}
+
+// Import a pain.001 initiation
+// @Summary Import pain.001 initiation
+// @Tags Transaction
+// @Description Import the transfers of an ISO 20022 pain.001 credit transfer initiation (any pain.001.001 version), sent as the body or as the archivo field of a multipart form.
+// @Description The document is validated against the schema structure first, NbOfTxs and CtrlSum included. Every CdtTrfTxInf becomes a pending transaction dated on the requested execution day: EndToEndId is its codigo_transaccion and DbtrAcct and CdtrAcct must carry a party id in Othr/Id.
+// @Description The report is the same as POST /transacciones/importar, with linea as the position of the transaction in the document.
+// @Accept application/xml
+// @Accept mpfd
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param archivo formData file false "file to import when sending a multipart form"
+// @Param modo query string false "completo (all or nothing, default) or parcial (best effort)"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/iso20022/pain001 [POST]
+func (t *Transaccion) ImportarPain001() gin.HandlerFunc {
+	return t.importarISO20022(iso20022.LeerPain001)
+}
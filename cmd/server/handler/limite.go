@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/limites"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type Limite struct {
+	service limites.Service
+}
+
+func NewLimite(s limites.Service) *Limite {
+	return &Limite{service: s}
+}
+
+// Get all limits
+// @Summary Get all limits
+// @Tags Limit
+// @Description Get the per issuer and currency limits checked when transactions are stored or updated; emisor_id 0 applies to issuers without their own limit
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Succes 200 {object} web.Response
+// @Router /limites [GET]
+func (l *Limite) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lista, err := l.service.GetAll()
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al recuperar los limites", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Limites recuperados con exito", lista, ""))
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type Moneda struct {
+	service monedas.Service
+}
+
+func NewMoneda(s monedas.Service) *Moneda {
+	return &Moneda{service: s}
+}
+
+// Get all currencies
+// @Summary Get all currencies
+// @Tags Currency
+// @Description Get the ISO 4217 currency catalogue used to validate transactions
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param activas query bool false "only active currencies"
+// @Succes 200 {object} web.Response
+// @Router /monedas [GET]
+func (m *Moneda) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		monedas, err := m.service.GetAll(ctx.Query("activas") == "true")
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al recuperar las monedas", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Monedas recuperadas con exito", monedas, ""))
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/gin-gonic/gin"
+)
+
+// consultaDesdeQuery lee limit, offset, cursor y sort. Sin limit se regresan
+// todas las transacciones, como antes de paginar.
+func consultaDesdeQuery(ctx *gin.Context, filtro transacciones.Filtro) (transacciones.Consulta, error) {
+	consulta := transacciones.Consulta{Filtro: filtro, Cursor: ctx.Query("cursor")}
+
+	var err error
+	if consulta.Limite, err = enteroDesdeQuery(ctx, "limit"); err != nil {
+		return consulta, err
+	}
+	if consulta.Limite > transacciones.LIMITE_MAXIMO {
+		consulta.Limite = transacciones.LIMITE_MAXIMO
+	}
+	if consulta.Desplazamiento, err = enteroDesdeQuery(ctx, "offset"); err != nil {
+		return consulta, err
+	}
+	if consulta.Orden, err = transacciones.ParseOrden(ctx.Query("sort")); err != nil {
+		return consulta, err
+	}
+	return consulta, nil
+}
+
+func enteroDesdeQuery(ctx *gin.Context, parametro string) (int, error) {
+	texto, ok := ctx.GetQuery(parametro)
+	if !ok {
+		return 0, nil
+	}
+	valor, err := strconv.Atoi(texto)
+	if err != nil || valor < 0 {
+		return 0, fmt.Errorf("el parametro %s debe ser un entero no negativo: %q", parametro, texto)
+	}
+	return valor, nil
+}
+
+// enlacesPagina agrega el encabezado Link con las paginas first, prev y next.
+// Si la peticion pagino por offset los enlaces siguen usando offset; si no,
+// next usa el cursor.
+func enlacesPagina(ctx *gin.Context, consulta transacciones.Consulta, pagina transacciones.Pagina) {
+	if consulta.Limite == 0 {
+		return
+	}
+
+	var enlaces []string
+	enlace := func(rel string, cambios map[string]string) {
+		url := *ctx.Request.URL
+		query := url.Query()
+		for parametro, valor := range cambios {
+			if valor == "" {
+				query.Del(parametro)
+				continue
+			}
+			query.Set(parametro, valor)
+		}
+		url.RawQuery = query.Encode()
+		enlaces = append(enlaces, fmt.Sprintf(`<%s>; rel="%s"`, url.RequestURI(), rel))
+	}
+
+	_, porDesplazamiento := ctx.GetQuery("offset")
+	enlace("first", map[string]string{"cursor": "", "offset": ""})
+	if porDesplazamiento && consulta.Desplazamiento > 0 {
+		anterior := consulta.Desplazamiento - consulta.Limite
+		if anterior < 0 {
+			anterior = 0
+		}
+		enlace("prev", map[string]string{"offset": strconv.Itoa(anterior)})
+	}
+	if pagina.SiguienteCursor != "" {
+		if porDesplazamiento {
+			enlace("next", map[string]string{"offset": strconv.Itoa(consulta.Desplazamiento + consulta.Limite)})
+		} else {
+			enlace("next", map[string]string{"cursor": pagina.SiguienteCursor})
+		}
+	}
+	ctx.Header("Link", strings.Join(enlaces, ", "))
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type parteRequest struct {
+	Nombre string        `json:"nombre" example:"Bancomer"`
+	Tipo   partes.Tipo   `json:"tipo" swaggertype:"string" example:"banco"`
+	Estado partes.Estado `json:"estado" swaggertype:"string" example:"activa"`
+}
+
+type Parte struct {
+	service partes.Service
+}
+
+func NewParte(s partes.Service) *Parte {
+	return &Parte{service: s}
+}
+
+// statusParte traduce los errores del registro de partes a su codigo http.
+func statusParte(err error) int {
+	switch {
+	case errors.Is(err, partes.ErrParteNoValida):
+		return http.StatusBadRequest
+	case errors.Is(err, partes.ErrNombreDuplicado):
+		return http.StatusConflict
+	case errors.Is(err, partes.ErrParteNoEncontrada):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// Get all parties
+// @Summary Get all parties
+// @Tags Party
+// @Description Get the registered banks, people and merchants that issue or receive transactions
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Succes 200 {object} web.Response
+// @Router /partes [GET]
+func (p *Parte) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lista, err := p.service.GetAll()
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al recuperar las partes", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Partes recuperadas con exito", lista, ""))
+	}
+}
+
+// Get a party
+// @Summary Get party
+// @Tags Party
+// @Description Get a registered party by id
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param Id path int true "Id"
+// @Succes 200 {object} web.Response
+// @Router /partes/{Id} [GET]
+func (p *Parte) Get() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("Id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "No se selecciono la parte", nil, err.Error()))
+			return
+		}
+
+		parte, err := p.service.Get(id)
+
+		if err != nil {
+			status := statusParte(err)
+			ctx.JSON(status, web.NewResponse(status, "Error al recuperar la parte", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Parte recuperada con exito", parte, ""))
+	}
+}
+
+// Store a party
+// @Summary Store party
+// @Tags Party
+// @Description Register an active party; names that only differ in case or spacing are rejected as duplicates
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param parte body parteRequest true "party"
+// @Succes 200 {object} web.Response
+// @Router /partes [POST]
+func (p *Parte) Store() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request parteRequest
+
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		parte, err := p.service.Store(request.Nombre, request.Tipo)
+
+		if err != nil {
+			status := statusParte(err)
+			ctx.JSON(status, web.NewResponse(status, "Error al tratar de almacenar la parte", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Parte almacenada con exito", parte, ""))
+	}
+}
+
+// Update a party
+// @Summary Update party
+// @Tags Party
+// @Description Update the name, type and status of a party; inactive parties cannot take part in new transactions
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param Id path int true "Id"
+// @Param parte body parteRequest true "party"
+// @Succes 200 {object} web.Response
+// @Router /partes/{Id} [PUT]
+func (p *Parte) Update() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request parteRequest
+
+		id, err := strconv.Atoi(ctx.Param("Id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "No se selecciono la parte a actualizar", nil, err.Error()))
+			return
+		}
+
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "La peticion no es valida", nil, err.Error()))
+			return
+		}
+
+		parte, err := p.service.Update(id, request.Nombre, request.Tipo, request.Estado)
+
+		if err != nil {
+			status := statusParte(err)
+			ctx.JSON(status, web.NewResponse(status, "Error al tratar de actualizar la parte", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Parte actualizada con exito", parte, ""))
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/reportes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type resumenReporte struct {
+	Agrupar []reportes.Clave `json:"agrupar" swaggertype:"array,string" example:"moneda,emisor"`
+	Grupos  []reportes.Grupo `json:"grupos"`
+}
+
+type Reporte struct {
+	service transacciones.Service
+	zona    *time.Location
+}
+
+// NewReporte crea el handler de reportes; los dias, semanas y meses se cuentan
+// en zona.
+func NewReporte(s transacciones.Service, zona *time.Location) *Reporte {
+	return &Reporte{service: s, zona: zona}
+}
+
+// Summarize transactions by group
+// @Summary Summarize transactions
+// @Tags Report
+// @Description Count and add up the transactions that match the same filters as GET /transacciones/, grouped by the requested keys, with the minimum, maximum and average amount of each group.
+// @Description Amounts of different currencies are never added, so moneda is always a grouping key. Days, ISO weeks (e.g. 2022-W16) and months are taken in the server time zone. Groups are sorted by their key values.
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param agrupar query string false "comma separated keys: moneda, emisor, receptor, dia, semana, mes; e.g. emisor,mes"
+// @Param id query int false "id"
+// @Param codigo_transaccion query string false "codigo_transaccion"
+// @Param moneda query string false "one or more comma separated currencies, e.g. MXN,USD"
+// @Param monto query string false "exact amount, 0 included"
+// @Param monto_min query string false "minimum amount, inclusive"
+// @Param monto_max query string false "maximum amount, inclusive"
+// @Param emisor query string false "emisor"
+// @Param emisor_coincidencia query string false "how emisor is matched: exacta (default), prefijo or contiene"
+// @Param receptor query string false "receptor"
+// @Param fecha_transaccion query string false "day of the transaction: dd/mm/yyyy, yyyy-mm-dd or RFC 3339"
+// @Param fecha_desde query string false "from date or timestamp, inclusive"
+// @Param fecha_hasta query string false "to date or timestamp, inclusive; a date includes the whole day"
+// @Param estado query string false "comma separated states: pendiente, autorizada, liquidada, rechazada, revertida"
+// @Succes 200 {object} web.Response
+// @Router /reportes/resumen [GET]
+func (r *Reporte) Resumen() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		agrupacion, err := reportes.ParseAgrupacion(ctx.Query("agrupar"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		filtro, err := filtroDesdeQuery(ctx, r.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		lista, err := r.service.GetTransaccionFiltrada(filtro)
+		if err != nil && !errors.Is(err, transacciones.ErrSinResultados) {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al calcular el resumen", nil, err.Error()))
+			return
+		}
+		grupos, err := reportes.Resumir(lista, agrupacion, r.zona)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al calcular el resumen", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Resumen calculado con exito", resumenReporte{Agrupar: agrupacion, Grupos: grupos}, ""))
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+// Revision atiende la cola de transacciones marcadas por las reglas de riesgo.
+type Revision struct {
+	service transacciones.Service
+}
+
+func NewRevision(s transacciones.Service) *Revision {
+	return &Revision{service: s}
+}
+
+// Get the manual review queue
+// @Summary Get review queue
+// @Tags Review
+// @Description Get the pending transactions flagged by the risk rules that wait for manual approval, highest risk score first
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Succes 200 {object} web.Response
+// @Router /revision [GET]
+func (r *Revision) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lista, err := r.service.EnRevision()
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al recuperar las transacciones en revision", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transacciones en revision recuperadas con exito", enVersion(ctx, lista), ""))
+	}
+}
+
+// Approve a flagged transaction
+// @Summary Approve flagged transaction
+// @Tags Review
+// @Description Approve a transaction flagged by the risk rules so it can be authorized; responds 409 when it is not waiting for review.
+// @Description Flagged transactions are rejected with POST /transacciones/{Id}/rechazar.
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param Id path int true "Id"
+// @Succes 200 {object} web.Response
+// @Router /revision/{Id}/aprobar [POST]
+func (r *Revision) Aprobar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("Id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "No se selecciono la transaccion", nil, err.Error()))
+			return
+		}
+
+		transaccion, err := r.service.AprobarRevision(id)
+
+		if errors.Is(err, transacciones.ErrSinRevision) {
+			ctx.JSON(http.StatusConflict, web.NewResponse(http.StatusConflict, "La transaccion no esta en revision", nil, err.Error()))
+			return
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Error al tratar de aprobar la transaccion", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion aprobada con exito", enVersion(ctx, transaccion), ""))
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/divisas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+type tipoCambioRequest struct {
+	Origen  string       `json:"origen"`
+	Destino string       `json:"destino"`
+	Fecha   string       `json:"fecha" example:"01/04/2022"`
+	Tasa    dinero.Monto `json:"tasa" swaggertype:"string" example:"0.054321"`
+}
+
+type TipoCambio struct {
+	service divisas.Service
+}
+
+func NewTipoCambio(s divisas.Service) *TipoCambio {
+	return &TipoCambio{service: s}
+}
+
+// Get all exchange rates
+// @Summary Get all exchange rates
+// @Tags ExchangeRate
+// @Description Get the dated exchange rates used to convert transactions
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Succes 200 {object} web.Response
+// @Router /tipos-cambio [GET]
+func (tc *TipoCambio) GetAll() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tiposCambio, err := tc.service.GetAll()
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al recuperar los tipos de cambio", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Tipos de cambio recuperados con exito", tiposCambio, ""))
+	}
+}
+
+// Store an exchange rate
+// @Summary Store exchange rate
+// @Tags ExchangeRate
+// @Description Store the rate of a currency pair from a date on, replacing the one of the same date
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param tipo_cambio body tipoCambioRequest true "exchange rate"
+// @Succes 200 {object} web.Response
+// @Router /tipos-cambio [POST]
+func (tc *TipoCambio) Store() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request tipoCambioRequest
+
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		tipoCambio, err := tc.service.Store(request.Origen, request.Destino, request.Fecha, request.Tasa)
+
+		if errors.Is(err, divisas.ErrTipoCambioNoValido) || errors.Is(err, monedas.ErrMonedaNoValida) {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al tratar de almacenar el tipo de cambio", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Tipo de cambio almacenado con exito", tipoCambio, ""))
+	}
+}
@@ -1,38 +1,76 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"reflect"
 	"strconv"
+	"time"
 
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/divisas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/limites"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/riesgo"
 	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
 	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
 	"github.com/gin-gonic/gin"
 )
 
 type request struct {
-	Id                int     `json:"id"`
-	CodigoTransaccion string  `json:"codigo_transaccion" validation:"required"`
-	Moneda            string  `json:"moneda" validation:"required"`
-	Monto             float64 `json:"monto" validation:"required"`
-	Emisor            string  `json:"emisor" validation:"required"`
-	Receptor          string  `json:"receptor" validation:"required"`
-	FechaTransaccion  string  `json:"fecha_transaccion" validation:"required"`
+	Id                int          `json:"id"`
+	CodigoTransaccion string       `json:"codigo_transaccion" validation:"required"`
+	Moneda            string       `json:"moneda" validation:"required"`
+	Monto             dinero.Monto `json:"monto" validation:"required" swaggertype:"string" example:"4000.50"`
+	EmisorId          int          `json:"emisor_id" validation:"required"`
+	ReceptorId        int          `json:"receptor_id" validation:"required"`
+	FechaTransaccion  string       `json:"fecha_transaccion" validation:"required" example:"2022-04-04T10:30:00-05:00"`
 }
 
 type patchRequest struct {
-	CodigoTransaccion string  `json:"codigo_transaccion" validation:"required"`
-	Monto             float64 `json:"monto" validation:"required"`
+	CodigoTransaccion string       `json:"codigo_transaccion" validation:"required"`
+	Monto             dinero.Monto `json:"monto" validation:"required" swaggertype:"string" example:"4000.50"`
 }
 
+// reversaRequest pide una reversa; todos los campos son opcionales. Sin monto
+// se revierte todo lo que falte y sin fecha se usa la hora actual.
+type reversaRequest struct {
+	CodigoTransaccion string        `json:"codigo_transaccion"`
+	Monto             *dinero.Monto `json:"monto" swaggertype:"string" example:"100.00"`
+	FechaTransaccion  string        `json:"fecha_transaccion" example:"2022-04-04T10:30:00-05:00"`
+}
+
+// transaccionDetalle agrega a la transaccion las reversas que la devuelven y
+// el monto que suman.
+type transaccionDetalle struct {
+	transacciones.Transaccion
+	Reversas       []transacciones.Transaccion `json:"reversas"`
+	MontoRevertido dinero.Monto                `json:"monto_revertido" swaggertype:"string"`
+}
+
+// transaccionConvertida agrega a la transaccion su monto en la moneda pedida
+// con convertir_a.
+type transaccionConvertida struct {
+	transacciones.Transaccion
+	Conversion divisas.Conversion `json:"conversion"`
+}
+
+// Transaccion interpreta en zona las fechas que llegan sin zona horaria.
 type Transaccion struct {
 	service transacciones.Service
+	monedas monedas.Service
+	divisas divisas.Service
+	partes  partes.Service
+	zona    *time.Location
 }
 
-func NewTransaccion(s transacciones.Service) *Transaccion {
-	return &Transaccion{service: s}
+func NewTransaccion(s transacciones.Service, m monedas.Service, d divisas.Service, p partes.Service, zona *time.Location) *Transaccion {
+	return &Transaccion{service: s, monedas: m, divisas: d, partes: p, zona: zona}
 }
 
 func ValidarTransaccion(request request) error {
@@ -44,7 +82,7 @@ func ValidarTransaccion(request request) error {
 		if !errValidation {
 			continue
 		}
-		if values.Field(i).IsZero() && validation == "required" {
+		if esCero(values.Field(i)) && validation == "required" {
 			tag, errTag := keys.Field(i).Tag.Lookup("json")
 			if !errTag {
 				badParameters += keys.Field(i).Name + ", "
@@ -59,6 +97,27 @@ func ValidarTransaccion(request request) error {
 	return fmt.Errorf("el campo %s es requerido", badParameters[:len(badParameters)-2])
 }
 
+// esCero considera vacios los valores que se declaran como cero, como un
+// dinero.Monto igual a 0.00, ademas del valor cero de cada tipo.
+func esCero(value reflect.Value) bool {
+	if cero, ok := value.Interface().(interface{ EsCero() bool }); ok {
+		return cero.EsCero()
+	}
+	return value.IsZero()
+}
+
+// esErrorDeValidacion indica si el servicio rechazo los datos de la peticion.
+func esErrorDeValidacion(err error) bool {
+	return errors.Is(err, transacciones.ErrMontoNoValido) || errors.Is(err, monedas.ErrMonedaNoValida) ||
+		errors.Is(err, transacciones.ErrFechaNoValida)
+}
+
+// esErrorDeParte indica si el emisor o el receptor no existen o estan
+// inactivos en el registro de partes.
+func esErrorDeParte(err error) bool {
+	return errors.Is(err, partes.ErrParteNoEncontrada) || errors.Is(err, partes.ErrParteInactiva)
+}
+
 func ValidarToken() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if ctx.GetHeader("authorization") != os.Getenv("TOKEN") {
@@ -69,25 +128,83 @@ func ValidarToken() gin.HandlerFunc {
 	}
 }
 
+// convertir expresa cada transaccion en la moneda destino. Si alguna no tiene
+// tipo de cambio para su fecha no se regresa ninguna.
+func (t *Transaccion) convertir(lista []transacciones.Transaccion, destino string) ([]transaccionConvertida, error) {
+	convertidas := make([]transaccionConvertida, 0, len(lista))
+	for _, transaccion := range lista {
+		conversion, err := t.divisas.ConvertirTransaccion(transaccion, destino)
+		if err != nil {
+			return nil, err
+		}
+		convertidas = append(convertidas, transaccionConvertida{Transaccion: transaccion, Conversion: conversion})
+	}
+	return convertidas, nil
+}
+
 // Get all transactions
 // @Summary Get all transactions
 // @Tags Transaction
-// @Description Get  alltransactions
+// @Description Get  alltransactions, optionally converted to another currency as of each transaction date
 // @Accept json
 // @Produce json
 // @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param convertir_a query string false "ISO 4217 currency to convert the amounts to"
+// @Param limit query int false "page size, all transactions when omitted; at most 1000"
+// @Param offset query int false "number of transactions to skip"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "comma separated fields, - for descending, e.g. -monto,fecha_transaccion"
 // @Succes 200 {object} web.Response
 // @Router /transacciones [GET]
 func (t *Transaccion) GetAll() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		transacciones, err := t.service.GetAll()
+		consulta, err := consultaDesdeQuery(ctx, transacciones.Filtro{})
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		pagina, err := t.service.Listar(consulta)
+
+		if errors.Is(err, transacciones.ErrConsultaNoValida) {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
 
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al recuperar las transacciones", nil, err.Error()))
 			return
 		}
 
-		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transacciones recuperadas con exito", transacciones, ""))
+		enlacesPagina(ctx, consulta, pagina)
+
+		destino := ctx.Query("convertir_a")
+		if destino == "" {
+			ctx.JSON(http.StatusOK, web.NewPageResponse(http.StatusOK, "Transacciones recuperadas con exito", enVersion(ctx, pagina.Transacciones),
+				pagina.Total, pagina.SiguienteCursor))
+			return
+		}
+
+		moneda, err := t.monedas.Validar(destino)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		convertidas, err := t.convertir(pagina.Transacciones, moneda.Codigo)
+		if errors.Is(err, divisas.ErrSinTipoCambio) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewResponse(http.StatusUnprocessableEntity, "No se lograron convertir las transacciones", nil, err.Error()))
+			return
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al convertir las transacciones", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewPageResponse(http.StatusOK, "Transacciones recuperadas con exito", enVersion(ctx, convertidas),
+			pagina.Total, pagina.SiguienteCursor))
 	}
 }
 
@@ -98,44 +215,66 @@ func (t *Transaccion) GetAll() gin.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
 // @Param id query int false "id"
 // @Param codigo_transaccion query string false "codigo_transaccion"
-// @Param moneda query string false "moneda"
-// @Param monto query float64 false "monto"
+// @Param moneda query string false "one or more comma separated currencies, e.g. MXN,USD"
+// @Param monto query string false "exact amount, 0 included"
+// @Param monto_min query string false "minimum amount, inclusive"
+// @Param monto_max query string false "maximum amount, inclusive"
 // @Param emisor query string false "emisor"
+// @Param emisor_coincidencia query string false "how emisor is matched: exacta (default), prefijo or contiene"
 // @Param receptor query string false "receptor"
-// @Param fecha_transaccion query string false "fecha_transaccion"
+// @Param fecha_transaccion query string false "day of the transaction: dd/mm/yyyy, yyyy-mm-dd or RFC 3339"
+// @Param fecha_desde query string false "from date or timestamp, inclusive"
+// @Param fecha_hasta query string false "to date or timestamp, inclusive; a date includes the whole day"
+// @Param estado query string false "comma separated states: pendiente, autorizada, liquidada, rechazada, revertida"
+// @Param limit query int false "page size, all transactions when omitted; at most 1000"
+// @Param offset query int false "number of transactions to skip"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "comma separated fields, - for descending, e.g. -monto,fecha_transaccion"
 // @Succes 200 {object} web.Response
 // @Router /transacciones/ [GET]
 func (t *Transaccion) GetTransaccionFiltrada() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		id, _ := strconv.Atoi(ctx.Query("id"))
-		codigoTransaccion := ctx.Query("codigo_transaccion")
-		moneda := ctx.Query("moneda")
-		monto, _ := strconv.ParseFloat(ctx.Query("monto"), 64)
-		emisor := ctx.Query("emisor")
-		receptor := ctx.Query("receptor")
-		fechaTransaccion := ctx.Query("fecha_transaccion")
+		filtro, err := filtroDesdeQuery(ctx, t.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		consulta, err := consultaDesdeQuery(ctx, filtro)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		pagina, err := t.service.Listar(consulta)
 
-		transacciones, err := t.service.GetTransaccionFiltrada(id, codigoTransaccion, moneda, monto, emisor,
-			receptor, fechaTransaccion)
+		if errors.Is(err, transacciones.ErrConsultaNoValida) {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
 
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Error al tratar de recuperar las transacciones", nil, err.Error()))
 			return
 		}
 
-		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transacciones recuperadas con exito", transacciones, ""))
+		enlacesPagina(ctx, consulta, pagina)
+		ctx.JSON(http.StatusOK, web.NewPageResponse(http.StatusOK, "Transacciones recuperadas con exito", enVersion(ctx, pagina.Transacciones),
+			pagina.Total, pagina.SiguienteCursor))
 	}
 }
 
 // Get a specific transaction
 // @Summary Get transaction
 // @Tags Transaction
-// @Description Get a specific transaction using the id
+// @Description Get a specific transaction using the id, with the reversals that refund it
 // @Accept json
 // @Produce json
 // @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
 // @Param Id path int true "Id"
 // @Succes 200 {object} web.Response
 // @Router /transacciones/{Id} [GET]
@@ -154,17 +293,56 @@ func (t *Transaccion) GetTransaccion() gin.HandlerFunc {
 			return
 		}
 
-		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion recuperada con exito", transaccion, ""))
+		reversas, err := t.service.GetReversas(idParam)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al tratar de recuperar las reversas", nil, err.Error()))
+			return
+		}
+		revertido, err := transacciones.MontoRevertido(reversas, transaccion.Monto.Escala())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al tratar de recuperar las reversas", nil, err.Error()))
+			return
+		}
+
+		detalle := transaccionDetalle{Transaccion: transaccion, Reversas: reversas, MontoRevertido: revertido}
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion recuperada con exito", enVersion(ctx, detalle), ""))
+	}
+}
+
+// Get a transaction by its code
+// @Summary Get transaction by code
+// @Tags Transaction
+// @Description Get the transaction with the given codigo_transaccion
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param codigo path string true "codigo_transaccion"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/codigo/{codigo} [GET]
+func (t *Transaccion) GetByCodigo() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		transaccion, err := t.service.GetByCodigo(ctx.Param("codigo"))
+
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Error al tratar de recuperar la transaccion", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion recuperada con exito", enVersion(ctx, transaccion), ""))
 	}
 }
 
 // Store a specific transaction
 // @Summary Store transaction
 // @Tags Transaction
-// @Description Store a specific transaction using the body
+// @Description Store a specific transaction using the body; responds 422 with the violated limit when it exceeds a limit of the issuer.
+// @Description Transactions are evaluated against the risk rules: reject responds 422 with the evaluation and flag stores them for manual review.
 // @Accept json
 // @Produce json
 // @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param Idempotency-Key header string false "retries with the same key and body replay the original response"
 // @Param transaction body request true "transaction"
 // @Succes 200 {object} web.Response
 // @Router /transacciones [POST]
@@ -182,25 +360,67 @@ func (t *Transaccion) Store() gin.HandlerFunc {
 			return
 		}
 
+		moneda, err := t.monedas.Validar(request.Moneda)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		request.Moneda = moneda.Codigo
+
+		fechaTransaccion, err := fecha.Parse(request.FechaTransaccion, t.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
 		transaccion, err := t.service.Store(request.CodigoTransaccion, request.Moneda,
-			request.Monto, request.Emisor, request.Receptor, request.FechaTransaccion)
+			request.Monto, request.EmisorId, request.ReceptorId, fechaTransaccion)
+
+		if errors.Is(err, transacciones.ErrCodigoDuplicado) {
+			ctx.JSON(http.StatusConflict, web.NewResponse(http.StatusConflict, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		if esErrorDeParte(err) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewResponse(http.StatusUnprocessableEntity, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		var excedido *limites.Excedido
+		if errors.As(err, &excedido) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewErrorResponse(http.StatusUnprocessableEntity, "La transaccion excede un limite del emisor", excedido, err.Error()))
+			return
+		}
+
+		var rechazo *riesgo.Rechazo
+		if errors.As(err, &rechazo) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewErrorResponse(http.StatusUnprocessableEntity, "La transaccion fue rechazada por las reglas de riesgo", rechazo.Evaluacion, err.Error()))
+			return
+		}
+
+		if esErrorDeValidacion(err) {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
 
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, web.NewResponse(http.StatusInternalServerError, "Error al tratar de almacenar la transaccion", nil, err.Error()))
 			return
 		}
 
-		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion almacenada con exito", transaccion, ""))
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion almacenada con exito", enVersion(ctx, transaccion), ""))
 	}
 }
 
 // Update a specific transaction
 // @Summary Update transaction
 // @Tags Transaction
-// @Description Update a specific transaction using the id and body
+// @Description Update a specific transaction using the id and body; responds 422 with the violated limit when it exceeds a limit of the issuer.
+// @Description Transactions are evaluated against the risk rules: reject responds 422 with the evaluation and flag stores them for manual review.
 // @Accept json
 // @Produce json
 // @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
 // @Param Id path int true "Id"
 // @Param transaction body request true "transaction"
 // @Succes 200 {object} web.Response
@@ -226,25 +446,67 @@ func (t *Transaccion) Update() gin.HandlerFunc {
 			return
 		}
 
+		moneda, err := t.monedas.Validar(request.Moneda)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "La peticion no es valida", nil, err.Error()))
+			return
+		}
+		request.Moneda = moneda.Codigo
+
+		fechaTransaccion, err := fecha.Parse(request.FechaTransaccion, t.zona)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "La peticion no es valida", nil, err.Error()))
+			return
+		}
+
 		transaccion, err := t.service.Update(id, request.CodigoTransaccion, request.Moneda,
-			request.Monto, request.Emisor, request.Receptor, request.FechaTransaccion)
+			request.Monto, request.EmisorId, request.ReceptorId, fechaTransaccion)
+
+		if errors.Is(err, transacciones.ErrCodigoDuplicado) || errors.Is(err, transacciones.ErrTransaccionNoEditable) {
+			ctx.JSON(http.StatusConflict, web.NewResponse(http.StatusConflict, "La peticion no es valida", nil, err.Error()))
+			return
+		}
+
+		if esErrorDeParte(err) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewResponse(http.StatusUnprocessableEntity, "La peticion no es valida", nil, err.Error()))
+			return
+		}
+
+		var excedido *limites.Excedido
+		if errors.As(err, &excedido) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewErrorResponse(http.StatusUnprocessableEntity, "La transaccion excede un limite del emisor", excedido, err.Error()))
+			return
+		}
+
+		var rechazo *riesgo.Rechazo
+		if errors.As(err, &rechazo) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewErrorResponse(http.StatusUnprocessableEntity, "La transaccion fue rechazada por las reglas de riesgo", rechazo.Evaluacion, err.Error()))
+			return
+		}
+
+		if esErrorDeValidacion(err) {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "La peticion no es valida", nil, err.Error()))
+			return
+		}
 
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Error al tratar de eliminar la transaccion", nil, err.Error()))
 			return
 		}
 
-		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion actualizada con exito", transaccion, ""))
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion actualizada con exito", enVersion(ctx, transaccion), ""))
 	}
 }
 
 // Update partiality a specific transaction
 // @Summary Patch transaction
 // @Tags Transaction
-// @Description Patch an specific transaction using the id and body
+// @Description Patch an specific transaction using the id and body; responds 422 with the violated limit when it exceeds a limit of the issuer.
+// @Description Transactions are evaluated against the risk rules: reject responds 422 with the evaluation and flag stores them for manual review.
 // @Accept json
 // @Produce json
 // @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
 // @Param Id path int true "Id"
 // @Param transaction body patchRequest true "transaction"
 // @Succes 200 {object} web.Response
@@ -265,26 +527,174 @@ func (t *Transaccion) Patch() gin.HandlerFunc {
 			return
 		}
 
-		if request.CodigoTransaccion == "" || request.Monto <= 0 {
+		if request.CodigoTransaccion == "" || !request.Monto.EsPositivo() {
 			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "El request no es valido", nil, ""))
 			return
 		}
 
 		transaccion, err := t.service.Patch(id, request.CodigoTransaccion, request.Monto)
 
+		if errors.Is(err, transacciones.ErrCodigoDuplicado) || errors.Is(err, transacciones.ErrTransaccionNoEditable) {
+			ctx.JSON(http.StatusConflict, web.NewResponse(http.StatusConflict, "El request no es valido", nil, err.Error()))
+			return
+		}
+
+		var excedido *limites.Excedido
+		if errors.As(err, &excedido) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewErrorResponse(http.StatusUnprocessableEntity, "La transaccion excede un limite del emisor", excedido, err.Error()))
+			return
+		}
+
+		var rechazo *riesgo.Rechazo
+		if errors.As(err, &rechazo) {
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewErrorResponse(http.StatusUnprocessableEntity, "La transaccion fue rechazada por las reglas de riesgo", rechazo.Evaluacion, err.Error()))
+			return
+		}
+
+		if esErrorDeValidacion(err) {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "El request no es valido", nil, err.Error()))
+			return
+		}
+
 		if err != nil {
 			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Error al tratar de actualizar la transaccion", nil, err.Error()))
 			return
 		}
 
-		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion actualizada con exito", transaccion, ""))
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion actualizada con exito", enVersion(ctx, transaccion), ""))
+	}
+}
+
+// transicionar responde la transaccion despues de pasarla a nuevo. Una
+// transicion que la maquina de estados no permite responde 409.
+func (t *Transaccion) transicionar(nuevo transacciones.Estado) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("Id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "No se selecciono la transaccion", nil, err.Error()))
+			return
+		}
+
+		transaccion, err := t.service.Transicionar(id, nuevo)
+
+		if errors.Is(err, transacciones.ErrTransicionNoValida) || errors.Is(err, transacciones.ErrEnRevision) {
+			ctx.JSON(http.StatusConflict, web.NewResponse(http.StatusConflict, "La transicion no es valida", nil, err.Error()))
+			return
+		}
+
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Error al tratar de cambiar el estado de la transaccion", nil, err.Error()))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Estado de la transaccion actualizado con exito", enVersion(ctx, transaccion), ""))
+	}
+}
+
+// Authorize a pending transaction
+// @Summary Authorize transaction
+// @Tags Transaction
+// @Description Authorize a pending transaction; any other transition, or a transaction waiting for risk review, responds 409
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param Id path int true "Id"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/{Id}/autorizar [POST]
+func (t *Transaccion) Autorizar() gin.HandlerFunc {
+	return t.transicionar(transacciones.ESTADO_AUTORIZADA)
+}
+
+// Settle an authorized transaction
+// @Summary Settle transaction
+// @Tags Transaction
+// @Description Settle an authorized transaction; any other transition responds 409
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param Id path int true "Id"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/{Id}/liquidar [POST]
+func (t *Transaccion) Liquidar() gin.HandlerFunc {
+	return t.transicionar(transacciones.ESTADO_LIQUIDADA)
+}
+
+// Reject a pending or authorized transaction
+// @Summary Reject transaction
+// @Tags Transaction
+// @Description Reject a pending or authorized transaction; any other transition responds 409
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param Id path int true "Id"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/{Id}/rechazar [POST]
+func (t *Transaccion) Rechazar() gin.HandlerFunc {
+	return t.transicionar(transacciones.ESTADO_RECHAZADA)
+}
+
+// Reverse a transaction
+// @Summary Reverse transaction
+// @Tags Transaction
+// @Description Refund a settled transaction, fully or partially, with a compensating transaction that references it.
+// @Description Refunds can not exceed the original amount; the original becomes revertida once fully refunded.
+// @Accept json
+// @Produce json
+// @Param authorization header string true "authorization"
+// @Param version query string false "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400"
+// @Param Idempotency-Key header string false "retries with the same key and body replay the original response"
+// @Param Id path int true "Id"
+// @Param reversa body reversaRequest false "amount to refund, everything left when omitted"
+// @Succes 200 {object} web.Response
+// @Router /transacciones/{Id}/reversa [POST]
+func (t *Transaccion) Reversa() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("Id"))
+
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "No se selecciono la transaccion a revertir", nil, err.Error()))
+			return
+		}
+
+		var request reversaRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+
+		reversa := transacciones.Reversa{CodigoTransaccion: request.CodigoTransaccion, Monto: request.Monto, FechaTransaccion: time.Now().In(t.zona)}
+		if request.FechaTransaccion != "" {
+			if reversa.FechaTransaccion, err = fecha.Parse(request.FechaTransaccion, t.zona); err != nil {
+				ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+				return
+			}
+		}
+
+		transaccion, err := t.service.StoreReversa(id, reversa)
+
+		switch {
+		case esErrorDeValidacion(err):
+			ctx.JSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+		case errors.Is(err, transacciones.ErrReversaExcedida):
+			ctx.JSON(http.StatusUnprocessableEntity, web.NewResponse(http.StatusUnprocessableEntity, "La reversa excede el monto original", nil, err.Error()))
+		case errors.Is(err, transacciones.ErrReversaNoValida), errors.Is(err, transacciones.ErrCodigoDuplicado):
+			ctx.JSON(http.StatusConflict, web.NewResponse(http.StatusConflict, "La transaccion no se puede revertir", nil, err.Error()))
+		case err != nil:
+			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Error al tratar de revertir la transaccion", nil, err.Error()))
+		default:
+			ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion revertida con exito", enVersion(ctx, transaccion), ""))
+		}
 	}
 }
 
 // Delete a specific transaction
 // @Summary Delete transaction
 // @Tags Transaction
-// @Description Delete an specific transaction using the id
+// @Description Delete an specific transaction using the id. A transaction with reversals, or a reversal itself, can not be deleted and responds 409
 // @Accept json
 // @Produce json
 // @Param authorization header string true "authorization"
@@ -301,7 +711,11 @@ func (t *Transaccion) Delete() gin.HandlerFunc {
 		}
 
 		if err := t.service.Delete(id); err != nil {
-			ctx.JSON(http.StatusNotFound, web.NewResponse(http.StatusNotFound, "Ocurrio un error al eliminar la transaccion", nil, err.Error()))
+			status := http.StatusNotFound
+			if errors.Is(err, transacciones.ErrReversaVinculada) {
+				status = http.StatusConflict
+			}
+			ctx.JSON(status, web.NewResponse(status, "Ocurrio un error al eliminar la transaccion", nil, err.Error()))
 			return
 		}
 		ctx.JSON(http.StatusOK, web.NewResponse(http.StatusOK, "Transaccion eliminada con exito", nil, ""))
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/divisas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/web"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	VERSION_LEGADO = "1"
+	VERSION_ACTUAL = "2"
+	// MEDIA_TYPE_V2 pide la version 2 por negociacion de contenido; el
+	// encabezado Accept puede pedir cualquier version como
+	// application/vnd.transacciones.v<version>+json.
+	MEDIA_TYPE_V2 = "application/vnd.transacciones.v2+json"
+
+	mediaTypePrefijo = "application/vnd.transacciones.v"
+	mediaTypeSufijo  = "+json"
+)
+
+var ErrVersionNoValida = errors.New("version de respuesta no soportada")
+
+// transaccionV1 muestra la fecha como dd/mm/aaaa en la zona en que se
+// registro, igual que antes de guardar fechas con hora.
+type transaccionV1 struct {
+	transacciones.Transaccion
+	FechaTransaccion string `json:"fecha_transaccion"`
+}
+
+type transaccionDetalleV1 struct {
+	transaccionV1
+	Reversas       []transaccionV1 `json:"reversas"`
+	MontoRevertido dinero.Monto    `json:"monto_revertido"`
+}
+
+type transaccionConvertidaV1 struct {
+	transaccionV1
+	Conversion divisas.Conversion `json:"conversion"`
+}
+
+// versionSolicitada regresa la version de respuesta que pide el cliente con
+// el parametro version o con el encabezado Accept. Sin ninguno se responde en
+// la version 1 para no romper a los clientes existentes; una version que no
+// existe es un error.
+func versionSolicitada(ctx *gin.Context) (string, error) {
+	if version := ctx.Query("version"); version != "" {
+		return validarVersion(version)
+	}
+	for _, tipo := range strings.Split(ctx.GetHeader("Accept"), ",") {
+		if index := strings.Index(tipo, ";"); index >= 0 {
+			tipo = tipo[:index]
+		}
+		tipo = strings.ToLower(strings.TrimSpace(tipo))
+		if strings.HasPrefix(tipo, mediaTypePrefijo) && strings.HasSuffix(tipo, mediaTypeSufijo) {
+			return validarVersion(strings.TrimSuffix(strings.TrimPrefix(tipo, mediaTypePrefijo), mediaTypeSufijo))
+		}
+	}
+	return VERSION_LEGADO, nil
+}
+
+func validarVersion(version string) (string, error) {
+	switch version = strings.TrimSpace(version); version {
+	case VERSION_LEGADO, VERSION_ACTUAL:
+		return version, nil
+	}
+	return "", fmt.Errorf("%w: %q, las versiones soportadas son %s y %s", ErrVersionNoValida, version, VERSION_LEGADO, VERSION_ACTUAL)
+}
+
+// VersionValida responde 400 antes de atender la peticion si el cliente pide
+// una version de respuesta que no existe.
+func VersionValida() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, err := versionSolicitada(ctx); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, web.NewResponse(http.StatusBadRequest, "Peticion no valida", nil, err.Error()))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func aV1(transaccion transacciones.Transaccion) transaccionV1 {
+	return transaccionV1{Transaccion: transaccion, FechaTransaccion: transaccion.FechaTransaccion.Format(fecha.FORMATO_LEGADO)}
+}
+
+// enVersion adapta las transacciones de data a la version que pidio el
+// cliente; en la version 2 la fecha se emite en RFC 3339.
+func enVersion(ctx *gin.Context, data interface{}) interface{} {
+	ctx.Header("Vary", "Accept")
+	if version, _ := versionSolicitada(ctx); version == VERSION_ACTUAL {
+		return data
+	}
+
+	switch valor := data.(type) {
+	case transacciones.Transaccion:
+		return aV1(valor)
+	case []transacciones.Transaccion:
+		lista := make([]transaccionV1, len(valor))
+		for index, transaccion := range valor {
+			lista[index] = aV1(transaccion)
+		}
+		return lista
+	case transaccionDetalle:
+		reversas := make([]transaccionV1, len(valor.Reversas))
+		for index, reversa := range valor.Reversas {
+			reversas[index] = aV1(reversa)
+		}
+		return transaccionDetalleV1{transaccionV1: aV1(valor.Transaccion), Reversas: reversas, MontoRevertido: valor.MontoRevertido}
+	case []transaccionConvertida:
+		lista := make([]transaccionConvertidaV1, len(valor))
+		for index, transaccion := range valor {
+			lista[index] = transaccionConvertidaV1{transaccionV1: aV1(transaccion.Transaccion), Conversion: transaccion.Conversion}
+		}
+		return lista
+	}
+	return data
+}
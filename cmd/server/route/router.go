@@ -1,9 +1,21 @@
 package route
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/BrandonICR/web_cl2_050422_8am/cmd/server/handler"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/conciliacion"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/divisas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/estadocuenta"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/idempotencia"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/libro"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/limites"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/riesgo"
 	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
-	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
 	"github.com/gin-gonic/gin"
 )
 
@@ -11,35 +23,172 @@ type Router interface {
 	MapRoutes()
 }
 
+// Repositories agrupa los repositorios sobre los que se construyen los
+// servicios de cada grupo de rutas.
+type Repositories struct {
+	Transacciones  transacciones.Repository
+	TiposCambio    divisas.Repository
+	Idempotencia   idempotencia.Repository
+	Libro          libro.Repository
+	Partes         partes.Repository
+	Limites        limites.Repository
+	Reglas         riesgo.Repository
+	Conciliaciones conciliacion.Repository
+}
+
 type router struct {
-	r  *gin.Engine
-	rg *gin.RouterGroup
-	db *store.Store
+	r             *gin.Engine
+	rg            *gin.RouterGroup
+	repositories  Repositories
+	monedas       monedas.Service
+	divisas       divisas.Service
+	libro         libro.Service
+	partes        partes.Service
+	limites       limites.Service
+	transacciones transacciones.Service
+	zona          *time.Location
 }
 
-func NewRouter(r *gin.Engine, db *store.Store) Router {
-	return &router{r: r, db: db}
+// NewRouter crea el router; zona es la zona horaria de las fechas que se
+// reciben sin zona.
+func NewRouter(r *gin.Engine, repositories Repositories, zona *time.Location) Router {
+	return &router{r: r, repositories: repositories, zona: zona}
 }
 
 func (r *router) MapRoutes() {
 	r.setGroup()
+	r.buildMonedaRoutes()
+	r.buildTipoCambioRoutes()
+	r.buildParteRoutes()
+	r.buildLimiteRoutes()
+	r.buildCuentaRoutes()
 	r.buildTransactionRoutes()
+	r.buildExtractoRoutes()
+	r.buildConciliacionRoutes()
+	r.buildReporteRoutes()
+	r.buildEstadoCuentaRoutes()
 }
 
 func (r *router) setGroup() {
-	r.rg = r.r.Group("/api/v1/transacciones")
+	r.rg = r.r.Group("/api/v1")
+}
+
+func (r *router) buildMonedaRoutes() {
+	r.monedas = monedas.NewService(monedas.NewRepository())
+	monedas := handler.NewMoneda(r.monedas)
+
+	rg := r.rg.Group("/monedas")
+	rg.GET("", monedas.GetAll())
+}
+
+func (r *router) buildTipoCambioRoutes() {
+	r.divisas = divisas.NewService(r.repositories.TiposCambio, r.monedas)
+	tiposCambio := handler.NewTipoCambio(r.divisas)
+
+	rg := r.rg.Group("/tipos-cambio")
+	rg.GET("", tiposCambio.GetAll())
+	rg.POST("", tiposCambio.Store())
+}
+
+func (r *router) buildParteRoutes() {
+	r.partes = partes.NewService(r.repositories.Partes)
+	partes := handler.NewParte(r.partes)
+
+	rg := r.rg.Group("/partes")
+	rg.GET("", partes.GetAll())
+	rg.GET("/:Id", partes.Get())
+	rg.POST("", partes.Store())
+	rg.PUT("/:Id", partes.Update())
+}
+
+func (r *router) buildLimiteRoutes() {
+	r.limites = limites.NewService(r.repositories.Limites)
+	limites := handler.NewLimite(r.limites)
+
+	rg := r.rg.Group("/limites")
+	rg.GET("", limites.GetAll())
+}
+
+// buildCuentaRoutes sincroniza el libro con las transacciones existentes antes
+// de que el servicio de transacciones empiece a notificarle cambios. Sin
+// transacciones se sincroniza con una lista vacia para no conservar asientos
+// de transacciones que ya no existen.
+func (r *router) buildCuentaRoutes() {
+	r.libro = libro.NewService(r.repositories.Libro)
+	existentes, err := r.repositories.Transacciones.GetAll()
+	if err != nil && !errors.Is(err, transacciones.ErrSinResultados) {
+		panic(fmt.Sprintf("error: no se lograron leer las transacciones para el libro mayor: %v", err))
+	}
+	if err := r.libro.Sincronizar(existentes); err != nil {
+		panic(fmt.Sprintf("error: no se logro sincronizar el libro mayor: %v", err))
+	}
+	cuentas := handler.NewCuenta(r.libro)
+
+	rg := r.rg.Group("/cuentas")
+	rg.GET("/:nombre/saldo", cuentas.GetSaldo())
+	rg.GET("/:nombre/movimientos", cuentas.GetMovimientos())
 }
 
 func (r *router) buildTransactionRoutes() {
-	repository := transacciones.NewRepository(*r.db)
-	service := transacciones.NewService(repository)
-	transacciones := handler.NewTransaccion(service)
-
-	r.rg.GET("", transacciones.GetAll())
-	r.rg.GET("/", transacciones.GetTransaccionFiltrada())
-	r.rg.POST("/:Id", transacciones.Store())
-	r.rg.GET("/:Id", transacciones.GetTransaccion())
-	r.rg.PUT("/:Id", transacciones.Update())
-	r.rg.PATCH("/:Id", transacciones.Patch())
-	r.rg.DELETE("/:Id", transacciones.Delete())
+	r.transacciones = transacciones.NewService(r.repositories.Transacciones, transacciones.ConMonedas(r.monedas),
+		transacciones.ConPartes(r.partes), transacciones.ConLimites(r.limites),
+		transacciones.ConReglas(riesgo.NewService(r.repositories.Reglas)), transacciones.ConObservador(r.libro))
+	transacciones := handler.NewTransaccion(r.transacciones, r.monedas, r.divisas, r.partes, r.zona)
+	revision := handler.NewRevision(r.transacciones)
+	idempotente := handler.Idempotencia(idempotencia.NewService(r.repositories.Idempotencia))
+
+	rg := r.rg.Group("/transacciones", handler.VersionValida())
+	rg.GET("", transacciones.GetAll())
+	rg.GET("/", transacciones.GetTransaccionFiltrada())
+	rg.POST("/:Id", idempotente, transacciones.Store())
+	rg.POST("/importar", transacciones.Importar())
+	rg.GET("/exportar", transacciones.Exportar())
+	rg.GET("/iso20022/camt053", transacciones.ExportarCamt053())
+	rg.POST("/iso20022/camt053", transacciones.ImportarCamt053())
+	rg.GET("/iso20022/pain001", transacciones.ExportarPain001())
+	rg.POST("/iso20022/pain001", transacciones.ImportarPain001())
+	rg.GET("/codigo/:codigo", transacciones.GetByCodigo())
+	rg.GET("/:Id", transacciones.GetTransaccion())
+	rg.PUT("/:Id", transacciones.Update())
+	rg.PATCH("/:Id", transacciones.Patch())
+	rg.DELETE("/:Id", transacciones.Delete())
+	rg.POST("/:Id/autorizar", transacciones.Autorizar())
+	rg.POST("/:Id/liquidar", transacciones.Liquidar())
+	rg.POST("/:Id/rechazar", transacciones.Rechazar())
+	rg.POST("/:Id/reversa", idempotente, transacciones.Reversa())
+
+	rgRevision := r.rg.Group("/revision", handler.VersionValida())
+	rgRevision.GET("", revision.GetAll())
+	rgRevision.POST("/:Id/aprobar", revision.Aprobar())
+}
+
+func (r *router) buildExtractoRoutes() {
+	extractos := handler.NewExtracto(r.zona)
+
+	rg := r.rg.Group("/extractos")
+	rg.POST("/mt940", extractos.MT940())
+}
+
+func (r *router) buildConciliacionRoutes() {
+	conciliaciones := handler.NewConciliacion(conciliacion.NewService(r.repositories.Conciliaciones, r.transacciones), r.zona)
+
+	rg := r.rg.Group("/conciliaciones")
+	rg.GET("", conciliaciones.GetAll())
+	rg.POST("", conciliaciones.Store())
+	rg.GET("/:Id", conciliaciones.Get())
+	rg.GET("/:Id/exportar", conciliaciones.Exportar())
+}
+
+func (r *router) buildReporteRoutes() {
+	reportes := handler.NewReporte(r.transacciones, r.zona)
+
+	rg := r.rg.Group("/reportes")
+	rg.GET("/resumen", reportes.Resumen())
+}
+
+func (r *router) buildEstadoCuentaRoutes() {
+	estados := handler.NewEstadoCuenta(estadocuenta.NewService(r.transacciones, r.partes), r.zona)
+
+	rg := r.rg.Group("/estados-cuenta")
+	rg.GET("/:nombre", estados.Get())
 }
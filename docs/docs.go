@@ -19,19 +19,1306 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/conciliaciones": {
+            "get": {
+                "description": "Get the stored reconciliation reports with their totals, without the matched and unmatched items",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliation"
+                ],
+                "summary": "Get all reconciliations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "description": "Reconcile an external statement against the stored transactions and keep the report. The statement is either a json body with its lines or an MT940 file, sent as the body or as the archivo field of a multipart form, with the parameters in the query.\nLines are first matched by codigo_transaccion and then, when no transaction has their code, by currency, amount and date. The sign of a line amount is ignored. A pair within both tolerances is conciliada; a pair by code within the day tolerance but outside the amount tolerance is a diferencia_monto; the rest end up in solo_internas or solo_externas with the reason.\nWithout a period, the transactions from the day of the first line to the day of the last one, widened by tolerancia_dias, are reconciled. Rejected transactions are never reconciled.",
+                "consumes": [
+                    "application/json",
+                    "text/plain",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliation"
+                ],
+                "summary": "Reconcile statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "statement lines and parameters, when sending json",
+                        "name": "conciliacion",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.conciliacionRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "MT940 file when sending a multipart form",
+                        "name": "archivo",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "MT940 only: reconcile the transactions where this party is emisor or receptor",
+                        "name": "parte_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MT940 only: from date or timestamp, inclusive",
+                        "name": "fecha_desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MT940 only: to date or timestamp, inclusive; a date includes the whole day",
+                        "name": "fecha_hasta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MT940 only: amount tolerance, e.g. 0.50; defaults to 0",
+                        "name": "tolerancia_monto",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "MT940 only: date tolerance in calendar days; defaults to 0",
+                        "name": "tolerancia_dias",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/conciliaciones/{Id}": {
+            "get": {
+                "description": "Get a stored reconciliation report with its conciliadas, diferencias_monto, solo_internas and solo_externas",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliation"
+                ],
+                "summary": "Get reconciliation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/conciliaciones/{Id}/exportar": {
+            "get": {
+                "description": "Export a stored reconciliation report as a CSV or JSON Lines file, one row per pair or unmatched item",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Reconciliation"
+                ],
+                "summary": "Export reconciliation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv (default) or ndjson",
+                        "name": "formato",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/cuentas/{nombre}/movimientos": {
+            "get": {
+                "description": "Get the ledger entries of a party ordered by date with the running balance per currency",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Get account statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "party name",
+                        "name": "nombre",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only entries in this currency",
+                        "name": "moneda",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/cuentas/{nombre}/saldo": {
+            "get": {
+                "description": "Get the ledger balance of a party for each currency it has moved",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Get account balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "party name",
+                        "name": "nombre",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/estados-cuenta/{nombre}": {
+            "get": {
+                "description": "Get every transaction where the party is emisor or receptor in the period, grouped by currency, with the opening balance, the running balance after each line and the closing balance.\nTransactions the party issued are charges (negative) and the ones it received are credits. Rejected transactions are listed but do not change the balance. Without fecha_desde the opening balance is zero.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/html"
+                ],
+                "tags": [
+                    "Statement"
+                ],
+                "summary": "Get account statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "registered party name, case and spacing insensitive",
+                        "name": "nombre",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "from date or timestamp, inclusive",
+                        "name": "fecha_desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "to date or timestamp, inclusive; a date includes the whole day. Defaults to now",
+                        "name": "fecha_hasta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only this currency",
+                        "name": "moneda",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv or html (printable)",
+                        "name": "formato",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/web.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/estadocuenta.EstadoCuenta"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/extractos/mt940": {
+            "post": {
+                "description": "Parse a SWIFT MT940 file, sent as the body or as the archivo field of a multipart form, into normalized statement lines for reconciliation. Nothing is stored.\nThe common bank dialects are accepted: with or without the SWIFT envelope, CRLF line breaks, comma or dot decimals, optional entry date, :28: or :28C:, statements without the closing \"-\" and a free, ?NN or /KEY/ structured :86:.\nAmounts are negative for debits. Balances that do not add up are reported as advertencias of the statement.",
+                "consumes": [
+                    "text/plain",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Statement"
+                ],
+                "summary": "Parse MT940 statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "file to parse when sending a multipart form",
+                        "name": "archivo",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/limites": {
+            "get": {
+                "description": "Get the per issuer and currency limits checked when transactions are stored or updated; emisor_id 0 applies to issuers without their own limit",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Limit"
+                ],
+                "summary": "Get all limits",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/monedas": {
+            "get": {
+                "description": "Get the ISO 4217 currency catalogue used to validate transactions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Currency"
+                ],
+                "summary": "Get all currencies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only active currencies",
+                        "name": "activas",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/partes": {
+            "get": {
+                "description": "Get the registered banks, people and merchants that issue or receive transactions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Party"
+                ],
+                "summary": "Get all parties",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "description": "Register an active party; names that only differ in case or spacing are rejected as duplicates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Party"
+                ],
+                "summary": "Store party",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "party",
+                        "name": "parte",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.parteRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/partes/{Id}": {
+            "get": {
+                "description": "Get a registered party by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Party"
+                ],
+                "summary": "Get party",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "put": {
+                "description": "Update the name, type and status of a party; inactive parties cannot take part in new transactions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Party"
+                ],
+                "summary": "Update party",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "party",
+                        "name": "parte",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.parteRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/reportes/resumen": {
+            "get": {
+                "description": "Count and add up the transactions that match the same filters as GET /transacciones/, grouped by the requested keys, with the minimum, maximum and average amount of each group.\nAmounts of different currencies are never added, so moneda is always a grouping key. Days, ISO weeks (e.g. 2022-W16) and months are taken in the server time zone. Groups are sorted by their key values.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Summarize transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated keys: moneda, emisor, receptor, dia, semana, mes; e.g. emisor,mes",
+                        "name": "agrupar",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "codigo_transaccion",
+                        "name": "codigo_transaccion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "one or more comma separated currencies, e.g. MXN,USD",
+                        "name": "moneda",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exact amount, 0 included",
+                        "name": "monto",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum amount, inclusive",
+                        "name": "monto_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "maximum amount, inclusive",
+                        "name": "monto_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "emisor",
+                        "name": "emisor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "how emisor is matched: exacta (default), prefijo or contiene",
+                        "name": "emisor_coincidencia",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "receptor",
+                        "name": "receptor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "day of the transaction: dd/mm/yyyy, yyyy-mm-dd or RFC 3339",
+                        "name": "fecha_transaccion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "from date or timestamp, inclusive",
+                        "name": "fecha_desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "to date or timestamp, inclusive; a date includes the whole day",
+                        "name": "fecha_hasta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated states: pendiente, autorizada, liquidada, rechazada, revertida",
+                        "name": "estado",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/revision": {
+            "get": {
+                "description": "Get the pending transactions flagged by the risk rules that wait for manual approval, highest risk score first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Get review queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/revision/{Id}/aprobar": {
+            "post": {
+                "description": "Approve a transaction flagged by the risk rules so it can be authorized; responds 409 when it is not waiting for review.\nFlagged transactions are rejected with POST /transacciones/{Id}/rechazar.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Approve flagged transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/tipos-cambio": {
+            "get": {
+                "description": "Get the dated exchange rates used to convert transactions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ExchangeRate"
+                ],
+                "summary": "Get all exchange rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "description": "Store the rate of a currency pair from a date on, replacing the one of the same date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ExchangeRate"
+                ],
+                "summary": "Store exchange rate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "exchange rate",
+                        "name": "tipo_cambio",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.tipoCambioRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
         "/transacciones": {
             "get": {
-                "description": "Get  alltransactions",
+                "description": "Get  alltransactions, optionally converted to another currency as of each transaction date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get all transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 4217 currency to convert the amounts to",
+                        "name": "convertir_a",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, all transactions when omitted; at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of transactions to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields, - for descending, e.g. -monto,fecha_transaccion",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "description": "Store a specific transaction using the body; responds 422 with the violated limit when it exceeds a limit of the issuer.\nTransactions are evaluated against the risk rules: reject responds 422 with the evaluation and flag stores them for manual review.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Store transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "retries with the same key and body replay the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "transaction",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.request"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/transacciones/": {
+            "get": {
+                "description": "Get transaction using a filter",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get transaction using a filter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "codigo_transaccion",
+                        "name": "codigo_transaccion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "one or more comma separated currencies, e.g. MXN,USD",
+                        "name": "moneda",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exact amount, 0 included",
+                        "name": "monto",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum amount, inclusive",
+                        "name": "monto_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "maximum amount, inclusive",
+                        "name": "monto_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "emisor",
+                        "name": "emisor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "how emisor is matched: exacta (default), prefijo or contiene",
+                        "name": "emisor_coincidencia",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "receptor",
+                        "name": "receptor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "day of the transaction: dd/mm/yyyy, yyyy-mm-dd or RFC 3339",
+                        "name": "fecha_transaccion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "from date or timestamp, inclusive",
+                        "name": "fecha_desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "to date or timestamp, inclusive; a date includes the whole day",
+                        "name": "fecha_hasta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated states: pendiente, autorizada, liquidada, rechazada, revertida",
+                        "name": "estado",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, all transactions when omitted; at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of transactions to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields, - for descending, e.g. -monto,fecha_transaccion",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/transacciones/codigo/{codigo}": {
+            "get": {
+                "description": "Get the transaction with the given codigo_transaccion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get transaction by code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "codigo_transaccion",
+                        "name": "codigo",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/transacciones/exportar": {
+            "get": {
+                "description": "Export the transactions that match the same filters as GET /transacciones/ as a CSV, JSON Lines or Excel file.\nRows are streamed page by page; an error after the first byte ends the file early.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Export transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv (default), ndjson or xlsx",
+                        "name": "formato",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated columns in order; defaults to id,codigo_transaccion,moneda,monto,emisor,receptor,fecha_transaccion,estado",
+                        "name": "columnas",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "number format of the csv amounts, e.g. es-MX or es-ES; a decimal comma also separates columns with ;",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "codigo_transaccion",
+                        "name": "codigo_transaccion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "one or more comma separated currencies, e.g. MXN,USD",
+                        "name": "moneda",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exact amount, 0 included",
+                        "name": "monto",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum amount, inclusive",
+                        "name": "monto_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "maximum amount, inclusive",
+                        "name": "monto_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "emisor",
+                        "name": "emisor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "how emisor is matched: exacta (default), prefijo or contiene",
+                        "name": "emisor_coincidencia",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "receptor",
+                        "name": "receptor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "day of the transaction: dd/mm/yyyy, yyyy-mm-dd or RFC 3339",
+                        "name": "fecha_transaccion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "from date or timestamp, inclusive",
+                        "name": "fecha_desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "to date or timestamp, inclusive; a date includes the whole day",
+                        "name": "fecha_hasta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated states: pendiente, autorizada, liquidada, rechazada, revertida",
+                        "name": "estado",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields, - for descending, e.g. -monto,fecha_transaccion",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/transacciones/importar": {
+            "post": {
+                "description": "Import transactions from a CSV file with a header row or from JSON Lines, sent as the body or as the archivo field of a multipart form.\nEvery row is validated like POST /transacciones and checked against the limits and risk rules.\nIn completo mode (default) nothing is stored when any row fails and the report responds 422; in parcial mode the valid rows are stored.",
                 "consumes": [
+                    "multipart/form-data",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Import transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "file to import when sending a multipart form",
+                        "name": "archivo",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson; inferred from the content type or the file extension when omitted",
+                        "name": "formato",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "completo (all or nothing, default) or parcial (best effort)",
+                        "name": "modo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated campo:columna pairs, e.g. codigo_transaccion:referencia,monto:importe",
+                        "name": "mapeo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/transacciones/iso20022/camt053": {
+            "get": {
+                "description": "Export the transactions of a party in one currency as an ISO 20022 camt.053.001.02 bank to customer statement.\nAccounts are identified by the party id in Othr/Id; transactions stored before the party registry are matched by name. Rejected transactions are left out; every other one is a BOOK entry, so the balances match GET /cuentas/{nombre}/saldo and GET /estados-cuenta/{nombre}.\nThe opening balance adds the transactions before fecha_desde.",
+                "produces": [
+                    "application/xml"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Export camt.053 statement",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "party id whose account is reported",
+                        "name": "parte",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "currency of the statement",
+                        "name": "moneda",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "from date or timestamp, inclusive",
+                        "name": "fecha_desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "to date or timestamp, inclusive; a date includes the whole day",
+                        "name": "fecha_hasta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Import the entries of an ISO 20022 camt.053 statement (any camt.053.001 version), sent as the body or as the archivo field of a multipart form.\nThe document is validated against the schema structure first. Every transaction detail becomes a pending transaction: EndToEndId is its codigo_transaccion and the debtor and creditor accounts must carry a party id in Othr/Id; a missing side is the statement account.\nThe report is the same as POST /transacciones/importar, with linea as the position of the transaction in the document.",
+                "consumes": [
+                    "application/xml",
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Transaction"
                 ],
-                "summary": "Get all transactions",
+                "summary": "Import camt.053 statement",
                 "parameters": [
                     {
                         "type": "string",
@@ -39,12 +1326,199 @@ const docTemplate = `{
                         "name": "authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "file to import when sending a multipart form",
+                        "name": "archivo",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "completo (all or nothing, default) or parcial (best effort)",
+                        "name": "modo",
+                        "in": "query"
                     }
                 ],
                 "responses": {}
+            }
+        },
+        "/transacciones/iso20022/pain001": {
+            "get": {
+                "description": "Export the transactions that match the same filters as GET /transacciones/ as an ISO 20022 pain.001.001.03 customer credit transfer initiation.\nTransfers are grouped in one PmtInf per emisor and execution day; EndToEndId carries codigo_transaccion and accounts carry the party id in Othr/Id.",
+                "produces": [
+                    "application/xml"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Export pain.001 initiation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "name of the initiating party",
+                        "name": "iniciador",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "codigo_transaccion",
+                        "name": "codigo_transaccion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "one or more comma separated currencies, e.g. MXN,USD",
+                        "name": "moneda",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum amount, inclusive",
+                        "name": "monto_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "maximum amount, inclusive",
+                        "name": "monto_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "emisor",
+                        "name": "emisor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "receptor",
+                        "name": "receptor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "from date or timestamp, inclusive",
+                        "name": "fecha_desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "to date or timestamp, inclusive; a date includes the whole day",
+                        "name": "fecha_hasta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated states, e.g. pendiente,autorizada",
+                        "name": "estado",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields, - for descending, e.g. -monto,fecha_transaccion",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
             },
             "post": {
-                "description": "Store a specific transaction using the body",
+                "description": "Import the transfers of an ISO 20022 pain.001 credit transfer initiation (any pain.001.001 version), sent as the body or as the archivo field of a multipart form.\nThe document is validated against the schema structure first, NbOfTxs and CtrlSum included. Every CdtTrfTxInf becomes a pending transaction dated on the requested execution day: EndToEndId is its codigo_transaccion and DbtrAcct and CdtrAcct must carry a party id in Othr/Id.\nThe report is the same as POST /transacciones/importar, with linea as the position of the transaction in the document.",
+                "consumes": [
+                    "application/xml",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Import pain.001 initiation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "file to import when sending a multipart form",
+                        "name": "archivo",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "completo (all or nothing, default) or parcial (best effort)",
+                        "name": "modo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/transacciones/{Id}": {
+            "get": {
+                "description": "Get a specific transaction using the id, with the reversals that refund it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Get transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "put": {
+                "description": "Update a specific transaction using the id and body; responds 422 with the violated limit when it exceeds a limit of the issuer.\nTransactions are evaluated against the risk rules: reject responds 422 with the evaluation and flag stores them for manual review.",
                 "consumes": [
                     "application/json"
                 ],
@@ -54,7 +1528,7 @@ const docTemplate = `{
                 "tags": [
                     "Transaction"
                 ],
-                "summary": "Store transaction",
+                "summary": "Update transaction",
                 "parameters": [
                     {
                         "type": "string",
@@ -63,6 +1537,19 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "description": "transaction",
                         "name": "transaction",
@@ -74,11 +1561,9 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {}
-            }
-        },
-        "/transacciones/": {
-            "get": {
-                "description": "Get transaction using a filter",
+            },
+            "delete": {
+                "description": "Delete an specific transaction using the id. A transaction with reversals, or a reversal itself, can not be deleted and responds 409",
                 "consumes": [
                     "application/json"
                 ],
@@ -88,7 +1573,7 @@ const docTemplate = `{
                 "tags": [
                     "Transaction"
                 ],
-                "summary": "Get transaction using a filter",
+                "summary": "Delete transaction",
                 "parameters": [
                     {
                         "type": "string",
@@ -99,53 +1584,63 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "id",
-                        "name": "id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "codigo_transaccion",
-                        "name": "codigo_transaccion",
-                        "in": "query"
-                    },
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "patch": {
+                "description": "Patch an specific transaction using the id and body; responds 422 with the violated limit when it exceeds a limit of the issuer.\nTransactions are evaluated against the risk rules: reject responds 422 with the evaluation and flag stores them for manual review.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Patch transaction",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "moneda",
-                        "name": "moneda",
-                        "in": "query"
-                    },
-                    {
-                        "type": "number",
-                        "description": "monto",
-                        "name": "monto",
-                        "in": "query"
+                        "description": "authorization",
+                        "name": "authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "emisor",
-                        "name": "emisor",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "receptor",
-                        "name": "receptor",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Id",
+                        "name": "Id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "fecha_transaccion",
-                        "name": "fecha_transaccion",
-                        "in": "query"
+                        "description": "transaction",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.patchRequest"
+                        }
                     }
                 ],
                 "responses": {}
             }
         },
-        "/transacciones/{Id}": {
-            "get": {
-                "description": "Get a specific transaction using the id",
+        "/transacciones/{Id}/autorizar": {
+            "post": {
+                "description": "Authorize a pending transaction; any other transition, or a transaction waiting for risk review, responds 409",
                 "consumes": [
                     "application/json"
                 ],
@@ -155,7 +1650,7 @@ const docTemplate = `{
                 "tags": [
                     "Transaction"
                 ],
-                "summary": "Get transaction",
+                "summary": "Authorize transaction",
                 "parameters": [
                     {
                         "type": "string",
@@ -164,6 +1659,12 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Id",
@@ -173,9 +1674,11 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {}
-            },
-            "put": {
-                "description": "Update a specific transaction using the id and body",
+            }
+        },
+        "/transacciones/{Id}/liquidar": {
+            "post": {
+                "description": "Settle an authorized transaction; any other transition responds 409",
                 "consumes": [
                     "application/json"
                 ],
@@ -185,7 +1688,7 @@ const docTemplate = `{
                 "tags": [
                     "Transaction"
                 ],
-                "summary": "Update transaction",
+                "summary": "Settle transaction",
                 "parameters": [
                     {
                         "type": "string",
@@ -194,27 +1697,26 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Id",
                         "name": "Id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "transaction",
-                        "name": "transaction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.request"
-                        }
                     }
                 ],
                 "responses": {}
-            },
-            "delete": {
-                "description": "Delete an specific transaction using the id",
+            }
+        },
+        "/transacciones/{Id}/rechazar": {
+            "post": {
+                "description": "Reject a pending or authorized transaction; any other transition responds 409",
                 "consumes": [
                     "application/json"
                 ],
@@ -224,7 +1726,7 @@ const docTemplate = `{
                 "tags": [
                     "Transaction"
                 ],
-                "summary": "Delete transaction",
+                "summary": "Reject transaction",
                 "parameters": [
                     {
                         "type": "string",
@@ -233,6 +1735,12 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Id",
@@ -242,9 +1750,11 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {}
-            },
-            "patch": {
-                "description": "Patch an specific transaction using the id and body",
+            }
+        },
+        "/transacciones/{Id}/reversa": {
+            "post": {
+                "description": "Refund a settled transaction, fully or partially, with a compensating transaction that references it.\nRefunds can not exceed the original amount; the original becomes revertida once fully refunded.",
                 "consumes": [
                     "application/json"
                 ],
@@ -254,7 +1764,7 @@ const docTemplate = `{
                 "tags": [
                     "Transaction"
                 ],
-                "summary": "Patch transaction",
+                "summary": "Reverse transaction",
                 "parameters": [
                     {
                         "type": "string",
@@ -263,6 +1773,18 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "response version: 1 with dd/mm/yyyy dates (default) or 2 with RFC 3339 timestamps; any other value is a 400",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "retries with the same key and body replay the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
                     {
                         "type": "integer",
                         "description": "Id",
@@ -271,12 +1793,11 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "transaction",
-                        "name": "transaction",
+                        "description": "amount to refund, everything left when omitted",
+                        "name": "reversa",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.patchRequest"
+                            "$ref": "#/definitions/handler.reversaRequest"
                         }
                     }
                 ],
@@ -285,6 +1806,159 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "estadocuenta.EstadoCuenta": {
+            "type": "object",
+            "properties": {
+                "desde": {
+                    "type": "string"
+                },
+                "generado": {
+                    "type": "string"
+                },
+                "hasta": {
+                    "type": "string"
+                },
+                "parte": {
+                    "type": "string"
+                },
+                "parte_id": {
+                    "type": "integer"
+                },
+                "secciones": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/estadocuenta.Seccion"
+                    }
+                }
+            }
+        },
+        "estadocuenta.Linea": {
+            "type": "object",
+            "properties": {
+                "codigo_transaccion": {
+                    "type": "string"
+                },
+                "contraparte": {
+                    "type": "string"
+                },
+                "estado": {
+                    "type": "string",
+                    "example": "liquidada"
+                },
+                "fecha": {
+                    "type": "string"
+                },
+                "monto": {
+                    "type": "string",
+                    "example": "-4000.00"
+                },
+                "saldo": {
+                    "type": "string",
+                    "example": "1500.00"
+                },
+                "transaccion_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "estadocuenta.Seccion": {
+            "type": "object",
+            "properties": {
+                "abonos": {
+                    "type": "string",
+                    "example": "0.00"
+                },
+                "cargos": {
+                    "type": "string",
+                    "example": "4000.00"
+                },
+                "lineas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/estadocuenta.Linea"
+                    }
+                },
+                "moneda": {
+                    "type": "string"
+                },
+                "saldo_final": {
+                    "type": "string",
+                    "example": "1500.00"
+                },
+                "saldo_inicial": {
+                    "type": "string",
+                    "example": "5500.00"
+                }
+            }
+        },
+        "handler.conciliacionRequest": {
+            "type": "object",
+            "properties": {
+                "fecha_desde": {
+                    "type": "string"
+                },
+                "fecha_hasta": {
+                    "type": "string"
+                },
+                "lineas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.lineaConciliacionRequest"
+                    }
+                },
+                "parte_id": {
+                    "type": "integer"
+                },
+                "tolerancia_dias": {
+                    "type": "integer"
+                },
+                "tolerancia_monto": {
+                    "type": "string",
+                    "example": "0.50"
+                }
+            }
+        },
+        "handler.lineaConciliacionRequest": {
+            "type": "object",
+            "properties": {
+                "codigo_transaccion": {
+                    "type": "string",
+                    "example": "ctr2"
+                },
+                "descripcion": {
+                    "type": "string"
+                },
+                "fecha": {
+                    "type": "string",
+                    "example": "2022-04-21"
+                },
+                "moneda": {
+                    "type": "string",
+                    "example": "MXN"
+                },
+                "monto": {
+                    "type": "string",
+                    "example": "-4000.00"
+                }
+            }
+        },
+        "handler.parteRequest": {
+            "type": "object",
+            "properties": {
+                "estado": {
+                    "type": "string",
+                    "example": "activa"
+                },
+                "nombre": {
+                    "type": "string",
+                    "example": "Bancomer"
+                },
+                "tipo": {
+                    "type": "string",
+                    "example": "banco"
+                }
+            }
+        },
         "handler.patchRequest": {
             "type": "object",
             "properties": {
@@ -292,7 +1966,8 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "monto": {
-                    "type": "number"
+                    "type": "string",
+                    "example": "4000.50"
                 }
             }
         },
@@ -302,11 +1977,12 @@ const docTemplate = `{
                 "codigo_transaccion": {
                     "type": "string"
                 },
-                "emisor": {
-                    "type": "string"
+                "emisor_id": {
+                    "type": "integer"
                 },
                 "fecha_transaccion": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "2022-04-04T10:30:00-05:00"
                 },
                 "id": {
                     "type": "integer"
@@ -315,10 +1991,67 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "monto": {
-                    "type": "number"
+                    "type": "string",
+                    "example": "4000.50"
+                },
+                "receptor_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.reversaRequest": {
+            "type": "object",
+            "properties": {
+                "codigo_transaccion": {
+                    "type": "string"
+                },
+                "fecha_transaccion": {
+                    "type": "string",
+                    "example": "2022-04-04T10:30:00-05:00"
+                },
+                "monto": {
+                    "type": "string",
+                    "example": "100.00"
+                }
+            }
+        },
+        "handler.tipoCambioRequest": {
+            "type": "object",
+            "properties": {
+                "destino": {
+                    "type": "string"
                 },
-                "receptor": {
+                "fecha": {
+                    "type": "string",
+                    "example": "01/04/2022"
+                },
+                "origen": {
+                    "type": "string"
+                },
+                "tasa": {
+                    "type": "string",
+                    "example": "0.054321"
+                }
+            }
+        },
+        "web.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
                     "type": "string"
+                },
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         }
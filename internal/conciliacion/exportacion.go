@@ -0,0 +1,148 @@
+package conciliacion
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RESULTADO_CONCILIADA   = "conciliada"
+	RESULTADO_DIFERENCIA   = "diferencia_monto"
+	RESULTADO_SOLO_INTERNA = "solo_interna"
+	RESULTADO_SOLO_EXTERNA = "solo_externa"
+
+	FORMATO_CSV    = "csv"
+	FORMATO_NDJSON = "ndjson"
+)
+
+// Fila es una linea del reporte exportado: una pareja o un elemento sin
+// conciliar. Los campos del lado que falta quedan vacios.
+type Fila struct {
+	Resultado         string `json:"resultado"`
+	Criterio          string `json:"criterio,omitempty"`
+	TransaccionId     int    `json:"transaccion_id,omitempty"`
+	CodigoTransaccion string `json:"codigo_transaccion,omitempty"`
+	Moneda            string `json:"moneda"`
+	MontoInterno      string `json:"monto_interno,omitempty"`
+	FechaTransaccion  string `json:"fecha_transaccion,omitempty"`
+	LineaExterna      int    `json:"linea_externa,omitempty"`
+	CodigoExterno     string `json:"codigo_externo,omitempty"`
+	MontoExterno      string `json:"monto_externo,omitempty"`
+	FechaExterna      string `json:"fecha_externa,omitempty"`
+	Diferencia        string `json:"diferencia,omitempty"`
+	Dias              int    `json:"dias,omitempty"`
+	Motivo            string `json:"motivo,omitempty"`
+}
+
+var columnasExportacion = []string{"resultado", "criterio", "transaccion_id", "codigo_transaccion", "moneda", "monto_interno",
+	"fecha_transaccion", "linea_externa", "codigo_externo", "monto_externo", "fecha_externa", "diferencia", "dias", "motivo"}
+
+// Filas regresa el reporte como filas, primero las conciliadas, luego las
+// diferencias de monto y al final lo que quedo sin conciliar de cada lado.
+func (c Conciliacion) Filas() []Fila {
+	var filas []Fila
+	pareja := func(resultado string, p Pareja) Fila {
+		fila := filaInterna(resultado, p.Interna)
+		agregarExterna(&fila, p.Externa)
+		fila.Criterio, fila.Diferencia, fila.Dias = p.Criterio, p.Diferencia.String(), p.Dias
+		return fila
+	}
+	for _, p := range c.Conciliadas {
+		filas = append(filas, pareja(RESULTADO_CONCILIADA, p))
+	}
+	for _, p := range c.DiferenciasMonto {
+		filas = append(filas, pareja(RESULTADO_DIFERENCIA, p))
+	}
+	for _, interna := range c.SoloInternas {
+		fila := filaInterna(RESULTADO_SOLO_INTERNA, interna)
+		fila.Motivo = interna.Motivo
+		filas = append(filas, fila)
+	}
+	for _, externa := range c.SoloExternas {
+		fila := Fila{Resultado: RESULTADO_SOLO_EXTERNA, Moneda: externa.Moneda, Motivo: externa.Motivo}
+		agregarExterna(&fila, externa)
+		filas = append(filas, fila)
+	}
+	return filas
+}
+
+func filaInterna(resultado string, interna Interna) Fila {
+	return Fila{
+		Resultado:         resultado,
+		TransaccionId:     interna.Id,
+		CodigoTransaccion: interna.CodigoTransaccion,
+		Moneda:            interna.Moneda,
+		MontoInterno:      interna.Monto.String(),
+		FechaTransaccion:  interna.FechaTransaccion.Format(time.RFC3339),
+	}
+}
+
+func agregarExterna(fila *Fila, externa Externa) {
+	fila.LineaExterna = externa.Linea
+	fila.CodigoExterno = externa.CodigoTransaccion
+	fila.MontoExterno = externa.Monto.String()
+	fila.FechaExterna = externa.Fecha.Format("2006-01-02")
+}
+
+// TipoContenido regresa el tipo de contenido de un formato de exportacion:
+// csv o ndjson.
+func TipoContenido(formato string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(formato)) {
+	case FORMATO_CSV:
+		return "text/csv; charset=utf-8", nil
+	case FORMATO_NDJSON:
+		return "application/x-ndjson", nil
+	}
+	return "", fmt.Errorf("%w: formato %q, se espera csv o ndjson", ErrConciliacionNoValida, formato)
+}
+
+// Exportar escribe las filas del reporte en csv, con encabezado, o como JSON
+// Lines.
+func Exportar(w io.Writer, formato string, conciliacion Conciliacion) error {
+	if _, err := TipoContenido(formato); err != nil {
+		return err
+	}
+	filas := conciliacion.Filas()
+	if strings.EqualFold(strings.TrimSpace(formato), FORMATO_NDJSON) {
+		encoder := json.NewEncoder(w)
+		for _, fila := range filas {
+			if err := encoder.Encode(fila); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columnasExportacion); err != nil {
+		return err
+	}
+	for _, fila := range filas {
+		// Los dias solo aplican a las parejas.
+		dias := ""
+		if fila.Criterio != "" {
+			dias = strconv.Itoa(fila.Dias)
+		}
+		registro := []string{fila.Resultado, fila.Criterio, entero(fila.TransaccionId), fila.CodigoTransaccion, fila.Moneda,
+			fila.MontoInterno, fila.FechaTransaccion, entero(fila.LineaExterna), fila.CodigoExterno, fila.MontoExterno,
+			fila.FechaExterna, fila.Diferencia, dias, fila.Motivo}
+		if err := writer.Write(registro); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// entero deja vacios los ids y lineas que no aplican.
+func entero(valor int) string {
+	if valor == 0 {
+		return ""
+	}
+	return strconv.Itoa(valor)
+}
@@ -0,0 +1,144 @@
+package conciliacion
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+)
+
+var ErrConciliacionNoEncontrada = errors.New("la conciliacion no fue encontrada")
+
+// Tolerancia es cuanto pueden diferir el monto y la fecha de una transaccion
+// y la linea del extracto para darlas por conciliadas. Dias cuenta dias
+// calendario.
+type Tolerancia struct {
+	Monto dinero.Monto `json:"monto" swaggertype:"string" example:"0.50"`
+	Dias  int          `json:"dias"`
+}
+
+// Interna es la transaccion tal como estaba al conciliar.
+type Interna struct {
+	Id                int          `json:"id"`
+	CodigoTransaccion string       `json:"codigo_transaccion"`
+	Moneda            string       `json:"moneda"`
+	Monto             dinero.Monto `json:"monto" swaggertype:"string" example:"4000.00"`
+	Emisor            string       `json:"emisor"`
+	Receptor          string       `json:"receptor"`
+	FechaTransaccion  time.Time    `json:"fecha_transaccion"`
+	Estado            string       `json:"estado" example:"liquidada"`
+	Motivo            string       `json:"motivo,omitempty"`
+}
+
+// Externa es una linea del extracto. Monto puede traer signo; se compara su
+// valor absoluto porque el signo depende de la cuenta del extracto.
+type Externa struct {
+	Linea             int          `json:"linea"`
+	CodigoTransaccion string       `json:"codigo_transaccion,omitempty"`
+	Moneda            string       `json:"moneda"`
+	Monto             dinero.Monto `json:"monto" swaggertype:"string" example:"-4000.00"`
+	Fecha             time.Time    `json:"fecha"`
+	Descripcion       string       `json:"descripcion,omitempty"`
+	Motivo            string       `json:"motivo,omitempty"`
+}
+
+// Pareja une una transaccion con su linea del extracto. Diferencia es el
+// monto de la linea menos el de la transaccion y Dias la distancia entre sus
+// fechas.
+type Pareja struct {
+	Criterio   string       `json:"criterio" example:"codigo"`
+	Interna    Interna      `json:"interna"`
+	Externa    Externa      `json:"externa"`
+	Diferencia dinero.Monto `json:"diferencia" swaggertype:"string" example:"0.00"`
+	Dias       int          `json:"dias"`
+}
+
+type Resumen struct {
+	Conciliadas      int `json:"conciliadas"`
+	DiferenciasMonto int `json:"diferencias_monto"`
+	SoloInternas     int `json:"solo_internas"`
+	SoloExternas     int `json:"solo_externas"`
+}
+
+// Conciliacion es el reporte de comparar las transacciones de un periodo con
+// un extracto.
+type Conciliacion struct {
+	Id               int        `json:"id"`
+	Creada           time.Time  `json:"creada"`
+	Fuente           string     `json:"fuente" example:"mt940"`
+	ParteId          int        `json:"parte_id,omitempty"`
+	Desde            time.Time  `json:"desde"`
+	Hasta            time.Time  `json:"hasta"`
+	Tolerancia       Tolerancia `json:"tolerancia"`
+	Resumen          Resumen    `json:"resumen"`
+	Conciliadas      []Pareja   `json:"conciliadas"`
+	DiferenciasMonto []Pareja   `json:"diferencias_monto"`
+	SoloInternas     []Interna  `json:"solo_internas"`
+	SoloExternas     []Externa  `json:"solo_externas"`
+}
+
+type Repository interface {
+	GetAll() ([]Conciliacion, error)
+	Get(id int) (Conciliacion, error)
+	Store(conciliacion Conciliacion) (Conciliacion, error)
+}
+
+type repository struct {
+	db store.Store
+	mu sync.Mutex
+}
+
+// NewRepository crea un Repository sobre un store de archivo. Si el archivo
+// aun no existe no hay conciliaciones.
+func NewRepository(db store.Store) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]Conciliacion, error) {
+	var conciliaciones []Conciliacion
+	if err := r.db.Read(&conciliaciones); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		return nil, err
+	}
+	return conciliaciones, nil
+}
+
+func (r *repository) Get(id int) (Conciliacion, error) {
+	conciliaciones, err := r.GetAll()
+	if err != nil {
+		return Conciliacion{}, err
+	}
+	for _, conciliacion := range conciliaciones {
+		if conciliacion.Id == id {
+			return conciliacion, nil
+		}
+	}
+	return Conciliacion{}, fmt.Errorf("%w: %d", ErrConciliacionNoEncontrada, id)
+}
+
+// Store agrega la conciliacion con el siguiente id disponible.
+func (r *repository) Store(conciliacion Conciliacion) (Conciliacion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return Conciliacion{}, err
+	}
+	defer unlock()
+
+	conciliaciones, err := r.GetAll()
+	if err != nil {
+		return Conciliacion{}, err
+	}
+	conciliacion.Id = 1
+	if len(conciliaciones) > 0 {
+		conciliacion.Id = conciliaciones[len(conciliaciones)-1].Id + 1
+	}
+	if err := r.db.Write(append(conciliaciones, conciliacion)); err != nil {
+		return Conciliacion{}, err
+	}
+	return conciliacion, nil
+}
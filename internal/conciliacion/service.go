@@ -0,0 +1,340 @@
+package conciliacion
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+)
+
+const (
+	// CRITERIO_CODIGO une una linea con la transaccion de su mismo
+	// codigo_transaccion; CRITERIO_MONTO_FECHA, a falta de codigo, con una de
+	// la misma moneda, monto y fecha dentro de la tolerancia.
+	CRITERIO_CODIGO      = "codigo"
+	CRITERIO_MONTO_FECHA = "monto_fecha"
+)
+
+var ErrConciliacionNoValida = errors.New("la conciliacion no es valida")
+
+// Solicitud indica que conciliar: las lineas del extracto contra las
+// transacciones de ParteId, o de todas las partes si es cero, entre Desde y
+// Hasta. Sin periodo se toman los dias de las lineas mas la tolerancia.
+type Solicitud struct {
+	Fuente     string
+	ParteId    int
+	Desde      *time.Time
+	Hasta      *time.Time
+	Tolerancia Tolerancia
+	Externas   []Externa
+}
+
+type Service interface {
+	GetAll() ([]Conciliacion, error)
+	Get(id int) (Conciliacion, error)
+	Conciliar(solicitud Solicitud) (Conciliacion, error)
+}
+
+type service struct {
+	repository    Repository
+	transacciones transacciones.Service
+	ahora         func() time.Time
+}
+
+func NewService(r Repository, t transacciones.Service) Service {
+	return &service{repository: r, transacciones: t, ahora: time.Now}
+}
+
+func (s *service) GetAll() ([]Conciliacion, error) {
+	return s.repository.GetAll()
+}
+
+func (s *service) Get(id int) (Conciliacion, error) {
+	return s.repository.Get(id)
+}
+
+// Conciliar compara el extracto con las transacciones del periodo y guarda
+// el reporte. Las transacciones rechazadas no movieron dinero y no se
+// concilian.
+func (s *service) Conciliar(solicitud Solicitud) (Conciliacion, error) {
+	if err := validar(&solicitud); err != nil {
+		return Conciliacion{}, err
+	}
+	desde, hasta := periodo(solicitud)
+
+	filtro := transacciones.Filtro{
+		FechaDesde: &desde,
+		FechaHasta: &hasta,
+		Estados: []transacciones.Estado{transacciones.ESTADO_PENDIENTE, transacciones.ESTADO_AUTORIZADA,
+			transacciones.ESTADO_LIQUIDADA, transacciones.ESTADO_REVERTIDA},
+	}
+	lista, err := s.transacciones.GetTransaccionFiltrada(filtro)
+	if err != nil && !errors.Is(err, transacciones.ErrSinResultados) {
+		return Conciliacion{}, err
+	}
+	var internas []Interna
+	codigos := map[string]bool{}
+	for _, transaccion := range lista {
+		if solicitud.ParteId == 0 || transaccion.EmisorId == solicitud.ParteId || transaccion.ReceptorId == solicitud.ParteId {
+			internas = append(internas, internaDe(transaccion))
+			codigos[transaccion.CodigoTransaccion] = true
+		}
+	}
+	fuera, err := s.fueraDelPeriodo(solicitud, codigos)
+	if err != nil {
+		return Conciliacion{}, err
+	}
+
+	conciliacion, err := conciliar(internas, fuera, solicitud.Externas, solicitud.Tolerancia)
+	if err != nil {
+		return Conciliacion{}, err
+	}
+	conciliacion.Creada = s.ahora().In(desde.Location())
+	conciliacion.Fuente = solicitud.Fuente
+	conciliacion.ParteId = solicitud.ParteId
+	conciliacion.Desde, conciliacion.Hasta = desde, hasta
+	conciliacion.Tolerancia = solicitud.Tolerancia
+	return s.repository.Store(conciliacion)
+}
+
+// fueraDelPeriodo busca las transacciones con el codigo de alguna linea que
+// no quedaron en el periodo, para explicar por que esa linea no se concilia.
+func (s *service) fueraDelPeriodo(solicitud Solicitud, codigos map[string]bool) (map[string]Interna, error) {
+	fuera := map[string]Interna{}
+	for _, externa := range solicitud.Externas {
+		codigo := externa.CodigoTransaccion
+		if codigo == "" || codigos[codigo] {
+			continue
+		}
+		codigos[codigo] = true
+		lista, err := s.transacciones.GetTransaccionFiltrada(transacciones.Filtro{CodigoTransaccion: &codigo})
+		if errors.Is(err, transacciones.ErrSinResultados) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		transaccion := lista[0]
+		if transaccion.Estado == transacciones.ESTADO_RECHAZADA ||
+			(solicitud.ParteId != 0 && transaccion.EmisorId != solicitud.ParteId && transaccion.ReceptorId != solicitud.ParteId) {
+			continue
+		}
+		fuera[codigo] = internaDe(transaccion)
+	}
+	return fuera, nil
+}
+
+func validar(solicitud *Solicitud) error {
+	if len(solicitud.Externas) == 0 {
+		return fmt.Errorf("%w: el extracto no tiene lineas", ErrConciliacionNoValida)
+	}
+	if solicitud.Tolerancia.Monto.EsNegativo() || solicitud.Tolerancia.Dias < 0 {
+		return fmt.Errorf("%w: la tolerancia no puede ser negativa", ErrConciliacionNoValida)
+	}
+	if solicitud.Desde != nil && solicitud.Hasta != nil && solicitud.Hasta.Before(*solicitud.Desde) {
+		return fmt.Errorf("%w: la fecha hasta es anterior a la fecha desde", ErrConciliacionNoValida)
+	}
+	for index := range solicitud.Externas {
+		externa := &solicitud.Externas[index]
+		externa.CodigoTransaccion = strings.TrimSpace(externa.CodigoTransaccion)
+		externa.Moneda = strings.ToUpper(strings.TrimSpace(externa.Moneda))
+		switch {
+		case externa.Moneda == "":
+			return fmt.Errorf("%w: linea %d: la moneda es requerida", ErrConciliacionNoValida, externa.Linea)
+		case externa.Fecha.IsZero():
+			return fmt.Errorf("%w: linea %d: la fecha es requerida", ErrConciliacionNoValida, externa.Linea)
+		}
+	}
+	return nil
+}
+
+// periodo regresa el periodo de la solicitud o, si falta, el que va del dia
+// de la primera linea al ultimo instante del dia de la ultima, ampliado por
+// la tolerancia en dias.
+func periodo(solicitud Solicitud) (time.Time, time.Time) {
+	primera, ultima := solicitud.Externas[0].Fecha, solicitud.Externas[0].Fecha
+	for _, externa := range solicitud.Externas[1:] {
+		if externa.Fecha.Before(primera) {
+			primera = externa.Fecha
+		}
+		if externa.Fecha.After(ultima) {
+			ultima = externa.Fecha
+		}
+	}
+	desde, _ := fecha.Dia(primera)
+	desde = desde.AddDate(0, 0, -solicitud.Tolerancia.Dias)
+	_, siguiente := fecha.Dia(ultima)
+	hasta := siguiente.AddDate(0, 0, solicitud.Tolerancia.Dias).Add(-time.Nanosecond)
+	if solicitud.Desde != nil {
+		desde = *solicitud.Desde
+	}
+	if solicitud.Hasta != nil {
+		hasta = *solicitud.Hasta
+	}
+	return desde, hasta
+}
+
+func internaDe(transaccion transacciones.Transaccion) Interna {
+	return Interna{
+		Id:                transaccion.Id,
+		CodigoTransaccion: transaccion.CodigoTransaccion,
+		Moneda:            transaccion.Moneda,
+		Monto:             transaccion.Monto,
+		Emisor:            transaccion.Emisor,
+		Receptor:          transaccion.Receptor,
+		FechaTransaccion:  transaccion.FechaTransaccion,
+		Estado:            string(transaccion.Estado),
+	}
+}
+
+// conciliar reparte las transacciones y las lineas en los cuatro grupos del
+// reporte. Primero se unen por codigo_transaccion; una linea con el codigo de
+// una transaccion en otra moneda, fuera de la tolerancia de dias o de las
+// transacciones fuera del periodo queda sin conciliar, con el motivo. Las
+// lineas sin codigo conocido se unen despues con la transaccion libre de la
+// misma moneda, monto y fecha mas cercana.
+func conciliar(internas []Interna, fuera map[string]Interna, externas []Externa, tolerancia Tolerancia) (Conciliacion, error) {
+	conciliacion := Conciliacion{Conciliadas: []Pareja{}, DiferenciasMonto: []Pareja{}, SoloInternas: []Interna{}, SoloExternas: []Externa{}}
+	usadas := make([]bool, len(internas))
+	porCodigo := map[string]int{}
+	for index, interna := range internas {
+		porCodigo[interna.CodigoTransaccion] = index
+	}
+
+	var pendientes []Externa
+	lineaDe := map[int]int{}
+	for _, externa := range externas {
+		if otra, ok := fuera[externa.CodigoTransaccion]; ok {
+			externa.Motivo = fmt.Sprintf("la transaccion %d con el mismo codigo es del %s, fuera del periodo",
+				otra.Id, otra.FechaTransaccion.In(externa.Fecha.Location()).Format("2006-01-02"))
+			conciliacion.SoloExternas = append(conciliacion.SoloExternas, externa)
+			continue
+		}
+		index, ok := porCodigo[externa.CodigoTransaccion]
+		if externa.CodigoTransaccion == "" || !ok {
+			pendientes = append(pendientes, externa)
+			continue
+		}
+		interna := internas[index]
+		if usadas[index] {
+			externa.Motivo = fmt.Sprintf("el codigo %s ya se concilio con la linea %d", externa.CodigoTransaccion, lineaDe[index])
+			conciliacion.SoloExternas = append(conciliacion.SoloExternas, externa)
+			continue
+		}
+		pareja, err := parejaDe(CRITERIO_CODIGO, interna, externa)
+		if err != nil {
+			return Conciliacion{}, err
+		}
+
+		switch {
+		case !strings.EqualFold(interna.Moneda, externa.Moneda):
+			externa.Motivo = fmt.Sprintf("la transaccion %d con el mismo codigo esta en %s", interna.Id, interna.Moneda)
+			internas[index].Motivo = fmt.Sprintf("la linea %d con el mismo codigo esta en %s", externa.Linea, externa.Moneda)
+			conciliacion.SoloExternas = append(conciliacion.SoloExternas, externa)
+		case pareja.Dias > tolerancia.Dias:
+			externa.Motivo = fmt.Sprintf("la transaccion %d con el mismo codigo esta a %d dias", interna.Id, pareja.Dias)
+			internas[index].Motivo = fmt.Sprintf("la linea %d con el mismo codigo esta a %d dias", externa.Linea, pareja.Dias)
+			conciliacion.SoloExternas = append(conciliacion.SoloExternas, externa)
+		case dentro(pareja.Diferencia, tolerancia.Monto):
+			usadas[index], lineaDe[index] = true, externa.Linea
+			conciliacion.Conciliadas = append(conciliacion.Conciliadas, pareja)
+		default:
+			usadas[index], lineaDe[index] = true, externa.Linea
+			conciliacion.DiferenciasMonto = append(conciliacion.DiferenciasMonto, pareja)
+		}
+	}
+
+	for _, externa := range pendientes {
+		mejor := -1
+		var mejorPareja Pareja
+		for index, interna := range internas {
+			if usadas[index] || interna.Motivo != "" || !strings.EqualFold(interna.Moneda, externa.Moneda) {
+				continue
+			}
+			pareja, err := parejaDe(CRITERIO_MONTO_FECHA, interna, externa)
+			if err != nil {
+				return Conciliacion{}, err
+			}
+			if pareja.Dias > tolerancia.Dias || !dentro(pareja.Diferencia, tolerancia.Monto) {
+				continue
+			}
+			if mejor < 0 || pareja.Dias < mejorPareja.Dias ||
+				(pareja.Dias == mejorPareja.Dias && abs(pareja.Diferencia).Cmp(abs(mejorPareja.Diferencia)) < 0) {
+				mejor, mejorPareja = index, pareja
+			}
+		}
+		if mejor < 0 {
+			if externa.CodigoTransaccion == "" {
+				externa.Motivo = "no hay una transaccion con la misma moneda, monto y fecha"
+			} else {
+				externa.Motivo = fmt.Sprintf("no hay una transaccion con el codigo %s ni con la misma moneda, monto y fecha", externa.CodigoTransaccion)
+			}
+			conciliacion.SoloExternas = append(conciliacion.SoloExternas, externa)
+			continue
+		}
+		usadas[mejor] = true
+		conciliacion.Conciliadas = append(conciliacion.Conciliadas, mejorPareja)
+	}
+
+	for index, interna := range internas {
+		if !usadas[index] {
+			if interna.Motivo == "" {
+				interna.Motivo = "no aparece en el extracto"
+			}
+			conciliacion.SoloInternas = append(conciliacion.SoloInternas, interna)
+		}
+	}
+	// Las lineas se reportan en el orden del extracto.
+	for _, grupo := range [][]Pareja{conciliacion.Conciliadas, conciliacion.DiferenciasMonto} {
+		sort.SliceStable(grupo, func(i, j int) bool { return grupo[i].Externa.Linea < grupo[j].Externa.Linea })
+	}
+	sort.SliceStable(conciliacion.SoloExternas, func(i, j int) bool {
+		return conciliacion.SoloExternas[i].Linea < conciliacion.SoloExternas[j].Linea
+	})
+
+	conciliacion.Resumen = Resumen{
+		Conciliadas:      len(conciliacion.Conciliadas),
+		DiferenciasMonto: len(conciliacion.DiferenciasMonto),
+		SoloInternas:     len(conciliacion.SoloInternas),
+		SoloExternas:     len(conciliacion.SoloExternas),
+	}
+	return conciliacion, nil
+}
+
+func parejaDe(criterio string, interna Interna, externa Externa) (Pareja, error) {
+	diferencia, err := abs(externa.Monto).Restar(interna.Monto)
+	if err != nil {
+		return Pareja{}, err
+	}
+	return Pareja{Criterio: criterio, Interna: interna, Externa: externa, Diferencia: diferencia, Dias: diasEntre(interna.FechaTransaccion, externa.Fecha)}, nil
+}
+
+// dentro indica si la diferencia no rebasa la tolerancia en ningun sentido.
+func dentro(diferencia, tolerancia dinero.Monto) bool {
+	return abs(diferencia).Cmp(tolerancia) <= 0
+}
+
+func abs(monto dinero.Monto) dinero.Monto {
+	if monto.EsNegativo() {
+		return monto.Negar()
+	}
+	return monto
+}
+
+// diasEntre cuenta los dias calendario entre dos fechas, en la zona de la
+// linea del extracto.
+func diasEntre(interna, externa time.Time) int {
+	interna = interna.In(externa.Location())
+	a := time.Date(interna.Year(), interna.Month(), interna.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(externa.Year(), externa.Month(), externa.Day(), 0, 0, 0, 0, time.UTC)
+	dias := int(a.Sub(b).Hours() / 24)
+	if dias < 0 {
+		return -dias
+	}
+	return dias
+}
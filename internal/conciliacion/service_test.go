@@ -0,0 +1,193 @@
+package conciliacion
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var zonaPrueba = time.FixedZone("CST", -6*60*60)
+
+func dia(d int) time.Time {
+	return time.Date(2022, 4, d, 0, 0, 0, 0, zonaPrueba)
+}
+
+func nuevoService(t *testing.T) *service {
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "transacciones.json")}
+	transaccion := func(id int, codigo, moneda, monto string, emisorId, receptorId int, fecha time.Time, estado transacciones.Estado) transacciones.Transaccion {
+		return transacciones.Transaccion{Id: id, CodigoTransaccion: codigo, Moneda: moneda, Monto: dinero.DebeParsear(monto),
+			Emisor: "Emisor", EmisorId: emisorId, Receptor: "Receptor", ReceptorId: receptorId, FechaTransaccion: fecha, Estado: estado}
+	}
+	assert.Nil(t, db.Write([]transacciones.Transaccion{
+		transaccion(1, "ctr1", "MXN", "4000.00", 1, 2, dia(21).Add(10*time.Hour), transacciones.ESTADO_LIQUIDADA),
+		transaccion(2, "ctr2", "MXN", "500.00", 1, 3, dia(21).Add(11*time.Hour), transacciones.ESTADO_LIQUIDADA),
+		transaccion(3, "ctr3", "USD", "790.00", 1, 5, dia(22), transacciones.ESTADO_AUTORIZADA),
+		transaccion(4, "ctr4", "MXN", "230.00", 6, 1, dia(22), transacciones.ESTADO_PENDIENTE),
+		transaccion(5, "ctr5", "MXN", "800.00", 1, 7, dia(23), transacciones.ESTADO_LIQUIDADA),
+		transaccion(6, "ctr6", "MXN", "999.00", 1, 2, dia(22), transacciones.ESTADO_RECHAZADA),
+		transaccion(7, "ctr7", "MXN", "120.00", 1, 2, dia(23), transacciones.ESTADO_LIQUIDADA),
+		transaccion(8, "ctr8", "MXN", "75.00", 4, 5, dia(22), transacciones.ESTADO_LIQUIDADA),
+		transaccion(9, "ctr9", "MXN", "60.00", 1, 2, dia(28), transacciones.ESTADO_LIQUIDADA),
+	}))
+
+	conciliaciones := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "conciliaciones.json")}
+	s := NewService(NewRepository(conciliaciones), transacciones.NewService(transacciones.NewRepository(db))).(*service)
+	s.ahora = func() time.Time { return time.Date(2022, 4, 30, 12, 0, 0, 0, zonaPrueba) }
+	return s
+}
+
+func externa(linea int, codigo, moneda, monto string, fecha time.Time) Externa {
+	return Externa{Linea: linea, CodigoTransaccion: codigo, Moneda: moneda, Monto: dinero.DebeParsear(monto), Fecha: fecha}
+}
+
+func TestServiceConciliar(t *testing.T) {
+	// Arrange
+	s := nuevoService(t)
+	solicitud := Solicitud{
+		Fuente:     "json",
+		ParteId:    1,
+		Tolerancia: Tolerancia{Monto: dinero.DebeParsear("0.50"), Dias: 1},
+		Externas: []Externa{
+			externa(1, "ctr1", "mxn", "-4000.00", dia(21)),
+			externa(2, "ctr2", "MXN", "-500.40", dia(22)),
+			externa(3, "ctr3", "MXN", "-790.00", dia(22)),
+			externa(4, "ctr4", "MXN", "210.00", dia(22)),
+			externa(5, "ctr5", "MXN", "-800.00", dia(21)),
+			externa(6, "", "MXN", "-120.00", dia(22)),
+			externa(7, "ctr1", "MXN", "-4000.00", dia(21)),
+			externa(8, "BANCO-9", "MXN", "-64.00", dia(22)),
+			externa(9, "ctr9", "MXN", "-60.00", dia(21)),
+		},
+	}
+
+	// Act
+	conciliacion, err := s.Conciliar(solicitud)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, conciliacion.Id)
+	assert.Equal(t, Resumen{Conciliadas: 3, DiferenciasMonto: 1, SoloInternas: 2, SoloExternas: 5}, conciliacion.Resumen)
+	assert.Equal(t, dia(20), conciliacion.Desde)
+	assert.Equal(t, dia(24).Add(-time.Nanosecond), conciliacion.Hasta)
+
+	conciliadas := conciliacion.Conciliadas
+	assert.Equal(t, 1, conciliadas[0].Interna.Id)
+	assert.Equal(t, CRITERIO_CODIGO, conciliadas[0].Criterio)
+	assert.Equal(t, "0.00", conciliadas[0].Diferencia.String())
+	// Dentro de la tolerancia de monto y de un dia.
+	assert.Equal(t, 2, conciliadas[1].Interna.Id)
+	assert.Equal(t, "0.40", conciliadas[1].Diferencia.String())
+	assert.Equal(t, 1, conciliadas[1].Dias)
+	// La linea sin codigo se concilia por moneda, monto y fecha.
+	assert.Equal(t, 7, conciliadas[2].Interna.Id)
+	assert.Equal(t, CRITERIO_MONTO_FECHA, conciliadas[2].Criterio)
+	assert.Equal(t, 6, conciliadas[2].Externa.Linea)
+
+	assert.Equal(t, 4, conciliacion.DiferenciasMonto[0].Interna.Id)
+	assert.Equal(t, "-20.00", conciliacion.DiferenciasMonto[0].Diferencia.String())
+
+	// ctr3 esta en otra moneda y ctr5 fuera de la tolerancia de dias; la
+	// rechazada ctr6, ctr8, de otras partes, y ctr9, fuera del periodo, no se
+	// concilian.
+	assert.Equal(t, []int{3, 5}, []int{conciliacion.SoloInternas[0].Id, conciliacion.SoloInternas[1].Id})
+	assert.Equal(t, "la linea 3 con el mismo codigo esta en MXN", conciliacion.SoloInternas[0].Motivo)
+	assert.Equal(t, "la linea 5 con el mismo codigo esta a 2 dias", conciliacion.SoloInternas[1].Motivo)
+	motivos := map[int]string{}
+	for _, sola := range conciliacion.SoloExternas {
+		motivos[sola.Linea] = sola.Motivo
+	}
+	assert.Equal(t, map[int]string{
+		3: "la transaccion 3 con el mismo codigo esta en USD",
+		5: "la transaccion 5 con el mismo codigo esta a 2 dias",
+		7: "el codigo ctr1 ya se concilio con la linea 1",
+		8: "no hay una transaccion con el codigo BANCO-9 ni con la misma moneda, monto y fecha",
+		9: "la transaccion 9 con el mismo codigo es del 2022-04-28, fuera del periodo",
+	}, motivos)
+
+	guardada, errGet := s.Get(conciliacion.Id)
+	assert.Nil(t, errGet)
+	assert.Equal(t, conciliacion.Resumen, guardada.Resumen)
+	assert.Equal(t, "json", guardada.Fuente)
+}
+
+func TestServiceConciliarPeriodo(t *testing.T) {
+	// Arrange
+	s := nuevoService(t)
+	desde, hasta := dia(20), dia(26)
+	solicitud := Solicitud{Desde: &desde, Hasta: &hasta, Externas: []Externa{externa(1, "ctr8", "MXN", "75.00", dia(22))}}
+
+	// Act
+	conciliacion, err := s.Conciliar(solicitud)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, conciliacion.Resumen.Conciliadas)
+	// Sin parte se toman todas las transacciones del periodo salvo la rechazada.
+	assert.Equal(t, 6, conciliacion.Resumen.SoloInternas)
+	assert.Equal(t, "no aparece en el extracto", conciliacion.SoloInternas[0].Motivo)
+}
+
+func TestServiceConciliarNoValida(t *testing.T) {
+	// Arrange
+	s := nuevoService(t)
+	desde, hasta := dia(22), dia(21)
+	casos := []struct {
+		nombre    string
+		solicitud Solicitud
+		problema  string
+	}{
+		{"sin lineas", Solicitud{}, "el extracto no tiene lineas"},
+		{"tolerancia", Solicitud{Tolerancia: Tolerancia{Dias: -1}, Externas: []Externa{externa(1, "", "MXN", "1.00", dia(21))}}, "la tolerancia no puede ser negativa"},
+		{"periodo", Solicitud{Desde: &desde, Hasta: &hasta, Externas: []Externa{externa(1, "", "MXN", "1.00", dia(21))}}, "la fecha hasta es anterior"},
+		{"moneda", Solicitud{Externas: []Externa{externa(4, "", " ", "1.00", dia(21))}}, "linea 4: la moneda es requerida"},
+		{"fecha", Solicitud{Externas: []Externa{externa(5, "", "MXN", "1.00", time.Time{})}}, "linea 5: la fecha es requerida"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			// Act
+			_, err := s.Conciliar(caso.solicitud)
+
+			// Assert
+			assert.ErrorIs(t, err, ErrConciliacionNoValida)
+			assert.Contains(t, err.Error(), caso.problema)
+		})
+	}
+	_, err := s.Get(1)
+	assert.ErrorIs(t, err, ErrConciliacionNoEncontrada)
+}
+
+func TestExportar(t *testing.T) {
+	// Arrange
+	s := nuevoService(t)
+	conciliacion, err := s.Conciliar(Solicitud{Externas: []Externa{
+		externa(1, "ctr1", "MXN", "-4000.00", dia(21)),
+		externa(2, "", "MXN", "1.00", dia(21)),
+	}})
+	assert.Nil(t, err)
+	var csv, ndjson bytes.Buffer
+
+	// Act
+	errCSV := Exportar(&csv, "CSV", conciliacion)
+	errNDJSON := Exportar(&ndjson, FORMATO_NDJSON, conciliacion)
+	errFormato := Exportar(&bytes.Buffer{}, "xlsx", conciliacion)
+
+	// Assert
+	assert.Nil(t, errCSV)
+	lineas := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	assert.Equal(t, "resultado,criterio,transaccion_id,codigo_transaccion,moneda,monto_interno,fecha_transaccion,linea_externa,codigo_externo,monto_externo,fecha_externa,diferencia,dias,motivo", lineas[0])
+	assert.Equal(t, "conciliada,codigo,1,ctr1,MXN,4000.00,2022-04-21T10:00:00-06:00,1,ctr1,-4000.00,2022-04-21,0.00,0,", lineas[1])
+	assert.Equal(t, "solo_interna,,2,ctr2,MXN,500.00,2022-04-21T11:00:00-06:00,,,,,,,no aparece en el extracto", lineas[2])
+	assert.Equal(t, "solo_externa,,,,MXN,,,2,,1.00,2022-04-21,,,\"no hay una transaccion con la misma moneda, monto y fecha\"", lineas[len(lineas)-1])
+	assert.Nil(t, errNDJSON)
+	assert.Equal(t, len(lineas)-1, strings.Count(ndjson.String(), "\n"))
+	assert.Contains(t, ndjson.String(), `{"resultado":"conciliada","criterio":"codigo","transaccion_id":1,`)
+	assert.ErrorIs(t, errFormato, ErrConciliacionNoValida)
+}
@@ -0,0 +1,68 @@
+package divisas
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+)
+
+// TipoCambio indica que, a partir de Fecha, una unidad de Origen equivale a
+// Tasa unidades de Destino.
+type TipoCambio struct {
+	Origen  string       `json:"origen"`
+	Destino string       `json:"destino"`
+	Fecha   string       `json:"fecha"`
+	Tasa    dinero.Monto `json:"tasa" swaggertype:"string" example:"0.054321"`
+}
+
+type Repository interface {
+	GetAll() ([]TipoCambio, error)
+	Store(tipoCambio TipoCambio) error
+}
+
+type repository struct {
+	db store.Store
+	mu sync.Mutex
+}
+
+// NewRepository crea un Repository sobre un store de archivo. Si el archivo
+// aun no existe la tabla se considera vacia.
+func NewRepository(db store.Store) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]TipoCambio, error) {
+	var tiposCambio []TipoCambio
+	if err := r.db.Read(&tiposCambio); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		return nil, err
+	}
+	return tiposCambio, nil
+}
+
+// Store agrega el tipo de cambio o reemplaza el que ya exista para el mismo
+// par de monedas y la misma fecha.
+func (r *repository) Store(tipoCambio TipoCambio) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tiposCambio, err := r.GetAll()
+	if err != nil {
+		return err
+	}
+
+	for i, existente := range tiposCambio {
+		if existente.Origen == tipoCambio.Origen && existente.Destino == tipoCambio.Destino && existente.Fecha == tipoCambio.Fecha {
+			tiposCambio[i] = tipoCambio
+			return r.db.Write(tiposCambio)
+		}
+	}
+	return r.db.Write(append(tiposCambio, tipoCambio))
+}
@@ -0,0 +1,151 @@
+package divisas
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+const (
+	// FORMATO_FECHA es el formato dd/mm/aaaa de las fechas de los tipos de
+	// cambio.
+	FORMATO_FECHA = "02/01/2006"
+	// ESCALA_TASA es el numero de decimales con que se reporta una tasa
+	// calculada como el inverso de otra.
+	ESCALA_TASA = 10
+)
+
+var (
+	ErrTipoCambioNoValido = errors.New("el tipo de cambio no es valido")
+	ErrSinTipoCambio      = errors.New("no existe un tipo de cambio")
+)
+
+// Conversion es un monto expresado en otra moneda junto con la tasa usada.
+type Conversion struct {
+	Moneda    string       `json:"moneda"`
+	Monto     dinero.Monto `json:"monto" swaggertype:"string" example:"217.31"`
+	Tasa      dinero.Monto `json:"tasa" swaggertype:"string" example:"0.054321"`
+	FechaTasa string       `json:"fecha_tasa"`
+}
+
+type Service interface {
+	GetAll() ([]TipoCambio, error)
+	Store(origen, destino, fecha string, tasa dinero.Monto) (TipoCambio, error)
+	Convertir(monto dinero.Monto, origen, destino string, fecha time.Time) (Conversion, error)
+	ConvertirTransaccion(transaccion transacciones.Transaccion, destino string) (Conversion, error)
+}
+
+type service struct {
+	repository Repository
+	monedas    monedas.Service
+}
+
+func NewService(r Repository, m monedas.Service) Service {
+	return &service{repository: r, monedas: m}
+}
+
+func (s *service) GetAll() ([]TipoCambio, error) {
+	return s.repository.GetAll()
+}
+
+func (s *service) Store(origen, destino, fecha string, tasa dinero.Monto) (TipoCambio, error) {
+	monedaOrigen, err := s.monedas.Validar(origen)
+	if err != nil {
+		return TipoCambio{}, err
+	}
+	monedaDestino, err := s.monedas.Validar(destino)
+	if err != nil {
+		return TipoCambio{}, err
+	}
+	if monedaOrigen.Codigo == monedaDestino.Codigo {
+		return TipoCambio{}, fmt.Errorf("%w: las monedas de origen y destino son iguales", ErrTipoCambioNoValido)
+	}
+	if _, err := time.Parse(FORMATO_FECHA, fecha); err != nil {
+		return TipoCambio{}, fmt.Errorf("%w: la fecha debe tener el formato dd/mm/aaaa", ErrTipoCambioNoValido)
+	}
+	if !tasa.EsPositivo() {
+		return TipoCambio{}, fmt.Errorf("%w: la tasa debe ser mayor que cero", ErrTipoCambioNoValido)
+	}
+
+	tipoCambio := TipoCambio{Origen: monedaOrigen.Codigo, Destino: monedaDestino.Codigo, Fecha: fecha, Tasa: tasa}
+	if err := s.repository.Store(tipoCambio); err != nil {
+		return TipoCambio{}, err
+	}
+	return tipoCambio, nil
+}
+
+// Convertir expresa el monto en la moneda destino con sus decimales, usando
+// el tipo de cambio mas reciente que no sea posterior a fecha. Si solo existe
+// la tasa del par inverso se divide entre ella.
+func (s *service) Convertir(monto dinero.Monto, origen, destino string, fecha time.Time) (Conversion, error) {
+	monedaDestino, err := s.monedas.Validar(destino)
+	if err != nil {
+		return Conversion{}, err
+	}
+	if origen == monedaDestino.Codigo {
+		return Conversion{Moneda: origen, Monto: monto, Tasa: dinero.Nuevo(1, 0), FechaTasa: fecha.Format(FORMATO_FECHA)}, nil
+	}
+
+	tipoCambio, inverso, err := s.buscar(origen, monedaDestino.Codigo, fecha)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	conversion := Conversion{Moneda: monedaDestino.Codigo, Tasa: tipoCambio.Tasa, FechaTasa: tipoCambio.Fecha}
+	if inverso {
+		conversion.Monto, err = monto.Dividir(tipoCambio.Tasa, monedaDestino.Decimales)
+		if err == nil {
+			conversion.Tasa, err = dinero.Nuevo(1, 0).Dividir(tipoCambio.Tasa, ESCALA_TASA)
+		}
+	} else {
+		conversion.Monto, err = monto.Multiplicar(tipoCambio.Tasa, monedaDestino.Decimales)
+	}
+	if err != nil {
+		return Conversion{}, err
+	}
+	return conversion, nil
+}
+
+func (s *service) ConvertirTransaccion(transaccion transacciones.Transaccion, destino string) (Conversion, error) {
+	conversion, err := s.Convertir(transaccion.Monto, transaccion.Moneda, destino, transaccion.FechaTransaccion)
+	if err != nil {
+		return Conversion{}, fmt.Errorf("transaccion %d: %w", transaccion.Id, err)
+	}
+	return conversion, nil
+}
+
+// buscar regresa el tipo de cambio vigente en fecha para el par, o el del par
+// inverso si es mas reciente. A igual fecha se prefiere el par directo. Cada
+// tipo de cambio rige desde la medianoche de su dia en la zona de fecha.
+func (s *service) buscar(origen, destino string, fecha time.Time) (TipoCambio, bool, error) {
+	tiposCambio, err := s.repository.GetAll()
+	if err != nil {
+		return TipoCambio{}, false, err
+	}
+
+	var encontrado TipoCambio
+	var fechaEncontrado time.Time
+	inverso, existe := false, false
+	for _, tipoCambio := range tiposCambio {
+		directo := tipoCambio.Origen == origen && tipoCambio.Destino == destino
+		if !directo && !(tipoCambio.Origen == destino && tipoCambio.Destino == origen) {
+			continue
+		}
+		fechaTipoCambio, err := time.ParseInLocation(FORMATO_FECHA, tipoCambio.Fecha, fecha.Location())
+		if err != nil || fechaTipoCambio.After(fecha) {
+			continue
+		}
+		if !existe || fechaTipoCambio.After(fechaEncontrado) || (fechaTipoCambio.Equal(fechaEncontrado) && directo && inverso) {
+			encontrado, fechaEncontrado, inverso, existe = tipoCambio, fechaTipoCambio, !directo, true
+		}
+	}
+
+	if !existe {
+		return TipoCambio{}, false, fmt.Errorf("%w de %s a %s al %s", ErrSinTipoCambio, origen, destino, fecha.Format(FORMATO_FECHA))
+	}
+	return encontrado, inverso, nil
+}
@@ -0,0 +1,100 @@
+package divisas
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func nuevoService(t *testing.T) Service {
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "tipos_cambio.json")}
+	return NewService(NewRepository(db), monedas.NewService(monedas.NewRepository()))
+}
+
+func fecha(texto string) time.Time {
+	valor, _ := time.Parse(FORMATO_FECHA, texto)
+	return valor
+}
+
+func TestServiceStore(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+
+	// Act
+	_, errPrimero := service.Store("mxn", "usd", "01/04/2022", dinero.DebeParsear("0.05"))
+	_, errReemplazo := service.Store("MXN", "USD", "01/04/2022", dinero.DebeParsear("0.0502"))
+	result, errGetAll := service.GetAll()
+
+	// Assert
+	assert.Nil(t, errPrimero)
+	assert.Nil(t, errReemplazo)
+	assert.Nil(t, errGetAll)
+	assert.Equal(t, []TipoCambio{{Origen: "MXN", Destino: "USD", Fecha: "01/04/2022", Tasa: dinero.DebeParsear("0.0502")}}, result)
+}
+
+func TestServiceStoreNoValido(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+
+	// Act
+	_, errMoneda := service.Store("XYZ", "USD", "01/04/2022", dinero.DebeParsear("1"))
+	_, errIguales := service.Store("USD", "USD", "01/04/2022", dinero.DebeParsear("1"))
+	_, errFecha := service.Store("MXN", "USD", "2022-04-01", dinero.DebeParsear("0.05"))
+	_, errTasa := service.Store("MXN", "USD", "01/04/2022", dinero.DebeParsear("0"))
+
+	// Assert
+	assert.ErrorIs(t, errMoneda, monedas.ErrMonedaNoValida)
+	assert.ErrorIs(t, errIguales, ErrTipoCambioNoValido)
+	assert.ErrorIs(t, errFecha, ErrTipoCambioNoValido)
+	assert.ErrorIs(t, errTasa, ErrTipoCambioNoValido)
+}
+
+func TestServiceConvertir(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+	_, _ = service.Store("MXN", "USD", "01/04/2022", dinero.DebeParsear("0.05"))
+	_, _ = service.Store("MXN", "USD", "04/04/2022", dinero.DebeParsear("0.054321"))
+	_, _ = service.Store("USD", "JPY", "01/04/2022", dinero.DebeParsear("122.5"))
+
+	// Act
+	vigente, errVigente := service.Convertir(dinero.DebeParsear("4000.50"), "MXN", "USD", fecha("05/04/2022"))
+	anterior, errAnterior := service.Convertir(dinero.DebeParsear("4000.50"), "MXN", "USD", fecha("03/04/2022"))
+	aYenes, errYenes := service.Convertir(dinero.DebeParsear("10.00"), "USD", "JPY", fecha("02/04/2022"))
+	inversa, errInversa := service.Convertir(dinero.DebeParsear("1225"), "JPY", "USD", fecha("02/04/2022"))
+	_, errSinTasa := service.Convertir(dinero.DebeParsear("10.00"), "MXN", "USD", fecha("31/03/2022"))
+
+	// Assert
+	assert.Nil(t, errVigente)
+	assert.Equal(t, Conversion{Moneda: "USD", Monto: dinero.DebeParsear("217.31"), Tasa: dinero.DebeParsear("0.054321"), FechaTasa: "04/04/2022"}, vigente)
+	assert.Nil(t, errAnterior)
+	assert.Equal(t, "200.02", anterior.Monto.String())
+	assert.Nil(t, errYenes)
+	assert.Equal(t, "1225", aYenes.Monto.String())
+	assert.Nil(t, errInversa)
+	assert.Equal(t, "10.00", inversa.Monto.String())
+	assert.Equal(t, "0.0081632653", inversa.Tasa.String())
+	assert.ErrorIs(t, errSinTasa, ErrSinTipoCambio)
+}
+
+func TestServiceConvertirTransaccion(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+	_, _ = service.Store("MXN", "USD", "01/04/2022", dinero.DebeParsear("0.05"))
+	zona, _ := time.LoadLocation("America/Mexico_City")
+	transaccion := transacciones.Transaccion{Id: 3, Moneda: "MXN", Monto: dinero.DebeParsear("500.00"), FechaTransaccion: time.Date(2022, 4, 1, 0, 0, 0, 0, zona)}
+
+	// Act
+	result, err := service.ConvertirTransaccion(transaccion, "USD")
+	_, errMisma := service.ConvertirTransaccion(transaccion, "MXN")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "25.00", result.Monto.String())
+	assert.Nil(t, errMisma)
+}
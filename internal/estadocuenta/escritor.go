@@ -0,0 +1,105 @@
+package estadocuenta
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+const (
+	FORMATO_JSON = "json"
+	FORMATO_CSV  = "csv"
+	FORMATO_HTML = "html"
+)
+
+//go:embed plantillas/estado_cuenta.html
+var plantillas embed.FS
+
+var plantillaHTML = template.Must(template.New("estado_cuenta.html").Funcs(template.FuncMap{
+	"fecha":     func(valor interface{}) string { return formatear(valor, "02/01/2006") },
+	"fechaHora": func(valor interface{}) string { return formatear(valor, "02/01/2006 15:04") },
+	"cargo": func(monto dinero.Monto) string {
+		if monto.EsNegativo() {
+			return monto.Negar().String()
+		}
+		return ""
+	},
+	"abono": func(monto dinero.Monto) string {
+		if monto.EsNegativo() {
+			return ""
+		}
+		return monto.String()
+	},
+}).ParseFS(plantillas, "plantillas/estado_cuenta.html"))
+
+// ParseFormato lee el formato de salida: json, csv o html.
+func ParseFormato(texto string) (string, error) {
+	switch formato := strings.ToLower(strings.TrimSpace(texto)); formato {
+	case FORMATO_JSON, FORMATO_CSV, FORMATO_HTML:
+		return formato, nil
+	}
+	return "", fmt.Errorf("%w: formato %q, se espera json, csv o html", ErrEstadoCuentaNoValido, texto)
+}
+
+func formatear(valor interface{}, formato string) string {
+	switch fecha := valor.(type) {
+	case time.Time:
+		return fecha.Format(formato)
+	case *time.Time:
+		if fecha != nil {
+			return fecha.Format(formato)
+		}
+	}
+	return ""
+}
+
+// EscribirHTML escribe el estado de cuenta como una pagina lista para
+// imprimir.
+func EscribirHTML(w io.Writer, estado EstadoCuenta) error {
+	return plantillaHTML.Execute(w, estado)
+}
+
+// EscribirCSV escribe una fila por linea entre una de saldo inicial y otra de
+// saldo final por moneda. Los cargos y abonos van en columnas separadas y sin
+// signo, como en el estado impreso.
+func EscribirCSV(w io.Writer, estado EstadoCuenta) error {
+	writer := csv.NewWriter(w)
+	encabezado := []string{"moneda", "fecha", "concepto", "transaccion_id", "codigo_transaccion", "contraparte", "estado", "cargo", "abono", "saldo"}
+	if err := writer.Write(encabezado); err != nil {
+		return err
+	}
+	inicio := ""
+	if estado.Desde != nil {
+		inicio = estado.Desde.Format(time.RFC3339)
+	}
+	for _, seccion := range estado.Secciones {
+		if err := writer.Write([]string{seccion.Moneda, inicio, "saldo inicial", "", "", "", "", "", "", seccion.SaldoInicial.String()}); err != nil {
+			return err
+		}
+		for _, linea := range seccion.Lineas {
+			cargo, abono := "", linea.Monto.String()
+			if linea.Monto.EsNegativo() {
+				cargo, abono = linea.Monto.Negar().String(), ""
+			}
+			fila := []string{seccion.Moneda, linea.Fecha.Format(time.RFC3339), "transaccion", strconv.Itoa(linea.TransaccionId),
+				linea.CodigoTransaccion, linea.Contraparte, linea.Estado, cargo, abono, linea.Saldo.String()}
+			if err := writer.Write(fila); err != nil {
+				return err
+			}
+		}
+		fila := []string{seccion.Moneda, estado.Hasta.Format(time.RFC3339), "saldo final", "", "", "", "",
+			seccion.Cargos.String(), seccion.Abonos.String(), seccion.SaldoFinal.String()}
+		if err := writer.Write(fila); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
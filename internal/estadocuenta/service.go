@@ -0,0 +1,183 @@
+// Package estadocuenta arma el estado de cuenta de una parte a partir de sus
+// transacciones: saldo inicial, movimientos con saldo corrido y saldo final
+// por moneda.
+package estadocuenta
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+var ErrEstadoCuentaNoValido = errors.New("el estado de cuenta no es valido")
+
+// Linea es una transaccion de la parte. Monto es negativo si la parte la
+// emitio; Saldo es el saldo en la moneda despues de aplicarla. Una transaccion
+// rechazada se lista pero no cambia el saldo.
+type Linea struct {
+	TransaccionId     int          `json:"transaccion_id"`
+	CodigoTransaccion string       `json:"codigo_transaccion"`
+	Fecha             time.Time    `json:"fecha"`
+	Estado            string       `json:"estado" example:"liquidada"`
+	Contraparte       string       `json:"contraparte"`
+	Monto             dinero.Monto `json:"monto" swaggertype:"string" example:"-4000.00"`
+	Saldo             dinero.Monto `json:"saldo" swaggertype:"string" example:"1500.00"`
+}
+
+// Seccion es el estado de cuenta en una moneda. Cargos y Abonos suman lo que
+// la parte envio y recibio en el periodo.
+type Seccion struct {
+	Moneda       string       `json:"moneda"`
+	SaldoInicial dinero.Monto `json:"saldo_inicial" swaggertype:"string" example:"5500.00"`
+	Cargos       dinero.Monto `json:"cargos" swaggertype:"string" example:"4000.00"`
+	Abonos       dinero.Monto `json:"abonos" swaggertype:"string" example:"0.00"`
+	SaldoFinal   dinero.Monto `json:"saldo_final" swaggertype:"string" example:"1500.00"`
+	Lineas       []Linea      `json:"lineas"`
+}
+
+// EstadoCuenta reune las secciones de la parte entre Desde y Hasta. Sin Desde
+// el periodo empieza con la primera transaccion y el saldo inicial es cero.
+type EstadoCuenta struct {
+	ParteId   int        `json:"parte_id"`
+	Parte     string     `json:"parte"`
+	Desde     *time.Time `json:"desde,omitempty"`
+	Hasta     time.Time  `json:"hasta"`
+	Generado  time.Time  `json:"generado"`
+	Secciones []Seccion  `json:"secciones"`
+}
+
+type Service interface {
+	Generar(nombre, moneda string, desde *time.Time, hasta time.Time) (EstadoCuenta, error)
+}
+
+type service struct {
+	transacciones transacciones.Service
+	partes        partes.Service
+	ahora         func() time.Time
+}
+
+func NewService(t transacciones.Service, p partes.Service) Service {
+	return &service{transacciones: t, partes: p, ahora: time.Now}
+}
+
+// Generar arma el estado de cuenta de la parte registrada con el nombre, sin
+// distinguir mayusculas ni espacios, en todas sus monedas o solo en moneda.
+// Las fechas se expresan en la zona de hasta.
+func (s *service) Generar(nombre, moneda string, desde *time.Time, hasta time.Time) (EstadoCuenta, error) {
+	if desde != nil && hasta.Before(*desde) {
+		return EstadoCuenta{}, fmt.Errorf("%w: la fecha hasta es anterior a la fecha desde", ErrEstadoCuentaNoValido)
+	}
+	parte, err := s.parteDe(nombre)
+	if err != nil {
+		return EstadoCuenta{}, err
+	}
+
+	// Las transacciones anteriores al periodo dan el saldo inicial.
+	filtro := transacciones.Filtro{FechaHasta: &hasta}
+	if moneda = strings.ToUpper(strings.TrimSpace(moneda)); moneda != "" {
+		filtro.Monedas = []string{moneda}
+	}
+	lista, err := s.transacciones.GetTransaccionFiltrada(filtro)
+	if err != nil && !errors.Is(err, transacciones.ErrSinResultados) {
+		return EstadoCuenta{}, err
+	}
+	sort.SliceStable(lista, func(i, j int) bool {
+		if !lista[i].FechaTransaccion.Equal(lista[j].FechaTransaccion) {
+			return lista[i].FechaTransaccion.Before(lista[j].FechaTransaccion)
+		}
+		return lista[i].Id < lista[j].Id
+	})
+
+	zona := hasta.Location()
+	estado := EstadoCuenta{ParteId: parte.Id, Parte: parte.Nombre, Desde: desde, Hasta: hasta, Generado: s.ahora().In(zona), Secciones: []Seccion{}}
+	porMoneda := map[string]int{}
+	for _, transaccion := range lista {
+		montos := montosDe(parte, transaccion)
+		if len(montos) == 0 {
+			continue
+		}
+		index, ok := porMoneda[transaccion.Moneda]
+		if !ok {
+			cero := dinero.Nuevo(0, transaccion.Monto.Escala())
+			index = len(estado.Secciones)
+			porMoneda[transaccion.Moneda] = index
+			estado.Secciones = append(estado.Secciones, Seccion{Moneda: transaccion.Moneda, SaldoInicial: cero, Cargos: cero,
+				Abonos: cero, SaldoFinal: cero, Lineas: []Linea{}})
+		}
+		seccion := &estado.Secciones[index]
+
+		mueve := transaccion.MueveSaldo()
+		enPeriodo := desde == nil || !transaccion.FechaTransaccion.Before(*desde)
+		for _, monto := range montos {
+			if mueve {
+				if seccion.SaldoFinal, err = seccion.SaldoFinal.Sumar(monto); err != nil {
+					return EstadoCuenta{}, err
+				}
+			}
+			if !enPeriodo {
+				seccion.SaldoInicial = seccion.SaldoFinal
+				continue
+			}
+			if mueve {
+				if monto.EsNegativo() {
+					seccion.Cargos, err = seccion.Cargos.Sumar(monto.Negar())
+				} else {
+					seccion.Abonos, err = seccion.Abonos.Sumar(monto)
+				}
+				if err != nil {
+					return EstadoCuenta{}, err
+				}
+			}
+			contraparte := transaccion.Receptor
+			if !monto.EsNegativo() {
+				contraparte = transaccion.Emisor
+			}
+			seccion.Lineas = append(seccion.Lineas, Linea{
+				TransaccionId:     transaccion.Id,
+				CodigoTransaccion: transaccion.CodigoTransaccion,
+				Fecha:             transaccion.FechaTransaccion.In(zona),
+				Estado:            string(transaccion.Estado),
+				Contraparte:       contraparte,
+				Monto:             monto,
+				Saldo:             seccion.SaldoFinal,
+			})
+		}
+	}
+	sort.Slice(estado.Secciones, func(i, j int) bool { return estado.Secciones[i].Moneda < estado.Secciones[j].Moneda })
+	return estado, nil
+}
+
+// parteDe busca la parte registrada con el nombre.
+func (s *service) parteDe(nombre string) (partes.Parte, error) {
+	registradas, err := s.partes.GetAll()
+	if err != nil {
+		return partes.Parte{}, err
+	}
+	clave := partes.ClaveNombre(nombre)
+	for _, parte := range registradas {
+		if partes.ClaveNombre(parte.Nombre) == clave {
+			return parte, nil
+		}
+	}
+	return partes.Parte{}, fmt.Errorf("%w: %s", partes.ErrParteNoEncontrada, strings.TrimSpace(nombre))
+}
+
+// montosDe regresa el efecto de la transaccion en la cuenta de la parte: un
+// cargo si la emitio y un abono si la recibio; ambos si se la envio a si
+// misma.
+func montosDe(parte partes.Parte, transaccion transacciones.Transaccion) []dinero.Monto {
+	var montos []dinero.Monto
+	if transaccion.EmitidaPor(parte) {
+		montos = append(montos, transaccion.Monto.Negar())
+	}
+	if transaccion.RecibidaPor(parte) {
+		montos = append(montos, transaccion.Monto)
+	}
+	return montos
+}
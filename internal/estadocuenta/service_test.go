@@ -0,0 +1,168 @@
+package estadocuenta
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+var zonaPrueba = time.FixedZone("CST", -6*60*60)
+
+func dia(d int) time.Time {
+	return time.Date(2022, 4, d, 0, 0, 0, 0, zonaPrueba)
+}
+
+func nuevoService(t *testing.T) *service {
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "transacciones.json")}
+	transaccion := func(id int, moneda, monto string, emisorId, receptorId int, fecha time.Time, estado transacciones.Estado) transacciones.Transaccion {
+		nombres := map[int]string{1: "Ana", 2: "Luis", 3: "Banco"}
+		return transacciones.Transaccion{Id: id, CodigoTransaccion: "ctr" + strconv.Itoa(id), Moneda: moneda,
+			Monto: dinero.DebeParsear(monto), Emisor: nombres[emisorId], EmisorId: emisorId, Receptor: nombres[receptorId],
+			ReceptorId: receptorId, FechaTransaccion: fecha, Estado: estado}
+	}
+	// La 9 es anterior al registro de partes: solo trae los nombres.
+	legada := transaccion(9, "MXN", "5.00", 0, 0, dia(16), transacciones.ESTADO_PENDIENTE)
+	legada.Emisor, legada.Receptor = " ana ", "Luis"
+	assert.Nil(t, db.Write([]transacciones.Transaccion{
+		transaccion(1, "MXN", "100.00", 3, 1, dia(1), transacciones.ESTADO_LIQUIDADA),
+		transaccion(2, "MXN", "30.00", 1, 2, dia(2), transacciones.ESTADO_LIQUIDADA),
+		transaccion(3, "MXN", "500.00", 3, 1, dia(10), transacciones.ESTADO_LIQUIDADA),
+		transaccion(4, "MXN", "200.00", 1, 2, dia(12), transacciones.ESTADO_RECHAZADA),
+		transaccion(5, "MXN", "50.00", 1, 2, dia(15), transacciones.ESTADO_PENDIENTE),
+		transaccion(6, "USD", "10.00", 2, 1, dia(11), transacciones.ESTADO_AUTORIZADA),
+		transaccion(7, "MXN", "20.00", 1, 1, dia(14), transacciones.ESTADO_LIQUIDADA),
+		transaccion(8, "MXN", "999.00", 2, 3, dia(13), transacciones.ESTADO_LIQUIDADA),
+		legada,
+		transaccion(10, "MXN", "1.00", 3, 1, dia(25), transacciones.ESTADO_LIQUIDADA),
+	}))
+
+	registro := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "partes.json")}
+	assert.Nil(t, registro.Write([]partes.Parte{
+		{Id: 1, Nombre: "Ana", Tipo: partes.TIPO_PERSONA, Estado: partes.ESTADO_ACTIVA},
+		{Id: 2, Nombre: "Luis", Tipo: partes.TIPO_PERSONA, Estado: partes.ESTADO_ACTIVA},
+		{Id: 3, Nombre: "Banco", Tipo: partes.TIPO_BANCO, Estado: partes.ESTADO_ACTIVA},
+	}))
+
+	s := NewService(transacciones.NewService(transacciones.NewRepository(db)), partes.NewService(partes.NewRepository(registro))).(*service)
+	s.ahora = func() time.Time { return time.Date(2022, 4, 30, 12, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func TestServiceGenerar(t *testing.T) {
+	// Arrange
+	s := nuevoService(t)
+	desde, hasta := dia(10), dia(21).Add(-time.Nanosecond)
+
+	// Act
+	estado, err := s.Generar("  ANA ", "", &desde, hasta)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, estado.ParteId)
+	assert.Equal(t, "Ana", estado.Parte)
+	assert.Equal(t, zonaPrueba, estado.Generado.Location())
+	assert.Equal(t, 2, len(estado.Secciones))
+
+	mxn := estado.Secciones[0]
+	assert.Equal(t, "MXN", mxn.Moneda)
+	// 100.00 recibidos el dia 1 menos 30.00 enviados el dia 2.
+	assert.Equal(t, "70.00", mxn.SaldoInicial.String())
+	assert.Equal(t, "75.00", mxn.Cargos.String())
+	assert.Equal(t, "520.00", mxn.Abonos.String())
+	assert.Equal(t, "515.00", mxn.SaldoFinal.String())
+
+	ids, montos, saldos := []int{}, []string{}, []string{}
+	for _, linea := range mxn.Lineas {
+		ids = append(ids, linea.TransaccionId)
+		montos = append(montos, linea.Monto.String())
+		saldos = append(saldos, linea.Saldo.String())
+	}
+	// La 4 esta rechazada: se lista pero no mueve el saldo. La 7 se la envio
+	// a si misma y aparece como cargo y como abono.
+	assert.Equal(t, []int{3, 4, 7, 7, 5, 9}, ids)
+	assert.Equal(t, []string{"500.00", "-200.00", "-20.00", "20.00", "-50.00", "-5.00"}, montos)
+	assert.Equal(t, []string{"570.00", "570.00", "550.00", "570.00", "520.00", "515.00"}, saldos)
+	assert.Equal(t, "Banco", mxn.Lineas[0].Contraparte)
+	assert.Equal(t, "Luis", mxn.Lineas[1].Contraparte)
+	assert.Equal(t, "rechazada", mxn.Lineas[1].Estado)
+
+	usd := estado.Secciones[1]
+	assert.Equal(t, "USD", usd.Moneda)
+	assert.Equal(t, "0.00", usd.SaldoInicial.String())
+	assert.Equal(t, "10.00", usd.SaldoFinal.String())
+	assert.Equal(t, "Luis", usd.Lineas[0].Contraparte)
+}
+
+func TestServiceGenerarFiltros(t *testing.T) {
+	// Arrange
+	s := nuevoService(t)
+	desde, hasta := dia(10), dia(21)
+	antes := dia(9)
+
+	// Act
+	soloUsd, errUsd := s.Generar("Ana", "usd", nil, hasta)
+	sinMovimientos, errSinMovimientos := s.Generar("Luis", "MXN", &hasta, dia(22))
+	_, errParte := s.Generar("Pedro", "", &desde, hasta)
+	_, errPeriodo := s.Generar("Ana", "", &desde, antes)
+
+	// Assert
+	assert.Nil(t, errUsd)
+	assert.Nil(t, soloUsd.Desde)
+	assert.Equal(t, 1, len(soloUsd.Secciones))
+	assert.Equal(t, "USD", soloUsd.Secciones[0].Moneda)
+
+	// Sin movimientos en el periodo la seccion solo trae los saldos.
+	assert.Nil(t, errSinMovimientos)
+	assert.Equal(t, 1, len(sinMovimientos.Secciones))
+	assert.Equal(t, []Linea{}, sinMovimientos.Secciones[0].Lineas)
+	assert.Equal(t, "-914.00", sinMovimientos.Secciones[0].SaldoInicial.String())
+	assert.Equal(t, "-914.00", sinMovimientos.Secciones[0].SaldoFinal.String())
+
+	assert.ErrorIs(t, errParte, partes.ErrParteNoEncontrada)
+	assert.ErrorIs(t, errPeriodo, ErrEstadoCuentaNoValido)
+}
+
+func TestEscribir(t *testing.T) {
+	// Arrange
+	s := nuevoService(t)
+	desde, hasta := dia(10), dia(21).Add(-time.Nanosecond)
+	estado, err := s.Generar("Ana", "", &desde, hasta)
+	assert.Nil(t, err)
+	var csv, html bytes.Buffer
+
+	// Act
+	errCsv := EscribirCSV(&csv, estado)
+	errHtml := EscribirHTML(&html, estado)
+	formato, errFormato := ParseFormato(" CSV ")
+	_, errFormatoNoValido := ParseFormato("pdf")
+
+	// Assert
+	assert.Nil(t, errCsv)
+	filas := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	// Encabezado, 6 lineas en MXN y 1 en USD, mas saldo inicial y final por moneda.
+	assert.Equal(t, 12, len(filas))
+	assert.Equal(t, "moneda,fecha,concepto,transaccion_id,codigo_transaccion,contraparte,estado,cargo,abono,saldo", filas[0])
+	assert.Equal(t, "MXN,2022-04-10T00:00:00-06:00,saldo inicial,,,,,,,70.00", filas[1])
+	assert.Equal(t, "MXN,2022-04-10T00:00:00-06:00,transaccion,3,ctr3,Banco,liquidada,,500.00,570.00", filas[2])
+	assert.Equal(t, "MXN,2022-04-12T00:00:00-06:00,transaccion,4,ctr4,Luis,rechazada,200.00,,570.00", filas[3])
+	assert.Equal(t, "MXN,2022-04-20T23:59:59-06:00,saldo final,,,,,75.00,520.00,515.00", filas[8])
+
+	assert.Nil(t, errHtml)
+	assert.Contains(t, html.String(), "Estado de cuenta de Ana")
+	assert.Contains(t, html.String(), "Periodo: 10/04/2022 al 20/04/2022")
+	assert.Contains(t, html.String(), `<tr class="rechazada">`)
+	assert.Contains(t, html.String(), "@media print")
+
+	assert.Nil(t, errFormato)
+	assert.Equal(t, FORMATO_CSV, formato)
+	assert.ErrorIs(t, errFormatoNoValido, ErrEstadoCuentaNoValido)
+}
@@ -0,0 +1,109 @@
+package exportacion
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+)
+
+var ErrExportacionNoValida = errors.New("la exportacion no es valida")
+
+// Columna es un campo de la transaccion que se exporta. valor regresa un
+// string, un int, un dinero.Monto, un time.Time o nil si no hay valor.
+type Columna struct {
+	Nombre string
+	valor  func(t transacciones.Transaccion) interface{}
+}
+
+// Valor regresa el valor de la columna para la transaccion.
+func (c Columna) Valor(t transacciones.Transaccion) interface{} {
+	return c.valor(t)
+}
+
+var columnas = []Columna{
+	{"id", func(t transacciones.Transaccion) interface{} { return t.Id }},
+	{"codigo_transaccion", func(t transacciones.Transaccion) interface{} { return t.CodigoTransaccion }},
+	{"moneda", func(t transacciones.Transaccion) interface{} { return t.Moneda }},
+	{"monto", func(t transacciones.Transaccion) interface{} { return t.Monto }},
+	{"emisor", func(t transacciones.Transaccion) interface{} { return t.Emisor }},
+	{"emisor_id", func(t transacciones.Transaccion) interface{} { return opcional(t.EmisorId) }},
+	{"receptor", func(t transacciones.Transaccion) interface{} { return t.Receptor }},
+	{"receptor_id", func(t transacciones.Transaccion) interface{} { return opcional(t.ReceptorId) }},
+	{"fecha_transaccion", func(t transacciones.Transaccion) interface{} { return t.FechaTransaccion }},
+	{"estado", func(t transacciones.Transaccion) interface{} { return string(t.Estado) }},
+	{"referencia", func(t transacciones.Transaccion) interface{} {
+		if t.Referencia == nil {
+			return nil
+		}
+		return *t.Referencia
+	}},
+	{"riesgo_puntaje", func(t transacciones.Transaccion) interface{} {
+		if t.Riesgo == nil {
+			return nil
+		}
+		return t.Riesgo.Puntaje
+	}},
+	{"riesgo_accion", func(t transacciones.Transaccion) interface{} {
+		if t.Riesgo == nil {
+			return nil
+		}
+		return string(t.Riesgo.Accion)
+	}},
+}
+
+// columnasPorDefecto son las columnas que se exportan si no se eligen otras.
+var columnasPorDefecto = []string{"id", "codigo_transaccion", "moneda", "monto", "emisor", "receptor", "fecha_transaccion", "estado"}
+
+// opcional regresa nil para los ids de parte de las transacciones anteriores
+// al registro de partes.
+func opcional(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// ParseColumnas lee los nombres de columna separados por comas, en el orden
+// en que se exportan. Sin nombres se usan las columnas por defecto.
+func ParseColumnas(texto string) ([]Columna, error) {
+	nombres := columnasPorDefecto
+	if strings.TrimSpace(texto) != "" {
+		nombres = strings.Split(texto, ",")
+	}
+
+	elegidas := make([]Columna, 0, len(nombres))
+	vistas := map[string]bool{}
+	for _, nombre := range nombres {
+		nombre = strings.ToLower(strings.TrimSpace(nombre))
+		columna, ok := buscarColumna(nombre)
+		if !ok {
+			return nil, fmt.Errorf("%w: columna %q, se espera una de %s", ErrExportacionNoValida, nombre, strings.Join(Nombres(), ", "))
+		}
+		if vistas[nombre] {
+			return nil, fmt.Errorf("%w: la columna %s esta repetida", ErrExportacionNoValida, nombre)
+		}
+		vistas[nombre] = true
+		elegidas = append(elegidas, columna)
+	}
+	return elegidas, nil
+}
+
+func buscarColumna(nombre string) (Columna, bool) {
+	for _, columna := range columnas {
+		if columna.Nombre == nombre {
+			return columna, true
+		}
+	}
+	return Columna{}, false
+}
+
+// Nombres regresa los nombres de todas las columnas exportables.
+func Nombres() []string {
+	nombres := make([]string, len(columnas))
+	for index, columna := range columnas {
+		nombres[index] = columna.Nombre
+	}
+	return nombres
+}
@@ -0,0 +1,159 @@
+package exportacion
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+// Escritor escribe transacciones una a una en un formato de archivo. Cerrar
+// completa el archivo, aunque no se haya escrito ninguna transaccion.
+type Escritor interface {
+	Escribir(transaccion transacciones.Transaccion) error
+	Cerrar() error
+}
+
+// Formato describe un formato de exportacion y como crear su escritor.
+type Formato struct {
+	Nombre        string
+	TipoContenido string
+	Extension     string
+	nuevo         func(w io.Writer, columnas []Columna, localizacion Localizacion) Escritor
+}
+
+// NewEscritor crea un escritor del formato sobre w.
+func (f Formato) NewEscritor(w io.Writer, columnas []Columna, localizacion Localizacion) Escritor {
+	return f.nuevo(w, columnas, localizacion)
+}
+
+var formatos = []Formato{
+	{Nombre: "csv", TipoContenido: "text/csv; charset=utf-8", Extension: "csv", nuevo: newEscritorCSV},
+	{Nombre: "ndjson", TipoContenido: "application/x-ndjson", Extension: "ndjson", nuevo: newEscritorNDJSON},
+	{Nombre: "xlsx", TipoContenido: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Extension: "xlsx", nuevo: newEscritorXLSX},
+}
+
+// ParseFormato lee el nombre de un formato: csv, ndjson o xlsx.
+func ParseFormato(texto string) (Formato, error) {
+	for _, formato := range formatos {
+		if strings.EqualFold(strings.TrimSpace(texto), formato.Nombre) {
+			return formato, nil
+		}
+	}
+	return Formato{}, fmt.Errorf("%w: formato %q, se espera csv, ndjson o xlsx", ErrExportacionNoValida, texto)
+}
+
+// escritorCSV escribe el encabezado con la primera fila o al cerrar.
+type escritorCSV struct {
+	csv          *csv.Writer
+	columnas     []Columna
+	localizacion Localizacion
+	encabezado   bool
+}
+
+func newEscritorCSV(w io.Writer, columnas []Columna, localizacion Localizacion) Escritor {
+	writer := csv.NewWriter(w)
+	writer.Comma = localizacion.Separador
+	return &escritorCSV{csv: writer, columnas: columnas, localizacion: localizacion}
+}
+
+func (e *escritorCSV) escribirEncabezado() error {
+	if e.encabezado {
+		return nil
+	}
+	e.encabezado = true
+	nombres := make([]string, len(e.columnas))
+	for index, columna := range e.columnas {
+		nombres[index] = columna.Nombre
+	}
+	return e.csv.Write(nombres)
+}
+
+func (e *escritorCSV) Escribir(transaccion transacciones.Transaccion) error {
+	if err := e.escribirEncabezado(); err != nil {
+		return err
+	}
+	registro := make([]string, len(e.columnas))
+	for index, columna := range e.columnas {
+		registro[index] = e.texto(columna.Valor(transaccion))
+	}
+	if err := e.csv.Write(registro); err != nil {
+		return err
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func (e *escritorCSV) Cerrar() error {
+	if err := e.escribirEncabezado(); err != nil {
+		return err
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func (e *escritorCSV) texto(valor interface{}) string {
+	switch v := valor.(type) {
+	case string:
+		return sinFormula(v)
+	case int:
+		return strconv.Itoa(v)
+	case dinero.Monto:
+		return e.localizacion.Monto(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// sinFormula antepone un apostrofo a los textos que una hoja de calculo
+// interpretaria como formula.
+func sinFormula(texto string) string {
+	if texto != "" && strings.ContainsRune("=+-@\t\r", rune(texto[0])) {
+		return "'" + texto
+	}
+	return texto
+}
+
+// escritorNDJSON escribe un objeto json por linea con las columnas en orden.
+// Los montos se escriben como texto exacto, igual que en la API.
+type escritorNDJSON struct {
+	w        io.Writer
+	columnas []Columna
+}
+
+func newEscritorNDJSON(w io.Writer, columnas []Columna, localizacion Localizacion) Escritor {
+	return &escritorNDJSON{w: w, columnas: columnas}
+}
+
+func (e *escritorNDJSON) Escribir(transaccion transacciones.Transaccion) error {
+	var linea bytes.Buffer
+	linea.WriteByte('{')
+	for index, columna := range e.columnas {
+		if index > 0 {
+			linea.WriteByte(',')
+		}
+		nombre, _ := json.Marshal(columna.Nombre)
+		valor, err := json.Marshal(columna.Valor(transaccion))
+		if err != nil {
+			return err
+		}
+		linea.Write(nombre)
+		linea.WriteByte(':')
+		linea.Write(valor)
+	}
+	linea.WriteString("}\n")
+	_, err := e.w.Write(linea.Bytes())
+	return err
+}
+
+func (e *escritorNDJSON) Cerrar() error {
+	return nil
+}
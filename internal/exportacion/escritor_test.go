@@ -0,0 +1,142 @@
+package exportacion
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/stretchr/testify/assert"
+)
+
+var zonaPrueba = time.FixedZone("-06:00", -6*60*60)
+
+var transaccionesPrueba = []transacciones.Transaccion{{
+	Id:                1,
+	CodigoTransaccion: "ctr1",
+	Moneda:            "MXN",
+	Monto:             dinero.DebeParsear("1234567.50"),
+	Emisor:            "Bancomer",
+	EmisorId:          1,
+	Receptor:          "=HYPERLINK(\"x\")",
+	FechaTransaccion:  time.Date(2022, 4, 21, 18, 0, 0, 0, zonaPrueba),
+	Estado:            transacciones.ESTADO_PENDIENTE,
+}, {
+	Id:                2,
+	CodigoTransaccion: "ctr2",
+	Moneda:            "JPY",
+	Monto:             dinero.DebeParsear("-500"),
+	Emisor:            "Banamex",
+	Receptor:          "Pedro & <Paco>",
+	FechaTransaccion:  time.Date(2022, 4, 22, 0, 0, 0, 0, time.UTC),
+	Estado:            transacciones.ESTADO_LIQUIDADA,
+}}
+
+func exportar(t *testing.T, nombreFormato, nombresColumnas, locale string) string {
+	formato, err := ParseFormato(nombreFormato)
+	assert.Nil(t, err)
+	columnas, err := ParseColumnas(nombresColumnas)
+	assert.Nil(t, err)
+	localizacion, err := ParseLocalizacion(locale)
+	assert.Nil(t, err)
+
+	var salida bytes.Buffer
+	escritor := formato.NewEscritor(&salida, columnas, localizacion)
+	for _, transaccion := range transaccionesPrueba {
+		assert.Nil(t, escritor.Escribir(transaccion))
+	}
+	assert.Nil(t, escritor.Cerrar())
+	return salida.String()
+}
+
+func TestEscritorCSV(t *testing.T) {
+	// Act
+	sinLocalizar := exportar(t, "csv", "", "")
+	localizado := exportar(t, "CSV", "codigo_transaccion,monto,emisor_id,receptor", "es_ES")
+
+	// Assert
+	assert.Equal(t, "id,codigo_transaccion,moneda,monto,emisor,receptor,fecha_transaccion,estado\n"+
+		"1,ctr1,MXN,1234567.50,Bancomer,\"'=HYPERLINK(\"\"x\"\")\",2022-04-21T18:00:00-06:00,pendiente\n"+
+		"2,ctr2,JPY,-500,Banamex,Pedro & <Paco>,2022-04-22T00:00:00Z,liquidada\n", sinLocalizar)
+	assert.Equal(t, "codigo_transaccion;monto;emisor_id;receptor\n"+
+		"ctr1;1.234.567,50;1;\"'=HYPERLINK(\"\"x\"\")\"\n"+
+		"ctr2;-500;;Pedro & <Paco>\n", localizado)
+}
+
+func TestEscritorCSVVacio(t *testing.T) {
+	// Arrange
+	formato, _ := ParseFormato("csv")
+	columnas, _ := ParseColumnas("id,monto")
+	var salida bytes.Buffer
+
+	// Act
+	err := formato.NewEscritor(&salida, columnas, SIN_LOCALIZACION).Cerrar()
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "id,monto\n", salida.String())
+}
+
+func TestEscritorNDJSON(t *testing.T) {
+	// Act
+	resultado := exportar(t, "ndjson", "id,monto,emisor_id,fecha_transaccion", "es-ES")
+
+	// Assert
+	assert.Equal(t, `{"id":1,"monto":"1234567.50","emisor_id":1,"fecha_transaccion":"2022-04-21T18:00:00-06:00"}`+"\n"+
+		`{"id":2,"monto":"-500","emisor_id":null,"fecha_transaccion":"2022-04-22T00:00:00Z"}`+"\n", resultado)
+}
+
+func TestEscritorXLSX(t *testing.T) {
+	// Act
+	resultado := exportar(t, "xlsx", "id,monto,receptor,fecha_transaccion,referencia", "")
+
+	// Assert
+	libro, err := zip.NewReader(bytes.NewReader([]byte(resultado)), int64(len(resultado)))
+	assert.Nil(t, err)
+	partes := map[string]string{}
+	for _, archivo := range libro.File {
+		lector, err := archivo.Open()
+		assert.Nil(t, err)
+		contenido, _ := io.ReadAll(lector)
+		partes[archivo.Name] = string(contenido)
+	}
+	assert.Contains(t, partes, "[Content_Types].xml")
+	assert.Contains(t, partes, "xl/workbook.xml")
+	assert.Contains(t, partes, "xl/styles.xml")
+	hoja := partes["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, hoja, `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`)
+	assert.Contains(t, hoja, `<c r="B2" s="2"><v>1234567.50</v></c>`)
+	assert.Contains(t, hoja, `<c r="D2" s="1"><v>44672.75</v></c>`)
+	assert.Contains(t, hoja, `<t xml:space="preserve">Pedro &amp; &lt;Paco&gt;</t>`)
+	assert.Contains(t, hoja, `<c r="D3" s="1"><v>44673</v></c></row></sheetData></worksheet>`)
+}
+
+func TestParseColumnasNoValidas(t *testing.T) {
+	// Act
+	_, errDesconocida := ParseColumnas("id,saldo")
+	_, errRepetida := ParseColumnas("id,monto,ID")
+	_, errFormato := ParseFormato("pdf")
+	_, errLocale := ParseLocalizacion("xx-XX")
+
+	// Assert
+	assert.ErrorIs(t, errDesconocida, ErrExportacionNoValida)
+	assert.ErrorIs(t, errRepetida, ErrExportacionNoValida)
+	assert.ErrorIs(t, errFormato, ErrExportacionNoValida)
+	assert.ErrorIs(t, errLocale, ErrExportacionNoValida)
+}
+
+func TestLocalizacionMonto(t *testing.T) {
+	// Arrange
+	mexico, _ := ParseLocalizacion("es-MX")
+	francia, _ := ParseLocalizacion("fr-FR")
+
+	// Act & Assert
+	assert.Equal(t, "999.99", mexico.Monto(dinero.DebeParsear("999.99")))
+	assert.Equal(t, "1,000", mexico.Monto(dinero.DebeParsear("1000")))
+	assert.Equal(t, "-12,345,678.001", mexico.Monto(dinero.DebeParsear("-12345678.001")))
+	assert.Equal(t, "123 456,00", francia.Monto(dinero.DebeParsear("123456.00")))
+	assert.Equal(t, "0.05", SIN_LOCALIZACION.Monto(dinero.DebeParsear("0.05")))
+}
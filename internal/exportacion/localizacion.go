@@ -0,0 +1,73 @@
+package exportacion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+// Localizacion indica como se escriben los montos en un csv. Donde la coma es
+// el separador decimal las columnas se separan con punto y coma, como espera
+// una hoja de calculo configurada en ese idioma.
+type Localizacion struct {
+	Decimal   string
+	Miles     string
+	Separador rune
+}
+
+// SIN_LOCALIZACION escribe los montos como en la API, sin separador de miles.
+var SIN_LOCALIZACION = Localizacion{Decimal: ".", Separador: ','}
+
+var localizaciones = map[string]Localizacion{
+	"es-mx": {Decimal: ".", Miles: ",", Separador: ','},
+	"en-us": {Decimal: ".", Miles: ",", Separador: ','},
+	"en-gb": {Decimal: ".", Miles: ",", Separador: ','},
+	"es-es": {Decimal: ",", Miles: ".", Separador: ';'},
+	"es-ar": {Decimal: ",", Miles: ".", Separador: ';'},
+	"pt-br": {Decimal: ",", Miles: ".", Separador: ';'},
+	"de-de": {Decimal: ",", Miles: ".", Separador: ';'},
+	"fr-fr": {Decimal: ",", Miles: " ", Separador: ';'},
+}
+
+// ParseLocalizacion lee una etiqueta de idioma como es-MX o es_ES. Una
+// etiqueta vacia no localiza los montos.
+func ParseLocalizacion(etiqueta string) (Localizacion, error) {
+	if strings.TrimSpace(etiqueta) == "" {
+		return SIN_LOCALIZACION, nil
+	}
+	clave := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(etiqueta), "_", "-"))
+	localizacion, ok := localizaciones[clave]
+	if !ok {
+		return Localizacion{}, fmt.Errorf("%w: locale %q no soportado", ErrExportacionNoValida, etiqueta)
+	}
+	return localizacion, nil
+}
+
+// Monto escribe el monto con los separadores de la localizacion.
+func (l Localizacion) Monto(monto dinero.Monto) string {
+	texto := monto.String()
+	signo := ""
+	if strings.HasPrefix(texto, "-") {
+		signo, texto = "-", texto[1:]
+	}
+	entero, decimales := texto, ""
+	if index := strings.Index(texto, "."); index >= 0 {
+		entero, decimales = texto[:index], texto[index+1:]
+	}
+
+	if l.Miles != "" {
+		var agrupado strings.Builder
+		for index, digito := range entero {
+			if index > 0 && (len(entero)-index)%3 == 0 {
+				agrupado.WriteString(l.Miles)
+			}
+			agrupado.WriteRune(digito)
+		}
+		entero = agrupado.String()
+	}
+	if decimales == "" {
+		return signo + entero
+	}
+	return signo + entero + l.Decimal + decimales
+}
@@ -0,0 +1,182 @@
+package exportacion
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+// Las partes fijas de un libro de Excel con una sola hoja. La hoja se escribe
+// al final del zip para poder generarla conforme llegan las transacciones.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="transacciones" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+	// xlsxStyles define el estilo 1 para fechas (formato 22, m/d/yy h:mm) y
+	// el 2 para montos (formato 4, #,##0.00); Excel los muestra con la
+	// configuracion regional de quien abre el archivo.
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`
+	xlsxInicioHoja = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxFinHoja = `</sheetData></worksheet>`
+
+	estiloFecha = "1"
+	estiloMonto = "2"
+)
+
+// epocaExcel es el dia cero de las fechas seriales de Excel.
+var epocaExcel = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// escritorXLSX escribe un libro de Excel con las partes fijas al crearse y la
+// hoja fila por fila. Los textos se guardan en linea, sin tabla de cadenas
+// compartidas, para no tener que conservarlos en memoria.
+type escritorXLSX struct {
+	zip      *zip.Writer
+	hoja     *bufio.Writer
+	columnas []Columna
+	fila     int
+	err      error
+}
+
+func newEscritorXLSX(w io.Writer, columnas []Columna, localizacion Localizacion) Escritor {
+	e := &escritorXLSX{zip: zip.NewWriter(w), columnas: columnas}
+	partes := []struct{ nombre, contenido string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, parte := range partes {
+		if e.err = e.escribirParte(parte.nombre, parte.contenido); e.err != nil {
+			return e
+		}
+	}
+
+	hoja, err := e.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		e.err = err
+		return e
+	}
+	e.hoja = bufio.NewWriter(hoja)
+	e.escribir(xlsxInicioHoja)
+
+	encabezado := make([]interface{}, len(columnas))
+	for index, columna := range columnas {
+		encabezado[index] = columna.Nombre
+	}
+	e.escribirFila(encabezado)
+	return e
+}
+
+func (e *escritorXLSX) escribirParte(nombre, contenido string) error {
+	parte, err := e.zip.Create(nombre)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(parte, contenido)
+	return err
+}
+
+func (e *escritorXLSX) Escribir(transaccion transacciones.Transaccion) error {
+	valores := make([]interface{}, len(e.columnas))
+	for index, columna := range e.columnas {
+		valores[index] = columna.Valor(transaccion)
+	}
+	e.escribirFila(valores)
+	return e.err
+}
+
+func (e *escritorXLSX) Cerrar() error {
+	e.escribir(xlsxFinHoja)
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.hoja.Flush(); err != nil {
+		return err
+	}
+	return e.zip.Close()
+}
+
+// escribir agrega texto a la hoja y conserva el primer error de escritura.
+func (e *escritorXLSX) escribir(textos ...string) {
+	for _, texto := range textos {
+		if e.err != nil {
+			return
+		}
+		_, e.err = e.hoja.WriteString(texto)
+	}
+}
+
+// escribirFila agrega una fila a la hoja; las celdas sin valor se omiten.
+func (e *escritorXLSX) escribirFila(valores []interface{}) {
+	e.fila++
+	fila := strconv.Itoa(e.fila)
+	e.escribir(`<row r="`, fila, `">`)
+	for index, valor := range valores {
+		referencia := nombreColumna(index) + fila
+		switch v := valor.(type) {
+		case string:
+			e.escribir(`<c r="`, referencia, `" t="inlineStr"><is><t xml:space="preserve">`)
+			if e.err == nil {
+				e.err = xml.EscapeText(e.hoja, []byte(v))
+			}
+			e.escribir(`</t></is></c>`)
+		case int:
+			e.escribir(`<c r="`, referencia, `"><v>`, strconv.Itoa(v), `</v></c>`)
+		case dinero.Monto:
+			e.escribir(`<c r="`, referencia, `" s="`, estiloMonto, `"><v>`, v.String(), `</v></c>`)
+		case time.Time:
+			e.escribir(`<c r="`, referencia, `" s="`, estiloFecha, `"><v>`, serialExcel(v), `</v></c>`)
+		}
+	}
+	e.escribir(`</row>`)
+}
+
+// nombreColumna convierte el indice 0, 1, ..., 26 en A, B, ..., AA.
+func nombreColumna(index int) string {
+	nombre := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		nombre = string(rune('A'+(index-1)%26)) + nombre
+	}
+	return nombre
+}
+
+// serialExcel expresa la fecha como dias desde la epoca de Excel, con la hora
+// que marcaba el reloj en la zona de la transaccion.
+func serialExcel(valor time.Time) string {
+	reloj := time.Date(valor.Year(), valor.Month(), valor.Day(), valor.Hour(), valor.Minute(), valor.Second(), valor.Nanosecond(), time.UTC)
+	dias := reloj.Sub(epocaExcel).Hours() / 24
+	return strconv.FormatFloat(dias, 'f', -1, 64)
+}
@@ -0,0 +1,134 @@
+package idempotencia
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+)
+
+// Registro guarda la respuesta de la primera peticion hecha con una clave.
+// Mientras la peticion esta en curso Status vale cero.
+type Registro struct {
+	Clave  string          `json:"clave"`
+	Hash   string          `json:"hash"`
+	Status int             `json:"status"`
+	Cuerpo json.RawMessage `json:"cuerpo,omitempty"`
+	Creado time.Time       `json:"creado"`
+}
+
+type Repository interface {
+	Reservar(registro Registro, reserva time.Duration) (Registro, bool, error)
+	Guardar(registro Registro) error
+	Eliminar(clave string) error
+}
+
+type repository struct {
+	db      store.Store
+	ventana time.Duration
+	mu      sync.Mutex
+}
+
+// NewRepository crea un Repository sobre un store de archivo. Los registros
+// con mas antiguedad que ventana se consideran vencidos y se descartan.
+func NewRepository(db store.Store, ventana time.Duration) Repository {
+	return &repository{db: db, ventana: ventana}
+}
+
+func (r *repository) leer() ([]Registro, error) {
+	var registros []Registro
+	if err := r.db.Read(&registros); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		return nil, err
+	}
+	return registros, nil
+}
+
+// vigentes descarta los registros vencidos.
+func (r *repository) vigentes(registros []Registro) []Registro {
+	resultado := registros[:0]
+	for _, registro := range registros {
+		if time.Since(registro.Creado) < r.ventana {
+			resultado = append(resultado, registro)
+		}
+	}
+	return resultado
+}
+
+// modificar aplica cambio sobre los registros vigentes con el store bloqueado.
+// Se escribe el resultado si cambio lo indica o si se descarto algun vencido.
+func (r *repository) modificar(cambio func(registros []Registro) ([]Registro, bool)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	registros, err := r.leer()
+	if err != nil {
+		return err
+	}
+	total := len(registros)
+	registros = r.vigentes(registros)
+
+	registros, escribir := cambio(registros)
+	if !escribir && len(registros) == total {
+		return nil
+	}
+	if registros == nil {
+		registros = []Registro{}
+	}
+	return r.db.Write(registros)
+}
+
+// Reservar guarda el registro si su clave no tiene uno vigente. Si ya lo tiene
+// regresa el existente y true, sin modificar nada, salvo que sea una reserva
+// de la misma peticion que sigue en curso desde hace reserva o mas: entonces
+// se da por abandonada y el registro la reemplaza.
+func (r *repository) Reservar(registro Registro, reserva time.Duration) (Registro, bool, error) {
+	var existente Registro
+	encontrado := false
+	err := r.modificar(func(registros []Registro) ([]Registro, bool) {
+		for i, actual := range registros {
+			if actual.Clave != registro.Clave {
+				continue
+			}
+			if actual.Status == 0 && actual.Hash == registro.Hash && registro.Creado.Sub(actual.Creado) >= reserva {
+				registros[i] = registro
+				return registros, true
+			}
+			existente, encontrado = actual, true
+			return registros, false
+		}
+		return append(registros, registro), true
+	})
+	return existente, encontrado, err
+}
+
+// Guardar reemplaza el registro con la misma clave.
+func (r *repository) Guardar(registro Registro) error {
+	return r.modificar(func(registros []Registro) ([]Registro, bool) {
+		for i, actual := range registros {
+			if actual.Clave == registro.Clave {
+				registros[i] = registro
+				return registros, true
+			}
+		}
+		return append(registros, registro), true
+	})
+}
+
+func (r *repository) Eliminar(clave string) error {
+	return r.modificar(func(registros []Registro) ([]Registro, bool) {
+		for i, actual := range registros {
+			if actual.Clave == clave {
+				return append(registros[:i], registros[i+1:]...), true
+			}
+		}
+		return registros, false
+	})
+}
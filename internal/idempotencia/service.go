@@ -0,0 +1,76 @@
+package idempotencia
+
+import (
+	"errors"
+	"time"
+)
+
+// LONGITUD_MAXIMA es la mayor longitud aceptada para una clave.
+const LONGITUD_MAXIMA = 255
+
+// DURACION_RESERVA es el tiempo que una peticion en curso conserva su clave.
+// Si el proceso termina antes de completarla, pasado este tiempo un reintento
+// puede volver a procesarla.
+const DURACION_RESERVA = time.Minute
+
+var (
+	ErrClaveNoValida    = errors.New("la clave de idempotencia no es valida")
+	ErrClaveReutilizada = errors.New("la clave de idempotencia ya se uso con una peticion distinta")
+	ErrEnCurso          = errors.New("la peticion con esta clave de idempotencia aun esta en curso")
+)
+
+// Respuesta es la respuesta que se repite a los reintentos de una peticion.
+type Respuesta struct {
+	Status int
+	Cuerpo []byte
+}
+
+type Service interface {
+	Iniciar(clave string, hash string) (*Respuesta, error)
+	Completar(clave string, hash string, respuesta Respuesta) error
+	Cancelar(clave string) error
+}
+
+type service struct {
+	repository Repository
+	ahora      func() time.Time
+}
+
+func NewService(r Repository) Service {
+	return &service{repository: r, ahora: time.Now}
+}
+
+// Iniciar reserva la clave para la peticion identificada por hash. Regresa nil
+// si la peticion es nueva o su reserva anterior ya vencio y debe procesarse, o
+// la respuesta original si es un reintento de una peticion ya completada.
+func (s *service) Iniciar(clave string, hash string) (*Respuesta, error) {
+	if clave == "" || len(clave) > LONGITUD_MAXIMA {
+		return nil, ErrClaveNoValida
+	}
+
+	existente, encontrado, err := s.repository.Reservar(Registro{Clave: clave, Hash: hash, Creado: s.ahora()}, DURACION_RESERVA)
+	if err != nil {
+		return nil, err
+	}
+	if !encontrado {
+		return nil, nil
+	}
+	if existente.Hash != hash {
+		return nil, ErrClaveReutilizada
+	}
+	if existente.Status == 0 {
+		return nil, ErrEnCurso
+	}
+	return &Respuesta{Status: existente.Status, Cuerpo: existente.Cuerpo}, nil
+}
+
+// Completar guarda la respuesta de una peticion iniciada para repetirla en
+// los reintentos.
+func (s *service) Completar(clave string, hash string, respuesta Respuesta) error {
+	return s.repository.Guardar(Registro{Clave: clave, Hash: hash, Status: respuesta.Status, Cuerpo: respuesta.Cuerpo, Creado: s.ahora()})
+}
+
+// Cancelar libera la clave para que un reintento vuelva a procesarse.
+func (s *service) Cancelar(clave string) error {
+	return s.repository.Eliminar(clave)
+}
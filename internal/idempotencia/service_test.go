@@ -0,0 +1,91 @@
+package idempotencia
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func nuevoService(t *testing.T, fileName string) *service {
+	db := &store.JsonFileStore{FileName: fileName}
+	return NewService(NewRepository(db, time.Hour)).(*service)
+}
+
+func TestServiceIniciarCompletar(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "idempotencia.json")
+	service := nuevoService(t, fileName)
+	respuesta := Respuesta{Status: 200, Cuerpo: []byte(`{"code":"200"}`)}
+
+	// Act
+	nueva, errNueva := service.Iniciar("clave-1", "hash-1")
+	_, errEnCurso := service.Iniciar("clave-1", "hash-1")
+	errCompletar := service.Completar("clave-1", "hash-1", respuesta)
+	repetida, errRepetida := nuevoService(t, fileName).Iniciar("clave-1", "hash-1")
+	_, errReutilizada := service.Iniciar("clave-1", "hash-2")
+
+	// Assert
+	assert.Nil(t, nueva)
+	assert.Nil(t, errNueva)
+	assert.ErrorIs(t, errEnCurso, ErrEnCurso)
+	assert.Nil(t, errCompletar)
+	assert.Nil(t, errRepetida)
+	assert.Equal(t, &respuesta, repetida)
+	assert.ErrorIs(t, errReutilizada, ErrClaveReutilizada)
+}
+
+func TestServiceIniciarVencida(t *testing.T) {
+	// Arrange
+	service := nuevoService(t, filepath.Join(t.TempDir(), "idempotencia.json"))
+	service.ahora = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	_, _ = service.Iniciar("clave-1", "hash-1")
+	_ = service.Completar("clave-1", "hash-1", Respuesta{Status: 200, Cuerpo: []byte(`{}`)})
+	service.ahora = time.Now
+
+	// Act
+	respuesta, err := service.Iniciar("clave-1", "hash-2")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, respuesta)
+}
+
+func TestServiceIniciarReservaVencida(t *testing.T) {
+	// Arrange
+	service := nuevoService(t, filepath.Join(t.TempDir(), "idempotencia.json"))
+	service.ahora = func() time.Time { return time.Now().Add(-DURACION_RESERVA) }
+	_, _ = service.Iniciar("clave-1", "hash-1")
+	_, _ = service.Iniciar("clave-2", "hash-2")
+	service.ahora = time.Now
+
+	// Act
+	respuesta, errReintento := service.Iniciar("clave-1", "hash-1")
+	_, errEnCurso := service.Iniciar("clave-1", "hash-1")
+	_, errReutilizada := service.Iniciar("clave-2", "hash-3")
+
+	// Assert
+	assert.Nil(t, errReintento)
+	assert.Nil(t, respuesta)
+	assert.ErrorIs(t, errEnCurso, ErrEnCurso)
+	assert.ErrorIs(t, errReutilizada, ErrClaveReutilizada)
+}
+
+func TestServiceCancelar(t *testing.T) {
+	// Arrange
+	service := nuevoService(t, filepath.Join(t.TempDir(), "idempotencia.json"))
+	_, _ = service.Iniciar("clave-1", "hash-1")
+
+	// Act
+	errCancelar := service.Cancelar("clave-1")
+	respuesta, errIniciar := service.Iniciar("clave-1", "hash-1")
+	_, errVacia := service.Iniciar("", "hash-1")
+
+	// Assert
+	assert.Nil(t, errCancelar)
+	assert.Nil(t, errIniciar)
+	assert.Nil(t, respuesta)
+	assert.ErrorIs(t, errVacia, ErrClaveNoValida)
+}
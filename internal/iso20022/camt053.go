@@ -0,0 +1,502 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+const (
+	SALDO_APERTURA = "OPBD"
+	SALDO_CIERRE   = "CLBD"
+
+	ENTRADA_CONTABILIZADA = "BOOK"
+	ENTRADA_PENDIENTE     = "PDNG"
+)
+
+type documentoCamt053 struct {
+	XMLName  xml.Name       `xml:"Document"`
+	Extracto *extractoBanco `xml:"BkToCstmrStmt"`
+}
+
+type extractoBanco struct {
+	Encabezado encabezadoExtracto `xml:"GrpHdr"`
+	Estados    []estadoCuenta     `xml:"Stmt"`
+}
+
+type encabezadoExtracto struct {
+	MsgId   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type estadoCuenta struct {
+	Id       string    `xml:"Id"`
+	CreDtTm  string    `xml:"CreDtTm"`
+	Periodo  *periodo  `xml:"FrToDt,omitempty"`
+	Cuenta   *cuenta   `xml:"Acct"`
+	Saldos   []saldo   `xml:"Bal"`
+	Resumen  *resumen  `xml:"TxsSummry,omitempty"`
+	Entradas []entrada `xml:"Ntry"`
+}
+
+type periodo struct {
+	Desde string `xml:"FrDtTm"`
+	Hasta string `xml:"ToDtTm"`
+}
+
+type codigoOPropietario struct {
+	Codigo      string `xml:"Cd,omitempty"`
+	Propietario string `xml:"Prtry,omitempty"`
+}
+
+type tipoSaldo struct {
+	CodigoOPropietario codigoOPropietario `xml:"CdOrPrtry"`
+}
+
+type saldo struct {
+	Tipo      tipoSaldo `xml:"Tp"`
+	Importe   *importe  `xml:"Amt"`
+	Indicador string    `xml:"CdtDbtInd"`
+	Fecha     *fecha    `xml:"Dt"`
+}
+
+type resumen struct {
+	Total totalEntradas `xml:"TtlNtries"`
+}
+
+type totalEntradas struct {
+	Numero string `xml:"NbOfNtries,omitempty"`
+	Suma   string `xml:"Sum,omitempty"`
+}
+
+// estadoEntrada acepta el codigo como texto o dentro de Cd, segun la version.
+type estadoEntrada struct {
+	Texto  string `xml:",chardata"`
+	Codigo string `xml:"Cd,omitempty"`
+}
+
+func (e *estadoEntrada) codigo() string {
+	if e == nil {
+		return ""
+	}
+	if e.Codigo != "" {
+		return e.Codigo
+	}
+	return strings.TrimSpace(e.Texto)
+}
+
+type familia struct {
+	Codigo     string `xml:"Cd"`
+	Subfamilia string `xml:"SubFmlyCd"`
+}
+
+type dominio struct {
+	Codigo  string  `xml:"Cd"`
+	Familia familia `xml:"Fmly"`
+}
+
+type codigoBanco struct {
+	Dominio *dominio `xml:"Domn,omitempty"`
+}
+
+type entrada struct {
+	Referencia  string            `xml:"NtryRef,omitempty"`
+	Importe     *importe          `xml:"Amt"`
+	Indicador   string            `xml:"CdtDbtInd"`
+	Reversa     string            `xml:"RvslInd,omitempty"`
+	Estado      *estadoEntrada    `xml:"Sts"`
+	Contable    *fecha            `xml:"BookgDt,omitempty"`
+	Valor       *fecha            `xml:"ValDt,omitempty"`
+	RefServicio string            `xml:"AcctSvcrRef,omitempty"`
+	CodigoBanco *codigoBanco      `xml:"BkTxCd"`
+	Detalles    []detallesEntrada `xml:"NtryDtls"`
+}
+
+type detallesEntrada struct {
+	Transacciones []detalleTransaccion `xml:"TxDtls"`
+}
+
+type referencias struct {
+	InstrId    string `xml:"InstrId,omitempty"`
+	EndToEndId string `xml:"EndToEndId,omitempty"`
+}
+
+type importeTransaccion struct {
+	Importe *importe `xml:"Amt"`
+}
+
+type detalleImportes struct {
+	Transaccion *importeTransaccion `xml:"TxAmt,omitempty"`
+}
+
+type partesRelacionadas struct {
+	Deudor         *parte  `xml:"Dbtr,omitempty"`
+	CuentaDeudor   *cuenta `xml:"DbtrAcct,omitempty"`
+	Acreedor       *parte  `xml:"Cdtr,omitempty"`
+	CuentaAcreedor *cuenta `xml:"CdtrAcct,omitempty"`
+}
+
+type detalleTransaccion struct {
+	Referencias *referencias        `xml:"Refs,omitempty"`
+	Importe     *importe            `xml:"Amt,omitempty"`
+	Importes    *detalleImportes    `xml:"AmtDtls,omitempty"`
+	Partes      *partesRelacionadas `xml:"RltdPties,omitempty"`
+}
+
+// importe regresa el importe propio de la transaccion, que en versiones
+// recientes va en Amt y en las anteriores en AmtDtls/TxAmt/Amt.
+func (d detalleTransaccion) importe() *importe {
+	if d.Importe != nil {
+		return d.Importe
+	}
+	if d.Importes != nil && d.Importes.Transaccion != nil {
+		return d.Importes.Transaccion.Importe
+	}
+	return nil
+}
+
+func (d *documentoCamt053) validar() error {
+	var v validacion
+	v.namespace(d.XMLName.Space, prefijoCamt053)
+	if d.Extracto == nil {
+		v.agregar("Document/BkToCstmrStmt", "falta el elemento")
+		return v.err()
+	}
+	v.texto("GrpHdr/MsgId", d.Extracto.Encabezado.MsgId, maximoTexto)
+	v.fechaHora("GrpHdr/CreDtTm", d.Extracto.Encabezado.CreDtTm)
+	if len(d.Extracto.Estados) == 0 {
+		v.agregar("BkToCstmrStmt/Stmt", "falta el elemento")
+	}
+
+	for i, estado := range d.Extracto.Estados {
+		ruta := fmt.Sprintf("Stmt[%d]", i+1)
+		v.texto(ruta+"/Id", estado.Id, maximoTexto)
+		v.fechaHora(ruta+"/CreDtTm", estado.CreDtTm)
+		if estado.Periodo != nil {
+			v.fechaHora(ruta+"/FrToDt/FrDtTm", estado.Periodo.Desde)
+			v.fechaHora(ruta+"/FrToDt/ToDtTm", estado.Periodo.Hasta)
+		}
+		v.cuenta(ruta+"/Acct", estado.Cuenta)
+		if estado.Cuenta != nil {
+			v.nombre(ruta+"/Acct/Ownr", estado.Cuenta.Dueno)
+		}
+		if len(estado.Saldos) == 0 {
+			v.agregar(ruta+"/Bal", "falta el elemento")
+		}
+		for j, saldo := range estado.Saldos {
+			rutaSaldo := fmt.Sprintf("%s/Bal[%d]", ruta, j+1)
+			if saldo.Tipo.CodigoOPropietario.Codigo == "" && saldo.Tipo.CodigoOPropietario.Propietario == "" {
+				v.agregar(rutaSaldo+"/Tp/CdOrPrtry", "falta Cd o Prtry")
+			}
+			v.importe(rutaSaldo+"/Amt", saldo.Importe)
+			v.indicador(rutaSaldo+"/CdtDbtInd", saldo.Indicador)
+			v.fecha(rutaSaldo+"/Dt", saldo.Fecha)
+		}
+
+		suma := dinero.Nuevo(0, 2)
+		for j, entrada := range estado.Entradas {
+			rutaEntrada := fmt.Sprintf("%s/Ntry[%d]", ruta, j+1)
+			if monto, ok := v.importe(rutaEntrada+"/Amt", entrada.Importe); ok {
+				suma, _ = suma.Sumar(monto)
+			}
+			v.indicador(rutaEntrada+"/CdtDbtInd", entrada.Indicador)
+			switch entrada.Estado.codigo() {
+			case ENTRADA_CONTABILIZADA, ENTRADA_PENDIENTE, "INFO":
+			case "":
+				v.agregar(rutaEntrada+"/Sts", "falta el elemento")
+			default:
+				v.agregar(rutaEntrada+"/Sts", "%q no es BOOK, PDNG ni INFO", entrada.Estado.codigo())
+			}
+			if entrada.Contable != nil {
+				v.fecha(rutaEntrada+"/BookgDt", entrada.Contable)
+			}
+			if entrada.Valor != nil {
+				v.fecha(rutaEntrada+"/ValDt", entrada.Valor)
+			}
+			if entrada.CodigoBanco == nil {
+				v.agregar(rutaEntrada+"/BkTxCd", "falta el elemento")
+			}
+			for k, detalle := range transaccionesDe(entrada) {
+				rutaDetalle := fmt.Sprintf("%s/NtryDtls/TxDtls[%d]", rutaEntrada, k+1)
+				if detalle.importe() != nil {
+					v.importe(rutaDetalle+"/Amt", detalle.importe())
+				}
+				if detalle.Referencias != nil && len(detalle.Referencias.EndToEndId) > maximoTexto {
+					v.texto(rutaDetalle+"/Refs/EndToEndId", detalle.Referencias.EndToEndId, maximoTexto)
+				}
+				if detalle.Partes != nil {
+					if detalle.Partes.CuentaDeudor != nil {
+						v.cuenta(rutaDetalle+"/RltdPties/DbtrAcct", detalle.Partes.CuentaDeudor)
+					}
+					if detalle.Partes.CuentaAcreedor != nil {
+						v.cuenta(rutaDetalle+"/RltdPties/CdtrAcct", detalle.Partes.CuentaAcreedor)
+					}
+				}
+			}
+		}
+
+		// El resumen es opcional, pero si viene debe cuadrar con las entradas.
+		if estado.Resumen != nil {
+			total := estado.Resumen.Total
+			if total.Numero != "" && total.Numero != strconv.Itoa(len(estado.Entradas)) {
+				v.agregar(ruta+"/TxsSummry/TtlNtries/NbOfNtries", "indica %s entradas y hay %d", total.Numero, len(estado.Entradas))
+			}
+			if total.Suma != "" {
+				if declarada, err := dinero.Parse(total.Suma); err != nil || !declarada.Igual(suma) {
+					v.agregar(ruta+"/TxsSummry/TtlNtries/Sum", "indica %s y las entradas suman %s", total.Suma, suma)
+				}
+			}
+		}
+	}
+	return v.err()
+}
+
+func transaccionesDe(entrada entrada) []detalleTransaccion {
+	var lista []detalleTransaccion
+	for _, detalles := range entrada.Detalles {
+		lista = append(lista, detalles.Transacciones...)
+	}
+	return lista
+}
+
+// EstadoCuenta es el estado de cuenta de una parte en una moneda. Apertura es
+// el saldo contable al inicio del periodo y Transacciones las del periodo;
+// las que no son de la parte o de la moneda se ignoran.
+type EstadoCuenta struct {
+	Id            string
+	Creado        time.Time
+	Parte         partes.Parte
+	Moneda        string
+	Desde         time.Time
+	Hasta         time.Time
+	Apertura      dinero.Monto
+	Transacciones []transacciones.Transaccion
+}
+
+// EscribirCamt053 escribe el estado de cuenta como un documento camt.053. Cada
+// transaccion es una entrada con cargo (DBIT) si la parte la emitio y abono
+// (CRDT) si la recibio. Las rechazadas se omiten; las demas ya mueven el saldo
+// y van como BOOK.
+func EscribirCamt053(escritor io.Writer, estado EstadoCuenta) error {
+	cierre := estado.Apertura
+	var entradas []entrada
+	suma := dinero.Nuevo(0, 2)
+	for _, transaccion := range estado.Transacciones {
+		if transaccion.Moneda != estado.Moneda || !transaccion.MueveSaldo() {
+			continue
+		}
+		for _, indicador := range []string{DEBITO, CREDITO} {
+			if (indicador == DEBITO && !transaccion.EmitidaPor(estado.Parte)) || (indicador == CREDITO && !transaccion.RecibidaPor(estado.Parte)) {
+				continue
+			}
+			entradas = append(entradas, entradaDe(transaccion, indicador))
+			var err error
+			if suma, err = suma.Sumar(transaccion.Monto); err != nil {
+				return err
+			}
+			if indicador == CREDITO {
+				cierre, err = cierre.Sumar(transaccion.Monto)
+			} else {
+				cierre, err = cierre.Restar(transaccion.Monto)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	desde, hasta := estado.Desde, estado.Hasta
+	if hasta.IsZero() {
+		hasta = estado.Creado
+	}
+	if desde.IsZero() && len(estado.Transacciones) > 0 {
+		desde = estado.Transacciones[0].FechaTransaccion
+	}
+	if desde.IsZero() {
+		desde = hasta
+	}
+
+	cuentaEstado := cuentaDeParte(estado.Parte.Id)
+	cuentaEstado.Moneda = estado.Moneda
+	if estado.Parte.Nombre != "" {
+		cuentaEstado.Dueno = &parte{Nombre: estado.Parte.Nombre}
+	}
+	documento := documentoCamt053{
+		XMLName: xml.Name{Space: NAMESPACE_CAMT053, Local: "Document"},
+		Extracto: &extractoBanco{
+			Encabezado: encabezadoExtracto{MsgId: estado.Id, CreDtTm: formatearFechaHora(estado.Creado)},
+			Estados: []estadoCuenta{{
+				Id:       estado.Id,
+				CreDtTm:  formatearFechaHora(estado.Creado),
+				Periodo:  &periodo{Desde: formatearFechaHora(desde), Hasta: formatearFechaHora(hasta)},
+				Cuenta:   cuentaEstado,
+				Saldos:   []saldo{saldoDe(SALDO_APERTURA, estado.Moneda, estado.Apertura, desde), saldoDe(SALDO_CIERRE, estado.Moneda, cierre, hasta)},
+				Resumen:  &resumen{Total: totalEntradas{Numero: strconv.Itoa(len(entradas)), Suma: suma.String()}},
+				Entradas: entradas,
+			}},
+		},
+	}
+	return escribirDocumento(escritor, NAMESPACE_CAMT053, &documento, documento.validar)
+}
+
+func saldoDe(tipo, moneda string, monto dinero.Monto, dia time.Time) saldo {
+	indicador := CREDITO
+	if monto.EsNegativo() {
+		indicador, monto = DEBITO, monto.Negar()
+	}
+	importe := nuevoImporte(moneda, monto)
+	return saldo{
+		Tipo:      tipoSaldo{CodigoOPropietario: codigoOPropietario{Codigo: tipo}},
+		Importe:   &importe,
+		Indicador: indicador,
+		Fecha:     &fecha{Dia: dia.Format(formatoFecha)},
+	}
+}
+
+func entradaDe(transaccion transacciones.Transaccion, indicador string) entrada {
+	// Transferencias emitidas (ICDT) o recibidas (RCDT) entre cuentas propias
+	// del banco (BOOK).
+	familiaBanco := "ICDT"
+	if indicador == CREDITO {
+		familiaBanco = "RCDT"
+	}
+	id := strconv.Itoa(transaccion.Id)
+	importe := nuevoImporte(transaccion.Moneda, transaccion.Monto)
+	return entrada{
+		Referencia:  id,
+		Importe:     &importe,
+		Indicador:   indicador,
+		Estado:      &estadoEntrada{Texto: ENTRADA_CONTABILIZADA},
+		Contable:    &fecha{DiaHora: formatearFechaHora(transaccion.FechaTransaccion)},
+		Valor:       &fecha{Dia: transaccion.FechaTransaccion.Format(formatoFecha)},
+		RefServicio: id,
+		CodigoBanco: &codigoBanco{Dominio: &dominio{Codigo: "PMNT", Familia: familia{Codigo: familiaBanco, Subfamilia: "BOOK"}}},
+		Detalles: []detallesEntrada{{Transacciones: []detalleTransaccion{{
+			Referencias: &referencias{InstrId: id, EndToEndId: transaccion.CodigoTransaccion},
+			Partes: &partesRelacionadas{
+				Deudor:         &parte{Nombre: transaccion.Emisor},
+				CuentaDeudor:   cuentaDeParte(transaccion.EmisorId),
+				Acreedor:       &parte{Nombre: transaccion.Receptor},
+				CuentaAcreedor: cuentaDeParte(transaccion.ReceptorId),
+			},
+		}}}},
+	}
+}
+
+// LeerCamt053 valida el documento y regresa una transaccion por cada detalle
+// de sus entradas. El emisor y el receptor salen de RltdPties; si falta la
+// cuenta del lado de la cuenta del estado se usa esta. Las fechas sin zona se
+// ubican en zona.
+func LeerCamt053(lector io.Reader, zona *time.Location) ([]Movimiento, error) {
+	var documento documentoCamt053
+	if err := xml.NewDecoder(lector).Decode(&documento); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDocumentoNoValido, err)
+	}
+	if err := documento.validar(); err != nil {
+		return nil, err
+	}
+
+	var movimientos []Movimiento
+	for _, estado := range documento.Extracto.Estados {
+		for _, entrada := range estado.Entradas {
+			detalles := transaccionesDe(entrada)
+			if len(detalles) == 0 {
+				detalles = []detalleTransaccion{{}}
+			}
+			for _, detalle := range detalles {
+				movimiento := movimientoDe(estado.Cuenta, entrada, detalle, len(detalles) > 1, zona)
+				movimiento.Posicion = len(movimientos) + 1
+				movimientos = append(movimientos, movimiento)
+			}
+		}
+	}
+	return movimientos, nil
+}
+
+// movimientoDe interpreta un detalle de la entrada; agrupada indica que la
+// entrada trae varios detalles y cada uno debe indicar su importe.
+func movimientoDe(cuentaEstado *cuenta, entrada entrada, detalle detalleTransaccion, agrupada bool, zona *time.Location) Movimiento {
+	var movimiento Movimiento
+	importe := entrada.Importe
+	if detalle.importe() != nil {
+		importe = detalle.importe()
+	} else if agrupada {
+		movimiento.Err = fmt.Errorf("la entrada agrupa varias transacciones y esta no indica su importe")
+	}
+	movimiento.Moneda = importe.Moneda
+	movimiento.Monto, _ = dinero.Parse(importe.Valor)
+
+	if detalle.Referencias != nil {
+		movimiento.CodigoTransaccion = codigo(detalle.Referencias.EndToEndId, detalle.Referencias.InstrId)
+	}
+	if movimiento.CodigoTransaccion == "" && !agrupada {
+		movimiento.CodigoTransaccion = codigo(entrada.RefServicio, entrada.Referencia)
+	}
+
+	var cuentaDeudor, cuentaAcreedor *cuenta
+	if detalle.Partes != nil {
+		cuentaDeudor, cuentaAcreedor = detalle.Partes.CuentaDeudor, detalle.Partes.CuentaAcreedor
+	}
+	if cuentaDeudor == nil && entrada.Indicador == DEBITO {
+		cuentaDeudor = cuentaEstado
+	}
+	if cuentaAcreedor == nil && entrada.Indicador == CREDITO {
+		cuentaAcreedor = cuentaEstado
+	}
+
+	fechaEntrada := entrada.Contable
+	if fechaEntrada == nil {
+		fechaEntrada = entrada.Valor
+	}
+
+	var err error
+	switch {
+	case movimiento.Err != nil:
+	case entrada.Reversa == "true" || entrada.Reversa == "1":
+		movimiento.Err = fmt.Errorf("la entrada es una reversa y no se importa")
+	case entrada.Estado.codigo() == "INFO":
+		movimiento.Err = fmt.Errorf("la entrada es informativa (INFO), solo se importan BOOK y PDNG")
+	case movimiento.CodigoTransaccion == "":
+		movimiento.Err = fmt.Errorf("la entrada no indica EndToEndId ni otra referencia")
+	case fechaEntrada == nil:
+		movimiento.Err = fmt.Errorf("la entrada no indica BookgDt ni ValDt")
+	}
+	if movimiento.Err == nil {
+		movimiento.EmisorId, movimiento.Err = idParte("deudor", cuentaDeudor)
+	}
+	if movimiento.Err == nil {
+		movimiento.ReceptorId, movimiento.Err = idParte("acreedor", cuentaAcreedor)
+	}
+	if movimiento.Err == nil {
+		if movimiento.Fecha, err = fechaEntrada.valor(zona); err != nil {
+			movimiento.Err = fmt.Errorf("la fecha de la entrada no es valida: %v", err)
+		}
+	}
+	return movimiento
+}
+
+// escribirDocumento valida el documento antes de escribirlo para no entregar
+// uno que el banco rechazaria.
+func escribirDocumento(escritor io.Writer, namespace string, documento interface{}, validar func() error) error {
+	if err := validar(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(escritor, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(escritor)
+	encoder.Indent("", "  ")
+	inicio := xml.StartElement{Name: xml.Name{Space: namespace, Local: "Document"}}
+	if err := encoder.EncodeElement(documento, inicio); err != nil {
+		return err
+	}
+	_, err := io.WriteString(escritor, "\n")
+	return err
+}
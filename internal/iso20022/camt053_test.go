@@ -0,0 +1,274 @@
+package iso20022
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/stretchr/testify/assert"
+)
+
+var zonaPrueba = time.FixedZone("-06:00", -6*60*60)
+
+var transaccionesPrueba = []transacciones.Transaccion{{
+	Id:                2,
+	CodigoTransaccion: "ctr2",
+	Moneda:            "MXN",
+	Monto:             dinero.DebeParsear("4000.00"),
+	Emisor:            "Bancomer",
+	EmisorId:          1,
+	Receptor:          "Pedrito",
+	ReceptorId:        2,
+	FechaTransaccion:  time.Date(2022, 4, 21, 18, 0, 0, 0, zonaPrueba),
+	Estado:            transacciones.ESTADO_LIQUIDADA,
+}, {
+	Id:                3,
+	CodigoTransaccion: "ct3",
+	Moneda:            "MXN",
+	Monto:             dinero.DebeParsear("500.00"),
+	Emisor:            "Bancomer",
+	EmisorId:          1,
+	Receptor:          "Pablo",
+	ReceptorId:        3,
+	FechaTransaccion:  time.Date(2022, 4, 21, 19, 0, 0, 0, zonaPrueba),
+	Estado:            transacciones.ESTADO_PENDIENTE,
+}, {
+	Id:                4,
+	CodigoTransaccion: "ct4",
+	Moneda:            "MXN",
+	Monto:             dinero.DebeParsear("100.50"),
+	Emisor:            "Banamex",
+	EmisorId:          4,
+	Receptor:          "Bancomer",
+	ReceptorId:        1,
+	FechaTransaccion:  time.Date(2022, 4, 22, 9, 30, 0, 0, zonaPrueba),
+	Estado:            transacciones.ESTADO_LIQUIDADA,
+}, {
+	Id:                5,
+	CodigoTransaccion: "ctr5",
+	Moneda:            "MXN",
+	Monto:             dinero.DebeParsear("800.00"),
+	Emisor:            "Bancomer",
+	EmisorId:          1,
+	Receptor:          "Lestat",
+	ReceptorId:        7,
+	FechaTransaccion:  time.Date(2022, 4, 22, 10, 0, 0, 0, zonaPrueba),
+	Estado:            transacciones.ESTADO_RECHAZADA,
+}, {
+	Id:                6,
+	CodigoTransaccion: "ctr6",
+	Moneda:            "USD",
+	Monto:             dinero.DebeParsear("20.00"),
+	Emisor:            "Bancomer",
+	EmisorId:          1,
+	Receptor:          "Pedrito",
+	ReceptorId:        2,
+	FechaTransaccion:  time.Date(2022, 4, 22, 11, 0, 0, 0, zonaPrueba),
+	Estado:            transacciones.ESTADO_LIQUIDADA,
+}}
+
+func estadoPrueba() EstadoCuenta {
+	return EstadoCuenta{
+		Id:            "CAMT053-1",
+		Creado:        time.Date(2022, 4, 23, 8, 0, 0, 0, zonaPrueba),
+		Parte:         partes.Parte{Id: 1, Nombre: "Bancomer"},
+		Moneda:        "MXN",
+		Desde:         time.Date(2022, 4, 21, 0, 0, 0, 0, zonaPrueba),
+		Hasta:         time.Date(2022, 4, 22, 23, 59, 59, 0, zonaPrueba),
+		Apertura:      dinero.DebeParsear("1000.00"),
+		Transacciones: transaccionesPrueba,
+	}
+}
+
+func TestEscribirCamt053(t *testing.T) {
+	// Arrange
+	var salida bytes.Buffer
+
+	// Act
+	err := EscribirCamt053(&salida, estadoPrueba())
+
+	// Assert
+	assert.Nil(t, err)
+	documento := salida.String()
+	assert.True(t, strings.HasPrefix(documento, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, documento, `<Document xmlns="`+NAMESPACE_CAMT053+`">`)
+	assert.Contains(t, documento, "<Ownr>\n          <Nm>Bancomer</Nm>")
+	// La rechazada y la de otra moneda no son entradas; las demas mueven el
+	// saldo de cierre aunque no esten liquidadas: 1000.00 - 4000.00 - 500.00 + 100.50.
+	assert.Equal(t, 3, strings.Count(documento, "<Ntry>"))
+	assert.Contains(t, documento, "<NbOfNtries>3</NbOfNtries>")
+	assert.Contains(t, documento, "<Sum>4600.50</Sum>")
+	assert.Contains(t, documento, "<Cd>OPBD</Cd>")
+	assert.Contains(t, documento, `<Amt Ccy="MXN">1000.00</Amt>`)
+	assert.Contains(t, documento, "<Cd>CLBD</Cd>")
+	assert.Contains(t, documento, `<Amt Ccy="MXN">3399.50</Amt>`+"\n        <CdtDbtInd>DBIT</CdtDbtInd>")
+	assert.Equal(t, 3, strings.Count(documento, "<Sts>BOOK</Sts>"))
+	assert.Contains(t, documento, "<EndToEndId>ct4</EndToEndId>")
+	assert.Contains(t, documento, "<BookgDt>\n          <DtTm>2022-04-22T09:30:00-06:00</DtTm>")
+}
+
+func TestSaldo(t *testing.T) {
+	// Act
+	saldo, err := Saldo(partes.Parte{Id: 1, Nombre: "Bancomer"}, "MXN", transaccionesPrueba)
+	saldoPendiente, errPendiente := Saldo(partes.Parte{Id: 3, Nombre: "Pablo"}, "MXN", transaccionesPrueba)
+	saldoRechazada, errRechazada := Saldo(partes.Parte{Id: 7, Nombre: "Lestat"}, "MXN", transaccionesPrueba)
+	saldoSinId, errSinId := Saldo(partes.Parte{Id: 1, Nombre: "Bancomer"}, "MXN", []transacciones.Transaccion{
+		{Moneda: "MXN", Monto: dinero.DebeParsear("30.00"), Emisor: "Pablo", Receptor: " BANCOMER ", Estado: transacciones.ESTADO_LIQUIDADA},
+		{Moneda: "MXN", Monto: dinero.DebeParsear("10.00"), Emisor: "Bancomer", EmisorId: 4, Receptor: "Pablo", Estado: transacciones.ESTADO_LIQUIDADA},
+	})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "-4399.50", saldo.String())
+	assert.Nil(t, errPendiente)
+	assert.Equal(t, "500.00", saldoPendiente.String())
+	assert.Nil(t, errRechazada)
+	assert.Equal(t, "0.00", saldoRechazada.String())
+	assert.Nil(t, errSinId)
+	assert.Equal(t, "30.00", saldoSinId.String())
+}
+
+func TestLeerCamt053(t *testing.T) {
+	// Arrange
+	var salida bytes.Buffer
+	assert.Nil(t, EscribirCamt053(&salida, estadoPrueba()))
+
+	// Act
+	movimientos, err := LeerCamt053(&salida, zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(movimientos))
+	for _, movimiento := range movimientos {
+		assert.Nil(t, movimiento.Err)
+	}
+	assert.Equal(t, Movimiento{
+		Posicion:          3,
+		CodigoTransaccion: "ct4",
+		Moneda:            "MXN",
+		Monto:             dinero.DebeParsear("100.50"),
+		EmisorId:          4,
+		ReceptorId:        1,
+		Fecha:             movimientos[2].Fecha,
+	}, movimientos[2])
+	assert.True(t, transaccionesPrueba[2].FechaTransaccion.Equal(movimientos[2].Fecha))
+}
+
+// camt053Banco es un estado de cuenta en la version 08, con el estado y las
+// fechas anidados, como los envian los bancos.
+const camt053Banco = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>BANCO-0001</MsgId><CreDtTm>2022-05-01T07:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>0001</Id>
+      <CreDtTm>2022-05-01T07:00:00</CreDtTm>
+      <Acct><Id><Othr><Id>6</Id></Othr></Id><Ccy>MXN</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="MXN">0</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2022-04-30</Dt></Dt>
+      </Bal>
+      <Ntry>
+        <Amt Ccy="MXN">150.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2022-04-30</Dt></BookgDt>
+        <AcctSvcrRef>REF-1</AcctSvcrRef>
+        <BkTxCd/>
+        <NtryDtls><TxDtls><RltdPties><CdtrAcct><Id><Othr><Id>7</Id></Othr></Id></CdtrAcct></RltdPties></TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="MXN">30.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <ValDt><DtTm>2022-04-30T12:00:00Z</DtTm></ValDt>
+        <BkTxCd/>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><EndToEndId>LOTE-1</EndToEndId></Refs>
+            <Amt Ccy="MXN">10.00</Amt>
+            <RltdPties><DbtrAcct><Id><Othr><Id>4</Id></Othr></Id></DbtrAcct></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <Refs><EndToEndId>LOTE-2</EndToEndId></Refs>
+            <AmtDtls><TxAmt><Amt Ccy="MXN">20.00</Amt></TxAmt></AmtDtls>
+            <RltdPties><DbtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></DbtrAcct></RltdPties>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="MXN">5.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>INFO</Cd></Sts>
+        <BkTxCd/>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestLeerCamt053Banco(t *testing.T) {
+	// Act
+	movimientos, err := LeerCamt053(strings.NewReader(camt053Banco), zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(movimientos))
+
+	// Sin cuenta del deudor se usa la del estado de cuenta.
+	assert.Nil(t, movimientos[0].Err)
+	assert.Equal(t, "REF-1", movimientos[0].CodigoTransaccion)
+	assert.Equal(t, 6, movimientos[0].EmisorId)
+	assert.Equal(t, 7, movimientos[0].ReceptorId)
+	assert.Equal(t, time.Date(2022, 4, 30, 0, 0, 0, 0, zonaPrueba), movimientos[0].Fecha)
+
+	// Una entrada agrupada da un movimiento por detalle con su propio importe.
+	assert.Nil(t, movimientos[1].Err)
+	assert.Equal(t, "LOTE-1", movimientos[1].CodigoTransaccion)
+	assert.Equal(t, "10.00", movimientos[1].Monto.String())
+	assert.Equal(t, 4, movimientos[1].EmisorId)
+	assert.Equal(t, 6, movimientos[1].ReceptorId)
+	assert.Equal(t, time.Date(2022, 4, 30, 12, 0, 0, 0, time.UTC), movimientos[1].Fecha)
+
+	assert.Equal(t, "20.00", movimientos[2].Monto.String())
+	assert.NotNil(t, movimientos[2].Err)
+	assert.Contains(t, movimientos[2].Err.Error(), "DE89370400440532013000")
+
+	assert.NotNil(t, movimientos[3].Err)
+	assert.Contains(t, movimientos[3].Err.Error(), "INFO")
+	assert.Equal(t, 4, movimientos[3].Posicion)
+}
+
+func TestLeerCamt053NoValido(t *testing.T) {
+	// Arrange
+	casos := []struct {
+		nombre    string
+		documento string
+		problema  string
+	}{
+		{"no es xml", "transacciones", "EOF"},
+		{"otro mensaje", strings.Replace(camt053Banco, "camt.053.001.08", "camt.052.001.08", 1), "namespace"},
+		{"sin cuenta", strings.Replace(camt053Banco, "<Acct><Id><Othr><Id>6</Id></Othr></Id><Ccy>MXN</Ccy></Acct>", "", 1), "Stmt[1]/Acct: falta el elemento"},
+		{"moneda", strings.Replace(camt053Banco, `<Amt Ccy="MXN">150.00</Amt>`, `<Amt Ccy="pesos">150.00</Amt>`, 1), "Stmt[1]/Ntry[1]/Amt/@Ccy"},
+		{"importe", strings.Replace(camt053Banco, `<Amt Ccy="MXN">150.00</Amt>`, `<Amt Ccy="MXN">-150.00</Amt>`, 1), "Stmt[1]/Ntry[1]/Amt: \"-150.00\" no es un importe valido"},
+		{"indicador", strings.Replace(camt053Banco, "<CdtDbtInd>DBIT</CdtDbtInd>", "<CdtDbtInd>D</CdtDbtInd>", 1), "Stmt[1]/Ntry[1]/CdtDbtInd"},
+		{"sin codigo de banco", strings.Replace(camt053Banco, "<AcctSvcrRef>REF-1</AcctSvcrRef>\n        <BkTxCd/>", "", 1), "Stmt[1]/Ntry[1]/BkTxCd: falta el elemento"},
+		{"resumen", strings.Replace(camt053Banco, "<Ntry>", "<TxsSummry><TtlNtries><NbOfNtries>2</NbOfNtries><Sum>185.00</Sum></TtlNtries></TxsSummry><Ntry>", 1), "NbOfNtries: indica 2 entradas y hay 3"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			// Act
+			movimientos, err := LeerCamt053(strings.NewReader(caso.documento), zonaPrueba)
+
+			// Assert
+			assert.Nil(t, movimientos)
+			assert.True(t, errors.Is(err, ErrDocumentoNoValido))
+			assert.Contains(t, err.Error(), caso.problema)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Package iso20022 convierte transacciones a y desde los mensajes ISO 20022
+// camt.053 (estado de cuenta del banco al cliente) y pain.001 (iniciacion de
+// transferencias del cliente). Las cuentas se identifican con el id de la
+// parte en Othr/Id; los documentos se validan contra la estructura del esquema
+// antes de leerlos o escribirlos.
+package iso20022
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+const (
+	NAMESPACE_CAMT053 = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+	NAMESPACE_PAIN001 = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+	// Se leen todas las versiones de cada mensaje; se escribe la indicada arriba.
+	prefijoCamt053 = "urn:iso:std:iso:20022:tech:xsd:camt.053.001."
+	prefijoPain001 = "urn:iso:std:iso:20022:tech:xsd:pain.001.001."
+
+	CREDITO = "CRDT"
+	DEBITO  = "DBIT"
+
+	// NO_INDICADO es el valor que el esquema usa cuando no hay referencia.
+	NO_INDICADO = "NOTPROVIDED"
+
+	formatoFecha     = "2006-01-02"
+	formatoFechaHora = "2006-01-02T15:04:05"
+
+	maximoTexto   = 35
+	maximoNombre  = 140
+	maximoErrores = 20
+)
+
+var ErrDocumentoNoValido = errors.New("el documento iso 20022 no es valido")
+
+var (
+	patronMoneda = regexp.MustCompile(`^[A-Z]{3}$`)
+	patronIBAN   = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[a-zA-Z0-9]{1,30}$`)
+	patronNumero = regexp.MustCompile(`^[0-9]{1,15}$`)
+)
+
+// Movimiento es una transaccion leida de un documento. Posicion es su numero
+// dentro del documento empezando en 1; Err indica por que no se puede dar de
+// alta aunque el documento sea valido.
+type Movimiento struct {
+	Posicion          int
+	CodigoTransaccion string
+	Moneda            string
+	Monto             dinero.Monto
+	EmisorId          int
+	ReceptorId        int
+	Fecha             time.Time
+	Err               error
+}
+
+type importe struct {
+	Moneda string `xml:"Ccy,attr"`
+	Valor  string `xml:",chardata"`
+}
+
+func nuevoImporte(moneda string, monto dinero.Monto) importe {
+	return importe{Moneda: moneda, Valor: monto.String()}
+}
+
+// fecha acepta el dia como texto (ISODate) o dentro de Dt o DtTm, segun la
+// version del mensaje.
+type fecha struct {
+	Texto   string `xml:",chardata"`
+	Dia     string `xml:"Dt,omitempty"`
+	DiaHora string `xml:"DtTm,omitempty"`
+}
+
+func (f *fecha) valor(zona *time.Location) (time.Time, error) {
+	if f.DiaHora != "" {
+		return parseFechaHora(f.DiaHora, zona)
+	}
+	dia := f.Dia
+	if dia == "" {
+		dia = strings.TrimSpace(f.Texto)
+	}
+	return time.ParseInLocation(formatoFecha, dia, zona)
+}
+
+// parseFechaHora lee un ISODateTime; sin desplazamiento se ubica en zona.
+func parseFechaHora(texto string, zona *time.Location) (time.Time, error) {
+	if valor, err := time.Parse(time.RFC3339Nano, texto); err == nil {
+		return valor, nil
+	}
+	return time.ParseInLocation(formatoFechaHora, texto, zona)
+}
+
+func formatearFechaHora(valor time.Time) string {
+	return valor.Format(time.RFC3339)
+}
+
+type otraIdentificacion struct {
+	Id string `xml:"Id"`
+}
+
+type identificacionCuenta struct {
+	IBAN string              `xml:"IBAN,omitempty"`
+	Otra *otraIdentificacion `xml:"Othr,omitempty"`
+}
+
+type cuenta struct {
+	Id     identificacionCuenta `xml:"Id"`
+	Moneda string               `xml:"Ccy,omitempty"`
+	Dueno  *parte               `xml:"Ownr,omitempty"`
+}
+
+func cuentaDeParte(id int) *cuenta {
+	return &cuenta{Id: identificacionCuenta{Otra: &otraIdentificacion{Id: strconv.Itoa(id)}}}
+}
+
+// idParte regresa la parte que identifica la cuenta; rol solo se usa en el
+// mensaje de error.
+func idParte(rol string, c *cuenta) (int, error) {
+	if c == nil {
+		return 0, fmt.Errorf("falta la cuenta del %s", rol)
+	}
+	if c.Id.Otra == nil {
+		return 0, fmt.Errorf("la cuenta %s del %s no es una parte registrada, se espera su id en Othr/Id", c.Id.IBAN, rol)
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(c.Id.Otra.Id))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("la cuenta %q del %s no es el id de una parte", c.Id.Otra.Id, rol)
+	}
+	return id, nil
+}
+
+type parte struct {
+	Nombre string `xml:"Nm,omitempty"`
+}
+
+// validacion junta los problemas de estructura de un documento junto con la
+// ruta del elemento en que aparecen.
+type validacion struct {
+	problemas []string
+}
+
+func (v *validacion) agregar(ruta, formato string, args ...interface{}) {
+	v.problemas = append(v.problemas, ruta+": "+fmt.Sprintf(formato, args...))
+}
+
+func (v *validacion) texto(ruta, valor string, maximo int) {
+	switch largo := len([]rune(valor)); {
+	case strings.TrimSpace(valor) == "":
+		v.agregar(ruta, "falta el elemento")
+	case largo > maximo:
+		v.agregar(ruta, "tiene %d caracteres, el maximo es %d", largo, maximo)
+	}
+}
+
+func (v *validacion) fechaHora(ruta, valor string) {
+	if strings.TrimSpace(valor) == "" {
+		v.agregar(ruta, "falta el elemento")
+		return
+	}
+	if _, err := parseFechaHora(valor, time.UTC); err != nil {
+		v.agregar(ruta, "%q no es un ISODateTime", valor)
+	}
+}
+
+func (v *validacion) fecha(ruta string, f *fecha) {
+	if f == nil {
+		v.agregar(ruta, "falta el elemento")
+		return
+	}
+	if f.DiaHora == "" && f.Dia == "" && strings.TrimSpace(f.Texto) == "" {
+		v.agregar(ruta, "falta la fecha")
+		return
+	}
+	if _, err := f.valor(time.UTC); err != nil {
+		v.agregar(ruta, "la fecha no es valida")
+	}
+}
+
+// importe revisa el tipo ActiveOrHistoricCurrencyAndAmount: moneda de tres
+// letras y un decimal no negativo de hasta 18 digitos, 5 de ellos decimales.
+func (v *validacion) importe(ruta string, i *importe) (dinero.Monto, bool) {
+	if i == nil {
+		v.agregar(ruta, "falta el elemento")
+		return dinero.Monto{}, false
+	}
+	valido := true
+	if !patronMoneda.MatchString(i.Moneda) {
+		v.agregar(ruta+"/@Ccy", "%q no es un codigo de moneda", i.Moneda)
+		valido = false
+	}
+	monto, err := dinero.Parse(i.Valor)
+	if err != nil || monto.EsNegativo() || monto.Escala() > 5 || len(strings.TrimLeft(strings.Replace(monto.String(), ".", "", 1), "0")) > 18 {
+		v.agregar(ruta, "%q no es un importe valido", strings.TrimSpace(i.Valor))
+		return dinero.Monto{}, false
+	}
+	return monto, valido
+}
+
+func (v *validacion) indicador(ruta, valor string) {
+	if valor != CREDITO && valor != DEBITO {
+		v.agregar(ruta, "%q no es CRDT ni DBIT", valor)
+	}
+}
+
+func (v *validacion) cuenta(ruta string, c *cuenta) {
+	if c == nil {
+		v.agregar(ruta, "falta el elemento")
+		return
+	}
+	switch {
+	case c.Id.IBAN != "":
+		if !patronIBAN.MatchString(c.Id.IBAN) {
+			v.agregar(ruta+"/Id/IBAN", "%q no es un IBAN", c.Id.IBAN)
+		}
+	case c.Id.Otra != nil:
+		v.texto(ruta+"/Id/Othr/Id", c.Id.Otra.Id, 34)
+	default:
+		v.agregar(ruta+"/Id", "falta IBAN u Othr")
+	}
+	if c.Moneda != "" && !patronMoneda.MatchString(c.Moneda) {
+		v.agregar(ruta+"/Ccy", "%q no es un codigo de moneda", c.Moneda)
+	}
+}
+
+func (v *validacion) nombre(ruta string, p *parte) {
+	if p != nil && len([]rune(p.Nombre)) > maximoNombre {
+		v.agregar(ruta+"/Nm", "el maximo es %d caracteres", maximoNombre)
+	}
+}
+
+func (v *validacion) namespace(espacio, prefijo string) {
+	if !strings.HasPrefix(espacio, prefijo) {
+		v.agregar("Document", "el namespace %q no es %s*", espacio, prefijo)
+	}
+}
+
+func (v *validacion) err() error {
+	if len(v.problemas) == 0 {
+		return nil
+	}
+	problemas := v.problemas
+	resto := ""
+	if len(problemas) > maximoErrores {
+		resto = fmt.Sprintf("; y %d problemas mas", len(problemas)-maximoErrores)
+		problemas = problemas[:maximoErrores]
+	}
+	return fmt.Errorf("%w: %s%s", ErrDocumentoNoValido, strings.Join(problemas, "; "), resto)
+}
+
+// Saldo regresa el saldo de la parte en la moneda que dejan las
+// transacciones: lo que recibio menos lo que envio. Sigue la regla de
+// Transaccion.MueveSaldo, como el libro y los estados de cuenta.
+func Saldo(parte partes.Parte, moneda string, lista []transacciones.Transaccion) (dinero.Monto, error) {
+	saldo := dinero.Nuevo(0, 2)
+	for _, transaccion := range lista {
+		if transaccion.Moneda != moneda || !transaccion.MueveSaldo() {
+			continue
+		}
+		var err error
+		if transaccion.RecibidaPor(parte) {
+			if saldo, err = saldo.Sumar(transaccion.Monto); err != nil {
+				return dinero.Monto{}, err
+			}
+		}
+		if transaccion.EmitidaPor(parte) {
+			if saldo, err = saldo.Restar(transaccion.Monto); err != nil {
+				return dinero.Monto{}, err
+			}
+		}
+	}
+	return saldo, nil
+}
+
+// codigo regresa la primera referencia indicada.
+func codigo(referencias ...string) string {
+	for _, referencia := range referencias {
+		if referencia = strings.TrimSpace(referencia); referencia != "" && referencia != NO_INDICADO {
+			return referencia
+		}
+	}
+	return ""
+}
@@ -0,0 +1,281 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+const (
+	METODO_TRANSFERENCIA = "TRF"
+)
+
+type documentoPain001 struct {
+	XMLName    xml.Name                  `xml:"Document"`
+	Iniciacion *iniciacionTransferencias `xml:"CstmrCdtTrfInitn"`
+}
+
+type iniciacionTransferencias struct {
+	Encabezado encabezadoIniciacion `xml:"GrpHdr"`
+	Pagos      []informacionPago    `xml:"PmtInf"`
+}
+
+type encabezadoIniciacion struct {
+	MsgId     string `xml:"MsgId"`
+	CreDtTm   string `xml:"CreDtTm"`
+	NbOfTxs   string `xml:"NbOfTxs"`
+	CtrlSum   string `xml:"CtrlSum,omitempty"`
+	Iniciador *parte `xml:"InitgPty"`
+}
+
+type institucion struct {
+	BIC   string              `xml:"BIC,omitempty"`
+	BICFI string              `xml:"BICFI,omitempty"`
+	Otra  *otraIdentificacion `xml:"Othr,omitempty"`
+}
+
+type agente struct {
+	Institucion institucion `xml:"FinInstnId"`
+}
+
+type informacionPago struct {
+	PmtInfId       string          `xml:"PmtInfId"`
+	Metodo         string          `xml:"PmtMtd"`
+	NbOfTxs        string          `xml:"NbOfTxs,omitempty"`
+	CtrlSum        string          `xml:"CtrlSum,omitempty"`
+	Ejecucion      *fecha          `xml:"ReqdExctnDt"`
+	Deudor         *parte          `xml:"Dbtr"`
+	CuentaDeudor   *cuenta         `xml:"DbtrAcct"`
+	AgenteDeudor   *agente         `xml:"DbtrAgt"`
+	Transferencias []transferencia `xml:"CdtTrfTxInf"`
+}
+
+type identificacionPago struct {
+	InstrId    string `xml:"InstrId,omitempty"`
+	EndToEndId string `xml:"EndToEndId"`
+}
+
+type importeInstruido struct {
+	Instruido   *importe  `xml:"InstdAmt,omitempty"`
+	Equivalente *struct{} `xml:"EqvtAmt,omitempty"`
+}
+
+type transferencia struct {
+	PmtId          identificacionPago `xml:"PmtId"`
+	Importe        *importeInstruido  `xml:"Amt"`
+	Acreedor       *parte             `xml:"Cdtr,omitempty"`
+	CuentaAcreedor *cuenta            `xml:"CdtrAcct,omitempty"`
+}
+
+func (d *documentoPain001) validar() error {
+	var v validacion
+	v.namespace(d.XMLName.Space, prefijoPain001)
+	if d.Iniciacion == nil {
+		v.agregar("Document/CstmrCdtTrfInitn", "falta el elemento")
+		return v.err()
+	}
+	encabezado := d.Iniciacion.Encabezado
+	v.texto("GrpHdr/MsgId", encabezado.MsgId, maximoTexto)
+	v.fechaHora("GrpHdr/CreDtTm", encabezado.CreDtTm)
+	if encabezado.Iniciador == nil {
+		v.agregar("GrpHdr/InitgPty", "falta el elemento")
+	}
+	v.nombre("GrpHdr/InitgPty", encabezado.Iniciador)
+	if len(d.Iniciacion.Pagos) == 0 {
+		v.agregar("CstmrCdtTrfInitn/PmtInf", "falta el elemento")
+	}
+
+	total := 0
+	sumaTotal := dinero.Nuevo(0, 2)
+	for i, pago := range d.Iniciacion.Pagos {
+		ruta := fmt.Sprintf("PmtInf[%d]", i+1)
+		v.texto(ruta+"/PmtInfId", pago.PmtInfId, maximoTexto)
+		switch pago.Metodo {
+		case METODO_TRANSFERENCIA, "CHK", "TRA":
+		default:
+			v.agregar(ruta+"/PmtMtd", "%q no es TRF, CHK ni TRA", pago.Metodo)
+		}
+		v.fecha(ruta+"/ReqdExctnDt", pago.Ejecucion)
+		if pago.Deudor == nil {
+			v.agregar(ruta+"/Dbtr", "falta el elemento")
+		}
+		v.nombre(ruta+"/Dbtr", pago.Deudor)
+		v.cuenta(ruta+"/DbtrAcct", pago.CuentaDeudor)
+		if pago.AgenteDeudor == nil {
+			v.agregar(ruta+"/DbtrAgt", "falta el elemento")
+		}
+		if len(pago.Transferencias) == 0 {
+			v.agregar(ruta+"/CdtTrfTxInf", "falta el elemento")
+		}
+
+		suma := dinero.Nuevo(0, 2)
+		for j, transferencia := range pago.Transferencias {
+			rutaTransferencia := fmt.Sprintf("%s/CdtTrfTxInf[%d]", ruta, j+1)
+			v.texto(rutaTransferencia+"/PmtId/EndToEndId", transferencia.PmtId.EndToEndId, maximoTexto)
+			switch {
+			case transferencia.Importe == nil:
+				v.agregar(rutaTransferencia+"/Amt", "falta el elemento")
+			case transferencia.Importe.Instruido != nil:
+				if monto, ok := v.importe(rutaTransferencia+"/Amt/InstdAmt", transferencia.Importe.Instruido); ok {
+					suma, _ = suma.Sumar(monto)
+				}
+			case transferencia.Importe.Equivalente == nil:
+				v.agregar(rutaTransferencia+"/Amt", "falta InstdAmt o EqvtAmt")
+			}
+			v.nombre(rutaTransferencia+"/Cdtr", transferencia.Acreedor)
+			if transferencia.CuentaAcreedor != nil {
+				v.cuenta(rutaTransferencia+"/CdtrAcct", transferencia.CuentaAcreedor)
+			}
+		}
+		controlar(&v, ruta, pago.NbOfTxs, pago.CtrlSum, len(pago.Transferencias), suma)
+		total += len(pago.Transferencias)
+		sumaTotal, _ = sumaTotal.Sumar(suma)
+	}
+	if encabezado.NbOfTxs == "" {
+		v.agregar("GrpHdr/NbOfTxs", "falta el elemento")
+	}
+	controlar(&v, "GrpHdr", encabezado.NbOfTxs, encabezado.CtrlSum, total, sumaTotal)
+	return v.err()
+}
+
+// controlar revisa que NbOfTxs y CtrlSum, si vienen, cuadren con las
+// transferencias.
+func controlar(v *validacion, ruta, numero, control string, total int, suma dinero.Monto) {
+	if numero != "" {
+		if !patronNumero.MatchString(numero) {
+			v.agregar(ruta+"/NbOfTxs", "%q no es un numero", numero)
+		} else if numero != strconv.Itoa(total) {
+			v.agregar(ruta+"/NbOfTxs", "indica %s transferencias y hay %d", numero, total)
+		}
+	}
+	if control != "" {
+		if declarada, err := dinero.Parse(control); err != nil || !declarada.Igual(suma) {
+			v.agregar(ruta+"/CtrlSum", "indica %s y las transferencias suman %s", control, suma)
+		}
+	}
+}
+
+// Iniciacion es una orden de transferencias dirigida al banco.
+type Iniciacion struct {
+	Id            string
+	Creado        time.Time
+	Iniciador     string
+	Transacciones []transacciones.Transaccion
+}
+
+// EscribirPain001 escribe las transacciones como un documento pain.001. Las
+// transacciones se agrupan en un PmtInf por emisor y dia de ejecucion, en el
+// orden en que aparecen; EndToEndId lleva el codigo_transaccion.
+func EscribirPain001(escritor io.Writer, iniciacion Iniciacion) error {
+	type grupo struct {
+		emisorId int
+		dia      string
+	}
+	var pagos []informacionPago
+	posiciones := map[grupo]int{}
+	suma := dinero.Nuevo(0, 2)
+	for _, transaccion := range iniciacion.Transacciones {
+		clave := grupo{emisorId: transaccion.EmisorId, dia: transaccion.FechaTransaccion.Format(formatoFecha)}
+		posicion, ok := posiciones[clave]
+		if !ok {
+			posicion = len(pagos)
+			posiciones[clave] = posicion
+			pagos = append(pagos, informacionPago{
+				PmtInfId:     fmt.Sprintf("%s-%d", iniciacion.Id, posicion+1),
+				Metodo:       METODO_TRANSFERENCIA,
+				Ejecucion:    &fecha{Texto: clave.dia},
+				Deudor:       &parte{Nombre: transaccion.Emisor},
+				CuentaDeudor: cuentaDeParte(transaccion.EmisorId),
+				AgenteDeudor: &agente{Institucion: institucion{Otra: &otraIdentificacion{Id: NO_INDICADO}}},
+			})
+		}
+		importe := nuevoImporte(transaccion.Moneda, transaccion.Monto)
+		pagos[posicion].Transferencias = append(pagos[posicion].Transferencias, transferencia{
+			PmtId:          identificacionPago{InstrId: strconv.Itoa(transaccion.Id), EndToEndId: transaccion.CodigoTransaccion},
+			Importe:        &importeInstruido{Instruido: &importe},
+			Acreedor:       &parte{Nombre: transaccion.Receptor},
+			CuentaAcreedor: cuentaDeParte(transaccion.ReceptorId),
+		})
+		var err error
+		if suma, err = suma.Sumar(transaccion.Monto); err != nil {
+			return err
+		}
+	}
+	for index := range pagos {
+		sumaPago := dinero.Nuevo(0, 2)
+		for _, transferencia := range pagos[index].Transferencias {
+			monto, _ := dinero.Parse(transferencia.Importe.Instruido.Valor)
+			sumaPago, _ = sumaPago.Sumar(monto)
+		}
+		pagos[index].NbOfTxs = strconv.Itoa(len(pagos[index].Transferencias))
+		pagos[index].CtrlSum = sumaPago.String()
+	}
+
+	documento := documentoPain001{
+		XMLName: xml.Name{Space: NAMESPACE_PAIN001, Local: "Document"},
+		Iniciacion: &iniciacionTransferencias{
+			Encabezado: encabezadoIniciacion{
+				MsgId:     iniciacion.Id,
+				CreDtTm:   formatearFechaHora(iniciacion.Creado),
+				NbOfTxs:   strconv.Itoa(len(iniciacion.Transacciones)),
+				CtrlSum:   suma.String(),
+				Iniciador: &parte{Nombre: iniciacion.Iniciador},
+			},
+			Pagos: pagos,
+		},
+	}
+	return escribirDocumento(escritor, NAMESPACE_PAIN001, &documento, documento.validar)
+}
+
+// LeerPain001 valida el documento y regresa una transaccion por cada
+// CdtTrfTxInf: el emisor es la cuenta del deudor del PmtInf, el receptor la
+// del acreedor y la fecha la de ejecucion solicitada, ubicada en zona.
+func LeerPain001(lector io.Reader, zona *time.Location) ([]Movimiento, error) {
+	var documento documentoPain001
+	if err := xml.NewDecoder(lector).Decode(&documento); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDocumentoNoValido, err)
+	}
+	if err := documento.validar(); err != nil {
+		return nil, err
+	}
+
+	var movimientos []Movimiento
+	for _, pago := range documento.Iniciacion.Pagos {
+		emisorId, errEmisor := idParte("deudor", pago.CuentaDeudor)
+		ejecucion, errFecha := pago.Ejecucion.valor(zona)
+		for _, transferencia := range pago.Transferencias {
+			movimiento := Movimiento{
+				Posicion:          len(movimientos) + 1,
+				CodigoTransaccion: codigo(transferencia.PmtId.EndToEndId, transferencia.PmtId.InstrId),
+				EmisorId:          emisorId,
+				Fecha:             ejecucion,
+			}
+			if instruido := transferencia.Importe.Instruido; instruido != nil {
+				movimiento.Moneda = instruido.Moneda
+				movimiento.Monto, _ = dinero.Parse(instruido.Valor)
+			}
+			switch {
+			case pago.Metodo != METODO_TRANSFERENCIA:
+				movimiento.Err = fmt.Errorf("el metodo de pago %s no es una transferencia", pago.Metodo)
+			case transferencia.Importe.Instruido == nil:
+				movimiento.Err = fmt.Errorf("solo se importan importes en InstdAmt")
+			case movimiento.CodigoTransaccion == "":
+				movimiento.Err = fmt.Errorf("la transferencia no indica EndToEndId ni InstrId")
+			case errEmisor != nil:
+				movimiento.Err = errEmisor
+			case errFecha != nil:
+				movimiento.Err = fmt.Errorf("la fecha de ejecucion no es valida: %v", errFecha)
+			}
+			if movimiento.Err == nil {
+				movimiento.ReceptorId, movimiento.Err = idParte("acreedor", transferencia.CuentaAcreedor)
+			}
+			movimientos = append(movimientos, movimiento)
+		}
+	}
+	return movimientos, nil
+}
@@ -0,0 +1,161 @@
+package iso20022
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func iniciacionPrueba() Iniciacion {
+	return Iniciacion{
+		Id:            "PAIN001-1",
+		Creado:        time.Date(2022, 4, 23, 8, 0, 0, 0, zonaPrueba),
+		Iniciador:     "Tesoreria",
+		Transacciones: transaccionesPrueba[:3],
+	}
+}
+
+func TestEscribirPain001(t *testing.T) {
+	// Arrange
+	var salida bytes.Buffer
+
+	// Act
+	err := EscribirPain001(&salida, iniciacionPrueba())
+
+	// Assert
+	assert.Nil(t, err)
+	documento := salida.String()
+	assert.Contains(t, documento, `<Document xmlns="`+NAMESPACE_PAIN001+`">`)
+	// ctr2 y ct3 salen del mismo emisor el mismo dia; ct4 va en otro PmtInf.
+	assert.Equal(t, 2, strings.Count(documento, "<PmtInf>"))
+	assert.Equal(t, 3, strings.Count(documento, "<CdtTrfTxInf>"))
+	assert.Contains(t, documento, "<PmtInfId>PAIN001-1-1</PmtInfId>\n      <PmtMtd>TRF</PmtMtd>\n      <NbOfTxs>2</NbOfTxs>\n      <CtrlSum>4500.00</CtrlSum>\n      <ReqdExctnDt>2022-04-21</ReqdExctnDt>")
+	assert.Contains(t, documento, "<NbOfTxs>3</NbOfTxs>\n      <CtrlSum>4600.50</CtrlSum>\n      <InitgPty>\n        <Nm>Tesoreria</Nm>")
+	assert.Contains(t, documento, `<InstdAmt Ccy="MXN">100.50</InstdAmt>`)
+	assert.Contains(t, documento, "<EndToEndId>ctr2</EndToEndId>")
+}
+
+func TestLeerPain001(t *testing.T) {
+	// Arrange
+	var salida bytes.Buffer
+	assert.Nil(t, EscribirPain001(&salida, iniciacionPrueba()))
+
+	// Act
+	movimientos, err := LeerPain001(&salida, zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(movimientos))
+	for index, movimiento := range movimientos {
+		transaccion := transaccionesPrueba[index]
+		assert.Nil(t, movimiento.Err)
+		assert.Equal(t, index+1, movimiento.Posicion)
+		assert.Equal(t, transaccion.CodigoTransaccion, movimiento.CodigoTransaccion)
+		assert.Equal(t, transaccion.Moneda, movimiento.Moneda)
+		assert.True(t, transaccion.Monto.Igual(movimiento.Monto))
+		assert.Equal(t, transaccion.EmisorId, movimiento.EmisorId)
+		assert.Equal(t, transaccion.ReceptorId, movimiento.ReceptorId)
+		// La fecha de ejecucion solo indica el dia.
+		anio, mes, dia := transaccion.FechaTransaccion.Date()
+		assert.Equal(t, time.Date(anio, mes, dia, 0, 0, 0, 0, zonaPrueba), movimiento.Fecha)
+	}
+}
+
+// pain001Cliente es una orden en la version 09, con la fecha de ejecucion
+// anidada.
+const pain001Cliente = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09">
+  <CstmrCdtTrfInitn>
+    <GrpHdr>
+      <MsgId>CLIENTE-7</MsgId>
+      <CreDtTm>2022-05-02T10:00:00</CreDtTm>
+      <NbOfTxs>2</NbOfTxs>
+      <CtrlSum>75.25</CtrlSum>
+      <InitgPty><Nm>Banregio</Nm></InitgPty>
+    </GrpHdr>
+    <PmtInf>
+      <PmtInfId>LOTE-7</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <ReqdExctnDt><Dt>2022-05-03</Dt></ReqdExctnDt>
+      <Dbtr><Nm>Banregio</Nm></Dbtr>
+      <DbtrAcct><Id><Othr><Id>6</Id></Othr></Id></DbtrAcct>
+      <DbtrAgt><FinInstnId><BICFI>BRGOMXMM</BICFI></FinInstnId></DbtrAgt>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>CLI-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="MXN">50.25</InstdAmt></Amt>
+        <Cdtr><Nm>Lestat</Nm></Cdtr>
+        <CdtrAcct><Id><Othr><Id>7</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>CLI-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="MXN">25</InstdAmt></Amt>
+        <Cdtr><Nm>Lestat</Nm></Cdtr>
+        <CdtrAcct><Id><Othr><Id>cuenta-lestat</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestLeerPain001Cliente(t *testing.T) {
+	// Act
+	movimientos, err := LeerPain001(strings.NewReader(pain001Cliente), zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(movimientos))
+	assert.Nil(t, movimientos[0].Err)
+	assert.Equal(t, "CLI-1", movimientos[0].CodigoTransaccion)
+	assert.Equal(t, 6, movimientos[0].EmisorId)
+	assert.Equal(t, 7, movimientos[0].ReceptorId)
+	assert.Equal(t, time.Date(2022, 5, 3, 0, 0, 0, 0, zonaPrueba), movimientos[0].Fecha)
+	assert.NotNil(t, movimientos[1].Err)
+	assert.Contains(t, movimientos[1].Err.Error(), `"cuenta-lestat"`)
+}
+
+func TestLeerPain001NoValido(t *testing.T) {
+	// Arrange
+	casos := []struct {
+		nombre    string
+		documento string
+		problema  string
+	}{
+		{"otro mensaje", strings.Replace(pain001Cliente, "pain.001.001.09", "pain.008.001.08", 1), "namespace"},
+		{"numero", strings.Replace(pain001Cliente, "<NbOfTxs>2</NbOfTxs>", "<NbOfTxs>3</NbOfTxs>", 1), "GrpHdr/NbOfTxs: indica 3 transferencias y hay 2"},
+		{"control", strings.Replace(pain001Cliente, "<CtrlSum>75.25</CtrlSum>", "<CtrlSum>75.00</CtrlSum>", 1), "GrpHdr/CtrlSum: indica 75.00 y las transferencias suman 75.25"},
+		{"sin agente", strings.Replace(pain001Cliente, "<DbtrAgt><FinInstnId><BICFI>BRGOMXMM</BICFI></FinInstnId></DbtrAgt>", "", 1), "PmtInf[1]/DbtrAgt: falta el elemento"},
+		{"sin fecha", strings.Replace(pain001Cliente, "<ReqdExctnDt><Dt>2022-05-03</Dt></ReqdExctnDt>", "", 1), "PmtInf[1]/ReqdExctnDt: falta el elemento"},
+		{"referencia larga", strings.Replace(pain001Cliente, "CLI-1", strings.Repeat("x", 36), 1), "CdtTrfTxInf[1]/PmtId/EndToEndId: tiene 36 caracteres"},
+		{"metodo", strings.Replace(pain001Cliente, "<PmtMtd>TRF</PmtMtd>", "<PmtMtd>SEPA</PmtMtd>", 1), "PmtInf[1]/PmtMtd"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			// Act
+			movimientos, err := LeerPain001(strings.NewReader(caso.documento), zonaPrueba)
+
+			// Assert
+			assert.Nil(t, movimientos)
+			assert.True(t, errors.Is(err, ErrDocumentoNoValido))
+			assert.Contains(t, err.Error(), caso.problema)
+		})
+	}
+}
+
+func TestEscribirPain001NoValido(t *testing.T) {
+	// Arrange
+	iniciacion := iniciacionPrueba()
+	iniciacion.Transacciones = nil
+	var salida bytes.Buffer
+
+	// Act
+	err := EscribirPain001(&salida, iniciacion)
+
+	// Assert
+	assert.True(t, errors.Is(err, ErrDocumentoNoValido))
+	assert.Contains(t, err.Error(), "PmtInf: falta el elemento")
+	assert.Equal(t, 0, salida.Len())
+}
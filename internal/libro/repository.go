@@ -0,0 +1,82 @@
+package libro
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+)
+
+// Asiento es un movimiento de una cuenta. Un monto negativo es un cargo
+// (debe) y uno positivo un abono (haber); los asientos de una misma
+// transaccion siempre suman cero por moneda.
+type Asiento struct {
+	Id            int          `json:"id"`
+	TransaccionId int          `json:"transaccion_id"`
+	Cuenta        string       `json:"cuenta"`
+	Moneda        string       `json:"moneda"`
+	Monto         dinero.Monto `json:"monto" swaggertype:"string" example:"-4000.50"`
+	Fecha         time.Time    `json:"fecha"`
+	Concepto      string       `json:"concepto"`
+	Registrado    time.Time    `json:"registrado"`
+}
+
+type Repository interface {
+	GetAll() ([]Asiento, error)
+	Agregar(nuevos func(asientos []Asiento) []Asiento) error
+}
+
+type repository struct {
+	db store.Store
+	mu sync.Mutex
+}
+
+// NewRepository crea un Repository sobre un store de archivo. Si el archivo
+// aun no existe el libro se considera vacio.
+func NewRepository(db store.Store) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]Asiento, error) {
+	var asientos []Asiento
+	if err := r.db.Read(&asientos); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		return nil, err
+	}
+	return asientos, nil
+}
+
+// Agregar calcula con nuevos, a partir de los asientos existentes, los que hay
+// que agregar y los guarda con ids consecutivos. Los asientos nunca se
+// modifican ni se eliminan; una correccion es un asiento de ajuste.
+func (r *repository) Agregar(nuevos func(asientos []Asiento) []Asiento) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	asientos, err := r.GetAll()
+	if err != nil {
+		return err
+	}
+
+	agregados := nuevos(asientos)
+	if len(agregados) == 0 {
+		return nil
+	}
+
+	ultimoId := 0
+	if len(asientos) > 0 {
+		ultimoId = asientos[len(asientos)-1].Id
+	}
+	for index := range agregados {
+		ultimoId++
+		agregados[index].Id = ultimoId
+	}
+	return r.db.Write(append(asientos, agregados...))
+}
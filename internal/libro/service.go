@@ -0,0 +1,261 @@
+package libro
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+const (
+	CONCEPTO_REGISTRO = "registro"
+	CONCEPTO_AJUSTE   = "ajuste"
+)
+
+var ErrCuentaNoEncontrada = errors.New("la cuenta no tiene movimientos")
+
+// Saldo es el saldo de una cuenta en una moneda.
+type Saldo struct {
+	Moneda string       `json:"moneda"`
+	Saldo  dinero.Monto `json:"saldo" swaggertype:"string" example:"1500.00"`
+}
+
+// Movimiento es un asiento con el saldo de la cuenta en su moneda despues de
+// aplicarlo.
+type Movimiento struct {
+	Asiento
+	Saldo dinero.Monto `json:"saldo" swaggertype:"string" example:"1500.00"`
+}
+
+// Service lleva un libro de partida doble: cada transaccion carga su monto al
+// emisor y lo abona al receptor. Implementa transacciones.Observador para
+// mantener el libro al dia cuando las transacciones cambian.
+type Service interface {
+	Actualizada(transaccion transacciones.Transaccion) error
+	Eliminada(id int) error
+	Sincronizar(lista []transacciones.Transaccion) error
+	Saldos(cuenta string) ([]Saldo, error)
+	Movimientos(cuenta string, moneda string) ([]Movimiento, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{repository: r}
+}
+
+// partidas regresa los asientos que corresponden a la transaccion.
+func partidas(transaccion transacciones.Transaccion) []Asiento {
+	if !transaccion.MueveSaldo() {
+		return nil
+	}
+	asiento := Asiento{
+		TransaccionId: transaccion.Id,
+		Moneda:        transaccion.Moneda,
+		Fecha:         transaccion.FechaTransaccion,
+		Concepto:      CONCEPTO_REGISTRO,
+	}
+	cargo, abono := asiento, asiento
+	cargo.Cuenta, cargo.Monto = transaccion.Emisor, transaccion.Monto.Negar()
+	abono.Cuenta, abono.Monto = transaccion.Receptor, transaccion.Monto
+	return []Asiento{cargo, abono}
+}
+
+type clave struct {
+	cuenta string
+	moneda string
+	fecha  int64
+}
+
+func claveDe(asiento Asiento) clave {
+	return clave{cuenta: asiento.Cuenta, moneda: asiento.Moneda, fecha: asiento.Fecha.UnixNano()}
+}
+
+// neto suma por cuenta, moneda y fecha los asientos dados, y omite los que
+// quedan en cero.
+func neto(asientos []Asiento) map[clave]Asiento {
+	totales := map[clave]Asiento{}
+	for _, asiento := range asientos {
+		k := claveDe(asiento)
+		total, ok := totales[k]
+		if !ok {
+			totales[k] = asiento
+			continue
+		}
+		suma, err := total.Monto.Sumar(asiento.Monto)
+		if err != nil {
+			continue
+		}
+		total.Monto = suma
+		totales[k] = total
+	}
+	for k, total := range totales {
+		if total.Monto.EsCero() {
+			delete(totales, k)
+		}
+	}
+	return totales
+}
+
+// ajustes regresa los asientos que llevan lo registrado para una transaccion,
+// registrados, a lo que le corresponde, esperados. Si no coinciden se cancela
+// todo lo registrado y se vuelve a registrar lo esperado.
+func ajustes(registrados []Asiento, esperados []Asiento, ahora time.Time) []Asiento {
+	actual, objetivo := neto(registrados), neto(esperados)
+	if iguales(actual, objetivo) {
+		return nil
+	}
+
+	claves := make([]clave, 0, len(actual))
+	for k := range actual {
+		claves = append(claves, k)
+	}
+	sort.Slice(claves, func(i, j int) bool {
+		a, b := claves[i], claves[j]
+		if a.fecha != b.fecha {
+			return a.fecha < b.fecha
+		}
+		if a.cuenta != b.cuenta {
+			return a.cuenta < b.cuenta
+		}
+		return a.moneda < b.moneda
+	})
+
+	var nuevos []Asiento
+	for _, k := range claves {
+		contra := actual[k]
+		contra.Monto = contra.Monto.Negar()
+		contra.Concepto = CONCEPTO_AJUSTE
+		contra.Registrado = ahora
+		nuevos = append(nuevos, contra)
+	}
+	for _, esperado := range esperados {
+		esperado.Registrado = ahora
+		nuevos = append(nuevos, esperado)
+	}
+	return nuevos
+}
+
+func iguales(a, b map[clave]Asiento) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, asiento := range a {
+		otro, ok := b[k]
+		if !ok || !asiento.Monto.Igual(otro.Monto) {
+			return false
+		}
+	}
+	return true
+}
+
+func deTransaccion(asientos []Asiento, id int) []Asiento {
+	var resultado []Asiento
+	for _, asiento := range asientos {
+		if asiento.TransaccionId == id {
+			resultado = append(resultado, asiento)
+		}
+	}
+	return resultado
+}
+
+func (s *service) Actualizada(transaccion transacciones.Transaccion) error {
+	return s.repository.Agregar(func(asientos []Asiento) []Asiento {
+		return ajustes(deTransaccion(asientos, transaccion.Id), partidas(transaccion), time.Now())
+	})
+}
+
+func (s *service) Eliminada(id int) error {
+	return s.repository.Agregar(func(asientos []Asiento) []Asiento {
+		return ajustes(deTransaccion(asientos, id), nil, time.Now())
+	})
+}
+
+// Sincronizar ajusta el libro para que refleje exactamente la lista de
+// transacciones: registra las que falten, corrige las que cambiaron y cancela
+// las que ya no existen.
+func (s *service) Sincronizar(lista []transacciones.Transaccion) error {
+	return s.repository.Agregar(func(asientos []Asiento) []Asiento {
+		ahora := time.Now()
+		porTransaccion := map[int][]Asiento{}
+		var ids []int
+		for _, asiento := range asientos {
+			if _, ok := porTransaccion[asiento.TransaccionId]; !ok {
+				ids = append(ids, asiento.TransaccionId)
+			}
+			porTransaccion[asiento.TransaccionId] = append(porTransaccion[asiento.TransaccionId], asiento)
+		}
+
+		var nuevos []Asiento
+		vigentes := map[int]bool{}
+		for _, transaccion := range lista {
+			vigentes[transaccion.Id] = true
+			nuevos = append(nuevos, ajustes(porTransaccion[transaccion.Id], partidas(transaccion), ahora)...)
+		}
+		for _, id := range ids {
+			if !vigentes[id] {
+				nuevos = append(nuevos, ajustes(porTransaccion[id], nil, ahora)...)
+			}
+		}
+		return nuevos
+	})
+}
+
+func (s *service) Saldos(cuenta string) ([]Saldo, error) {
+	movimientos, err := s.Movimientos(cuenta, "")
+	if err != nil {
+		return nil, err
+	}
+
+	porMoneda := map[string]dinero.Monto{}
+	for _, movimiento := range movimientos {
+		porMoneda[movimiento.Moneda] = movimiento.Saldo
+	}
+	saldos := make([]Saldo, 0, len(porMoneda))
+	for moneda, saldo := range porMoneda {
+		saldos = append(saldos, Saldo{Moneda: moneda, Saldo: saldo})
+	}
+	sort.Slice(saldos, func(i, j int) bool { return saldos[i].Moneda < saldos[j].Moneda })
+	return saldos, nil
+}
+
+// Movimientos regresa los asientos de la cuenta ordenados por fecha, con el
+// saldo acumulado en cada moneda. Una moneda vacia regresa todas.
+func (s *service) Movimientos(cuenta string, moneda string) ([]Movimiento, error) {
+	asientos, err := s.repository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var movimientos []Movimiento
+	for _, asiento := range asientos {
+		if asiento.Cuenta == cuenta && (moneda == "" || asiento.Moneda == moneda) {
+			movimientos = append(movimientos, Movimiento{Asiento: asiento})
+		}
+	}
+	if len(movimientos) == 0 {
+		return nil, ErrCuentaNoEncontrada
+	}
+
+	sort.SliceStable(movimientos, func(i, j int) bool {
+		return movimientos[i].Fecha.Before(movimientos[j].Fecha)
+	})
+
+	saldos := map[string]dinero.Monto{}
+	for index, movimiento := range movimientos {
+		saldo, ok := saldos[movimiento.Moneda]
+		if !ok {
+			saldo = dinero.Nuevo(0, movimiento.Monto.Escala())
+		}
+		if saldo, err = saldo.Sumar(movimiento.Monto); err != nil {
+			return nil, err
+		}
+		saldos[movimiento.Moneda] = saldo
+		movimientos[index].Saldo = saldo
+	}
+	return movimientos, nil
+}
@@ -0,0 +1,120 @@
+package libro
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func nuevoService(t *testing.T) Service {
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "libro.json")}
+	return NewService(NewRepository(db))
+}
+
+func transaccion(id int, emisor, receptor, moneda, monto string, dia int) transacciones.Transaccion {
+	return transacciones.Transaccion{
+		Id:               id,
+		Moneda:           moneda,
+		Monto:            dinero.DebeParsear(monto),
+		Emisor:           emisor,
+		Receptor:         receptor,
+		FechaTransaccion: time.Date(2022, 4, dia, 0, 0, 0, 0, time.UTC),
+		Estado:           transacciones.ESTADO_PENDIENTE,
+	}
+}
+
+func saldos(t *testing.T, service Service, cuenta string) map[string]string {
+	lista, err := service.Saldos(cuenta)
+	assert.Nil(t, err)
+	resultado := map[string]string{}
+	for _, saldo := range lista {
+		resultado[saldo.Moneda] = saldo.Saldo.String()
+	}
+	return resultado
+}
+
+func TestServiceActualizada(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+	primera := transaccion(1, "Ana", "Luis", "MXN", "100.00", 1)
+	segunda := transaccion(2, "Luis", "Ana", "MXN", "30.00", 2)
+	tercera := transaccion(3, "Ana", "Luis", "USD", "5.00", 3)
+
+	// Act
+	assert.Nil(t, service.Actualizada(primera))
+	assert.Nil(t, service.Actualizada(segunda))
+	assert.Nil(t, service.Actualizada(tercera))
+	assert.Nil(t, service.Actualizada(segunda))
+	movimientos, err := service.Movimientos("Ana", "MXN")
+
+	// Assert
+	assert.Equal(t, map[string]string{"MXN": "-70.00", "USD": "-5.00"}, saldos(t, service, "Ana"))
+	assert.Equal(t, map[string]string{"MXN": "70.00", "USD": "5.00"}, saldos(t, service, "Luis"))
+	assert.Nil(t, err)
+	assert.Len(t, movimientos, 2)
+	assert.Equal(t, "-100.00", movimientos[0].Saldo.String())
+	assert.Equal(t, "-70.00", movimientos[1].Saldo.String())
+}
+
+func TestServiceCambiosYBajas(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+	original := transaccion(1, "Ana", "Luis", "MXN", "100.00", 1)
+	_ = service.Actualizada(original)
+
+	// Act
+	cambiada := original
+	cambiada.Monto = dinero.DebeParsear("80.00")
+	cambiada.Receptor = "Eva"
+	errCambio := service.Actualizada(cambiada)
+	saldosCambio := [3]map[string]string{saldos(t, service, "Ana"), saldos(t, service, "Luis"), saldos(t, service, "Eva")}
+
+	rechazada := cambiada
+	rechazada.Estado = transacciones.ESTADO_RECHAZADA
+	errRechazo := service.Actualizada(rechazada)
+	saldoRechazo := saldos(t, service, "Ana")
+
+	errAlta := service.Actualizada(cambiada)
+	errBaja := service.Eliminada(cambiada.Id)
+	movimientos, _ := service.Movimientos("Ana", "")
+
+	// Assert
+	assert.Nil(t, errCambio)
+	assert.Equal(t, [3]map[string]string{{"MXN": "-80.00"}, {"MXN": "0.00"}, {"MXN": "80.00"}}, saldosCambio)
+	assert.Nil(t, errRechazo)
+	assert.Equal(t, map[string]string{"MXN": "0.00"}, saldoRechazo)
+	assert.Nil(t, errAlta)
+	assert.Nil(t, errBaja)
+	assert.Equal(t, "0.00", movimientos[len(movimientos)-1].Saldo.String())
+	assert.Equal(t, CONCEPTO_AJUSTE, movimientos[len(movimientos)-1].Concepto)
+}
+
+func TestServiceSincronizar(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+	_ = service.Actualizada(transaccion(1, "Ana", "Luis", "MXN", "100.00", 1))
+	_ = service.Actualizada(transaccion(2, "Ana", "Luis", "MXN", "20.00", 2))
+	lista := []transacciones.Transaccion{
+		transaccion(1, "Ana", "Luis", "MXN", "100.00", 1),
+		transaccion(3, "Luis", "Ana", "MXN", "50.00", 3),
+	}
+
+	// Act
+	err := service.Sincronizar(lista)
+	antes, _ := service.Movimientos("Ana", "")
+	errRepetido := service.Sincronizar(lista)
+	despues, _ := service.Movimientos("Ana", "")
+	_, errCuenta := service.Saldos("Eva")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"MXN": "-50.00"}, saldos(t, service, "Ana"))
+	assert.Nil(t, errRepetido)
+	assert.Equal(t, antes, despues)
+	assert.ErrorIs(t, errCuenta, ErrCuentaNoEncontrada)
+}
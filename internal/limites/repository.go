@@ -0,0 +1,41 @@
+package limites
+
+import (
+	"errors"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+)
+
+// Limite acota las transacciones que un emisor puede emitir en una moneda. Un
+// EmisorId igual a cero aplica a los emisores sin un limite propio en esa
+// moneda. Los campos vacios no se revisan.
+type Limite struct {
+	EmisorId      int           `json:"emisor_id"`
+	Moneda        string        `json:"moneda"`
+	MontoMaximo   *dinero.Monto `json:"monto_maximo,omitempty" swaggertype:"string" example:"50000.00"`
+	TotalDiario   *dinero.Monto `json:"total_diario,omitempty" swaggertype:"string" example:"100000.00"`
+	MaximoPorHora int           `json:"maximo_por_hora,omitempty"`
+}
+
+type Repository interface {
+	GetAll() ([]Limite, error)
+}
+
+type repository struct {
+	db store.Store
+}
+
+// NewRepository crea un Repository de solo lectura sobre un store de archivo.
+// Si el archivo no existe no hay limites.
+func NewRepository(db store.Store) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]Limite, error) {
+	var limites []Limite
+	if err := r.db.Read(&limites); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		return nil, err
+	}
+	return limites, nil
+}
@@ -0,0 +1,182 @@
+package limites
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+const (
+	LIMITE_MONTO_MAXIMO    = "monto_maximo"
+	LIMITE_TOTAL_DIARIO    = "total_diario"
+	LIMITE_MAXIMO_POR_HORA = "maximo_por_hora"
+)
+
+var ErrLimiteExcedido = errors.New("la transaccion excede un limite del emisor")
+
+// Excedido describe el limite que rebasaria la transaccion. Acumulado es lo
+// que el emisor ya uso en la ventana y Disponible lo que aun puede usar; en
+// maximo_por_hora se cuentan transacciones en lugar de montos.
+type Excedido struct {
+	Limite     string     `json:"limite"`
+	EmisorId   int        `json:"emisor_id"`
+	Moneda     string     `json:"moneda"`
+	Permitido  string     `json:"permitido"`
+	Acumulado  string     `json:"acumulado"`
+	Solicitado string     `json:"solicitado"`
+	Disponible string     `json:"disponible"`
+	Desde      *time.Time `json:"desde,omitempty"`
+	Hasta      *time.Time `json:"hasta,omitempty"`
+}
+
+func (e *Excedido) Error() string {
+	return fmt.Sprintf("%s: %s de %s %s, disponible %s", ErrLimiteExcedido, e.Limite, e.Permitido, e.Moneda, e.Disponible)
+}
+
+func (e *Excedido) Is(objetivo error) bool {
+	return objetivo == ErrLimiteExcedido
+}
+
+// Movimiento es una transaccion previa del emisor que cuenta para sus limites.
+type Movimiento struct {
+	Monto dinero.Monto
+	Fecha time.Time
+}
+
+// Previos regresa los movimientos del emisor en la moneda entre desde
+// (inclusive) y hasta (exclusive).
+type Previos func(desde, hasta time.Time) ([]Movimiento, error)
+
+type Service interface {
+	GetAll() ([]Limite, error)
+	Verificar(emisorId int, moneda string, monto dinero.Monto, fecha time.Time, previos Previos) error
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{repository: r}
+}
+
+func (s *service) GetAll() ([]Limite, error) {
+	return s.repository.GetAll()
+}
+
+// limiteDe regresa el limite propio del emisor en la moneda o, si no tiene, el
+// general de la moneda.
+func (s *service) limiteDe(emisorId int, moneda string) (Limite, bool, error) {
+	limites, err := s.repository.GetAll()
+	if err != nil {
+		return Limite{}, false, err
+	}
+	var general *Limite
+	for index, limite := range limites {
+		if !strings.EqualFold(limite.Moneda, moneda) {
+			continue
+		}
+		if limite.EmisorId == emisorId {
+			return limite, true, nil
+		}
+		if limite.EmisorId == 0 {
+			general = &limites[index]
+		}
+	}
+	if general == nil {
+		return Limite{}, false, nil
+	}
+	return *general, true, nil
+}
+
+// Verificar revisa que una transaccion de monto en fecha respete el limite
+// del emisor en la moneda. El total diario se cuenta en el dia calendario y
+// el maximo por hora en la hora de reloj de fecha, en su zona horaria.
+func (s *service) Verificar(emisorId int, moneda string, monto dinero.Monto, fecha time.Time, previos Previos) error {
+	limite, ok, err := s.limiteDe(emisorId, moneda)
+	if err != nil || !ok {
+		return err
+	}
+
+	excedido := func(nombre string, permitido, acumulado, disponible fmt.Stringer, solicitado string, desde, hasta *time.Time) error {
+		return &Excedido{
+			Limite:     nombre,
+			EmisorId:   emisorId,
+			Moneda:     moneda,
+			Permitido:  permitido.String(),
+			Acumulado:  acumulado.String(),
+			Solicitado: solicitado,
+			Disponible: disponible.String(),
+			Desde:      desde,
+			Hasta:      hasta,
+		}
+	}
+
+	if limite.MontoMaximo != nil && monto.Cmp(*limite.MontoMaximo) > 0 {
+		cero := dinero.Nuevo(0, monto.Escala())
+		return excedido(LIMITE_MONTO_MAXIMO, limite.MontoMaximo, cero, limite.MontoMaximo, monto.String(), nil, nil)
+	}
+	if limite.TotalDiario == nil && limite.MaximoPorHora == 0 {
+		return nil
+	}
+
+	inicioDia := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, fecha.Location())
+	finDia := inicioDia.AddDate(0, 0, 1)
+	movimientos, err := previos(inicioDia, finDia)
+	if err != nil {
+		return err
+	}
+
+	if limite.TotalDiario != nil {
+		acumulado := dinero.Nuevo(0, monto.Escala())
+		for _, movimiento := range movimientos {
+			if acumulado, err = acumulado.Sumar(movimiento.Monto); err != nil {
+				return err
+			}
+		}
+		total, err := acumulado.Sumar(monto)
+		if err != nil {
+			return err
+		}
+		if total.Cmp(*limite.TotalDiario) > 0 {
+			disponible, err := limite.TotalDiario.Restar(acumulado)
+			if err != nil {
+				return err
+			}
+			if disponible.EsNegativo() {
+				disponible = dinero.Nuevo(0, disponible.Escala())
+			}
+			return excedido(LIMITE_TOTAL_DIARIO, limite.TotalDiario, acumulado, disponible, monto.String(), &inicioDia, &finDia)
+		}
+	}
+
+	if limite.MaximoPorHora > 0 {
+		inicioHora := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), fecha.Hour(), 0, 0, 0, fecha.Location())
+		finHora := inicioHora.Add(time.Hour)
+		var enHora int
+		for _, movimiento := range movimientos {
+			if !movimiento.Fecha.Before(inicioHora) && movimiento.Fecha.Before(finHora) {
+				enHora++
+			}
+		}
+		if enHora+1 > limite.MaximoPorHora {
+			disponible := limite.MaximoPorHora - enHora
+			if disponible < 0 {
+				disponible = 0
+			}
+			return excedido(LIMITE_MAXIMO_POR_HORA, conteo(limite.MaximoPorHora), conteo(enHora), conteo(disponible), "1", &inicioHora, &finHora)
+		}
+	}
+	return nil
+}
+
+// conteo expresa un numero de transacciones igual que los montos.
+type conteo int
+
+func (c conteo) String() string {
+	return strconv.Itoa(int(c))
+}
@@ -0,0 +1,95 @@
+package limites
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func monto(texto string) *dinero.Monto {
+	valor := dinero.DebeParsear(texto)
+	return &valor
+}
+
+func nuevoService(t *testing.T, limites []Limite) Service {
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "limites.json")}
+	if err := db.Write(limites); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(NewRepository(db))
+}
+
+// sinPrevios simula un emisor sin transacciones previas.
+func sinPrevios(desde, hasta time.Time) ([]Movimiento, error) {
+	return nil, nil
+}
+
+func TestServiceVerificarMontoMaximo(t *testing.T) {
+	// Arrange
+	service := nuevoService(t, []Limite{
+		{EmisorId: 0, Moneda: "MXN", MontoMaximo: monto("1000.00")},
+		{EmisorId: 1, Moneda: "MXN", MontoMaximo: monto("5000.00")},
+	})
+	fecha := time.Date(2022, 4, 21, 10, 0, 0, 0, time.UTC)
+
+	// Act
+	errPropio := service.Verificar(1, "MXN", dinero.DebeParsear("5000.00"), fecha, sinPrevios)
+	errGeneral := service.Verificar(2, "MXN", dinero.DebeParsear("1000.01"), fecha, sinPrevios)
+	errOtraMoneda := service.Verificar(2, "USD", dinero.DebeParsear("1000000.00"), fecha, sinPrevios)
+
+	// Assert
+	assert.Nil(t, errPropio)
+	assert.ErrorIs(t, errGeneral, ErrLimiteExcedido)
+	assert.Equal(t, &Excedido{
+		Limite:     LIMITE_MONTO_MAXIMO,
+		EmisorId:   2,
+		Moneda:     "MXN",
+		Permitido:  "1000.00",
+		Acumulado:  "0.00",
+		Solicitado: "1000.01",
+		Disponible: "1000.00",
+	}, errGeneral)
+	assert.Nil(t, errOtraMoneda)
+}
+
+func TestServiceVerificarVentanas(t *testing.T) {
+	// Arrange
+	service := nuevoService(t, []Limite{{EmisorId: 1, Moneda: "MXN", TotalDiario: monto("1000.00"), MaximoPorHora: 2}})
+	zona := time.FixedZone("CST", -6*60*60)
+	fecha := time.Date(2022, 4, 21, 10, 30, 0, 0, zona)
+	var ventana [2]time.Time
+	previos := func(desde, hasta time.Time) ([]Movimiento, error) {
+		ventana = [2]time.Time{desde, hasta}
+		return []Movimiento{
+			{Monto: dinero.DebeParsear("300.00"), Fecha: time.Date(2022, 4, 21, 8, 0, 0, 0, zona)},
+			{Monto: dinero.DebeParsear("400.00"), Fecha: time.Date(2022, 4, 21, 10, 5, 0, 0, zona)},
+		}, nil
+	}
+
+	// Act
+	errDisponible := service.Verificar(1, "MXN", dinero.DebeParsear("300.00"), fecha, previos)
+	errDiario := service.Verificar(1, "MXN", dinero.DebeParsear("300.01"), fecha, previos)
+	errHora := service.Verificar(1, "MXN", dinero.DebeParsear("1.00"), time.Date(2022, 4, 21, 8, 59, 0, 0, zona), func(desde, hasta time.Time) ([]Movimiento, error) {
+		lista, _ := previos(desde, hasta)
+		return append(lista, Movimiento{Monto: dinero.DebeParsear("1.00"), Fecha: time.Date(2022, 4, 21, 8, 10, 0, 0, zona)}), nil
+	})
+
+	// Assert
+	assert.Nil(t, errDisponible)
+	assert.Equal(t, [2]time.Time{time.Date(2022, 4, 21, 0, 0, 0, 0, zona), time.Date(2022, 4, 22, 0, 0, 0, 0, zona)}, ventana)
+	excedido, ok := errDiario.(*Excedido)
+	assert.True(t, ok)
+	assert.Equal(t, LIMITE_TOTAL_DIARIO, excedido.Limite)
+	assert.Equal(t, "700.00", excedido.Acumulado)
+	assert.Equal(t, "300.00", excedido.Disponible)
+	excedido, ok = errHora.(*Excedido)
+	assert.True(t, ok)
+	assert.Equal(t, LIMITE_MAXIMO_POR_HORA, excedido.Limite)
+	assert.Equal(t, "2", excedido.Acumulado)
+	assert.Equal(t, "0", excedido.Disponible)
+	assert.Equal(t, time.Date(2022, 4, 21, 8, 0, 0, 0, zona), *excedido.Desde)
+}
@@ -0,0 +1,180 @@
+package monedas
+
+// catalogo contiene las monedas de la norma ISO 4217. Las que fueron
+// reemplazadas se conservan como inactivas para poder leer transacciones
+// anteriores, pero no se aceptan en transacciones nuevas.
+var catalogo = []Moneda{
+	{Codigo: "AED", CodigoNumerico: "784", Nombre: "Dirham de los Emiratos Arabes Unidos", Decimales: 2, Activa: true},
+	{Codigo: "AFN", CodigoNumerico: "971", Nombre: "Afgani afgano", Decimales: 2, Activa: true},
+	{Codigo: "ALL", CodigoNumerico: "008", Nombre: "Lek albanes", Decimales: 2, Activa: true},
+	{Codigo: "AMD", CodigoNumerico: "051", Nombre: "Dram armenio", Decimales: 2, Activa: true},
+	{Codigo: "ANG", CodigoNumerico: "532", Nombre: "Florin antillano neerlandes", Decimales: 2, Activa: false},
+	{Codigo: "AOA", CodigoNumerico: "973", Nombre: "Kwanza angoleno", Decimales: 2, Activa: true},
+	{Codigo: "ARS", CodigoNumerico: "032", Nombre: "Peso argentino", Decimales: 2, Activa: true},
+	{Codigo: "AUD", CodigoNumerico: "036", Nombre: "Dolar australiano", Decimales: 2, Activa: true},
+	{Codigo: "AWG", CodigoNumerico: "533", Nombre: "Florin arubeno", Decimales: 2, Activa: true},
+	{Codigo: "AZN", CodigoNumerico: "944", Nombre: "Manat azerbaiyano", Decimales: 2, Activa: true},
+	{Codigo: "BAM", CodigoNumerico: "977", Nombre: "Marco convertible de Bosnia y Herzegovina", Decimales: 2, Activa: true},
+	{Codigo: "BBD", CodigoNumerico: "052", Nombre: "Dolar de Barbados", Decimales: 2, Activa: true},
+	{Codigo: "BDT", CodigoNumerico: "050", Nombre: "Taka de Banglades", Decimales: 2, Activa: true},
+	{Codigo: "BGN", CodigoNumerico: "975", Nombre: "Lev bulgaro", Decimales: 2, Activa: true},
+	{Codigo: "BHD", CodigoNumerico: "048", Nombre: "Dinar bareini", Decimales: 3, Activa: true},
+	{Codigo: "BIF", CodigoNumerico: "108", Nombre: "Franco de Burundi", Decimales: 0, Activa: true},
+	{Codigo: "BMD", CodigoNumerico: "060", Nombre: "Dolar de Bermudas", Decimales: 2, Activa: true},
+	{Codigo: "BND", CodigoNumerico: "096", Nombre: "Dolar de Brunei", Decimales: 2, Activa: true},
+	{Codigo: "BOB", CodigoNumerico: "068", Nombre: "Boliviano", Decimales: 2, Activa: true},
+	{Codigo: "BOV", CodigoNumerico: "984", Nombre: "Mvdol boliviano", Decimales: 2, Activa: true},
+	{Codigo: "BRL", CodigoNumerico: "986", Nombre: "Real brasileno", Decimales: 2, Activa: true},
+	{Codigo: "BSD", CodigoNumerico: "044", Nombre: "Dolar bahameno", Decimales: 2, Activa: true},
+	{Codigo: "BTN", CodigoNumerico: "064", Nombre: "Ngultrum butanes", Decimales: 2, Activa: true},
+	{Codigo: "BWP", CodigoNumerico: "072", Nombre: "Pula de Botsuana", Decimales: 2, Activa: true},
+	{Codigo: "BYN", CodigoNumerico: "933", Nombre: "Rublo bielorruso", Decimales: 2, Activa: true},
+	{Codigo: "BZD", CodigoNumerico: "084", Nombre: "Dolar beliceno", Decimales: 2, Activa: true},
+	{Codigo: "CAD", CodigoNumerico: "124", Nombre: "Dolar canadiense", Decimales: 2, Activa: true},
+	{Codigo: "CDF", CodigoNumerico: "976", Nombre: "Franco congoleno", Decimales: 2, Activa: true},
+	{Codigo: "CHE", CodigoNumerico: "947", Nombre: "Euro WIR", Decimales: 2, Activa: true},
+	{Codigo: "CHF", CodigoNumerico: "756", Nombre: "Franco suizo", Decimales: 2, Activa: true},
+	{Codigo: "CHW", CodigoNumerico: "948", Nombre: "Franco WIR", Decimales: 2, Activa: true},
+	{Codigo: "CLF", CodigoNumerico: "990", Nombre: "Unidad de fomento chilena", Decimales: 4, Activa: true},
+	{Codigo: "CLP", CodigoNumerico: "152", Nombre: "Peso chileno", Decimales: 0, Activa: true},
+	{Codigo: "CNY", CodigoNumerico: "156", Nombre: "Yuan chino", Decimales: 2, Activa: true},
+	{Codigo: "COP", CodigoNumerico: "170", Nombre: "Peso colombiano", Decimales: 2, Activa: true},
+	{Codigo: "COU", CodigoNumerico: "970", Nombre: "Unidad de valor real colombiana", Decimales: 2, Activa: true},
+	{Codigo: "CRC", CodigoNumerico: "188", Nombre: "Colon costarricense", Decimales: 2, Activa: true},
+	{Codigo: "CUC", CodigoNumerico: "931", Nombre: "Peso cubano convertible", Decimales: 2, Activa: false},
+	{Codigo: "CUP", CodigoNumerico: "192", Nombre: "Peso cubano", Decimales: 2, Activa: true},
+	{Codigo: "CVE", CodigoNumerico: "132", Nombre: "Escudo caboverdiano", Decimales: 2, Activa: true},
+	{Codigo: "CZK", CodigoNumerico: "203", Nombre: "Corona checa", Decimales: 2, Activa: true},
+	{Codigo: "DJF", CodigoNumerico: "262", Nombre: "Franco yibutiano", Decimales: 0, Activa: true},
+	{Codigo: "DKK", CodigoNumerico: "208", Nombre: "Corona danesa", Decimales: 2, Activa: true},
+	{Codigo: "DOP", CodigoNumerico: "214", Nombre: "Peso dominicano", Decimales: 2, Activa: true},
+	{Codigo: "DZD", CodigoNumerico: "012", Nombre: "Dinar argelino", Decimales: 2, Activa: true},
+	{Codigo: "EGP", CodigoNumerico: "818", Nombre: "Libra egipcia", Decimales: 2, Activa: true},
+	{Codigo: "ERN", CodigoNumerico: "232", Nombre: "Nakfa eritreo", Decimales: 2, Activa: true},
+	{Codigo: "ETB", CodigoNumerico: "230", Nombre: "Birr etiope", Decimales: 2, Activa: true},
+	{Codigo: "EUR", CodigoNumerico: "978", Nombre: "Euro", Decimales: 2, Activa: true},
+	{Codigo: "FJD", CodigoNumerico: "242", Nombre: "Dolar fiyiano", Decimales: 2, Activa: true},
+	{Codigo: "FKP", CodigoNumerico: "238", Nombre: "Libra malvinense", Decimales: 2, Activa: true},
+	{Codigo: "GBP", CodigoNumerico: "826", Nombre: "Libra esterlina", Decimales: 2, Activa: true},
+	{Codigo: "GEL", CodigoNumerico: "981", Nombre: "Lari georgiano", Decimales: 2, Activa: true},
+	{Codigo: "GHS", CodigoNumerico: "936", Nombre: "Cedi ghanes", Decimales: 2, Activa: true},
+	{Codigo: "GIP", CodigoNumerico: "292", Nombre: "Libra de Gibraltar", Decimales: 2, Activa: true},
+	{Codigo: "GMD", CodigoNumerico: "270", Nombre: "Dalasi gambiano", Decimales: 2, Activa: true},
+	{Codigo: "GNF", CodigoNumerico: "324", Nombre: "Franco guineano", Decimales: 0, Activa: true},
+	{Codigo: "GTQ", CodigoNumerico: "320", Nombre: "Quetzal guatemalteco", Decimales: 2, Activa: true},
+	{Codigo: "GYD", CodigoNumerico: "328", Nombre: "Dolar guyanes", Decimales: 2, Activa: true},
+	{Codigo: "HKD", CodigoNumerico: "344", Nombre: "Dolar de Hong Kong", Decimales: 2, Activa: true},
+	{Codigo: "HNL", CodigoNumerico: "340", Nombre: "Lempira hondureno", Decimales: 2, Activa: true},
+	{Codigo: "HRK", CodigoNumerico: "191", Nombre: "Kuna croata", Decimales: 2, Activa: false},
+	{Codigo: "HTG", CodigoNumerico: "332", Nombre: "Gourde haitiano", Decimales: 2, Activa: true},
+	{Codigo: "HUF", CodigoNumerico: "348", Nombre: "Forinto hungaro", Decimales: 2, Activa: true},
+	{Codigo: "IDR", CodigoNumerico: "360", Nombre: "Rupia indonesia", Decimales: 2, Activa: true},
+	{Codigo: "ILS", CodigoNumerico: "376", Nombre: "Nuevo sequel israeli", Decimales: 2, Activa: true},
+	{Codigo: "INR", CodigoNumerico: "356", Nombre: "Rupia india", Decimales: 2, Activa: true},
+	{Codigo: "IQD", CodigoNumerico: "368", Nombre: "Dinar iraqui", Decimales: 3, Activa: true},
+	{Codigo: "IRR", CodigoNumerico: "364", Nombre: "Rial irani", Decimales: 2, Activa: true},
+	{Codigo: "ISK", CodigoNumerico: "352", Nombre: "Corona islandesa", Decimales: 0, Activa: true},
+	{Codigo: "JMD", CodigoNumerico: "388", Nombre: "Dolar jamaiquino", Decimales: 2, Activa: true},
+	{Codigo: "JOD", CodigoNumerico: "400", Nombre: "Dinar jordano", Decimales: 3, Activa: true},
+	{Codigo: "JPY", CodigoNumerico: "392", Nombre: "Yen japones", Decimales: 0, Activa: true},
+	{Codigo: "KES", CodigoNumerico: "404", Nombre: "Chelin keniano", Decimales: 2, Activa: true},
+	{Codigo: "KGS", CodigoNumerico: "417", Nombre: "Som kirguis", Decimales: 2, Activa: true},
+	{Codigo: "KHR", CodigoNumerico: "116", Nombre: "Riel camboyano", Decimales: 2, Activa: true},
+	{Codigo: "KMF", CodigoNumerico: "174", Nombre: "Franco comorense", Decimales: 0, Activa: true},
+	{Codigo: "KPW", CodigoNumerico: "408", Nombre: "Won norcoreano", Decimales: 2, Activa: true},
+	{Codigo: "KRW", CodigoNumerico: "410", Nombre: "Won surcoreano", Decimales: 0, Activa: true},
+	{Codigo: "KWD", CodigoNumerico: "414", Nombre: "Dinar kuwaiti", Decimales: 3, Activa: true},
+	{Codigo: "KYD", CodigoNumerico: "136", Nombre: "Dolar de las Islas Caiman", Decimales: 2, Activa: true},
+	{Codigo: "KZT", CodigoNumerico: "398", Nombre: "Tenge kazajo", Decimales: 2, Activa: true},
+	{Codigo: "LAK", CodigoNumerico: "418", Nombre: "Kip laosiano", Decimales: 2, Activa: true},
+	{Codigo: "LBP", CodigoNumerico: "422", Nombre: "Libra libanesa", Decimales: 2, Activa: true},
+	{Codigo: "LKR", CodigoNumerico: "144", Nombre: "Rupia de Sri Lanka", Decimales: 2, Activa: true},
+	{Codigo: "LRD", CodigoNumerico: "430", Nombre: "Dolar liberiano", Decimales: 2, Activa: true},
+	{Codigo: "LSL", CodigoNumerico: "426", Nombre: "Loti lesothense", Decimales: 2, Activa: true},
+	{Codigo: "LYD", CodigoNumerico: "434", Nombre: "Dinar libio", Decimales: 3, Activa: true},
+	{Codigo: "MAD", CodigoNumerico: "504", Nombre: "Dirham marroqui", Decimales: 2, Activa: true},
+	{Codigo: "MDL", CodigoNumerico: "498", Nombre: "Leu moldavo", Decimales: 2, Activa: true},
+	{Codigo: "MGA", CodigoNumerico: "969", Nombre: "Ariary malgache", Decimales: 2, Activa: true},
+	{Codigo: "MKD", CodigoNumerico: "807", Nombre: "Denar macedonio", Decimales: 2, Activa: true},
+	{Codigo: "MMK", CodigoNumerico: "104", Nombre: "Kyat birmano", Decimales: 2, Activa: true},
+	{Codigo: "MNT", CodigoNumerico: "496", Nombre: "Tugrik mongol", Decimales: 2, Activa: true},
+	{Codigo: "MOP", CodigoNumerico: "446", Nombre: "Pataca de Macao", Decimales: 2, Activa: true},
+	{Codigo: "MRO", CodigoNumerico: "478", Nombre: "Uguiya mauritano anterior", Decimales: 2, Activa: false},
+	{Codigo: "MRU", CodigoNumerico: "929", Nombre: "Uguiya mauritano", Decimales: 2, Activa: true},
+	{Codigo: "MUR", CodigoNumerico: "480", Nombre: "Rupia mauriciana", Decimales: 2, Activa: true},
+	{Codigo: "MVR", CodigoNumerico: "462", Nombre: "Rufiyaa maldiva", Decimales: 2, Activa: true},
+	{Codigo: "MWK", CodigoNumerico: "454", Nombre: "Kwacha malaui", Decimales: 2, Activa: true},
+	{Codigo: "MXN", CodigoNumerico: "484", Nombre: "Peso mexicano", Decimales: 2, Activa: true},
+	{Codigo: "MXV", CodigoNumerico: "979", Nombre: "Unidad de inversion mexicana", Decimales: 2, Activa: true},
+	{Codigo: "MYR", CodigoNumerico: "458", Nombre: "Ringgit malayo", Decimales: 2, Activa: true},
+	{Codigo: "MZN", CodigoNumerico: "943", Nombre: "Metical mozambiqueno", Decimales: 2, Activa: true},
+	{Codigo: "NAD", CodigoNumerico: "516", Nombre: "Dolar namibio", Decimales: 2, Activa: true},
+	{Codigo: "NGN", CodigoNumerico: "566", Nombre: "Naira nigeriana", Decimales: 2, Activa: true},
+	{Codigo: "NIO", CodigoNumerico: "558", Nombre: "Cordoba nicaraguense", Decimales: 2, Activa: true},
+	{Codigo: "NOK", CodigoNumerico: "578", Nombre: "Corona noruega", Decimales: 2, Activa: true},
+	{Codigo: "NPR", CodigoNumerico: "524", Nombre: "Rupia nepali", Decimales: 2, Activa: true},
+	{Codigo: "NZD", CodigoNumerico: "554", Nombre: "Dolar neozelandes", Decimales: 2, Activa: true},
+	{Codigo: "OMR", CodigoNumerico: "512", Nombre: "Rial omani", Decimales: 3, Activa: true},
+	{Codigo: "PAB", CodigoNumerico: "590", Nombre: "Balboa panameno", Decimales: 2, Activa: true},
+	{Codigo: "PEN", CodigoNumerico: "604", Nombre: "Sol peruano", Decimales: 2, Activa: true},
+	{Codigo: "PGK", CodigoNumerico: "598", Nombre: "Kina de Papua Nueva Guinea", Decimales: 2, Activa: true},
+	{Codigo: "PHP", CodigoNumerico: "608", Nombre: "Peso filipino", Decimales: 2, Activa: true},
+	{Codigo: "PKR", CodigoNumerico: "586", Nombre: "Rupia pakistani", Decimales: 2, Activa: true},
+	{Codigo: "PLN", CodigoNumerico: "985", Nombre: "Zloty polaco", Decimales: 2, Activa: true},
+	{Codigo: "PYG", CodigoNumerico: "600", Nombre: "Guarani paraguayo", Decimales: 0, Activa: true},
+	{Codigo: "QAR", CodigoNumerico: "634", Nombre: "Riyal catari", Decimales: 2, Activa: true},
+	{Codigo: "RON", CodigoNumerico: "946", Nombre: "Leu rumano", Decimales: 2, Activa: true},
+	{Codigo: "RSD", CodigoNumerico: "941", Nombre: "Dinar serbio", Decimales: 2, Activa: true},
+	{Codigo: "RUB", CodigoNumerico: "643", Nombre: "Rublo ruso", Decimales: 2, Activa: true},
+	{Codigo: "RWF", CodigoNumerico: "646", Nombre: "Franco ruandes", Decimales: 0, Activa: true},
+	{Codigo: "SAR", CodigoNumerico: "682", Nombre: "Riyal saudi", Decimales: 2, Activa: true},
+	{Codigo: "SBD", CodigoNumerico: "090", Nombre: "Dolar de las Islas Salomon", Decimales: 2, Activa: true},
+	{Codigo: "SCR", CodigoNumerico: "690", Nombre: "Rupia de Seychelles", Decimales: 2, Activa: true},
+	{Codigo: "SDG", CodigoNumerico: "938", Nombre: "Libra sudanesa", Decimales: 2, Activa: true},
+	{Codigo: "SEK", CodigoNumerico: "752", Nombre: "Corona sueca", Decimales: 2, Activa: true},
+	{Codigo: "SGD", CodigoNumerico: "702", Nombre: "Dolar de Singapur", Decimales: 2, Activa: true},
+	{Codigo: "SHP", CodigoNumerico: "654", Nombre: "Libra de Santa Elena", Decimales: 2, Activa: true},
+	{Codigo: "SLE", CodigoNumerico: "925", Nombre: "Leone de Sierra Leona", Decimales: 2, Activa: true},
+	{Codigo: "SLL", CodigoNumerico: "694", Nombre: "Leone de Sierra Leona anterior", Decimales: 2, Activa: false},
+	{Codigo: "SOS", CodigoNumerico: "706", Nombre: "Chelin somali", Decimales: 2, Activa: true},
+	{Codigo: "SRD", CodigoNumerico: "968", Nombre: "Dolar surinames", Decimales: 2, Activa: true},
+	{Codigo: "SSP", CodigoNumerico: "728", Nombre: "Libra sursudanesa", Decimales: 2, Activa: true},
+	{Codigo: "STD", CodigoNumerico: "678", Nombre: "Dobra santotomense anterior", Decimales: 2, Activa: false},
+	{Codigo: "STN", CodigoNumerico: "930", Nombre: "Dobra santotomense", Decimales: 2, Activa: true},
+	{Codigo: "SVC", CodigoNumerico: "222", Nombre: "Colon salvadoreno", Decimales: 2, Activa: true},
+	{Codigo: "SYP", CodigoNumerico: "760", Nombre: "Libra siria", Decimales: 2, Activa: true},
+	{Codigo: "SZL", CodigoNumerico: "748", Nombre: "Lilangeni suazi", Decimales: 2, Activa: true},
+	{Codigo: "THB", CodigoNumerico: "764", Nombre: "Baht tailandes", Decimales: 2, Activa: true},
+	{Codigo: "TJS", CodigoNumerico: "972", Nombre: "Somoni tayiko", Decimales: 2, Activa: true},
+	{Codigo: "TMT", CodigoNumerico: "934", Nombre: "Manat turkmeno", Decimales: 2, Activa: true},
+	{Codigo: "TND", CodigoNumerico: "788", Nombre: "Dinar tunecino", Decimales: 3, Activa: true},
+	{Codigo: "TOP", CodigoNumerico: "776", Nombre: "Paanga tongano", Decimales: 2, Activa: true},
+	{Codigo: "TRY", CodigoNumerico: "949", Nombre: "Lira turca", Decimales: 2, Activa: true},
+	{Codigo: "TTD", CodigoNumerico: "780", Nombre: "Dolar de Trinidad y Tobago", Decimales: 2, Activa: true},
+	{Codigo: "TWD", CodigoNumerico: "901", Nombre: "Nuevo dolar taiwanes", Decimales: 2, Activa: true},
+	{Codigo: "TZS", CodigoNumerico: "834", Nombre: "Chelin tanzano", Decimales: 2, Activa: true},
+	{Codigo: "UAH", CodigoNumerico: "980", Nombre: "Grivna ucraniana", Decimales: 2, Activa: true},
+	{Codigo: "UGX", CodigoNumerico: "800", Nombre: "Chelin ugandes", Decimales: 0, Activa: true},
+	{Codigo: "USD", CodigoNumerico: "840", Nombre: "Dolar estadounidense", Decimales: 2, Activa: true},
+	{Codigo: "USN", CodigoNumerico: "997", Nombre: "Dolar estadounidense del dia siguiente", Decimales: 2, Activa: true},
+	{Codigo: "UYI", CodigoNumerico: "940", Nombre: "Peso uruguayo en unidades indexadas", Decimales: 0, Activa: true},
+	{Codigo: "UYU", CodigoNumerico: "858", Nombre: "Peso uruguayo", Decimales: 2, Activa: true},
+	{Codigo: "UYW", CodigoNumerico: "927", Nombre: "Unidad previsional uruguaya", Decimales: 4, Activa: true},
+	{Codigo: "UZS", CodigoNumerico: "860", Nombre: "Som uzbeko", Decimales: 2, Activa: true},
+	{Codigo: "VED", CodigoNumerico: "926", Nombre: "Bolivar digital venezolano", Decimales: 2, Activa: true},
+	{Codigo: "VEF", CodigoNumerico: "937", Nombre: "Bolivar fuerte venezolano", Decimales: 2, Activa: false},
+	{Codigo: "VES", CodigoNumerico: "928", Nombre: "Bolivar soberano venezolano", Decimales: 2, Activa: true},
+	{Codigo: "VND", CodigoNumerico: "704", Nombre: "Dong vietnamita", Decimales: 0, Activa: true},
+	{Codigo: "VUV", CodigoNumerico: "548", Nombre: "Vatu de Vanuatu", Decimales: 0, Activa: true},
+	{Codigo: "WST", CodigoNumerico: "882", Nombre: "Tala samoano", Decimales: 2, Activa: true},
+	{Codigo: "XAF", CodigoNumerico: "950", Nombre: "Franco CFA de Africa Central", Decimales: 0, Activa: true},
+	{Codigo: "XCD", CodigoNumerico: "951", Nombre: "Dolar del Caribe Oriental", Decimales: 2, Activa: true},
+	{Codigo: "XCG", CodigoNumerico: "532", Nombre: "Florin del Caribe", Decimales: 2, Activa: true},
+	{Codigo: "XOF", CodigoNumerico: "952", Nombre: "Franco CFA de Africa Occidental", Decimales: 0, Activa: true},
+	{Codigo: "XPF", CodigoNumerico: "953", Nombre: "Franco CFP", Decimales: 0, Activa: true},
+	{Codigo: "YER", CodigoNumerico: "886", Nombre: "Rial yemeni", Decimales: 2, Activa: true},
+	{Codigo: "ZAR", CodigoNumerico: "710", Nombre: "Rand sudafricano", Decimales: 2, Activa: true},
+	{Codigo: "ZMW", CodigoNumerico: "967", Nombre: "Kwacha zambiano", Decimales: 2, Activa: true},
+	{Codigo: "ZWG", CodigoNumerico: "924", Nombre: "Oro de Zimbabue", Decimales: 2, Activa: true},
+	{Codigo: "ZWL", CodigoNumerico: "932", Nombre: "Dolar zimbabuense", Decimales: 2, Activa: false},
+}
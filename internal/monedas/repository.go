@@ -0,0 +1,47 @@
+package monedas
+
+import (
+	"errors"
+	"strings"
+)
+
+type Moneda struct {
+	Codigo         string `json:"codigo"`
+	CodigoNumerico string `json:"codigo_numerico"`
+	Nombre         string `json:"nombre"`
+	Decimales      int    `json:"decimales"`
+	Activa         bool   `json:"activa"`
+}
+
+type Repository interface {
+	GetAll() ([]Moneda, error)
+	Get(codigo string) (Moneda, error)
+}
+
+type repository struct {
+	monedas []Moneda
+	indice  map[string]Moneda
+}
+
+// NewRepository crea un Repository de solo lectura sobre el catalogo ISO 4217.
+func NewRepository() Repository {
+	indice := make(map[string]Moneda, len(catalogo))
+	for _, moneda := range catalogo {
+		indice[moneda.Codigo] = moneda
+	}
+	return &repository{monedas: catalogo, indice: indice}
+}
+
+func (r *repository) GetAll() ([]Moneda, error) {
+	monedas := make([]Moneda, len(r.monedas))
+	copy(monedas, r.monedas)
+	return monedas, nil
+}
+
+func (r *repository) Get(codigo string) (Moneda, error) {
+	moneda, ok := r.indice[strings.ToUpper(strings.TrimSpace(codigo))]
+	if !ok {
+		return Moneda{}, errors.New("la moneda no existe en el catalogo")
+	}
+	return moneda, nil
+}
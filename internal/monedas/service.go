@@ -0,0 +1,50 @@
+package monedas
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMonedaNoValida = errors.New("la moneda no es valida")
+
+type Service interface {
+	GetAll(soloActivas bool) ([]Moneda, error)
+	Validar(codigo string) (Moneda, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{repository: r}
+}
+
+func (s *service) GetAll(soloActivas bool) ([]Moneda, error) {
+	monedas, err := s.repository.GetAll()
+	if err != nil || !soloActivas {
+		return monedas, err
+	}
+
+	activas := make([]Moneda, 0, len(monedas))
+	for _, moneda := range monedas {
+		if moneda.Activa {
+			activas = append(activas, moneda)
+		}
+	}
+	return activas, nil
+}
+
+// Validar busca la moneda sin importar mayusculas ni espacios y regresa su
+// registro, cuyo Codigo es la forma normalizada. Falla si no existe o si ya
+// no esta activa.
+func (s *service) Validar(codigo string) (Moneda, error) {
+	moneda, err := s.repository.Get(codigo)
+	if err != nil {
+		return Moneda{}, fmt.Errorf("%w: %q no es un codigo ISO 4217", ErrMonedaNoValida, codigo)
+	}
+	if !moneda.Activa {
+		return Moneda{}, fmt.Errorf("%w: %s ya no esta activa", ErrMonedaNoValida, moneda.Codigo)
+	}
+	return moneda, nil
+}
@@ -0,0 +1,61 @@
+package monedas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceValidar(t *testing.T) {
+	// Arrange
+	service := NewService(NewRepository())
+
+	// Act
+	result, err := service.Validar(" mxn ")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "MXN", result.Codigo)
+	assert.Equal(t, "484", result.CodigoNumerico)
+	assert.Equal(t, 2, result.Decimales)
+}
+
+func TestServiceValidarNoValida(t *testing.T) {
+	// Arrange
+	service := NewService(NewRepository())
+
+	// Act
+	_, errDesconocida := service.Validar("XYZ")
+	_, errInactiva := service.Validar("VEF")
+
+	// Assert
+	assert.ErrorIs(t, errDesconocida, ErrMonedaNoValida)
+	assert.ErrorIs(t, errInactiva, ErrMonedaNoValida)
+}
+
+func TestServiceGetAllActivas(t *testing.T) {
+	// Arrange
+	service := NewService(NewRepository())
+
+	// Act
+	todas, errTodas := service.GetAll(false)
+	activas, errActivas := service.GetAll(true)
+
+	// Assert
+	assert.Nil(t, errTodas)
+	assert.Nil(t, errActivas)
+	assert.Less(t, len(activas), len(todas))
+	for _, moneda := range activas {
+		assert.True(t, moneda.Activa, moneda.Codigo)
+	}
+}
+
+func TestCatalogoSinDuplicados(t *testing.T) {
+	codigos := map[string]bool{}
+	for _, moneda := range catalogo {
+		assert.False(t, codigos[moneda.Codigo], moneda.Codigo)
+		assert.Len(t, moneda.Codigo, 3, moneda.Codigo)
+		assert.Len(t, moneda.CodigoNumerico, 3, moneda.Codigo)
+		codigos[moneda.Codigo] = true
+	}
+}
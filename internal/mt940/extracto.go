@@ -0,0 +1,67 @@
+// Package mt940 lee estados de cuenta SWIFT MT940 y los normaliza en lineas
+// listas para conciliar. Tolera los dialectos comunes de los bancos: con o sin
+// sobre SWIFT, saltos de linea CRLF, coma o punto decimal, fecha contable
+// opcional, :28: o :28C:, extractos sin "-" final y el campo :86: libre,
+// estructurado con ?NN o con /CLAVE/.
+package mt940
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+var ErrMT940NoValido = errors.New("el archivo MT940 no es valido")
+
+// Saldo es un saldo del extracto; Monto es negativo si el saldo es deudor.
+type Saldo struct {
+	Fecha      time.Time    `json:"fecha"`
+	Moneda     string       `json:"moneda"`
+	Monto      dinero.Monto `json:"monto" swaggertype:"string" example:"1500.00"`
+	Intermedio bool         `json:"intermedio,omitempty"` // 60M o 62M, el extracto sigue en otro mensaje
+}
+
+// Linea es un movimiento (:61:) con su informacion (:86:) ya interpretada.
+// Monto es negativo para los cargos; una reversa de abono tambien es un cargo.
+type Linea struct {
+	LineaArchivo      int          `json:"linea"`
+	Cuenta            string       `json:"cuenta"`
+	Fecha             time.Time    `json:"fecha"`
+	FechaContable     *time.Time   `json:"fecha_contable,omitempty"`
+	Moneda            string       `json:"moneda"`
+	Monto             dinero.Monto `json:"monto" swaggertype:"string" example:"-4000.00"`
+	Reversa           bool         `json:"reversa,omitempty"`
+	Tipo              string       `json:"tipo" example:"NTRF"`
+	Referencia        string       `json:"referencia,omitempty"`
+	ReferenciaBanco   string       `json:"referencia_banco,omitempty"`
+	ReferenciaExtremo string       `json:"referencia_extremo,omitempty"`
+	Detalle           string       `json:"detalle,omitempty"`
+	Contraparte       string       `json:"contraparte,omitempty"`
+	Concepto          string       `json:"concepto,omitempty"`
+	Informacion       string       `json:"informacion,omitempty"`
+}
+
+// Extracto es un estado de cuenta. Advertencias reune lo que no impide leerlo
+// pero conviene revisar, como saldos que no cuadran con las lineas.
+type Extracto struct {
+	Referencia            string   `json:"referencia"`
+	ReferenciaRelacionada string   `json:"referencia_relacionada,omitempty"`
+	Cuenta                string   `json:"cuenta"`
+	Numero                string   `json:"numero,omitempty"`
+	Apertura              Saldo    `json:"apertura"`
+	Cierre                Saldo    `json:"cierre"`
+	Disponible            *Saldo   `json:"disponible,omitempty"`
+	Informacion           string   `json:"informacion,omitempty"`
+	Lineas                []Linea  `json:"lineas"`
+	Advertencias          []string `json:"advertencias,omitempty"`
+}
+
+// Lineas regresa las lineas de todos los extractos en orden.
+func Lineas(extractos []Extracto) []Linea {
+	var lineas []Linea
+	for _, extracto := range extractos {
+		lineas = append(lineas, extracto.Lineas...)
+	}
+	return lineas
+}
@@ -0,0 +1,116 @@
+package mt940
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// patronSubcampos reconoce el :86: de los bancos alemanes: un codigo de
+	// tres digitos y subcampos ?NN, donde ? puede ser otro separador.
+	patronSubcampos = regexp.MustCompile(`^[0-9]{3}([^A-Za-z0-9 ])[0-9]{2}`)
+	// patronClaves reconoce el :86: con /CLAVE/valor de SWIFT y los bancos
+	// holandeses.
+	patronClaves = regexp.MustCompile(`/(NAME|REMI|EREF|CNTP|ORDP|BENM|IBAN|BIC|ADDR|TRCD|MARF|CSID|PREF|RTRN|PURP|ULTC|ULTD|BUSP)/`)
+	// patronSEPA reconoce la referencia extremo a extremo dentro del concepto.
+	patronSEPA = regexp.MustCompile(`EREF\+\s?(\S+)`)
+)
+
+// interpretarInformacion llena la contraparte, el concepto y la referencia
+// extremo a extremo de la linea a partir del :86:, segun su dialecto.
+func interpretarInformacion(linea *Linea, texto string) {
+	linea.Informacion = unirLineas(texto)
+	plano := strings.ReplaceAll(strings.TrimSpace(texto), "\n", "")
+
+	switch {
+	case patronSubcampos.MatchString(plano):
+		interpretarSubcampos(linea, plano)
+	case patronClaves.MatchString(plano) && strings.HasPrefix(plano, "/"):
+		interpretarClaves(linea, plano)
+	default:
+		linea.Concepto = linea.Informacion
+	}
+	if linea.ReferenciaExtremo == "" {
+		if referencia := patronSEPA.FindStringSubmatch(linea.Concepto); referencia != nil {
+			linea.ReferenciaExtremo = referencia[1]
+		}
+	}
+}
+
+// interpretarSubcampos lee ?20 a ?29 y ?60 a ?63 como concepto y ?32 y ?33
+// como nombre de la contraparte. Los saltos de linea pueden caer a la mitad
+// de un subcampo, por eso se quitan antes.
+func interpretarSubcampos(linea *Linea, plano string) {
+	separador := patronSubcampos.FindStringSubmatch(plano)[1]
+	partes := strings.Split(plano[3:], separador)
+	var concepto, contraparte []string
+	for _, parte := range partes {
+		if len(parte) < 2 {
+			continue
+		}
+		codigo, valor := parte[:2], strings.TrimSpace(parte[2:])
+		switch {
+		case valor == "":
+		case codigo >= "20" && codigo <= "29", codigo >= "60" && codigo <= "63":
+			concepto = append(concepto, valor)
+		case codigo == "32" || codigo == "33":
+			contraparte = append(contraparte, valor)
+		case codigo == "00" && linea.Detalle == "":
+			linea.Detalle = valor
+		}
+	}
+	linea.Concepto = strings.Join(concepto, " ")
+	linea.Contraparte = strings.Join(contraparte, " ")
+}
+
+// interpretarClaves lee /CLAVE/valor; NAME o el nombre dentro de CNTP, ORDP o
+// BENM es la contraparte, REMI el concepto y EREF la referencia extremo a
+// extremo.
+func interpretarClaves(linea *Linea, plano string) {
+	valores := map[string]string{}
+	indices := patronClaves.FindAllStringSubmatchIndex(plano, -1)
+	for index, encontrado := range indices {
+		fin := len(plano)
+		if index+1 < len(indices) {
+			fin = indices[index+1][0]
+		}
+		clave := plano[encontrado[2]:encontrado[3]]
+		if _, repetida := valores[clave]; !repetida {
+			valores[clave] = strings.TrimSpace(strings.TrimSuffix(plano[encontrado[1]:fin], "/"))
+		}
+	}
+
+	linea.Contraparte = valores["NAME"]
+	for _, clave := range []string{"CNTP", "ORDP", "BENM"} {
+		if linea.Contraparte != "" {
+			break
+		}
+		linea.Contraparte = nombreEn(valores[clave])
+	}
+
+	concepto := valores["REMI"]
+	// USTD//texto es concepto libre; STRD/CUR/ref es una referencia estructurada.
+	for _, prefijo := range []string{"USTD//", "USTD/", "STRD/CUR/", "STRD/ISO/"} {
+		concepto = strings.TrimPrefix(concepto, prefijo)
+	}
+	linea.Concepto = strings.TrimSpace(concepto)
+	if referencia := valores["EREF"]; referencia != "NOTPROVIDED" {
+		linea.ReferenciaExtremo = referencia
+	}
+}
+
+// nombreEn busca el nombre en una contraparte como cuenta/BIC/nombre/ciudad
+// o //NAME/nombre.
+func nombreEn(valor string) string {
+	if valor == "" {
+		return ""
+	}
+	if indice := strings.Index(valor, "NAME/"); indice >= 0 {
+		return strings.TrimSpace(strings.SplitN(valor[indice+len("NAME/"):], "/", 2)[0])
+	}
+	partes := strings.Split(valor, "/")
+	if len(partes) >= 3 {
+		return strings.TrimSpace(partes[2])
+	}
+	return ""
+}
@@ -0,0 +1,314 @@
+package mt940
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+var (
+	patronEtiqueta = regexp.MustCompile(`^:([0-9]{2}[A-Z]?):`)
+	patronSaldo    = regexp.MustCompile(`^([CD])([0-9]{6})([A-Z]{3})([0-9][0-9.,]*)$`)
+	// :61: fecha valor, fecha contable, indicador, codigo de fondos, monto,
+	// tipo, referencia del cliente y //referencia del banco.
+	patronLinea = regexp.MustCompile(`^([0-9]{6})([0-9]{4})?(RC|RD|EC|ED|C|D)([A-Z])?([0-9][0-9.,]*)([NFS][A-Z0-9]{3})(.*?)(?://(.*))?$`)
+)
+
+// campo es una etiqueta con su valor; las lineas de continuacion se unen con
+// saltos de linea. linea es la linea del archivo en que empieza.
+type campo struct {
+	etiqueta string
+	valor    string
+	linea    int
+}
+
+// Parse lee todos los extractos del archivo. Las fechas del archivo no traen
+// zona y se ubican en zona.
+func Parse(lector io.Reader, zona *time.Location) ([]Extracto, error) {
+	contenido, err := ioutil.ReadAll(lector)
+	if err != nil {
+		return nil, err
+	}
+	texto := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(string(contenido))
+	texto = strings.TrimPrefix(texto, "\ufeff")
+
+	var extractos []Extracto
+	var campos []campo
+	cerrar := func() error {
+		if len(campos) == 0 {
+			return nil
+		}
+		extracto, err := extractoDe(campos, zona)
+		if err != nil {
+			return err
+		}
+		extractos = append(extractos, extracto)
+		campos = nil
+		return nil
+	}
+
+	for index, linea := range strings.Split(texto, "\n") {
+		numero := index + 1
+		linea = strings.TrimRight(linea, " \t")
+
+		// Sobre SWIFT: el bloque {4: abre el texto y -} lo cierra; el resto de
+		// los bloques se ignora.
+		if inicio := strings.Index(linea, "{4:"); inicio >= 0 {
+			if err := cerrar(); err != nil {
+				return nil, err
+			}
+			linea = linea[inicio+len("{4:"):]
+		}
+		if strings.HasPrefix(linea, "-}") || linea == "-" {
+			if err := cerrar(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.HasPrefix(linea, "{") || strings.TrimSpace(linea) == "" {
+			continue
+		}
+
+		if etiqueta := patronEtiqueta.FindStringSubmatch(linea); etiqueta != nil {
+			// Algunos bancos no separan los extractos con "-".
+			if etiqueta[1] == "20" {
+				if err := cerrar(); err != nil {
+					return nil, err
+				}
+			}
+			campos = append(campos, campo{etiqueta: etiqueta[1], valor: linea[len(etiqueta[0]):], linea: numero})
+			continue
+		}
+		// Las lineas antes de la primera etiqueta son encabezados del banco.
+		if len(campos) > 0 {
+			campos[len(campos)-1].valor += "\n" + linea
+		}
+	}
+	if err := cerrar(); err != nil {
+		return nil, err
+	}
+	if len(extractos) == 0 {
+		return nil, fmt.Errorf("%w: el archivo no tiene extractos", ErrMT940NoValido)
+	}
+	return extractos, nil
+}
+
+func errorEn(linea int, formato string, args ...interface{}) error {
+	return fmt.Errorf("%w: linea %d: %s", ErrMT940NoValido, linea, fmt.Sprintf(formato, args...))
+}
+
+func extractoDe(campos []campo, zona *time.Location) (Extracto, error) {
+	extracto := Extracto{Lineas: []Linea{}}
+	var apertura, cierre bool
+	ultima := ""
+	for _, campo := range campos {
+		valor := strings.TrimSpace(campo.valor)
+		switch campo.etiqueta {
+		case "20":
+			extracto.Referencia = valor
+		case "21":
+			extracto.ReferenciaRelacionada = valor
+		case "25", "25P":
+			extracto.Cuenta = strings.TrimSpace(strings.SplitN(valor, "\n", 2)[0])
+		case "28", "28C":
+			extracto.Numero = valor
+		case "60F", "60M":
+			if apertura {
+				break
+			}
+			saldo, err := saldoDe(campo, zona)
+			if err != nil {
+				return Extracto{}, err
+			}
+			extracto.Apertura, apertura = saldo, true
+		case "61":
+			linea, err := lineaDe(campo, zona)
+			if err != nil {
+				return Extracto{}, err
+			}
+			extracto.Lineas = append(extracto.Lineas, linea)
+		case "86":
+			if ultima == "61" {
+				interpretarInformacion(&extracto.Lineas[len(extracto.Lineas)-1], campo.valor)
+			} else {
+				extracto.Informacion = unirLineas(campo.valor)
+			}
+		case "62F", "62M":
+			saldo, err := saldoDe(campo, zona)
+			if err != nil {
+				return Extracto{}, err
+			}
+			extracto.Cierre, cierre = saldo, true
+		case "64":
+			saldo, err := saldoDe(campo, zona)
+			if err != nil {
+				return Extracto{}, err
+			}
+			extracto.Disponible = &saldo
+		}
+		ultima = campo.etiqueta
+	}
+
+	inicio := campos[0].linea
+	switch {
+	case extracto.Cuenta == "":
+		return Extracto{}, errorEn(inicio, "el extracto no tiene cuenta (:25:)")
+	case !apertura:
+		return Extracto{}, errorEn(inicio, "el extracto no tiene saldo inicial (:60F: o :60M:)")
+	case !cierre:
+		return Extracto{}, errorEn(inicio, "el extracto no tiene saldo final (:62F: o :62M:)")
+	}
+	if extracto.Referencia == "" {
+		extracto.Advertencias = append(extracto.Advertencias, "el extracto no tiene referencia (:20:)")
+	}
+
+	total := extracto.Apertura.Monto
+	for index := range extracto.Lineas {
+		linea := &extracto.Lineas[index]
+		linea.Cuenta, linea.Moneda = extracto.Cuenta, extracto.Apertura.Moneda
+		total, _ = total.Sumar(linea.Monto)
+	}
+	if extracto.Cierre.Moneda != extracto.Apertura.Moneda {
+		extracto.Advertencias = append(extracto.Advertencias,
+			fmt.Sprintf("el saldo final esta en %s y el inicial en %s", extracto.Cierre.Moneda, extracto.Apertura.Moneda))
+	} else if !total.Igual(extracto.Cierre.Monto) {
+		extracto.Advertencias = append(extracto.Advertencias,
+			fmt.Sprintf("el saldo inicial mas las lineas da %s y el saldo final es %s", total, extracto.Cierre.Monto))
+	}
+	return extracto, nil
+}
+
+// saldoDe lee un saldo como C220421MXN1234,56.
+func saldoDe(campo campo, zona *time.Location) (Saldo, error) {
+	valor := strings.Join(strings.Fields(campo.valor), "")
+	partes := patronSaldo.FindStringSubmatch(valor)
+	if partes == nil {
+		return Saldo{}, errorEn(campo.linea, ":%s: %q no es un saldo, se espera C o D, aammdd, moneda y monto", campo.etiqueta, valor)
+	}
+	fecha, err := parseFecha(partes[2], zona)
+	if err != nil {
+		return Saldo{}, errorEn(campo.linea, ":%s: %v", campo.etiqueta, err)
+	}
+	monto, err := parseMonto(partes[4])
+	if err != nil {
+		return Saldo{}, errorEn(campo.linea, ":%s: el monto %q no es valido", campo.etiqueta, partes[4])
+	}
+	if partes[1] == "D" {
+		monto = monto.Negar()
+	}
+	return Saldo{Fecha: fecha, Moneda: partes[3], Monto: monto, Intermedio: strings.HasSuffix(campo.etiqueta, "M")}, nil
+}
+
+// lineaDe lee un :61:; su segunda linea, si la hay, es el detalle.
+func lineaDe(campo campo, zona *time.Location) (Linea, error) {
+	renglones := strings.SplitN(strings.TrimSpace(campo.valor), "\n", 2)
+	primera := strings.TrimSpace(renglones[0])
+	partes := patronLinea.FindStringSubmatch(primera)
+	if partes == nil {
+		return Linea{}, errorEn(campo.linea, ":61: %q no es un movimiento", primera)
+	}
+
+	linea := Linea{LineaArchivo: campo.linea, Tipo: partes[6]}
+	var err error
+	if linea.Fecha, err = parseFecha(partes[1], zona); err != nil {
+		return Linea{}, errorEn(campo.linea, ":61: %v", err)
+	}
+	if partes[2] != "" {
+		contable, err := fechaContable(partes[2], linea.Fecha)
+		if err != nil {
+			return Linea{}, errorEn(campo.linea, ":61: %v", err)
+		}
+		linea.FechaContable = &contable
+	}
+	if linea.Monto, err = parseMonto(partes[5]); err != nil {
+		return Linea{}, errorEn(campo.linea, ":61: el monto %q no es valido", partes[5])
+	}
+	// RC es la reversa de un abono y por eso resta; RD la de un cargo.
+	switch indicador := partes[3]; indicador {
+	case "D", "ED", "RC":
+		linea.Monto = linea.Monto.Negar()
+		linea.Reversa = indicador == "RC"
+	case "RD":
+		linea.Reversa = true
+	}
+	if referencia := strings.TrimSpace(partes[7]); referencia != "NONREF" {
+		linea.Referencia = referencia
+	}
+	linea.ReferenciaBanco = strings.TrimSpace(partes[8])
+	if len(renglones) > 1 {
+		linea.Detalle = unirLineas(renglones[1])
+	}
+	return linea, nil
+}
+
+// parseFecha lee una fecha aammdd.
+func parseFecha(texto string, zona *time.Location) (time.Time, error) {
+	fecha, err := time.ParseInLocation("060102", texto, zona)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("la fecha %q no es valida", texto)
+	}
+	return fecha, nil
+}
+
+// fechaContable lee una fecha mmdd sin anio; se toma el anio que la deja mas
+// cerca de la fecha valor, para cruzar bien el cambio de anio.
+func fechaContable(texto string, valor time.Time) (time.Time, error) {
+	var mejor time.Time
+	for _, anio := range []int{valor.Year() - 1, valor.Year(), valor.Year() + 1} {
+		// Un 29 de febrero solo existe en algunos de los anios.
+		fecha, err := time.ParseInLocation("20060102", fmt.Sprintf("%04d%s", anio, texto), valor.Location())
+		if err == nil && (mejor.IsZero() || distancia(fecha, valor) < distancia(mejor, valor)) {
+			mejor = fecha
+		}
+	}
+	if mejor.IsZero() {
+		return time.Time{}, fmt.Errorf("la fecha contable %q no es valida", texto)
+	}
+	return mejor, nil
+}
+
+func distancia(a, b time.Time) time.Duration {
+	if a.Before(b) {
+		return b.Sub(a)
+	}
+	return a.Sub(b)
+}
+
+// parseMonto lee un monto con coma decimal, como indica SWIFT, o con punto.
+// Si trae ambos, el ultimo es el decimal y el otro separa miles. Siempre
+// regresa al menos dos decimales.
+func parseMonto(texto string) (dinero.Monto, error) {
+	coma, punto := strings.LastIndex(texto, ","), strings.LastIndex(texto, ".")
+	switch {
+	case coma >= 0 && punto >= 0 && coma > punto:
+		texto = strings.Replace(strings.ReplaceAll(texto, ".", ""), ",", ".", 1)
+	case coma >= 0 && punto >= 0:
+		texto = strings.ReplaceAll(texto, ",", "")
+	case coma >= 0:
+		texto = strings.Replace(texto, ",", ".", 1)
+	}
+	monto, err := dinero.Parse(strings.TrimSuffix(texto, "."))
+	if err != nil {
+		return dinero.Monto{}, err
+	}
+	if monto.Escala() < 2 {
+		return monto.Escalar(2)
+	}
+	return monto, nil
+}
+
+// unirLineas une un valor de varias lineas con espacios.
+func unirLineas(texto string) string {
+	var partes []string
+	for _, linea := range strings.Split(texto, "\n") {
+		if linea = strings.TrimSpace(linea); linea != "" {
+			partes = append(partes, linea)
+		}
+	}
+	return strings.Join(partes, " ")
+}
@@ -0,0 +1,129 @@
+package mt940
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// go test ./internal/mt940 -actualizar reescribe los archivos golden despues
+// de un cambio intencional en el parser.
+var actualizar = flag.Bool("actualizar", false, "reescribe los archivos golden de testdata")
+
+var zonaPrueba = time.FixedZone("CST", -6*60*60)
+
+func TestParseGolden(t *testing.T) {
+	// Arrange
+	archivos, err := filepath.Glob(filepath.Join("testdata", "*.mt940"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, archivos)
+
+	for _, archivo := range archivos {
+		t.Run(filepath.Base(archivo), func(t *testing.T) {
+			entrada, err := os.Open(archivo)
+			assert.Nil(t, err)
+			defer entrada.Close()
+
+			// Act
+			extractos, err := Parse(entrada, zonaPrueba)
+
+			// Assert
+			assert.Nil(t, err)
+			obtenido, err := json.MarshalIndent(extractos, "", "  ")
+			assert.Nil(t, err)
+			obtenido = append(obtenido, '\n')
+			golden := strings.TrimSuffix(archivo, ".mt940") + ".golden.json"
+			if *actualizar {
+				assert.Nil(t, os.WriteFile(golden, obtenido, 0644))
+			}
+			esperado, err := os.ReadFile(golden)
+			assert.Nil(t, err)
+			assert.Equal(t, string(esperado), string(obtenido))
+		})
+	}
+}
+
+func TestParseDialectos(t *testing.T) {
+	// Arrange
+	entrada, err := os.Open(filepath.Join("testdata", "aleman.mt940"))
+	assert.Nil(t, err)
+	defer entrada.Close()
+
+	// Act
+	extractos, err := Parse(entrada, zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	lineas := Lineas(extractos)
+	assert.Equal(t, 2, len(lineas))
+	assert.Equal(t, "-250.00", lineas[0].Monto.String())
+	// La fecha contable cruza el cambio de anio.
+	assert.Equal(t, time.Date(2023, 1, 2, 0, 0, 0, 0, zonaPrueba), *lineas[0].FechaContable)
+	// El salto de linea parte el nombre a la mitad.
+	assert.Equal(t, "HAUSVERWALTUNG MUELLER GMBH", lineas[0].Contraparte)
+	assert.Equal(t, "2022-12-999", lineas[0].ReferenciaExtremo)
+	// La reversa de un abono resta.
+	assert.True(t, lineas[1].Reversa)
+	assert.Equal(t, "-100.00", lineas[1].Monto.String())
+	assert.Empty(t, extractos[0].Advertencias)
+}
+
+func TestParseNoValido(t *testing.T) {
+	// Arrange
+	extracto := ":20:REF\n:25:0123456789\n:60F:C220420MXN100,00\n:61:220421D50,00NTRFREF1\n:62F:C220421MXN50,00\n-\n"
+	casos := []struct {
+		nombre   string
+		archivo  string
+		problema string
+	}{
+		{"vacio", "", "el archivo no tiene extractos"},
+		{"sin cuenta", strings.Replace(extracto, ":25:0123456789\n", "", 1), "linea 1: el extracto no tiene cuenta"},
+		{"sin saldo inicial", strings.Replace(extracto, ":60F:C220420MXN100,00\n", "", 1), "el extracto no tiene saldo inicial"},
+		{"sin saldo final", strings.Replace(extracto, ":62F:C220421MXN50,00\n", "", 1), "el extracto no tiene saldo final"},
+		{"saldo", strings.Replace(extracto, "C220420MXN", "X220420MXN", 1), `linea 3: :60F: "X220420MXN100,00" no es un saldo`},
+		{"movimiento", strings.Replace(extracto, "D50,00NTRF", "D50,00", 1), `linea 4: :61: "220421D50,00REF1" no es un movimiento`},
+		{"fecha", strings.Replace(extracto, ":61:220421", ":61:221321", 1), `linea 4: :61: la fecha "221321" no es valida`},
+		{"monto", strings.Replace(extracto, "MXN100,00", "MXN1,0,0", 1), `:60F: el monto "1,0,0" no es valido`},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			// Act
+			extractos, err := Parse(strings.NewReader(caso.archivo), zonaPrueba)
+
+			// Assert
+			assert.Nil(t, extractos)
+			assert.True(t, errors.Is(err, ErrMT940NoValido))
+			assert.Contains(t, err.Error(), caso.problema)
+		})
+	}
+}
+
+func TestParseMonto(t *testing.T) {
+	casos := map[string]string{
+		"1234,56":  "1234.56",
+		"1234,":    "1234.00",
+		"1234.5":   "1234.50",
+		"1.234,56": "1234.56",
+		"1,234.56": "1234.56",
+		"0,001":    "0.001",
+		"10000,00": "10000.00",
+		"4,000.00": "4000.00",
+	}
+
+	for texto, esperado := range casos {
+		// Act
+		monto, err := parseMonto(texto)
+
+		// Assert
+		assert.Nil(t, err, texto)
+		assert.Equal(t, esperado, monto.String(), texto)
+	}
+}
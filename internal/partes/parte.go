@@ -0,0 +1,140 @@
+package partes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Tipo string
+
+const (
+	TIPO_BANCO    Tipo = "banco"
+	TIPO_PERSONA  Tipo = "persona"
+	TIPO_COMERCIO Tipo = "comercio"
+)
+
+type Estado string
+
+const (
+	ESTADO_ACTIVA   Estado = "activa"
+	ESTADO_INACTIVA Estado = "inactiva"
+)
+
+var (
+	ErrParteNoEncontrada = errors.New("la parte no esta registrada")
+	ErrParteInactiva     = errors.New("la parte esta inactiva")
+	ErrParteNoValida     = errors.New("la parte no es valida")
+	ErrNombreDuplicado   = errors.New("ya existe una parte con ese nombre")
+)
+
+func ParseTipo(texto string) (Tipo, error) {
+	switch tipo := Tipo(strings.ToLower(strings.TrimSpace(texto))); tipo {
+	case TIPO_BANCO, TIPO_PERSONA, TIPO_COMERCIO:
+		return tipo, nil
+	}
+	return "", fmt.Errorf("%w: tipo %q, se espera banco, persona o comercio", ErrParteNoValida, texto)
+}
+
+func ParseEstado(texto string) (Estado, error) {
+	switch estado := Estado(strings.ToLower(strings.TrimSpace(texto))); estado {
+	case ESTADO_ACTIVA, ESTADO_INACTIVA:
+		return estado, nil
+	}
+	return "", fmt.Errorf("%w: estado %q, se espera activa o inactiva", ErrParteNoValida, texto)
+}
+
+// NormalizarNombre quita los espacios de los extremos y deja uno solo entre
+// palabras.
+func NormalizarNombre(nombre string) string {
+	return strings.Join(strings.Fields(nombre), " ")
+}
+
+// ClaveNombre es la forma en que se comparan los nombres: "Bancomer",
+// "BANCOMER" y " Bancomer " son la misma parte.
+func ClaveNombre(nombre string) string {
+	return strings.ToLower(NormalizarNombre(nombre))
+}
+
+// Coincide indica si el id y el nombre con que una transaccion registra a una
+// parte corresponden a p. Las transacciones anteriores al registro de partes
+// no tienen id y se reconocen por el nombre.
+func (p Parte) Coincide(id int, nombre string) bool {
+	if id != 0 {
+		return id == p.Id
+	}
+	return ClaveNombre(nombre) == ClaveNombre(p.Nombre)
+}
+
+// Deduplicar agrupa los nombres por ClaveNombre y regresa el registro con
+// una parte nueva por cada grupo que aun no esta en partes, junto con la parte
+// que corresponde a cada nombre. El nombre de una parte nueva es la escritura
+// mas repetida de su grupo y su tipo el que indica tipos por ClaveNombre, u
+// otro si no aparece. Sin otro, una parte nueva sin tipo es un error que
+// lista las que faltan por clasificar.
+func Deduplicar(partes []Parte, nombres []string, tipos map[string]Tipo, otro Tipo) ([]Parte, map[string]Parte, error) {
+	registradas := map[string]Parte{}
+	siguiente := 1
+	for _, parte := range partes {
+		registradas[ClaveNombre(parte.Nombre)] = parte
+		if parte.Id >= siguiente {
+			siguiente = parte.Id + 1
+		}
+	}
+
+	var claves []string
+	escrituras := map[string]map[string]int{}
+	for _, nombre := range nombres {
+		clave := ClaveNombre(nombre)
+		if clave == "" {
+			continue
+		}
+		if _, ok := escrituras[clave]; !ok {
+			escrituras[clave] = map[string]int{}
+			claves = append(claves, clave)
+		}
+		escrituras[clave][NormalizarNombre(nombre)]++
+	}
+
+	var sinTipo []string
+	for _, clave := range claves {
+		if _, ok := registradas[clave]; ok {
+			continue
+		}
+		tipo, ok := tipos[clave]
+		if !ok {
+			tipo = otro
+		}
+		parte := Parte{Id: siguiente, Nombre: masRepetido(nombres, escrituras[clave]), Tipo: tipo, Estado: ESTADO_ACTIVA}
+		if tipo == "" {
+			sinTipo = append(sinTipo, parte.Nombre)
+		}
+		partes = append(partes, parte)
+		registradas[clave] = parte
+		siguiente++
+	}
+	if len(sinTipo) > 0 {
+		return nil, nil, fmt.Errorf("%w: falta el tipo de %s", ErrParteNoValida, strings.Join(sinTipo, ", "))
+	}
+
+	porNombre := map[string]Parte{}
+	for _, nombre := range nombres {
+		if parte, ok := registradas[ClaveNombre(nombre)]; ok {
+			porNombre[nombre] = parte
+		}
+	}
+	return partes, porNombre, nil
+}
+
+// masRepetido regresa la escritura con mas apariciones; en un empate gana la
+// que aparece primero en nombres.
+func masRepetido(nombres []string, conteo map[string]int) string {
+	var elegido string
+	for _, nombre := range nombres {
+		escritura := NormalizarNombre(nombre)
+		if conteo[escritura] > conteo[elegido] {
+			elegido = escritura
+		}
+	}
+	return elegido
+}
@@ -0,0 +1,123 @@
+package partes
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+)
+
+// Parte es un banco, persona o comercio que emite o recibe transacciones.
+type Parte struct {
+	Id     int    `json:"id"`
+	Nombre string `json:"nombre"`
+	Tipo   Tipo   `json:"tipo"`
+	Estado Estado `json:"estado"`
+}
+
+type Repository interface {
+	GetAll() ([]Parte, error)
+	Get(id int) (Parte, error)
+	Store(parte Parte) (Parte, error)
+	Update(parte Parte) (Parte, error)
+}
+
+type repository struct {
+	db store.Store
+	mu sync.Mutex
+}
+
+// NewRepository crea un Repository sobre un store de archivo. Si el archivo
+// aun no existe el registro se considera vacio.
+func NewRepository(db store.Store) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetAll() ([]Parte, error) {
+	var partes []Parte
+	if err := r.db.Read(&partes); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		return nil, err
+	}
+	return partes, nil
+}
+
+func (r *repository) Get(id int) (Parte, error) {
+	partes, err := r.GetAll()
+	if err != nil {
+		return Parte{}, err
+	}
+	for _, parte := range partes {
+		if parte.Id == id {
+			return parte, nil
+		}
+	}
+	return Parte{}, fmt.Errorf("%w: %d", ErrParteNoEncontrada, id)
+}
+
+// Store agrega la parte con el siguiente id disponible.
+func (r *repository) Store(parte Parte) (Parte, error) {
+	var guardada Parte
+	err := r.modificar(func(partes []Parte) ([]Parte, error) {
+		if err := nombreOcupado(partes, parte); err != nil {
+			return nil, err
+		}
+		parte.Id = 1
+		if len(partes) > 0 {
+			parte.Id = partes[len(partes)-1].Id + 1
+		}
+		guardada = parte
+		return append(partes, parte), nil
+	})
+	return guardada, err
+}
+
+func (r *repository) Update(parte Parte) (Parte, error) {
+	err := r.modificar(func(partes []Parte) ([]Parte, error) {
+		if err := nombreOcupado(partes, parte); err != nil {
+			return nil, err
+		}
+		for i := range partes {
+			if partes[i].Id == parte.Id {
+				partes[i] = parte
+				return partes, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %d", ErrParteNoEncontrada, parte.Id)
+	})
+	if err != nil {
+		return Parte{}, err
+	}
+	return parte, nil
+}
+
+// nombreOcupado revisa que ninguna otra parte tenga el mismo nombre.
+func nombreOcupado(partes []Parte, parte Parte) error {
+	clave := ClaveNombre(parte.Nombre)
+	for _, existente := range partes {
+		if existente.Id != parte.Id && ClaveNombre(existente.Nombre) == clave {
+			return fmt.Errorf("%w: %s ya esta registrada con el id %d", ErrNombreDuplicado, parte.Nombre, existente.Id)
+		}
+	}
+	return nil
+}
+
+func (r *repository) modificar(cambio func(partes []Parte) ([]Parte, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	partes, err := r.GetAll()
+	if err != nil {
+		return err
+	}
+	if partes, err = cambio(partes); err != nil {
+		return err
+	}
+	return r.db.Write(partes)
+}
@@ -0,0 +1,74 @@
+package partes
+
+import (
+	"fmt"
+)
+
+type Service interface {
+	GetAll() ([]Parte, error)
+	Get(id int) (Parte, error)
+	Store(nombre string, tipo Tipo) (Parte, error)
+	Update(id int, nombre string, tipo Tipo, estado Estado) (Parte, error)
+	Activa(id int) (Parte, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{repository: r}
+}
+
+func (s *service) GetAll() ([]Parte, error) {
+	return s.repository.GetAll()
+}
+
+func (s *service) Get(id int) (Parte, error) {
+	return s.repository.Get(id)
+}
+
+// Store registra una parte activa con el nombre normalizado.
+func (s *service) Store(nombre string, tipo Tipo) (Parte, error) {
+	parte, err := nuevaParte(0, nombre, tipo, ESTADO_ACTIVA)
+	if err != nil {
+		return Parte{}, err
+	}
+	return s.repository.Store(parte)
+}
+
+func (s *service) Update(id int, nombre string, tipo Tipo, estado Estado) (Parte, error) {
+	parte, err := nuevaParte(id, nombre, tipo, estado)
+	if err != nil {
+		return Parte{}, err
+	}
+	return s.repository.Update(parte)
+}
+
+// Activa regresa la parte id si esta registrada y puede participar en
+// transacciones nuevas.
+func (s *service) Activa(id int) (Parte, error) {
+	parte, err := s.repository.Get(id)
+	if err != nil {
+		return Parte{}, err
+	}
+	if parte.Estado != ESTADO_ACTIVA {
+		return Parte{}, fmt.Errorf("%w: %d", ErrParteInactiva, id)
+	}
+	return parte, nil
+}
+
+func nuevaParte(id int, nombre string, tipo Tipo, estado Estado) (Parte, error) {
+	nombre = NormalizarNombre(nombre)
+	if nombre == "" {
+		return Parte{}, fmt.Errorf("%w: el nombre es requerido", ErrParteNoValida)
+	}
+	tipo, err := ParseTipo(string(tipo))
+	if err != nil {
+		return Parte{}, err
+	}
+	if estado, err = ParseEstado(string(estado)); err != nil {
+		return Parte{}, err
+	}
+	return Parte{Id: id, Nombre: nombre, Tipo: tipo, Estado: estado}, nil
+}
@@ -0,0 +1,98 @@
+package partes
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func nuevoService(t *testing.T) Service {
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "partes.json")}
+	return NewService(NewRepository(db))
+}
+
+func TestServiceStore(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+
+	// Act
+	bancomer, err := service.Store("  Bancomer   MX ", "Banco")
+	_, errDuplicado := service.Store("BANCOMER mx", TIPO_BANCO)
+	_, errTipo := service.Store("Ana", "empresa")
+	_, errNombre := service.Store("   ", TIPO_PERSONA)
+	ana, errAna := service.Store("Ana", TIPO_PERSONA)
+	lista, _ := service.GetAll()
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, Parte{Id: 1, Nombre: "Bancomer MX", Tipo: TIPO_BANCO, Estado: ESTADO_ACTIVA}, bancomer)
+	assert.ErrorIs(t, errDuplicado, ErrNombreDuplicado)
+	assert.ErrorIs(t, errTipo, ErrParteNoValida)
+	assert.ErrorIs(t, errNombre, ErrParteNoValida)
+	assert.Nil(t, errAna)
+	assert.Equal(t, 2, ana.Id)
+	assert.Equal(t, []Parte{bancomer, ana}, lista)
+}
+
+func TestServiceUpdateYActiva(t *testing.T) {
+	// Arrange
+	service := nuevoService(t)
+	ana, _ := service.Store("Ana", TIPO_PERSONA)
+	_, _ = service.Store("Luis", TIPO_PERSONA)
+
+	// Act
+	activa, errActiva := service.Activa(ana.Id)
+	inactiva, errUpdate := service.Update(ana.Id, "Ana Maria", TIPO_COMERCIO, ESTADO_INACTIVA)
+	_, errInactiva := service.Activa(ana.Id)
+	_, errDuplicado := service.Update(ana.Id, "luis", TIPO_PERSONA, ESTADO_ACTIVA)
+	_, errNoExiste := service.Update(99, "Eva", TIPO_PERSONA, ESTADO_ACTIVA)
+	_, errEstado := service.Update(ana.Id, "Ana", TIPO_PERSONA, "borrada")
+
+	// Assert
+	assert.Nil(t, errActiva)
+	assert.Equal(t, ana, activa)
+	assert.Nil(t, errUpdate)
+	assert.Equal(t, Parte{Id: ana.Id, Nombre: "Ana Maria", Tipo: TIPO_COMERCIO, Estado: ESTADO_INACTIVA}, inactiva)
+	assert.ErrorIs(t, errInactiva, ErrParteInactiva)
+	assert.ErrorIs(t, errDuplicado, ErrNombreDuplicado)
+	assert.ErrorIs(t, errNoExiste, ErrParteNoEncontrada)
+	assert.ErrorIs(t, errEstado, ErrParteNoValida)
+}
+
+func TestDeduplicar(t *testing.T) {
+	// Arrange
+	registradas := []Parte{{Id: 4, Nombre: "Banamex", Tipo: TIPO_BANCO, Estado: ESTADO_ACTIVA}}
+	nombres := []string{"BANCOMER", "Bancomer", "Bancomer ", "banamex", "Pedrito", " BANCOMER", "Bancomer", ""}
+	tipos := map[string]Tipo{"bancomer": TIPO_BANCO}
+
+	// Act
+	lista, porNombre, err := Deduplicar(registradas, nombres, tipos, TIPO_PERSONA)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []Parte{
+		registradas[0],
+		{Id: 5, Nombre: "Bancomer", Tipo: TIPO_BANCO, Estado: ESTADO_ACTIVA},
+		{Id: 6, Nombre: "Pedrito", Tipo: TIPO_PERSONA, Estado: ESTADO_ACTIVA},
+	}, lista)
+	assert.Equal(t, 5, porNombre["Bancomer "].Id)
+	assert.Equal(t, 4, porNombre["banamex"].Id)
+	assert.NotContains(t, porNombre, "")
+}
+
+func TestDeduplicarSinTipo(t *testing.T) {
+	// Arrange
+	nombres := []string{"Bancomer", "Pedrito", "Paco", "banamex"}
+	tipos := map[string]Tipo{"bancomer": TIPO_BANCO, "banamex": TIPO_BANCO}
+
+	// Act
+	lista, porNombre, err := Deduplicar(nil, nombres, tipos, "")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrParteNoValida)
+	assert.Contains(t, err.Error(), "falta el tipo de Pedrito, Paco")
+	assert.Nil(t, lista)
+	assert.Nil(t, porNombre)
+}
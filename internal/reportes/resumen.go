@@ -0,0 +1,144 @@
+// Package reportes calcula totales de transacciones agrupadas.
+package reportes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+// Clave es un criterio por el que se agrupan las transacciones.
+type Clave string
+
+const (
+	CLAVE_MONEDA   Clave = "moneda"
+	CLAVE_EMISOR   Clave = "emisor"
+	CLAVE_RECEPTOR Clave = "receptor"
+	CLAVE_DIA      Clave = "dia"
+	CLAVE_SEMANA   Clave = "semana"
+	CLAVE_MES      Clave = "mes"
+)
+
+var ErrAgrupacionNoValida = errors.New("la agrupacion no es valida")
+
+// claves indica como obtener el valor de cada clave; las de fecha usan el dia
+// de la transaccion en la zona del reporte.
+var claves = map[Clave]func(t transacciones.Transaccion, zona *time.Location) string{
+	CLAVE_MONEDA:   func(t transacciones.Transaccion, _ *time.Location) string { return t.Moneda },
+	CLAVE_EMISOR:   func(t transacciones.Transaccion, _ *time.Location) string { return t.Emisor },
+	CLAVE_RECEPTOR: func(t transacciones.Transaccion, _ *time.Location) string { return t.Receptor },
+	CLAVE_DIA: func(t transacciones.Transaccion, zona *time.Location) string {
+		return t.FechaTransaccion.In(zona).Format("2006-01-02")
+	},
+	// La semana es la ISO 8601, que empieza en lunes, como 2022-W16.
+	CLAVE_SEMANA: func(t transacciones.Transaccion, zona *time.Location) string {
+		anio, semana := t.FechaTransaccion.In(zona).ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", anio, semana)
+	},
+	CLAVE_MES: func(t transacciones.Transaccion, zona *time.Location) string {
+		return t.FechaTransaccion.In(zona).Format("2006-01")
+	},
+}
+
+// ParseAgrupacion lee las claves separadas por coma, en orden. Los montos de
+// distintas monedas nunca se suman, por eso moneda siempre forma parte de la
+// agrupacion: si no se pide, se agrega al principio.
+func ParseAgrupacion(texto string) ([]Clave, error) {
+	agrupacion := []Clave{}
+	vistas := map[Clave]bool{}
+	for _, parte := range strings.Split(texto, ",") {
+		clave := Clave(strings.ToLower(strings.TrimSpace(parte)))
+		if clave == "" {
+			continue
+		}
+		if _, ok := claves[clave]; !ok {
+			return nil, fmt.Errorf("%w: %q, se espera moneda, emisor, receptor, dia, semana o mes", ErrAgrupacionNoValida, parte)
+		}
+		if vistas[clave] {
+			return nil, fmt.Errorf("%w: %q esta repetida", ErrAgrupacionNoValida, parte)
+		}
+		vistas[clave] = true
+		agrupacion = append(agrupacion, clave)
+	}
+	if !vistas[CLAVE_MONEDA] {
+		agrupacion = append([]Clave{CLAVE_MONEDA}, agrupacion...)
+	}
+	return agrupacion, nil
+}
+
+// Grupo reune las transacciones con los mismos valores en las claves. El
+// promedio se redondea a la escala de los montos.
+type Grupo struct {
+	Claves   map[Clave]string `json:"claves"`
+	Cantidad int              `json:"cantidad"`
+	Suma     dinero.Monto     `json:"suma" swaggertype:"string" example:"4500.00"`
+	Minimo   dinero.Monto     `json:"minimo" swaggertype:"string" example:"500.00"`
+	Maximo   dinero.Monto     `json:"maximo" swaggertype:"string" example:"4000.00"`
+	Promedio dinero.Monto     `json:"promedio" swaggertype:"string" example:"2250.00"`
+	valores  []string
+}
+
+// Resumir agrupa las transacciones por las claves y calcula los totales de
+// cada grupo. Los grupos se ordenan por sus valores, en el orden de las
+// claves.
+func Resumir(lista []transacciones.Transaccion, agrupacion []Clave, zona *time.Location) ([]Grupo, error) {
+	porValores := map[string]*Grupo{}
+	var grupos []*Grupo
+	for _, transaccion := range lista {
+		valores := make([]string, len(agrupacion))
+		for index, clave := range agrupacion {
+			valores[index] = claves[clave](transaccion, zona)
+		}
+		// Cada valor va entre comillas y escapado, por lo que emisores o
+		// receptores con cualquier texto no se confunden entre si.
+		llave := fmt.Sprintf("%q", valores)
+
+		grupo, ok := porValores[llave]
+		if !ok {
+			grupo = &Grupo{Claves: map[Clave]string{}, Suma: dinero.Nuevo(0, transaccion.Monto.Escala()),
+				Minimo: transaccion.Monto, Maximo: transaccion.Monto, valores: valores}
+			for index, clave := range agrupacion {
+				grupo.Claves[clave] = valores[index]
+			}
+			porValores[llave] = grupo
+			grupos = append(grupos, grupo)
+		}
+
+		suma, err := grupo.Suma.Sumar(transaccion.Monto)
+		if err != nil {
+			return nil, err
+		}
+		grupo.Suma = suma
+		grupo.Cantidad++
+		if transaccion.Monto.Cmp(grupo.Minimo) < 0 {
+			grupo.Minimo = transaccion.Monto
+		}
+		if transaccion.Monto.Cmp(grupo.Maximo) > 0 {
+			grupo.Maximo = transaccion.Monto
+		}
+	}
+
+	sort.Slice(grupos, func(i, j int) bool {
+		for index := range agrupacion {
+			if grupos[i].valores[index] != grupos[j].valores[index] {
+				return grupos[i].valores[index] < grupos[j].valores[index]
+			}
+		}
+		return false
+	})
+	resultado := make([]Grupo, len(grupos))
+	for index, grupo := range grupos {
+		promedio, err := grupo.Suma.Dividir(dinero.Nuevo(int64(grupo.Cantidad), 0), grupo.Suma.Escala())
+		if err != nil {
+			return nil, err
+		}
+		grupo.Promedio = promedio
+		resultado[index] = *grupo
+	}
+	return resultado, nil
+}
@@ -0,0 +1,124 @@
+package reportes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/transacciones"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/stretchr/testify/assert"
+)
+
+var zonaPrueba = time.FixedZone("CST", -6*60*60)
+
+func transaccion(moneda, monto, emisor, receptor string, fecha time.Time) transacciones.Transaccion {
+	return transacciones.Transaccion{Moneda: moneda, Monto: dinero.DebeParsear(monto), Emisor: emisor, Receptor: receptor, FechaTransaccion: fecha}
+}
+
+var listaPrueba = []transacciones.Transaccion{
+	transaccion("MXN", "4000.00", "Bancomer", "Pedrito", time.Date(2022, 4, 4, 10, 0, 0, 0, zonaPrueba)),
+	transaccion("MXN", "500.00", "Bancomer", "Pablo", time.Date(2022, 4, 1, 0, 0, 0, 0, zonaPrueba)),
+	transaccion("MXN", "790.00", "Banamex", "Paco", time.Date(2022, 4, 12, 0, 0, 0, 0, zonaPrueba)),
+	// Las 3 de la manana en UTC aun son el 19 de abril en la zona del reporte.
+	transaccion("USD", "100.50", "Banregio", "Lestat", time.Date(2022, 4, 20, 3, 0, 0, 0, time.UTC)),
+	transaccion("USD", "30.00", "Banregio", "Lestat", time.Date(2022, 5, 2, 0, 0, 0, 0, zonaPrueba)),
+	transaccion("MXN", "230.01", "Bancomer", "Pablo", time.Date(2022, 4, 3, 23, 0, 0, 0, zonaPrueba)),
+}
+
+func TestParseAgrupacion(t *testing.T) {
+	// Act
+	vacia, errVacia := ParseAgrupacion("")
+	mes, errMes := ParseAgrupacion(" Emisor , mes")
+	conMoneda, errConMoneda := ParseAgrupacion("semana,moneda")
+	_, errClave := ParseAgrupacion("emisor,anio")
+	_, errRepetida := ParseAgrupacion("dia,dia")
+
+	// Assert
+	assert.Nil(t, errVacia)
+	assert.Equal(t, []Clave{CLAVE_MONEDA}, vacia)
+	assert.Nil(t, errMes)
+	assert.Equal(t, []Clave{CLAVE_MONEDA, CLAVE_EMISOR, CLAVE_MES}, mes)
+	assert.Nil(t, errConMoneda)
+	assert.Equal(t, []Clave{CLAVE_SEMANA, CLAVE_MONEDA}, conMoneda)
+	assert.ErrorIs(t, errClave, ErrAgrupacionNoValida)
+	assert.Contains(t, errClave.Error(), `"anio"`)
+	assert.ErrorIs(t, errRepetida, ErrAgrupacionNoValida)
+}
+
+func TestResumirPorEmisor(t *testing.T) {
+	// Act
+	grupos, err := Resumir(listaPrueba, []Clave{CLAVE_MONEDA, CLAVE_EMISOR}, zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(grupos))
+	assert.Equal(t, map[Clave]string{CLAVE_MONEDA: "MXN", CLAVE_EMISOR: "Banamex"}, grupos[0].Claves)
+	bancomer := grupos[1]
+	assert.Equal(t, "Bancomer", bancomer.Claves[CLAVE_EMISOR])
+	assert.Equal(t, 3, bancomer.Cantidad)
+	assert.Equal(t, "4730.01", bancomer.Suma.String())
+	assert.Equal(t, "230.01", bancomer.Minimo.String())
+	assert.Equal(t, "4000.00", bancomer.Maximo.String())
+	// 4730.01 / 3 = 1576.67
+	assert.Equal(t, "1576.67", bancomer.Promedio.String())
+	assert.Equal(t, map[Clave]string{CLAVE_MONEDA: "USD", CLAVE_EMISOR: "Banregio"}, grupos[2].Claves)
+	assert.Equal(t, "65.25", grupos[2].Promedio.String())
+}
+
+func TestResumirValoresConSeparador(t *testing.T) {
+	// Arrange
+	fecha := time.Date(2022, 4, 4, 10, 0, 0, 0, zonaPrueba)
+	lista := []transacciones.Transaccion{
+		transaccion("MXN", "10.00", "A\x00B", "C", fecha),
+		transaccion("MXN", "20.00", "A", "B\x00C", fecha),
+	}
+
+	// Act
+	grupos, err := Resumir(lista, []Clave{CLAVE_MONEDA, CLAVE_EMISOR, CLAVE_RECEPTOR}, zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(grupos))
+	assert.Equal(t, map[Clave]string{CLAVE_MONEDA: "MXN", CLAVE_EMISOR: "A", CLAVE_RECEPTOR: "B\x00C"}, grupos[0].Claves)
+	assert.Equal(t, "20.00", grupos[0].Suma.String())
+	assert.Equal(t, map[Clave]string{CLAVE_MONEDA: "MXN", CLAVE_EMISOR: "A\x00B", CLAVE_RECEPTOR: "C"}, grupos[1].Claves)
+	assert.Equal(t, "10.00", grupos[1].Suma.String())
+}
+
+func TestResumirPorFecha(t *testing.T) {
+	// Act
+	dias, errDias := Resumir(listaPrueba, []Clave{CLAVE_DIA, CLAVE_MONEDA}, zonaPrueba)
+	semanas, errSemanas := Resumir(listaPrueba, []Clave{CLAVE_MONEDA, CLAVE_SEMANA}, zonaPrueba)
+	meses, errMeses := Resumir(listaPrueba, []Clave{CLAVE_MONEDA, CLAVE_MES}, zonaPrueba)
+
+	// Assert
+	assert.Nil(t, errDias)
+	etiquetas := []string{}
+	for _, grupo := range dias {
+		etiquetas = append(etiquetas, grupo.Claves[CLAVE_DIA])
+	}
+	assert.Equal(t, []string{"2022-04-01", "2022-04-03", "2022-04-04", "2022-04-12", "2022-04-19", "2022-05-02"}, etiquetas)
+
+	assert.Nil(t, errSemanas)
+	// El 1 y el 3 de abril de 2022 caen en la semana 13; el 4, lunes, abre la 14.
+	assert.Equal(t, "2022-W13", semanas[0].Claves[CLAVE_SEMANA])
+	assert.Equal(t, 2, semanas[0].Cantidad)
+	assert.Equal(t, "730.01", semanas[0].Suma.String())
+	assert.Equal(t, "2022-W14", semanas[1].Claves[CLAVE_SEMANA])
+
+	assert.Nil(t, errMeses)
+	assert.Equal(t, 3, len(meses))
+	assert.Equal(t, map[Clave]string{CLAVE_MONEDA: "MXN", CLAVE_MES: "2022-04"}, meses[0].Claves)
+	assert.Equal(t, 4, meses[0].Cantidad)
+	assert.Equal(t, "5520.01", meses[0].Suma.String())
+	assert.Equal(t, "2022-05", meses[2].Claves[CLAVE_MES])
+}
+
+func TestResumirVacio(t *testing.T) {
+	// Act
+	grupos, err := Resumir(nil, []Clave{CLAVE_MONEDA}, zonaPrueba)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []Grupo{}, grupos)
+}
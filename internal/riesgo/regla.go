@@ -0,0 +1,415 @@
+package riesgo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+type Accion string
+
+const (
+	ACCION_PERMITIR Accion = "allow"
+	ACCION_MARCAR   Accion = "flag"
+	ACCION_RECHAZAR Accion = "reject"
+)
+
+// puntosPorDefecto son los puntos de riesgo de una regla que no los indica.
+var puntosPorDefecto = map[Accion]int{
+	ACCION_PERMITIR: 0,
+	ACCION_MARCAR:   50,
+	ACCION_RECHAZAR: 100,
+}
+
+// severidad ordena las acciones de la menos a la mas restrictiva.
+var severidad = map[Accion]int{
+	ACCION_PERMITIR: 0,
+	ACCION_MARCAR:   1,
+	ACCION_RECHAZAR: 2,
+}
+
+var ErrReglaNoValida = errors.New("la regla de riesgo no es valida")
+
+// Datos son los campos de una transaccion sobre los que se evaluan las reglas.
+type Datos struct {
+	CodigoTransaccion string
+	Moneda            string
+	Monto             dinero.Monto
+	Emisor            string
+	EmisorId          int
+	Receptor          string
+	ReceptorId        int
+	Fecha             time.Time
+}
+
+// Regla asigna una accion y unos puntos de riesgo a las transacciones que
+// cumplen su condicion.
+type Regla struct {
+	Nombre    string
+	Accion    Accion
+	Puntos    int
+	condicion condicion
+}
+
+type condicion interface {
+	cumple(datos Datos) bool
+}
+
+type y []condicion
+
+func (c y) cumple(datos Datos) bool {
+	for _, parte := range c {
+		if !parte.cumple(datos) {
+			return false
+		}
+	}
+	return true
+}
+
+type o []condicion
+
+func (c o) cumple(datos Datos) bool {
+	for _, parte := range c {
+		if parte.cumple(datos) {
+			return true
+		}
+	}
+	return false
+}
+
+type no struct {
+	condicion condicion
+}
+
+func (c no) cumple(datos Datos) bool {
+	return !c.condicion.cumple(datos)
+}
+
+// comparacion compara un campo con un valor. comparar regresa el signo de
+// campo - valor.
+type comparacion struct {
+	operador string
+	comparar func(datos Datos) int
+}
+
+func (c comparacion) cumple(datos Datos) bool {
+	resultado := c.comparar(datos)
+	switch c.operador {
+	case "==":
+		return resultado == 0
+	case "!=":
+		return resultado != 0
+	case ">":
+		return resultado > 0
+	case ">=":
+		return resultado >= 0
+	case "<":
+		return resultado < 0
+	}
+	return resultado <= 0
+}
+
+func compararEnteros(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// campos contiene los campos que una regla puede comparar. Los de texto solo
+// admiten == y != y se comparan sin distinguir mayusculas.
+var campos = map[string]func(valor string) (func(datos Datos) int, bool, error){
+	"monto": func(valor string) (func(datos Datos) int, bool, error) {
+		monto, err := dinero.Parse(valor)
+		return func(datos Datos) int { return datos.Monto.Cmp(monto) }, true, err
+	},
+	"emisor_id":          campoEntero(func(datos Datos) int { return datos.EmisorId }),
+	"receptor_id":        campoEntero(func(datos Datos) int { return datos.ReceptorId }),
+	"hora":               campoEntero(func(datos Datos) int { return datos.Fecha.Hour() }),
+	"moneda":             campoTexto(func(datos Datos) string { return datos.Moneda }),
+	"emisor":             campoTexto(func(datos Datos) string { return datos.Emisor }),
+	"receptor":           campoTexto(func(datos Datos) string { return datos.Receptor }),
+	"codigo_transaccion": campoTexto(func(datos Datos) string { return datos.CodigoTransaccion }),
+}
+
+func campoEntero(leer func(datos Datos) int) func(valor string) (func(datos Datos) int, bool, error) {
+	return func(valor string) (func(datos Datos) int, bool, error) {
+		numero, err := strconv.Atoi(valor)
+		return func(datos Datos) int { return compararEnteros(leer(datos), numero) }, true, err
+	}
+}
+
+func campoTexto(leer func(datos Datos) string) func(valor string) (func(datos Datos) int, bool, error) {
+	return func(valor string) (func(datos Datos) int, bool, error) {
+		return func(datos Datos) int {
+			if strings.EqualFold(leer(datos), valor) {
+				return 0
+			}
+			return 1
+		}, false, nil
+	}
+}
+
+// ParseRegla interpreta una regla con la forma
+//
+//	[nombre:] condicion -> accion [puntos]
+//
+// donde la condicion compara campos con ==, !=, >, >=, < y <=, y las
+// comparaciones se combinan con and, or, not y parentesis. La accion es allow,
+// flag (o review) o reject. Por ejemplo:
+//
+//	usd_alto: monto > 10000 and moneda == USD -> review 60
+func ParseRegla(texto string) (Regla, error) {
+	var regla Regla
+	if index := strings.Index(texto, ":"); index >= 0 && esNombre(strings.TrimSpace(texto[:index])) {
+		regla.Nombre, texto = strings.TrimSpace(texto[:index]), texto[index+1:]
+	}
+
+	index := strings.Index(texto, "->")
+	if index < 0 {
+		return Regla{}, fmt.Errorf("%w: falta -> accion", ErrReglaNoValida)
+	}
+	textoCondicion, textoAccion := texto[:index], texto[index+len("->"):]
+
+	accion := strings.Fields(textoAccion)
+	if len(accion) == 0 || len(accion) > 2 {
+		return Regla{}, fmt.Errorf("%w: se espera accion [puntos] despues de ->", ErrReglaNoValida)
+	}
+	switch Accion(strings.ToLower(accion[0])) {
+	case ACCION_PERMITIR:
+		regla.Accion = ACCION_PERMITIR
+	case ACCION_MARCAR, "review":
+		regla.Accion = ACCION_MARCAR
+	case ACCION_RECHAZAR:
+		regla.Accion = ACCION_RECHAZAR
+	default:
+		return Regla{}, fmt.Errorf("%w: accion %q, se espera allow, flag, review o reject", ErrReglaNoValida, accion[0])
+	}
+	regla.Puntos = puntosPorDefecto[regla.Accion]
+	if len(accion) == 2 {
+		puntos, err := strconv.Atoi(accion[1])
+		if err != nil || puntos < 0 {
+			return Regla{}, fmt.Errorf("%w: los puntos deben ser un entero no negativo: %q", ErrReglaNoValida, accion[1])
+		}
+		regla.Puntos = puntos
+	}
+
+	tokens, err := separar(textoCondicion)
+	if err != nil {
+		return Regla{}, err
+	}
+	p := &parser{tokens: tokens}
+	if regla.condicion, err = p.expresion(); err != nil {
+		return Regla{}, err
+	}
+	if p.posicion < len(p.tokens) {
+		return Regla{}, fmt.Errorf("%w: sobra %q", ErrReglaNoValida, p.tokens[p.posicion].texto)
+	}
+	return regla, nil
+}
+
+func esNombre(texto string) bool {
+	if texto == "" {
+		return false
+	}
+	for _, r := range texto {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseReglas lee una regla por linea; se ignoran las lineas vacias y las que
+// empiezan con #. Las reglas sin nombre se llaman por su numero de linea.
+func ParseReglas(lector io.Reader) ([]Regla, error) {
+	var reglas []Regla
+	scanner := bufio.NewScanner(lector)
+	for linea := 1; scanner.Scan(); linea++ {
+		texto := strings.TrimSpace(scanner.Text())
+		if texto == "" || strings.HasPrefix(texto, "#") {
+			continue
+		}
+		regla, err := ParseRegla(texto)
+		if err != nil {
+			return nil, fmt.Errorf("linea %d: %w", linea, err)
+		}
+		if regla.Nombre == "" {
+			regla.Nombre = "linea_" + strconv.Itoa(linea)
+		}
+		reglas = append(reglas, regla)
+	}
+	return reglas, scanner.Err()
+}
+
+type token struct {
+	texto string
+	// literal indica un texto entre comillas, que nunca es palabra reservada.
+	literal bool
+}
+
+func separar(texto string) ([]token, error) {
+	var tokens []token
+	runas := []rune(texto)
+	for i := 0; i < len(runas); {
+		r := runas[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{texto: string(r)})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			fin := i + 1
+			if fin < len(runas) && runas[fin] == '=' {
+				fin++
+			}
+			operador := string(runas[i:fin])
+			if operador == "=" || operador == "!" {
+				return nil, fmt.Errorf("%w: operador %q", ErrReglaNoValida, operador)
+			}
+			tokens = append(tokens, token{texto: operador})
+			i = fin
+		case r == '"':
+			fin := i + 1
+			for fin < len(runas) && runas[fin] != '"' {
+				fin++
+			}
+			if fin == len(runas) {
+				return nil, fmt.Errorf("%w: falta cerrar comillas", ErrReglaNoValida)
+			}
+			tokens = append(tokens, token{texto: string(runas[i+1 : fin]), literal: true})
+			i = fin + 1
+		default:
+			fin := i
+			for fin < len(runas) && !unicode.IsSpace(runas[fin]) && !strings.ContainsRune("()=!<>\"", runas[fin]) {
+				fin++
+			}
+			tokens = append(tokens, token{texto: string(runas[i:fin])})
+			i = fin
+		}
+	}
+	return tokens, nil
+}
+
+// parser reconoce expresion := termino (or termino)*,
+// termino := factor (and factor)* y factor := not factor | ( expresion ) |
+// campo operador valor.
+type parser struct {
+	tokens   []token
+	posicion int
+}
+
+func (p *parser) siguiente() (token, bool) {
+	if p.posicion >= len(p.tokens) {
+		return token{}, false
+	}
+	t := p.tokens[p.posicion]
+	p.posicion++
+	return t, true
+}
+
+func (p *parser) palabra(palabra string) bool {
+	if p.posicion < len(p.tokens) && !p.tokens[p.posicion].literal && strings.EqualFold(p.tokens[p.posicion].texto, palabra) {
+		p.posicion++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expresion() (condicion, error) {
+	var partes o
+	for {
+		termino, err := p.termino()
+		if err != nil {
+			return nil, err
+		}
+		partes = append(partes, termino)
+		if !p.palabra("or") {
+			break
+		}
+	}
+	if len(partes) == 1 {
+		return partes[0], nil
+	}
+	return partes, nil
+}
+
+func (p *parser) termino() (condicion, error) {
+	var partes y
+	for {
+		factor, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		partes = append(partes, factor)
+		if !p.palabra("and") {
+			break
+		}
+	}
+	if len(partes) == 1 {
+		return partes[0], nil
+	}
+	return partes, nil
+}
+
+func (p *parser) factor() (condicion, error) {
+	if p.palabra("not") {
+		negada, err := p.factor()
+		return no{condicion: negada}, err
+	}
+	if p.palabra("(") {
+		agrupada, err := p.expresion()
+		if err != nil {
+			return nil, err
+		}
+		if !p.palabra(")") {
+			return nil, fmt.Errorf("%w: falta cerrar parentesis", ErrReglaNoValida)
+		}
+		return agrupada, nil
+	}
+
+	campo, ok := p.siguiente()
+	if !ok {
+		return nil, fmt.Errorf("%w: falta la condicion", ErrReglaNoValida)
+	}
+	nuevo, ok := campos[strings.ToLower(campo.texto)]
+	if !ok || campo.literal {
+		return nil, fmt.Errorf("%w: campo %q desconocido", ErrReglaNoValida, campo.texto)
+	}
+	operador, ok := p.siguiente()
+	if !ok || operador.literal || !esOperador(operador.texto) {
+		return nil, fmt.Errorf("%w: se espera un operador despues de %s", ErrReglaNoValida, campo.texto)
+	}
+	valor, ok := p.siguiente()
+	if !ok {
+		return nil, fmt.Errorf("%w: falta el valor de %s", ErrReglaNoValida, campo.texto)
+	}
+
+	comparar, ordenable, err := nuevo(valor.texto)
+	if err != nil {
+		return nil, fmt.Errorf("%w: valor %q no valido para %s", ErrReglaNoValida, valor.texto, campo.texto)
+	}
+	if !ordenable && operador.texto != "==" && operador.texto != "!=" {
+		return nil, fmt.Errorf("%w: %s solo admite == y !=", ErrReglaNoValida, campo.texto)
+	}
+	return comparacion{operador: operador.texto, comparar: comparar}, nil
+}
+
+func esOperador(texto string) bool {
+	switch texto {
+	case "==", "!=", ">", ">=", "<", "<=":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package riesgo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/stretchr/testify/assert"
+)
+
+func datos(moneda, monto string) Datos {
+	return Datos{
+		CodigoTransaccion: "ctr1",
+		Moneda:            moneda,
+		Monto:             dinero.DebeParsear(monto),
+		Emisor:            "Bancomer",
+		EmisorId:          1,
+		Receptor:          "Pedro Paramo",
+		ReceptorId:        2,
+		Fecha:             time.Date(2022, 4, 21, 23, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestParseRegla(t *testing.T) {
+	// Arrange
+	casos := []struct {
+		texto  string
+		datos  Datos
+		cumple bool
+	}{
+		{"monto > 10000 and moneda == USD -> review", datos("USD", "10000.01"), true},
+		{"monto > 10000 and moneda == USD -> review", datos("USD", "10000.00"), false},
+		{"monto > 10000 and moneda == usd -> review", datos("MXN", "20000.00"), false},
+		{"moneda == EUR or monto >= 500 and emisor_id == 1 -> flag", datos("MXN", "500.00"), true},
+		{"(moneda == EUR or monto >= 500) and emisor_id == 2 -> flag", datos("MXN", "500.00"), false},
+		{"not moneda == MXN -> flag", datos("MXN", "1.00"), false},
+		{`receptor == "pedro paramo" and hora >= 22 -> reject`, datos("MXN", "1.00"), true},
+		{"receptor_id != 2 or codigo_transaccion == ctr1 -> allow", datos("MXN", "1.00"), true},
+	}
+
+	for _, caso := range casos {
+		// Act
+		regla, err := ParseRegla(caso.texto)
+
+		// Assert
+		assert.Nil(t, err, caso.texto)
+		assert.Equal(t, caso.cumple, regla.condicion.cumple(caso.datos), caso.texto)
+	}
+}
+
+func TestParseReglaAccionYPuntos(t *testing.T) {
+	// Act
+	marcar, errMarcar := ParseRegla("usd_alto: monto > 10000 and moneda == USD -> review 60")
+	rechazar, errRechazar := ParseRegla("monto > 1000000 -> REJECT")
+
+	// Assert
+	assert.Nil(t, errMarcar)
+	assert.Equal(t, "usd_alto", marcar.Nombre)
+	assert.Equal(t, ACCION_MARCAR, marcar.Accion)
+	assert.Equal(t, 60, marcar.Puntos)
+	assert.Nil(t, errRechazar)
+	assert.Equal(t, ACCION_RECHAZAR, rechazar.Accion)
+	assert.Equal(t, 100, rechazar.Puntos)
+}
+
+func TestParseReglaNoValida(t *testing.T) {
+	textos := []string{
+		"monto > 10000",
+		"monto > 10000 -> block",
+		"monto > 10000 -> flag -5",
+		"monto > diez -> flag",
+		"moneda > USD -> flag",
+		"pais == MX -> flag",
+		"monto = 10 -> flag",
+		"(monto > 10 -> flag",
+		"monto > 10 moneda == USD -> flag",
+		`emisor == "Bancomer -> flag`,
+		"-> flag",
+	}
+
+	for _, texto := range textos {
+		// Act
+		_, err := ParseRegla(texto)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrReglaNoValida, texto)
+	}
+}
+
+func TestParseReglas(t *testing.T) {
+	// Arrange
+	archivo := "# reglas de prueba\n\nmonto > 10000 -> flag\nnocturna: hora >= 22 -> flag 20\n"
+
+	// Act
+	reglas, err := ParseReglas(strings.NewReader(archivo))
+	_, errLinea := ParseReglas(strings.NewReader("monto > 1 -> flag\nmonto > -> flag\n"))
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, reglas, 2)
+	assert.Equal(t, "linea_3", reglas[0].Nombre)
+	assert.Equal(t, "nocturna", reglas[1].Nombre)
+	assert.ErrorIs(t, errLinea, ErrReglaNoValida)
+	assert.Contains(t, errLinea.Error(), "linea 2")
+}
@@ -0,0 +1,34 @@
+package riesgo
+
+import (
+	"errors"
+	"os"
+)
+
+type Repository interface {
+	GetAll() ([]Regla, error)
+}
+
+// repository lee las reglas del archivo en cada consulta, de modo que los
+// cambios al archivo aplican sin reiniciar el servidor.
+type repository struct {
+	fileName string
+}
+
+func NewRepository(fileName string) Repository {
+	return &repository{fileName: fileName}
+}
+
+// GetAll regresa las reglas en el orden del archivo; si no existe no hay
+// reglas.
+func (r *repository) GetAll() ([]Regla, error) {
+	archivo, err := os.Open(r.fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("error al leer las reglas de riesgo")
+	}
+	defer archivo.Close()
+	return ParseReglas(archivo)
+}
@@ -0,0 +1,74 @@
+package riesgo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PUNTAJE_MAXIMO acota la suma de puntos de las reglas que se cumplen.
+const PUNTAJE_MAXIMO = 100
+
+var ErrRechazadaPorRiesgo = errors.New("la transaccion fue rechazada por las reglas de riesgo")
+
+// Evaluacion es el resultado de aplicar las reglas a una transaccion: la
+// accion mas restrictiva de las reglas que se cumplieron, la suma de sus
+// puntos y sus nombres.
+type Evaluacion struct {
+	Accion  Accion   `json:"accion" swaggertype:"string" example:"flag"`
+	Puntaje int      `json:"puntaje"`
+	Reglas  []string `json:"reglas,omitempty"`
+}
+
+// Rechazo es el error de una transaccion a la que una regla le asigno reject.
+type Rechazo struct {
+	Evaluacion
+}
+
+func (e *Rechazo) Error() string {
+	return fmt.Sprintf("%s: %s", ErrRechazadaPorRiesgo, strings.Join(e.Reglas, ", "))
+}
+
+func (e *Rechazo) Is(objetivo error) bool {
+	return objetivo == ErrRechazadaPorRiesgo
+}
+
+type Service interface {
+	Evaluar(datos Datos) (Evaluacion, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{repository: r}
+}
+
+// Evaluar aplica las reglas en el orden del archivo. Una regla allow que se
+// cumple detiene la evaluacion, de modo que las excepciones se escriben antes
+// que las reglas que marcan o rechazan.
+func (s *service) Evaluar(datos Datos) (Evaluacion, error) {
+	reglas, err := s.repository.GetAll()
+	if err != nil {
+		return Evaluacion{}, err
+	}
+	evaluacion := Evaluacion{Accion: ACCION_PERMITIR}
+	for _, regla := range reglas {
+		if !regla.condicion.cumple(datos) {
+			continue
+		}
+		evaluacion.Reglas = append(evaluacion.Reglas, regla.Nombre)
+		evaluacion.Puntaje += regla.Puntos
+		if severidad[regla.Accion] > severidad[evaluacion.Accion] {
+			evaluacion.Accion = regla.Accion
+		}
+		if regla.Accion == ACCION_PERMITIR {
+			break
+		}
+	}
+	if evaluacion.Puntaje > PUNTAJE_MAXIMO {
+		evaluacion.Puntaje = PUNTAJE_MAXIMO
+	}
+	return evaluacion, nil
+}
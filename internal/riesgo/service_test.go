@@ -0,0 +1,71 @@
+package riesgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nuevoService(t *testing.T, reglas string) Service {
+	fileName := filepath.Join(t.TempDir(), "reglas.txt")
+	if err := os.WriteFile(fileName, []byte(reglas), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return NewService(NewRepository(fileName))
+}
+
+func TestServiceEvaluar(t *testing.T) {
+	// Arrange
+	service := nuevoService(t, `
+usd_alto: monto > 10000 and moneda == USD -> flag 60
+nocturna: hora >= 22 -> flag 30
+enorme: monto > 1000000 -> reject
+`)
+
+	// Act
+	sinReglas, err := service.Evaluar(datos("MXN", "100.00"))
+	marcada, _ := service.Evaluar(datos("USD", "20000.00"))
+	rechazada, _ := service.Evaluar(datos("USD", "2000000.00"))
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, Evaluacion{Accion: ACCION_MARCAR, Puntaje: 30, Reglas: []string{"nocturna"}}, sinReglas)
+	assert.Equal(t, Evaluacion{Accion: ACCION_MARCAR, Puntaje: 90, Reglas: []string{"usd_alto", "nocturna"}}, marcada)
+	assert.Equal(t, Evaluacion{Accion: ACCION_RECHAZAR, Puntaje: PUNTAJE_MAXIMO, Reglas: []string{"usd_alto", "nocturna", "enorme"}}, rechazada)
+}
+
+func TestServiceEvaluarPermitir(t *testing.T) {
+	// Arrange
+	service := nuevoService(t, `
+confiable: emisor_id == 1 -> allow
+monto > 100 -> reject
+`)
+	otroEmisor := datos("MXN", "500.00")
+	otroEmisor.EmisorId = 2
+
+	// Act
+	permitida, _ := service.Evaluar(datos("MXN", "500.00"))
+	rechazada, _ := service.Evaluar(otroEmisor)
+
+	// Assert
+	assert.Equal(t, Evaluacion{Accion: ACCION_PERMITIR, Reglas: []string{"confiable"}}, permitida)
+	assert.Equal(t, ACCION_RECHAZAR, rechazada.Accion)
+	assert.Equal(t, []string{"linea_3"}, rechazada.Reglas)
+}
+
+func TestServiceEvaluarArchivo(t *testing.T) {
+	// Arrange
+	sinArchivo := NewService(NewRepository(filepath.Join(t.TempDir(), "reglas.txt")))
+	noValido := nuevoService(t, "monto >> 10 -> flag\n")
+
+	// Act
+	evaluacion, err := sinArchivo.Evaluar(datos("MXN", "100.00"))
+	_, errNoValido := noValido.Evaluar(datos("MXN", "100.00"))
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, Evaluacion{Accion: ACCION_PERMITIR}, evaluacion)
+	assert.ErrorIs(t, errNoValido, ErrReglaNoValida)
+}
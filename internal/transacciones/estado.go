@@ -0,0 +1,59 @@
+package transacciones
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Estado es la etapa del ciclo de vida de una transaccion.
+type Estado string
+
+const (
+	ESTADO_PENDIENTE  Estado = "pendiente"
+	ESTADO_AUTORIZADA Estado = "autorizada"
+	ESTADO_LIQUIDADA  Estado = "liquidada"
+	ESTADO_RECHAZADA  Estado = "rechazada"
+	ESTADO_REVERTIDA  Estado = "revertida"
+)
+
+var (
+	ErrEstadoNoValido        = errors.New("el estado no es valido")
+	ErrTransicionNoValida    = errors.New("la transicion de estado no es valida")
+	ErrTransaccionNoEditable = errors.New("solo se pueden modificar transacciones pendientes")
+)
+
+// transiciones indica los estados a los que se puede pasar desde cada estado.
+// rechazada y revertida son finales. Una liquidada solo llega a revertida con
+// StoreReversa, para que la devolucion quede registrada como transaccion, y
+// solo regresa a liquidada si se rechaza una de sus reversas.
+var transiciones = map[Estado][]Estado{
+	ESTADO_PENDIENTE:  {ESTADO_AUTORIZADA, ESTADO_RECHAZADA},
+	ESTADO_AUTORIZADA: {ESTADO_LIQUIDADA, ESTADO_RECHAZADA},
+}
+
+// ParseEstado lee un estado sin distinguir mayusculas de minusculas.
+func ParseEstado(texto string) (Estado, error) {
+	estado := Estado(strings.ToLower(strings.TrimSpace(texto)))
+	switch estado {
+	case ESTADO_PENDIENTE, ESTADO_AUTORIZADA, ESTADO_LIQUIDADA, ESTADO_RECHAZADA, ESTADO_REVERTIDA:
+		return estado, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrEstadoNoValido, texto)
+}
+
+// MueveSaldo indica si la transaccion cuenta para los saldos: lo hace desde
+// que se da de alta, salvo que se rechace.
+func (t Transaccion) MueveSaldo() bool {
+	return t.Estado != ESTADO_RECHAZADA
+}
+
+// PuedePasarA indica si la maquina de estados permite ir de e a destino.
+func (e Estado) PuedePasarA(destino Estado) bool {
+	for _, permitido := range transiciones[e] {
+		if permitido == destino {
+			return true
+		}
+	}
+	return false
+}
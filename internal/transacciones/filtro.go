@@ -0,0 +1,99 @@
+package transacciones
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+// Coincidencia indica como se compara un filtro de texto.
+type Coincidencia string
+
+const (
+	COINCIDENCIA_EXACTA   Coincidencia = "exacta"
+	COINCIDENCIA_PREFIJO  Coincidencia = "prefijo"
+	COINCIDENCIA_CONTIENE Coincidencia = "contiene"
+)
+
+var ErrFiltroNoValido = errors.New("el filtro no es valido")
+
+// ParseCoincidencia lee el modo de comparacion de un filtro de texto; vacio
+// equivale a una coincidencia exacta.
+func ParseCoincidencia(texto string) (Coincidencia, error) {
+	switch coincidencia := Coincidencia(strings.ToLower(strings.TrimSpace(texto))); coincidencia {
+	case "":
+		return COINCIDENCIA_EXACTA, nil
+	case COINCIDENCIA_EXACTA, COINCIDENCIA_PREFIJO, COINCIDENCIA_CONTIENE:
+		return coincidencia, nil
+	}
+	return "", fmt.Errorf("%w: la coincidencia %q no es exacta, prefijo ni contiene", ErrFiltroNoValido, texto)
+}
+
+// Texto filtra un campo de texto. Las coincidencias por prefijo y por
+// contenido no distinguen mayusculas de minusculas.
+type Texto struct {
+	Valor        string
+	Coincidencia Coincidencia
+}
+
+func (t Texto) coincide(valor string) bool {
+	switch t.Coincidencia {
+	case COINCIDENCIA_PREFIJO:
+		return strings.HasPrefix(strings.ToLower(valor), strings.ToLower(t.Valor))
+	case COINCIDENCIA_CONTIENE:
+		return strings.Contains(strings.ToLower(valor), strings.ToLower(t.Valor))
+	}
+	return valor == t.Valor
+}
+
+// Filtro selecciona transacciones. Solo se aplican los campos presentes, los
+// punteros distintos de nil y las listas no vacias, de modo que tambien se
+// puede buscar un id o un monto igual a cero. Los rangos son inclusivos.
+type Filtro struct {
+	Id                *int
+	CodigoTransaccion *string
+	Monedas           []string
+	Monto             *dinero.Monto
+	MontoMin          *dinero.Monto
+	MontoMax          *dinero.Monto
+	Emisor            *Texto
+	Receptor          *string
+	FechaDesde        *time.Time
+	FechaHasta        *time.Time
+	Estados           []Estado
+}
+
+func (f Filtro) Coincide(transaccion Transaccion) bool {
+	return (f.Id == nil || transaccion.Id == *f.Id) &&
+		(f.CodigoTransaccion == nil || transaccion.CodigoTransaccion == *f.CodigoTransaccion) &&
+		(len(f.Monedas) == INT_ZERO || contiene(f.Monedas, transaccion.Moneda)) &&
+		(f.Monto == nil || transaccion.Monto.Igual(*f.Monto)) &&
+		(f.MontoMin == nil || transaccion.Monto.Cmp(*f.MontoMin) >= 0) &&
+		(f.MontoMax == nil || transaccion.Monto.Cmp(*f.MontoMax) <= 0) &&
+		(f.Emisor == nil || f.Emisor.coincide(transaccion.Emisor)) &&
+		(f.Receptor == nil || transaccion.Receptor == *f.Receptor) &&
+		(f.FechaDesde == nil || !transaccion.FechaTransaccion.Before(*f.FechaDesde)) &&
+		(f.FechaHasta == nil || !transaccion.FechaTransaccion.After(*f.FechaHasta)) &&
+		(len(f.Estados) == INT_ZERO || contieneEstado(f.Estados, transaccion.Estado))
+}
+
+func contiene(valores []string, valor string) bool {
+	for _, v := range valores {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
+
+func contieneEstado(estados []Estado, estado Estado) bool {
+	for _, e := range estados {
+		if e == estado {
+			return true
+		}
+	}
+	return false
+}
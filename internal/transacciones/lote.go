@@ -0,0 +1,107 @@
+package transacciones
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+var ErrLoteCancelado = errors.New("la transaccion no se guardo porque otra del lote fue rechazada")
+
+// Nueva son los datos de una transaccion por dar de alta en un lote.
+type Nueva struct {
+	CodigoTransaccion string
+	Moneda            string
+	Monto             dinero.Monto
+	EmisorId          int
+	ReceptorId        int
+	FechaTransaccion  time.Time
+}
+
+// ResultadoLote es el resultado de dar de alta una transaccion del lote; Err
+// es nil si quedo guardada.
+type ResultadoLote struct {
+	Transaccion Transaccion
+	Err         error
+}
+
+// StoreLote da de alta las transacciones con las mismas reglas que Store. Todas
+// las filas se revisan en orden antes de guardar ninguna, por lo que los
+// limites consideran las filas anteriores del lote que ya pasaron. Las
+// aceptadas se guardan en una sola escritura y solo entonces se notifican. Si
+// completo es verdadero y alguna falla, no se guarda ninguna y las que pasaron
+// quedan canceladas. El error indica que no se logro escribir el lote; los
+// resultados solo traen los rechazos de cada fila.
+func (s *service) StoreLote(nuevas []Nueva, completo bool) ([]ResultadoLote, error) {
+	s.escritura.Lock()
+	defer s.escritura.Unlock()
+
+	resultados := make([]ResultadoLote, len(nuevas))
+	var lote []Transaccion
+	var posiciones []int
+	for index, nueva := range nuevas {
+		transaccion, err := s.validarNueva(nueva, lote)
+		if err != nil {
+			resultados[index].Err = err
+			continue
+		}
+		lote = append(lote, transaccion)
+		posiciones = append(posiciones, index)
+	}
+	if completo && len(lote) < len(nuevas) {
+		for _, posicion := range posiciones {
+			resultados[posicion].Err = ErrLoteCancelado
+		}
+		return resultados, nil
+	}
+	if len(lote) == INT_ZERO {
+		return resultados, nil
+	}
+
+	guardadas, err := s.repository.StoreLote(lote)
+	if err != nil {
+		return nil, err
+	}
+	for index, transaccion := range guardadas {
+		resultados[posiciones[index]].Transaccion = transaccion
+		s.notificar(transaccion)
+	}
+	return resultados, nil
+}
+
+// validarNueva revisa una fila del lote como lo haria Store y regresa la
+// transaccion por guardar con su evaluacion de riesgo. lote son las filas
+// anteriores ya aceptadas, que cuentan para los limites y cuyos codigos ya no
+// pueden usarse.
+func (s *service) validarNueva(nueva Nueva, lote []Transaccion) (Transaccion, error) {
+	if nueva.FechaTransaccion.IsZero() {
+		return Transaccion{}, ErrFechaNoValida
+	}
+	moneda, monto, err := s.prepararMonto(nueva.Moneda, nueva.Monto)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	emisor, receptor, err := s.resolverPartes(nueva.EmisorId, nueva.ReceptorId)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	if _, err := s.repository.GetByCodigo(nueva.CodigoTransaccion); err == nil {
+		return Transaccion{}, ErrCodigoDuplicado
+	}
+	for _, anterior := range lote {
+		if anterior.CodigoTransaccion == nueva.CodigoTransaccion {
+			return Transaccion{}, ErrCodigoDuplicado
+		}
+	}
+	if err := s.verificarLimites(INT_ZERO, lote, emisor.Id, moneda, monto, nueva.FechaTransaccion); err != nil {
+		return Transaccion{}, err
+	}
+
+	transaccion := Transaccion{CodigoTransaccion: nueva.CodigoTransaccion, Moneda: moneda, Monto: monto, Emisor: emisor.Nombre,
+		EmisorId: emisor.Id, Receptor: receptor.Nombre, ReceptorId: receptor.Id, FechaTransaccion: nueva.FechaTransaccion}
+	if transaccion.Riesgo, err = s.evaluarRiesgo(transaccion); err != nil {
+		return Transaccion{}, err
+	}
+	return transaccion, nil
+}
@@ -0,0 +1,54 @@
+package transacciones
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+// ESCALA_POR_DEFECTO es el numero de decimales que se usa al migrar montos de
+// monedas que no estan en el catalogo.
+const ESCALA_POR_DEFECTO = 2
+
+var ErrMontoNoValido = errors.New("el monto no es valido para la moneda")
+
+var catalogoMonedas = monedas.NewRepository()
+
+// normalizarMonto expresa el monto con los decimales de su moneda, de modo que
+// "100" en MXN se guarda como "100.00". Falla si el monto trae mas decimales
+// de los que admite la moneda.
+func normalizarMonto(monto dinero.Monto, moneda monedas.Moneda) (dinero.Monto, error) {
+	normalizado, err := monto.Escalar(moneda.Decimales)
+	if err != nil {
+		return dinero.Monto{}, fmt.Errorf("%w: la moneda %s admite %d decimales", ErrMontoNoValido, moneda.Codigo, moneda.Decimales)
+	}
+	return normalizado, nil
+}
+
+// escalaMoneda regresa los decimales de la moneda segun el catalogo, incluso
+// si ya no esta activa.
+func escalaMoneda(codigo string) int {
+	moneda, err := catalogoMonedas.Get(codigo)
+	if err != nil {
+		return ESCALA_POR_DEFECTO
+	}
+	return moneda.Decimales
+}
+
+// NormalizarMontos convierte los montos leidos de un archivo anterior, que los
+// guardaba como numeros flotantes, a los decimales de su moneda redondeando
+// al par mas cercano. Se usa para migrar archivos existentes.
+func NormalizarMontos(transacciones []Transaccion) ([]Transaccion, error) {
+	normalizadas := make([]Transaccion, len(transacciones))
+	for index, transaccion := range transacciones {
+		monto, err := transaccion.Monto.Redondear(escalaMoneda(transaccion.Moneda))
+		if err != nil {
+			return nil, fmt.Errorf("transaccion %d: %w", transaccion.Id, err)
+		}
+		transaccion.Monto = monto
+		normalizadas[index] = transaccion
+	}
+	return normalizadas, nil
+}
@@ -0,0 +1,273 @@
+package transacciones
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+// LIMITE_MAXIMO es el mayor numero de transacciones que se regresan por pagina.
+const LIMITE_MAXIMO = 1000
+
+var ErrConsultaNoValida = errors.New("la consulta no es valida")
+
+// Orden indica un campo por el cual ordenar.
+type Orden struct {
+	Campo       string
+	Descendente bool
+}
+
+// Consulta describe una pagina de transacciones. Un Limite de cero regresa
+// todas las que cumplen el filtro. Cursor, si se indica, es el next_cursor de
+// la pagina anterior y sustituye a Desplazamiento.
+type Consulta struct {
+	Filtro         Filtro
+	Orden          []Orden
+	Limite         int
+	Desplazamiento int
+	Cursor         string
+}
+
+// Pagina es el resultado de una Consulta. Total cuenta todas las transacciones
+// que cumplen el filtro y SiguienteCursor esta vacio en la ultima pagina.
+type Pagina struct {
+	Transacciones   []Transaccion
+	Total           int
+	SiguienteCursor string
+}
+
+// campoOrden describe como comparar un campo ordenable en memoria y en sql, y
+// como guardarlo en un cursor.
+type campoOrden struct {
+	columna  string
+	comparar func(a, b Transaccion) int
+	valor    func(t Transaccion) string
+	leer     func(texto string, t *Transaccion) error
+	valorSQL func(t Transaccion) interface{}
+}
+
+func compararTexto(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+func campoTexto(columna string, campo func(t *Transaccion) *string) campoOrden {
+	return campoOrden{
+		columna:  columna,
+		comparar: func(a, b Transaccion) int { return compararTexto(*campo(&a), *campo(&b)) },
+		valor:    func(t Transaccion) string { return *campo(&t) },
+		leer: func(texto string, t *Transaccion) error {
+			*campo(t) = texto
+			return nil
+		},
+		valorSQL: func(t Transaccion) interface{} { return *campo(&t) },
+	}
+}
+
+var camposOrden = map[string]campoOrden{
+	"id": {
+		columna: "id",
+		comparar: func(a, b Transaccion) int {
+			switch {
+			case a.Id < b.Id:
+				return -1
+			case a.Id > b.Id:
+				return 1
+			}
+			return 0
+		},
+		valor: func(t Transaccion) string { return strconv.Itoa(t.Id) },
+		leer: func(texto string, t *Transaccion) error {
+			var err error
+			t.Id, err = strconv.Atoi(texto)
+			return err
+		},
+		valorSQL: func(t Transaccion) interface{} { return t.Id },
+	},
+	"codigo_transaccion": campoTexto("codigo_transaccion", func(t *Transaccion) *string { return &t.CodigoTransaccion }),
+	"moneda":             campoTexto("moneda", func(t *Transaccion) *string { return &t.Moneda }),
+	"emisor":             campoTexto("emisor", func(t *Transaccion) *string { return &t.Emisor }),
+	"receptor":           campoTexto("receptor", func(t *Transaccion) *string { return &t.Receptor }),
+	"monto": {
+		columna:  "monto_diezmilesimas",
+		comparar: func(a, b Transaccion) int { return a.Monto.Cmp(b.Monto) },
+		valor:    func(t Transaccion) string { return t.Monto.String() },
+		leer: func(texto string, t *Transaccion) error {
+			var err error
+			t.Monto, err = dinero.Parse(texto)
+			return err
+		},
+		valorSQL: func(t Transaccion) interface{} { return montoComparable(t.Monto) },
+	},
+	"fecha_transaccion": {
+		columna: "fecha_transaccion_unix_nano",
+		comparar: func(a, b Transaccion) int {
+			switch {
+			case a.FechaTransaccion.Before(b.FechaTransaccion):
+				return -1
+			case a.FechaTransaccion.After(b.FechaTransaccion):
+				return 1
+			}
+			return 0
+		},
+		valor: func(t Transaccion) string { return t.FechaTransaccion.Format(time.RFC3339Nano) },
+		leer: func(texto string, t *Transaccion) error {
+			var err error
+			t.FechaTransaccion, err = time.Parse(time.RFC3339Nano, texto)
+			return err
+		},
+		valorSQL: func(t Transaccion) interface{} { return t.FechaTransaccion.UnixNano() },
+	},
+}
+
+// ParseOrden lee una lista de campos separados por coma; un "-" al inicio
+// indica orden descendente, p. ej. "-monto,fecha_transaccion".
+func ParseOrden(texto string) ([]Orden, error) {
+	var orden []Orden
+	for _, parte := range strings.Split(texto, ",") {
+		parte = strings.TrimSpace(parte)
+		if parte == "" {
+			continue
+		}
+		campo := Orden{Campo: strings.TrimPrefix(parte, "-"), Descendente: strings.HasPrefix(parte, "-")}
+		if _, ok := camposOrden[campo.Campo]; !ok {
+			return nil, fmt.Errorf("%w: no se puede ordenar por %q", ErrConsultaNoValida, campo.Campo)
+		}
+		orden = append(orden, campo)
+	}
+	return orden, nil
+}
+
+func formatearOrden(orden []Orden) string {
+	partes := make([]string, len(orden))
+	for index, campo := range orden {
+		partes[index] = campo.Campo
+		if campo.Descendente {
+			partes[index] = "-" + campo.Campo
+		}
+	}
+	return strings.Join(partes, ",")
+}
+
+// ordenCompleto agrega el id como ultimo criterio para que el orden sea total
+// y un cursor identifique una posicion unica.
+func (c Consulta) ordenCompleto() []Orden {
+	orden := make([]Orden, 0, len(c.Orden)+1)
+	for _, campo := range c.Orden {
+		orden = append(orden, campo)
+		if campo.Campo == "id" {
+			return orden
+		}
+	}
+	return append(orden, Orden{Campo: "id"})
+}
+
+// validar revisa los limites de la consulta y recorta Limite a LIMITE_MAXIMO.
+func (c Consulta) validar() (Consulta, error) {
+	if c.Limite < INT_ZERO || c.Desplazamiento < INT_ZERO {
+		return c, fmt.Errorf("%w: limit y offset no pueden ser negativos", ErrConsultaNoValida)
+	}
+	if c.Cursor != STRING_EMPTY && c.Desplazamiento > INT_ZERO {
+		return c, fmt.Errorf("%w: no se pueden combinar cursor y offset", ErrConsultaNoValida)
+	}
+	if c.Limite > LIMITE_MAXIMO {
+		c.Limite = LIMITE_MAXIMO
+	}
+	return c, nil
+}
+
+func compararEnOrden(a, b Transaccion, orden []Orden) int {
+	for _, campo := range orden {
+		comparacion := camposOrden[campo.Campo].comparar(a, b)
+		if campo.Descendente {
+			comparacion = -comparacion
+		}
+		if comparacion != 0 {
+			return comparacion
+		}
+	}
+	return 0
+}
+
+type cursor struct {
+	Orden   string   `json:"o"`
+	Valores []string `json:"v"`
+}
+
+// codificarCursor guarda los valores de orden de la transaccion, de modo que
+// la siguiente pagina empiece despues de ella aunque se haya eliminado.
+func codificarCursor(orden []Orden, transaccion Transaccion) string {
+	c := cursor{Orden: formatearOrden(orden)}
+	for _, campo := range orden {
+		c.Valores = append(c.Valores, camposOrden[campo.Campo].valor(transaccion))
+	}
+	contenido, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(contenido)
+}
+
+// decodificarCursor regresa una transaccion con los valores de orden del
+// cursor. Falla si el cursor se genero con otro orden.
+func decodificarCursor(texto string, orden []Orden) (Transaccion, error) {
+	var c cursor
+	contenido, err := base64.RawURLEncoding.DecodeString(texto)
+	if err == nil {
+		err = json.Unmarshal(contenido, &c)
+	}
+	if err != nil || c.Orden != formatearOrden(orden) || len(c.Valores) != len(orden) {
+		return Transaccion{}, fmt.Errorf("%w: el cursor no es valido para este orden", ErrConsultaNoValida)
+	}
+
+	var transaccion Transaccion
+	for index, campo := range orden {
+		if err := camposOrden[campo.Campo].leer(c.Valores[index], &transaccion); err != nil {
+			return Transaccion{}, fmt.Errorf("%w: el cursor no es valido", ErrConsultaNoValida)
+		}
+	}
+	return transaccion, nil
+}
+
+// paginar aplica la consulta sobre una lista completa en memoria.
+func paginar(transacciones []Transaccion, consulta Consulta) (Pagina, error) {
+	orden := consulta.ordenCompleto()
+
+	filtradas := make([]Transaccion, 0, len(transacciones))
+	for _, transaccion := range transacciones {
+		if consulta.Filtro.Coincide(transaccion) {
+			filtradas = append(filtradas, transaccion)
+		}
+	}
+	sort.SliceStable(filtradas, func(i, j int) bool {
+		return compararEnOrden(filtradas[i], filtradas[j], orden) < 0
+	})
+
+	inicio := consulta.Desplazamiento
+	if consulta.Cursor != STRING_EMPTY {
+		ultima, err := decodificarCursor(consulta.Cursor, orden)
+		if err != nil {
+			return Pagina{}, err
+		}
+		inicio = sort.Search(len(filtradas), func(i int) bool {
+			return compararEnOrden(filtradas[i], ultima, orden) > 0
+		})
+	}
+	if inicio > len(filtradas) {
+		inicio = len(filtradas)
+	}
+
+	fin := len(filtradas)
+	if consulta.Limite > INT_ZERO && inicio+consulta.Limite < fin {
+		fin = inicio + consulta.Limite
+	}
+
+	pagina := Pagina{Transacciones: filtradas[inicio:fin], Total: len(filtradas)}
+	if fin < len(filtradas) && fin > inicio {
+		pagina.SiguienteCursor = codificarCursor(orden, filtradas[fin-1])
+	}
+	return pagina, nil
+}
@@ -2,173 +2,647 @@ package transacciones
 
 import (
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
 	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
 )
 
 type Transaccion struct {
-	Id                int     `json:"id"`
-	CodigoTransaccion string  `json:"codigo_transaccion"`
-	Moneda            string  `json:"moneda"`
-	Monto             float64 `json:"monto"`
-	Emisor            string  `json:"emisor"`
-	Receptor          string  `json:"receptor"`
-	FechaTransaccion  string  `json:"fecha_transaccion"`
+	Id                int          `json:"id"`
+	CodigoTransaccion string       `json:"codigo_transaccion"`
+	Moneda            string       `json:"moneda"`
+	Monto             dinero.Monto `json:"monto"`
+	Emisor            string       `json:"emisor"`
+	EmisorId          int          `json:"emisor_id,omitempty"`
+	Receptor          string       `json:"receptor"`
+	ReceptorId        int          `json:"receptor_id,omitempty"`
+	FechaTransaccion  time.Time    `json:"fecha_transaccion"`
+	Estado            Estado       `json:"estado" swaggertype:"string" example:"pendiente"`
+	Referencia        *int         `json:"referencia,omitempty"` // id de la transaccion que esta revierte
+	Riesgo            *Riesgo      `json:"riesgo,omitempty"`
 }
 
-var transaccionesList []Transaccion
+// EmitidaPor indica si la parte emitio la transaccion, segun partes.Parte.Coincide.
+func (t Transaccion) EmitidaPor(parte partes.Parte) bool {
+	return parte.Coincide(t.EmisorId, t.Emisor)
+}
+
+// RecibidaPor indica si la parte recibio la transaccion, segun partes.Parte.Coincide.
+func (t Transaccion) RecibidaPor(parte partes.Parte) bool {
+	return parte.Coincide(t.ReceptorId, t.Receptor)
+}
+
+var (
+	ErrCodigoDuplicado = errors.New("ya existe una transaccion con el mismo codigo_transaccion")
+	ErrSinResultados   = errors.New("ninguna transaccion fue encontrada")
+)
 
 type Repository interface {
 	GetAll() ([]Transaccion, error)
-	Store(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error)
-	Update(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error)
-	Patch(id int, codigoTransaccion string, monto float64) (Transaccion, error)
+	GetTransaccionFiltrada(filtro Filtro) ([]Transaccion, error)
+	GetByCodigo(codigoTransaccion string) (Transaccion, error)
+	Listar(consulta Consulta) (Pagina, error)
+	// Store, Update y Patch guardan la evaluacion de riesgo en la misma
+	// escritura que los datos; en Update y Patch un riesgo nil conserva la
+	// evaluacion anterior.
+	Store(codigoTransaccion, moneda string, monto dinero.Monto, emisor, receptor partes.Parte, fechaTransaccion time.Time, riesgo *Riesgo) (Transaccion, error)
+	Update(id int, codigoTransaccion, moneda string, monto dinero.Monto, emisor, receptor partes.Parte, fechaTransaccion time.Time, riesgo *Riesgo) (Transaccion, error)
+	Patch(id int, codigoTransaccion string, monto dinero.Monto, riesgo *Riesgo) (Transaccion, error)
+	StoreLote(nuevas []Transaccion) ([]Transaccion, error)
+	CambiarEstado(id int, actual, nuevo Estado) (Transaccion, error)
+	CambiarRiesgo(id int, riesgo *Riesgo) (Transaccion, error)
+	StoreReversa(id int, reversa Reversa) (Transaccion, error)
+	GetReversas(id int) ([]Transaccion, error)
 	Delete(id int) error
 	LastID() (int, error)
 }
 
+// repository guarda la ultima version leida del store. Cada operacion la
+// vuelve a leer con mu tomado, y las que modifican ademas bloquean el store,
+// por lo que las peticiones concurrentes se aplican una a la vez. Si el store
+// es versionado la lectura se omite cuando su contenido no cambio, y si
+// guarda por registro solo se escribe la transaccion afectada. porCodigo
+// indexa la posicion de cada codigo_transaccion y solo se reconstruye cuando
+// cambia la lista, de modo que con un store versionado GetByCodigo no recorre
+// las transacciones.
 type repository struct {
-	db store.Store
+	db            store.Store
+	mu            sync.Mutex
+	transacciones []Transaccion
+	porCodigo     map[string]int
+	cargado       bool
+	version       uint64
 }
 
 func NewRepository(db store.Store) Repository {
 	return &repository{db: db}
 }
 
+// cargar lee el store sobre el estado del repositorio. Debe llamarse con mu tomado.
+func (r *repository) cargar() error {
+	versioner, versionado := r.db.(store.Versioner)
+	var version uint64
+	if versionado {
+		v, err := versioner.Version()
+		if err != nil {
+			return errors.New("error al leer del store")
+		}
+		if r.cargado && v == r.version {
+			return nil
+		}
+		version = v
+	}
+
+	// Si el archivo aun no existe el repositorio empieza vacio.
+	var transacciones []Transaccion
+	if err := r.db.Read(&transacciones); err != nil && !errors.Is(err, store.ErrFileNotFound) {
+		r.cargado = false
+		return errors.New("error al leer del store")
+	}
+	// Las transacciones guardadas antes de existir los estados quedan pendientes.
+	for index := range transacciones {
+		if transacciones[index].Estado == STRING_EMPTY {
+			transacciones[index].Estado = ESTADO_PENDIENTE
+		}
+	}
+	r.transacciones = transacciones
+	r.indexar()
+	r.version = version
+	r.cargado = versionado
+	return nil
+}
+
+// indexar reconstruye porCodigo. Si el store ya traia codigos repetidos se
+// indexa el primero.
+func (r *repository) indexar() {
+	r.porCodigo = make(map[string]int, len(r.transacciones))
+	for index, transaccion := range r.transacciones {
+		if _, ok := r.porCodigo[transaccion.CodigoTransaccion]; !ok {
+			r.porCodigo[transaccion.CodigoTransaccion] = index
+		}
+	}
+}
+
+// codigoOcupado indica si otra transaccion distinta de id ya usa el codigo.
+func (r *repository) codigoOcupado(codigoTransaccion string, id int) bool {
+	index, ok := r.porCodigo[codigoTransaccion]
+	return ok && r.transacciones[index].Id != id
+}
+
+// guardar persiste el cambio de las transacciones indicadas; transacciones es
+// la lista completa ya modificada. Si la escritura falla el estado se descarta
+// para que la siguiente operacion lo lea de nuevo.
+func (r *repository) guardar(transacciones []Transaccion, cambiadas ...Transaccion) error {
+	var err error
+	if recordStore, ok := r.db.(store.RecordStore); ok {
+		for _, transaccion := range cambiadas {
+			if err = recordStore.Put(transaccion.Id, transaccion); err != nil {
+				break
+			}
+		}
+	} else {
+		err = r.db.Write(transacciones)
+	}
+	return r.confirmar(transacciones, err)
+}
+
+// eliminar persiste la baja de la transaccion indicada; transacciones es la
+// lista completa sin ella.
+func (r *repository) eliminar(transacciones []Transaccion, id int) error {
+	var err error
+	if recordStore, ok := r.db.(store.RecordStore); ok {
+		err = recordStore.Delete(id)
+	} else {
+		err = r.db.Write(transacciones)
+	}
+	return r.confirmar(transacciones, err)
+}
+
+func (r *repository) confirmar(transacciones []Transaccion, err error) error {
+	if err != nil {
+		r.cargado = false
+		r.transacciones = nil
+		r.porCodigo = nil
+		return err
+	}
+	r.transacciones = transacciones
+	r.indexar()
+	if versioner, ok := r.db.(store.Versioner); ok {
+		if version, err := versioner.Version(); err == nil {
+			r.version = version
+			return nil
+		}
+		r.cargado = false
+	}
+	return nil
+}
+
+// indice regresa la posicion de la transaccion id, o -1 si no existe.
+func (r *repository) indice(id int) int {
+	for index, transaccion := range r.transacciones {
+		if transaccion.Id == id {
+			return index
+		}
+	}
+	return -1
+}
+
+// reemplazar persiste la transaccion en la posicion index sobre una copia de
+// la lista, de modo que el estado del repositorio solo cambia si la escritura
+// tuvo exito.
+func (r *repository) reemplazar(index int, transaccion Transaccion) error {
+	transacciones := r.copia()
+	transacciones[index] = transaccion
+	return r.guardar(transacciones, transaccion)
+}
+
+// copia regresa una lista que el llamador puede modificar sin afectar al repositorio.
+func (r *repository) copia() []Transaccion {
+	transacciones := make([]Transaccion, len(r.transacciones))
+	copy(transacciones, r.transacciones)
+	return transacciones
+}
+
+func (r *repository) ultimoID() int {
+	var maxId int
+	for _, transaccion := range r.transacciones {
+		if maxId < transaccion.Id {
+			maxId = transaccion.Id
+		}
+	}
+	return maxId
+}
+
 func (r *repository) GetAll() ([]Transaccion, error) {
-	if err := r.db.Read(&transaccionesList); err != nil {
-		return []Transaccion{}, errors.New("error al leer del store")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.cargar(); err != nil {
+		return []Transaccion{}, err
+	}
+
+	if len(r.transacciones) == INT_ZERO {
+		return []Transaccion{}, ErrSinResultados
+	}
+
+	return r.copia(), nil
+}
+
+func (r *repository) GetTransaccionFiltrada(filtro Filtro) ([]Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.cargar(); err != nil {
+		return []Transaccion{}, err
+	}
+
+	var transaccionesFiltradas []Transaccion
+
+	for _, transaccion := range r.transacciones {
+		if filtro.Coincide(transaccion) {
+			transaccionesFiltradas = append(transaccionesFiltradas, transaccion)
+		}
 	}
 
-	if len(transaccionesList) == INT_ZERO {
-		return []Transaccion{}, errors.New("ninguna transaccion fue encontrada")
+	if len(transaccionesFiltradas) == INT_ZERO {
+		return []Transaccion{}, ErrSinResultados
 	}
 
-	return transaccionesList, nil
+	return transaccionesFiltradas, nil
 }
 
-func (r *repository) Store(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error) {
-	if err := r.db.Read(&transaccionesList); err != nil {
-		return Transaccion{}, errors.New("error al leer del store")
+func (r *repository) GetByCodigo(codigoTransaccion string) (Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.cargar(); err != nil {
+		return Transaccion{}, err
+	}
+
+	index, ok := r.porCodigo[codigoTransaccion]
+	if !ok {
+		return Transaccion{}, errors.New("no se encontro la transaccion")
+	}
+	return r.transacciones[index], nil
+}
+
+func (r *repository) Listar(consulta Consulta) (Pagina, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.cargar(); err != nil {
+		return Pagina{}, err
+	}
+
+	pagina, err := paginar(r.transacciones, consulta)
+	if err != nil {
+		return Pagina{}, err
+	}
+
+	if pagina.Total == INT_ZERO {
+		return Pagina{}, ErrSinResultados
+	}
+
+	return pagina, nil
+}
+
+func (r *repository) Store(codigoTransaccion, moneda string, monto dinero.Monto, emisor, receptor partes.Parte, fechaTransaccion time.Time, riesgo *Riesgo) (Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	defer unlock()
+
+	if err := r.cargar(); err != nil {
+		return Transaccion{}, err
+	}
+
+	if r.codigoOcupado(codigoTransaccion, INT_ZERO) {
+		return Transaccion{}, ErrCodigoDuplicado
 	}
 
 	transaccion := Transaccion{
-		Id:                id,
+		Id:                r.ultimoID() + 1,
 		CodigoTransaccion: codigoTransaccion,
 		Moneda:            moneda,
 		Monto:             monto,
-		Emisor:            emisor,
-		Receptor:          receptor,
+		Emisor:            emisor.Nombre,
+		EmisorId:          emisor.Id,
+		Receptor:          receptor.Nombre,
+		ReceptorId:        receptor.Id,
 		FechaTransaccion:  fechaTransaccion,
+		Estado:            ESTADO_PENDIENTE,
+		Riesgo:            riesgo,
 	}
 
-	transaccionesList = append(transaccionesList, transaccion)
-
-	if err := r.db.Write(transaccionesList); err != nil {
+	if err := r.guardar(append(r.copia(), transaccion), transaccion); err != nil {
 		return Transaccion{}, err
 	}
 
 	return transaccion, nil
 }
 
-func (r *repository) Update(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error) {
-	if err := r.db.Read(&transaccionesList); err != nil {
-		return Transaccion{}, errors.New("error al leer del store")
+// StoreLote da de alta las transacciones en una sola escritura, con ids
+// consecutivos y en estado pendiente. Si el codigo de alguna ya esta ocupado
+// no se guarda ninguna. Aun con un store por registro se reescribe la lista
+// completa para que el lote no quede a medias.
+func (r *repository) StoreLote(nuevas []Transaccion) ([]Transaccion, error) {
+	if len(nuevas) == INT_ZERO {
+		return []Transaccion{}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := r.cargar(); err != nil {
+		return nil, err
+	}
+
+	guardadas := make([]Transaccion, len(nuevas))
+	codigos := make(map[string]bool, len(nuevas))
+	id := r.ultimoID()
+	for index, nueva := range nuevas {
+		if r.codigoOcupado(nueva.CodigoTransaccion, INT_ZERO) || codigos[nueva.CodigoTransaccion] {
+			return nil, fmt.Errorf("%w: %s", ErrCodigoDuplicado, nueva.CodigoTransaccion)
+		}
+		codigos[nueva.CodigoTransaccion] = true
+		id++
+		nueva.Id, nueva.Estado, nueva.Referencia = id, ESTADO_PENDIENTE, nil
+		guardadas[index] = nueva
+	}
+
+	transacciones := append(r.copia(), guardadas...)
+	if err := r.confirmar(transacciones, r.db.Write(transacciones)); err != nil {
+		return nil, err
+	}
+	return guardadas, nil
+}
+
+func (r *repository) Update(id int, codigoTransaccion, moneda string, monto dinero.Monto, emisor, receptor partes.Parte, fechaTransaccion time.Time, riesgo *Riesgo) (Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	defer unlock()
+
+	if err := r.cargar(); err != nil {
+		return Transaccion{}, err
+	}
+	if r.codigoOcupado(codigoTransaccion, id) {
+		return Transaccion{}, ErrCodigoDuplicado
 	}
 	transaccionUpdated := Transaccion{
 		Id:                id,
 		CodigoTransaccion: codigoTransaccion,
 		Moneda:            moneda,
 		Monto:             monto,
-		Emisor:            emisor,
-		Receptor:          receptor,
+		Emisor:            emisor.Nombre,
+		EmisorId:          emisor.Id,
+		Receptor:          receptor.Nombre,
+		ReceptorId:        receptor.Id,
 		FechaTransaccion:  fechaTransaccion,
+		Estado:            ESTADO_PENDIENTE,
+		Riesgo:            riesgo,
+	}
+
+	index := r.indice(id)
+	if index < INT_ZERO {
+		return Transaccion{}, errors.New("no se encontro la transaccion a actualizar")
+	}
+	if r.transacciones[index].Referencia != nil {
+		return Transaccion{}, ErrReversaNoEditable
+	}
+	if r.transacciones[index].Estado != ESTADO_PENDIENTE {
+		return Transaccion{}, ErrTransaccionNoEditable
+	}
+	if riesgo == nil {
+		transaccionUpdated.Riesgo = r.transacciones[index].Riesgo
 	}
 
-	var wasUpdated bool //Elegi con boolean en lugar de directo si no incrementaría la complejidad ciclomática por el writeRepository
+	if err := r.reemplazar(index, transaccionUpdated); err != nil {
+		return Transaccion{}, err
+	}
 
-	for index, transaccion := range transaccionesList {
-		if transaccion.Id == transaccionUpdated.Id {
-			transaccionesList[index] = transaccionUpdated
-			wasUpdated = true
-		}
+	return transaccionUpdated, nil
+}
+
+func (r *repository) Patch(id int, codigoTransaccion string, monto dinero.Monto, riesgo *Riesgo) (Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return Transaccion{}, err
 	}
+	defer unlock()
 
-	if !wasUpdated {
+	if err := r.cargar(); err != nil {
+		return Transaccion{}, err
+	}
+	if r.codigoOcupado(codigoTransaccion, id) {
+		return Transaccion{}, ErrCodigoDuplicado
+	}
+	index := r.indice(id)
+	if index < INT_ZERO {
 		return Transaccion{}, errors.New("no se encontro la transaccion a actualizar")
 	}
+	transaccionUpdated := r.transacciones[index]
+	if transaccionUpdated.Referencia != nil {
+		return Transaccion{}, ErrReversaNoEditable
+	}
+	if transaccionUpdated.Estado != ESTADO_PENDIENTE {
+		return Transaccion{}, ErrTransaccionNoEditable
+	}
+	transaccionUpdated.CodigoTransaccion = codigoTransaccion
+	transaccionUpdated.Monto = monto
+	if riesgo != nil {
+		transaccionUpdated.Riesgo = riesgo
+	}
 
-	if err := r.db.Write(transaccionesList); err != nil {
+	if err := r.reemplazar(index, transaccionUpdated); err != nil {
 		return Transaccion{}, err
 	}
 
 	return transaccionUpdated, nil
 }
 
-func (r *repository) Patch(id int, codigoTransaccion string, monto float64) (Transaccion, error) {
-	if err := r.db.Read(&transaccionesList); err != nil {
-		return Transaccion{}, errors.New("error al leer del store")
+// CambiarEstado pasa la transaccion a nuevo solo si sigue en actual, de modo
+// que dos transiciones concurrentes no se apliquen sobre el mismo estado. Si
+// se rechaza una reversa de una original revertida, la original regresa a
+// liquidada en la misma escritura porque ya no esta revertida por completo.
+func (r *repository) CambiarEstado(id int, actual, nuevo Estado) (Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return Transaccion{}, err
 	}
-	var wasUpdated bool
-	var transaccionUpdated Transaccion
+	defer unlock()
 
-	for index, transaccion := range transaccionesList {
-		if transaccion.Id == id {
-			transaccion.CodigoTransaccion = codigoTransaccion
-			transaccion.Monto = monto
-			transaccionUpdated = transaccion
-			transaccionesList[index] = transaccion
-			wasUpdated = true
+	if err := r.cargar(); err != nil {
+		return Transaccion{}, err
+	}
+
+	index := r.indice(id)
+	if index < INT_ZERO {
+		return Transaccion{}, errors.New("no se encontro la transaccion")
+	}
+	transaccion := r.transacciones[index]
+	if transaccion.Estado != actual {
+		return Transaccion{}, fmt.Errorf("%w: la transaccion esta %s", ErrTransicionNoValida, transaccion.Estado)
+	}
+	transaccion.Estado = nuevo
+
+	transacciones := r.copia()
+	transacciones[index] = transaccion
+	cambiadas := []Transaccion{transaccion}
+	if nuevo == ESTADO_RECHAZADA && transaccion.Referencia != nil {
+		indexOriginal := r.indice(*transaccion.Referencia)
+		if indexOriginal >= INT_ZERO && transacciones[indexOriginal].Estado == ESTADO_REVERTIDA {
+			original := transacciones[indexOriginal]
+			original.Estado = ESTADO_LIQUIDADA
+			transacciones[indexOriginal] = original
+			cambiadas = append(cambiadas, original)
 		}
 	}
 
-	if !wasUpdated {
-		return Transaccion{}, errors.New("no se encontro la transaccion a actualizar")
+	if err := r.guardar(transacciones, cambiadas...); err != nil {
+		return Transaccion{}, err
 	}
+	return transaccion, nil
+}
+
+// CambiarRiesgo guarda la evaluacion de riesgo de la transaccion.
+func (r *repository) CambiarRiesgo(id int, riesgo *Riesgo) (Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if err := r.db.Write(transaccionesList); err != nil {
+	unlock, err := store.Lock(r.db)
+	if err != nil {
 		return Transaccion{}, err
 	}
+	defer unlock()
 
-	return transaccionUpdated, nil
+	if err := r.cargar(); err != nil {
+		return Transaccion{}, err
+	}
+
+	index := r.indice(id)
+	if index < INT_ZERO {
+		return Transaccion{}, errors.New("no se encontro la transaccion")
+	}
+	transaccion := r.transacciones[index]
+	transaccion.Riesgo = riesgo
+	if err := r.reemplazar(index, transaccion); err != nil {
+		return Transaccion{}, err
+	}
+	return transaccion, nil
 }
 
-func (r *repository) LastID() (int, error) {
-	if err := r.db.Read(&transaccionesList); err != nil {
-		return 0, errors.New("error al leer del store")
+// StoreReversa agrega la reversa de la transaccion id. La suma de reversas se
+// revisa con el store bloqueado para que dos peticiones concurrentes no
+// devuelvan mas que el monto original.
+func (r *repository) StoreReversa(id int, solicitud Reversa) (Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return Transaccion{}, err
 	}
-	var maxId int
-	for _, transaccion := range transaccionesList {
-		if maxId < transaccion.Id {
-			maxId = transaccion.Id
+	defer unlock()
+
+	if err := r.cargar(); err != nil {
+		return Transaccion{}, err
+	}
+
+	indexOriginal := r.indice(id)
+	if indexOriginal < INT_ZERO {
+		return Transaccion{}, errors.New("no se encontro la transaccion")
+	}
+
+	original := r.transacciones[indexOriginal]
+	reversa, completa, err := planearReversa(original, r.reversas(id), solicitud)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	if r.codigoOcupado(reversa.CodigoTransaccion, INT_ZERO) {
+		return Transaccion{}, ErrCodigoDuplicado
+	}
+	reversa.Id = r.ultimoID() + 1
+
+	transacciones := append(r.copia(), reversa)
+	cambiadas := []Transaccion{reversa}
+	if completa {
+		original.Estado = ESTADO_REVERTIDA
+		transacciones[indexOriginal] = original
+		cambiadas = append(cambiadas, original)
+	}
+
+	if err := r.guardar(transacciones, cambiadas...); err != nil {
+		return Transaccion{}, err
+	}
+	return reversa, nil
+}
+
+func (r *repository) reversas(id int) []Transaccion {
+	reversas := []Transaccion{}
+	for _, transaccion := range r.transacciones {
+		if transaccion.Referencia != nil && *transaccion.Referencia == id {
+			reversas = append(reversas, transaccion)
 		}
 	}
-	return maxId, nil
+	return reversas
+}
+
+// GetReversas regresa las reversas de la transaccion id, aunque no tenga ninguna.
+func (r *repository) GetReversas(id int) ([]Transaccion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.cargar(); err != nil {
+		return []Transaccion{}, err
+	}
+	return r.reversas(id), nil
+}
+
+func (r *repository) LastID() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.cargar(); err != nil {
+		return 0, err
+	}
+	return r.ultimoID(), nil
 }
 
 func (r *repository) Delete(id int) error {
-	if err := r.db.Read(&transaccionesList); err != nil {
-		return errors.New("error al leer del store")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := store.Lock(r.db)
+	if err != nil {
+		return err
 	}
-	var idIndex int
+	defer unlock()
 
-	for index, transaccion := range transaccionesList {
-		if transaccion.Id == id {
-			idIndex = index + 1
-		}
+	if err := r.cargar(); err != nil {
+		return err
 	}
 
-	if idIndex == 0 {
+	index := r.indice(id)
+	if index < INT_ZERO {
 		return errors.New("la transaccion a eliminar no existe")
 	}
+	if r.transacciones[index].Referencia != nil || len(r.reversas(id)) > INT_ZERO {
+		return ErrReversaVinculada
+	}
 
-	if idIndex > len(transaccionesList)-1 {
-		transaccionesList = transaccionesList[:idIndex-1]
-	} else {
-		transaccionesList = append(transaccionesList[:idIndex-1], transaccionesList[idIndex:]...)
+	transacciones := make([]Transaccion, 0, len(r.transacciones)-1)
+	for _, transaccion := range r.transacciones {
+		if transaccion.Id != id {
+			transacciones = append(transacciones, transaccion)
+		}
 	}
 
-	if err := r.db.Write(transaccionesList); err != nil {
+	if err := r.eliminar(transacciones, id); err != nil {
 		return err
 	}
 
@@ -1,22 +1,53 @@
 package transacciones
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/riesgo"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
 	"github.com/stretchr/testify/assert"
 )
 
-type DummyStore struct{}
+// fechaPrueba interpreta una fecha dd/mm/aaaa a medianoche en UTC.
+func fechaPrueba(texto string) time.Time {
+	valor, err := fecha.Parse(texto, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return valor
+}
 
-func (d *DummyStore) Read(data interface{}) error {
-	trans := data.(*[]Transaccion)
-	*trans = []Transaccion{}
-	return nil
+func montoFiltro(texto string) *dinero.Monto {
+	monto := dinero.DebeParsear(texto)
+	return &monto
 }
 
-func (s *DummyStore) Write(data interface{}) error {
-	return nil
+func textoFiltro(texto string) *string {
+	return &texto
+}
+
+func fechaFiltro(valor time.Time) *time.Time {
+	return &valor
+}
+
+func parte(nombre string) partes.Parte {
+	return partes.Parte{Nombre: nombre}
+}
+
+func emisorDe(transaccion Transaccion) partes.Parte {
+	return partes.Parte{Id: transaccion.EmisorId, Nombre: transaccion.Emisor}
+}
+
+func receptorDe(transaccion Transaccion) partes.Parte {
+	return partes.Parte{Id: transaccion.ReceptorId, Nombre: transaccion.Receptor}
 }
 
 type ErrorReadStore struct {
@@ -49,54 +80,6 @@ func (d *ErrorWriteStore) Write(data interface{}) error {
 	return errors.New("error al escribir la data dentro del store")
 }
 
-type StubStore struct{}
-
-func (s *StubStore) Read(data interface{}) error {
-	trans := data.(*[]Transaccion)
-	*trans = []Transaccion{
-		{
-			Id:                1,
-			CodigoTransaccion: "ctr1",
-			Moneda:            "MXN",
-			Monto:             4000,
-			Emisor:            "Brandon",
-			Receptor:          "Juan",
-			FechaTransaccion:  "21/04/2022",
-		},
-		{
-			Id:                2,
-			CodigoTransaccion: "ctr2",
-			Moneda:            "USD",
-			Monto:             200,
-			Emisor:            "Juan",
-			Receptor:          "Brandon",
-			FechaTransaccion:  "21/04/2022",
-		},
-	}
-	return nil
-}
-
-func (s *StubStore) Write(data interface{}) error {
-	return nil
-}
-
-type SpyStore struct {
-	readWasCalled  bool
-	writeWasCalled bool
-}
-
-func (s *SpyStore) Read(data interface{}) error {
-	trans := data.(*[]Transaccion)
-	*trans = []Transaccion{}
-	s.readWasCalled = true
-	return nil
-}
-
-func (s *SpyStore) Write(data interface{}) error {
-	s.writeWasCalled = true
-	return nil
-}
-
 type MockStore struct {
 	readWasCalled  bool
 	writeWasCalled bool
@@ -116,245 +99,734 @@ func (s *MockStore) Write(data interface{}) error {
 	return nil
 }
 
-func TestRepositoryGetAll(t *testing.T) {
+// ErrorWriteMockStore comparte su lista con el repositorio al leer, como
+// MockStore, pero falla al escribir.
+type ErrorWriteMockStore struct {
+	MockStore
+}
+
+func (s *ErrorWriteMockStore) Write(data interface{}) error {
+	s.writeWasCalled = true
+	return errors.New("error al escribir la data dentro del store")
+}
+
+func TestRepositoryWriteErrorKeepsState(t *testing.T) {
 	// Arrange
-	expected := []Transaccion{
-		{
-			Id:                1,
-			CodigoTransaccion: "ctr1",
-			Moneda:            "MXN",
-			Monto:             4000,
-			Emisor:            "Brandon",
-			Receptor:          "Juan",
-			FechaTransaccion:  "21/04/2022",
-		},
-		{
-			Id:                2,
-			CodigoTransaccion: "ctr2",
-			Moneda:            "USD",
-			Monto:             200,
-			Emisor:            "Juan",
-			Receptor:          "Brandon",
-			FechaTransaccion:  "21/04/2022",
-		},
-	}
-	stubStore := &StubStore{}
-	repo := NewRepository(stubStore)
+	original := Transaccion{Id: 1, CodigoTransaccion: "ctr1", Moneda: "MXN", Monto: dinero.DebeParsear("10.00"),
+		Emisor: "Brandon", Receptor: "Juan", FechaTransaccion: fechaPrueba("21/04/2022"), Estado: ESTADO_PENDIENTE}
+	errorStore := &ErrorWriteMockStore{MockStore{Data: []Transaccion{original}}}
+	repo := NewRepository(errorStore)
 
 	// Act
-	result, err := repo.GetAll()
+	_, errUpdate := repo.Update(1, "ctr9", "USD", dinero.DebeParsear("99.00"), parte("Ana"), parte("Luis"), fechaPrueba("22/04/2022"), nil)
+	_, errPatch := repo.Patch(1, "ctr9", dinero.DebeParsear("99.00"), nil)
+	_, errEstado := repo.CambiarEstado(1, ESTADO_PENDIENTE, ESTADO_AUTORIZADA)
+	_, errRiesgo := repo.CambiarRiesgo(1, &Riesgo{Aprobada: true})
+	result, errGetAll := repo.GetAll()
 
 	// Assert
-	assert.Nil(t, err)
-	assert.Equal(t, expected, result)
+	assert.NotNil(t, errUpdate)
+	assert.NotNil(t, errPatch)
+	assert.NotNil(t, errEstado)
+	assert.NotNil(t, errRiesgo)
+	assert.True(t, errorStore.writeWasCalled)
+	assert.Nil(t, errGetAll)
+	assert.Equal(t, []Transaccion{original}, result)
+	assert.Equal(t, []Transaccion{original}, errorStore.Data)
+}
+
+// CountingStore cuenta las lecturas completas de un JsonFileStore.
+type CountingStore struct {
+	*store.JsonFileStore
+	reads  int
+	writes int
+}
+
+func (s *CountingStore) Read(data interface{}) error {
+	s.reads++
+	return s.JsonFileStore.Read(data)
+}
+
+func (s *CountingStore) Write(data interface{}) error {
+	s.writes++
+	return s.JsonFileStore.Write(data)
 }
 
-func TestRepositoryGetAllEmpty(t *testing.T) {
+func TestRepositoryGetByCodigoReusesIndex(t *testing.T) {
 	// Arrange
-	dummyStore := &DummyStore{}
-	repo := NewRepository(dummyStore)
+	fileName := filepath.Join(t.TempDir(), "transacciones.json")
+	countingStore := &CountingStore{JsonFileStore: &store.JsonFileStore{FileName: fileName}}
+	repo := NewRepository(countingStore)
+	_, errStore := repo.Store("ctr1", "MXN", dinero.DebeParsear("100"), parte("Banamex"), parte("Bancomer"), fechaPrueba("21/02/2022"), nil)
 
 	// Act
-	result, err := repo.GetAll()
+	_, errFirst := repo.GetByCodigo("ctr1")
+	_, errSecond := repo.GetByCodigo("ctr1")
+	readsUnchanged := countingStore.reads
+	_, errOther := NewRepository(store.NewStore(store.JsonFileType, fileName)).Store("ctr2", "USD", dinero.DebeParsear("200"),
+		parte("Bancomer"), parte("Banamex"), fechaPrueba("22/02/2022"), nil)
+	result, errChanged := repo.GetByCodigo("ctr2")
 
 	// Assert
-	assert.NotNil(t, err)
-	assert.Empty(t, result)
+	assert.Nil(t, errStore)
+	assert.Nil(t, errFirst)
+	assert.Nil(t, errSecond)
+	assert.Equal(t, 1, readsUnchanged)
+	assert.Nil(t, errOther)
+	assert.Nil(t, errChanged)
+	assert.Equal(t, 2, result.Id)
+	assert.Equal(t, 2, countingStore.reads)
 }
 
-func TestRepositoryStore(t *testing.T) {
+func TestRepositoryJournalStore(t *testing.T) {
 	// Arrange
-	mockStore := &MockStore{
-		Data: []Transaccion{{
-			Id:                100,
-			CodigoTransaccion: "Before Update",
-			Moneda:            "MXN",
-			Monto:             0,
-			Emisor:            "Brandon",
-			Receptor:          "Juan",
-			FechaTransaccion:  "21/04/2022",
-		}},
-	}
-	repo := NewRepository(mockStore)
-	expected := Transaccion{
-		Id:                101,
-		CodigoTransaccion: "ctr",
+	fileName := filepath.Join(t.TempDir(), "transacciones.json")
+	repo := NewRepository(store.NewStore(store.JournalFileType, fileName))
+	expected := []Transaccion{{
+		Id:                1,
+		CodigoTransaccion: "ctr1 actualizado",
 		Moneda:            "MXN",
-		Monto:             100,
+		Monto:             dinero.DebeParsear("150"),
 		Emisor:            "Banamex",
 		Receptor:          "Bancomer",
-		FechaTransaccion:  "21/02/2022",
-	}
+		FechaTransaccion:  fechaPrueba("21/02/2022"),
+		Estado:            ESTADO_PENDIENTE,
+	}}
 
 	// Act
-	result, err := repo.Store(expected.Id, expected.CodigoTransaccion, expected.Moneda,
-		expected.Monto, expected.Emisor, expected.Receptor, expected.FechaTransaccion)
+	_, errStore1 := repo.Store("ctr1", "MXN", dinero.DebeParsear("100"), parte("Banamex"), parte("Bancomer"), fechaPrueba("21/02/2022"), nil)
+	_, errStore2 := repo.Store("ctr2", "USD", dinero.DebeParsear("200"), parte("Bancomer"), parte("Banamex"), fechaPrueba("22/02/2022"), nil)
+	_, errPatch := repo.Patch(1, "ctr1 actualizado", dinero.DebeParsear("150"), nil)
+	errDelete := repo.Delete(2)
+	result, err := NewRepository(store.NewStore(store.JournalFileType, fileName)).GetAll()
 
 	// Assert
+	assert.Nil(t, errStore1)
+	assert.Nil(t, errStore2)
+	assert.Nil(t, errPatch)
+	assert.Nil(t, errDelete)
 	assert.Nil(t, err)
-	assert.True(t, mockStore.readWasCalled)
-	assert.True(t, mockStore.writeWasCalled)
 	assert.Equal(t, expected, result)
+	assert.NoFileExists(t, fileName)
 }
 
-func TestRepositoryUpdate(t *testing.T) {
-	// Arrange
-	mockStore := &MockStore{
-		Data: []Transaccion{
-			{
-				Id:                1,
-				CodigoTransaccion: "Before Update",
-				Moneda:            "MXN",
-				Monto:             100,
-				Emisor:            "Banamex",
-				Receptor:          "Bancomer",
-				FechaTransaccion:  "22/04/2022",
-			},
-		},
-	}
-	repo := NewRepository(mockStore)
-	expected := Transaccion{
-		Id:                1,
-		CodigoTransaccion: "After Update",
-		Moneda:            "USD",
-		Monto:             200,
-		Emisor:            "Banregio",
-		Receptor:          "Visa",
-		FechaTransaccion:  "22/02/2022",
-	}
-
-	// Act
-	result, err := repo.Update(expected.Id, expected.CodigoTransaccion, expected.Moneda,
-		expected.Monto, expected.Emisor, expected.Receptor, expected.FechaTransaccion)
+type implementacionRepository struct {
+	nombre string
+	nuevo  func(t *testing.T) Repository
+}
 
-	// Assert
-	assert.Nil(t, err)
-	assert.True(t, mockStore.readWasCalled)
-	assert.True(t, mockStore.writeWasCalled)
-	assert.Equal(t, expected, result)
+// implementacionesRepository construye cada implementacion de Repository sobre
+// un almacenamiento vacio; TestRepository corre sobre todas las mismas pruebas.
+var implementacionesRepository = []implementacionRepository{
+	{
+		nombre: "jsonFile",
+		nuevo: func(t *testing.T) Repository {
+			fileName := filepath.Join(t.TempDir(), "transacciones.json")
+			return NewRepository(store.NewStore(store.JsonFileType, fileName))
+		},
+	},
+	{
+		nombre: "journalFile",
+		nuevo: func(t *testing.T) Repository {
+			fileName := filepath.Join(t.TempDir(), "transacciones.json")
+			return NewRepository(store.NewStore(store.JournalFileType, fileName))
+		},
+	},
+	{
+		nombre: "sqlite",
+		nuevo: func(t *testing.T) Repository {
+			db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "transacciones.db"))
+			assert.Nil(t, err)
+			t.Cleanup(func() { db.Close() })
+			// Las migraciones ya aplicadas se omiten al volver a correrlas.
+			assert.Nil(t, MigrarSQL(db, time.UTC))
+			assert.Nil(t, MigrarSQL(db, time.UTC))
+			return NewSQLRepository(db)
+		},
+	},
 }
 
-func TestRepositoryUpdateNotFound(t *testing.T) {
-	// Arrange
-	spyStore := &SpyStore{}
-	repo := NewRepository(spyStore)
-	data := Transaccion{
+var transaccionesConformidad = []Transaccion{
+	{
 		Id:                1,
-		CodigoTransaccion: "After Update",
+		CodigoTransaccion: "ctr1",
+		Moneda:            "MXN",
+		Monto:             dinero.DebeParsear("4000"),
+		Emisor:            "Brandon",
+		Receptor:          "Juan",
+		FechaTransaccion:  fechaPrueba("21/04/2022"),
+		Estado:            ESTADO_PENDIENTE,
+	},
+	{
+		Id:                2,
+		CodigoTransaccion: "ctr2",
 		Moneda:            "USD",
-		Monto:             200,
-		Emisor:            "Banregio",
-		Receptor:          "Visa",
-		FechaTransaccion:  "22/02/2022",
-	}
-
-	// Act
-	result, err := repo.Update(data.Id, data.CodigoTransaccion, data.Moneda,
-		data.Monto, data.Emisor, data.Receptor, data.FechaTransaccion)
-
-	// Assert
-	assert.NotNil(t, err)
-	assert.True(t, spyStore.readWasCalled)
-	assert.False(t, spyStore.writeWasCalled)
-	assert.Empty(t, result)
+		Monto:             dinero.DebeParsear("200"),
+		Emisor:            "Juan",
+		Receptor:          "Brandon",
+		FechaTransaccion:  fechaPrueba("21/04/2022"),
+		Estado:            ESTADO_PENDIENTE,
+	},
 }
 
-func TestRepositoryPatch(t *testing.T) {
-	// Arrange
-	mockStore := &MockStore{
-		Data: []Transaccion{
-			{
-				Id:                1,
-				CodigoTransaccion: "Before Update",
-				Moneda:            "MXN",
-				Monto:             0,
-				Emisor:            "Brandon",
-				Receptor:          "Juan",
-				FechaTransaccion:  "21/04/2022",
-			},
-		}}
-	repo := NewRepository(mockStore)
-	id := 1
-	newCodigoTransaction := "After Update"
-	newMonto := 200.0
-
-	// Act
-	result, err := repo.Patch(id, newCodigoTransaction, newMonto)
-
-	// Assert
-	assert.Nil(t, err)
-	assert.True(t, mockStore.readWasCalled)
-	assert.Equal(t, newCodigoTransaction, result.CodigoTransaccion)
-	assert.Equal(t, newMonto, result.Monto)
+func sembrarRepository(t *testing.T, repo Repository) {
+	for _, transaccion := range transaccionesConformidad {
+		_, err := repo.Store(transaccion.CodigoTransaccion, transaccion.Moneda, transaccion.Monto,
+			emisorDe(transaccion), receptorDe(transaccion), transaccion.FechaTransaccion, nil)
+		assert.Nil(t, err)
+	}
 }
 
-func TestRepositoryPatchNotFound(t *testing.T) {
-	// Arrange
-	spyStore := &SpyStore{}
-	repo := NewRepository(spyStore)
-	id := 1
-	newCodigoTransaction := "After Update"
-	newMonto := 200.0
-
-	// Act
-	result, err := repo.Patch(id, newCodigoTransaction, newMonto)
+var casosConformidadRepository = []struct {
+	nombre string
+	probar func(t *testing.T, repo Repository)
+}{
+	{"GetAll", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.GetAll()
+
+		assert.Nil(t, err)
+		assert.Equal(t, transaccionesConformidad, result)
+	}},
+	{"GetAllEmpty", func(t *testing.T, repo Repository) {
+		result, err := repo.GetAll()
+
+		assert.NotNil(t, err)
+		assert.Empty(t, result)
+	}},
+	{"GetTransaccionFiltrada", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.GetTransaccionFiltrada(Filtro{Monedas: []string{"USD"}, Monto: montoFiltro("200"), Receptor: textoFiltro("Brandon")})
+
+		assert.Nil(t, err)
+		assert.Equal(t, transaccionesConformidad[1:], result)
+	}},
+	{"GetTransaccionFiltradaNotFound", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.GetTransaccionFiltrada(Filtro{Monedas: []string{"EUR"}})
+
+		assert.NotNil(t, err)
+		assert.Empty(t, result)
+	}},
+	{"GetTransaccionFiltradaRangoFechas", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		zona, _ := time.LoadLocation("America/Mexico_City")
+
+		delDia, err := repo.GetTransaccionFiltrada(Filtro{
+			FechaDesde: fechaFiltro(time.Date(2022, 4, 20, 0, 0, 0, 0, zona)),
+			FechaHasta: fechaFiltro(time.Date(2022, 4, 20, 23, 59, 59, 0, zona)),
+		})
+		_, errSiguiente := repo.GetTransaccionFiltrada(Filtro{FechaDesde: fechaFiltro(time.Date(2022, 4, 21, 0, 0, 0, 0, zona))})
+
+		assert.Nil(t, err)
+		assert.Len(t, delDia, 2)
+		assert.NotNil(t, errSiguiente)
+	}},
+	{"GetTransaccionFiltradaRangoMontos", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.GetTransaccionFiltrada(Filtro{MontoMin: montoFiltro("200.00"), MontoMax: montoFiltro("3999.99999")})
+		_, errVacio := repo.GetTransaccionFiltrada(Filtro{MontoMin: montoFiltro("200.00001"), MontoMax: montoFiltro("3999.99999")})
+
+		assert.Nil(t, err)
+		assert.Equal(t, transaccionesConformidad[1:], result)
+		assert.NotNil(t, errVacio)
+	}},
+	{"GetTransaccionFiltradaMontoCero", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		_, errStore := repo.Store("ctr0", "MXN", dinero.DebeParsear("0.00"), parte("Brandon"), parte("Juan"), fechaPrueba("21/04/2022"), nil)
+
+		result, err := repo.GetTransaccionFiltrada(Filtro{Monto: montoFiltro("0")})
+
+		assert.Nil(t, errStore)
+		assert.Nil(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "ctr0", result[0].CodigoTransaccion)
+	}},
+	{"GetTransaccionFiltradaVariasMonedas", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.GetTransaccionFiltrada(Filtro{Monedas: []string{"EUR", "MXN", "USD"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, transaccionesConformidad, result)
+	}},
+	{"GetTransaccionFiltradaEmisor", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		prefijo, errPrefijo := repo.GetTransaccionFiltrada(Filtro{Emisor: &Texto{Valor: "bran", Coincidencia: COINCIDENCIA_PREFIJO}})
+		contiene, errContiene := repo.GetTransaccionFiltrada(Filtro{Emisor: &Texto{Valor: "UA", Coincidencia: COINCIDENCIA_CONTIENE}})
+		_, errComodin := repo.GetTransaccionFiltrada(Filtro{Emisor: &Texto{Valor: "%", Coincidencia: COINCIDENCIA_CONTIENE}})
+		_, errExacta := repo.GetTransaccionFiltrada(Filtro{Emisor: &Texto{Valor: "bran"}})
+
+		assert.Nil(t, errPrefijo)
+		assert.Equal(t, transaccionesConformidad[:1], prefijo)
+		assert.Nil(t, errContiene)
+		assert.Equal(t, transaccionesConformidad[1:], contiene)
+		assert.NotNil(t, errComodin)
+		assert.NotNil(t, errExacta)
+	}},
+	{"ListarCursor", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		_, errStore := repo.Store("ctr3", "EUR", dinero.DebeParsear("200"), parte("Ana"), parte("Juan"), fechaPrueba("21/04/2022"), nil)
+		orden, _ := ParseOrden("-monto,fecha_transaccion")
+
+		primera, errPrimera := repo.Listar(Consulta{Orden: orden, Limite: 2})
+		segunda, errSegunda := repo.Listar(Consulta{Orden: orden, Limite: 2, Cursor: primera.SiguienteCursor})
+		_, errOtroOrden := repo.Listar(Consulta{Limite: 2, Cursor: primera.SiguienteCursor})
+
+		assert.Nil(t, errStore)
+		assert.Nil(t, errPrimera)
+		assert.Equal(t, 3, primera.Total)
+		assert.Equal(t, transaccionesConformidad, primera.Transacciones)
+		assert.NotEmpty(t, primera.SiguienteCursor)
+		assert.Nil(t, errSegunda)
+		assert.Equal(t, 3, segunda.Total)
+		assert.Len(t, segunda.Transacciones, 1)
+		assert.Equal(t, "ctr3", segunda.Transacciones[0].CodigoTransaccion)
+		assert.Empty(t, segunda.SiguienteCursor)
+		assert.ErrorIs(t, errOtroOrden, ErrConsultaNoValida)
+	}},
+	{"ListarMismoSegundo", func(t *testing.T, repo Repository) {
+		segundo := time.Date(2022, 4, 21, 10, 0, 0, 0, time.UTC)
+		for _, milisegundos := range []int{300, 100, 200} {
+			_, err := repo.Store(fmt.Sprintf("ctr%d", milisegundos), "MXN", dinero.DebeParsear("10"), parte("Ana"), parte("Juan"),
+				segundo.Add(time.Duration(milisegundos)*time.Millisecond), nil)
+			assert.Nil(t, err)
+		}
+		orden, _ := ParseOrden("fecha_transaccion")
+		desde := segundo.Add(200 * time.Millisecond)
+
+		primera, errPrimera := repo.Listar(Consulta{Orden: orden, Limite: 2})
+		segunda, errSegunda := repo.Listar(Consulta{Orden: orden, Limite: 2, Cursor: primera.SiguienteCursor})
+		filtrada, errFiltrada := repo.Listar(Consulta{Filtro: Filtro{FechaDesde: &desde}, Orden: orden})
+
+		assert.Nil(t, errPrimera)
+		assert.Len(t, primera.Transacciones, 2)
+		assert.Equal(t, "ctr100", primera.Transacciones[0].CodigoTransaccion)
+		assert.Equal(t, "ctr200", primera.Transacciones[1].CodigoTransaccion)
+		assert.Nil(t, errSegunda)
+		assert.Len(t, segunda.Transacciones, 1)
+		assert.Equal(t, "ctr300", segunda.Transacciones[0].CodigoTransaccion)
+		assert.Nil(t, errFiltrada)
+		assert.Equal(t, 2, filtrada.Total)
+		assert.Equal(t, "ctr200", filtrada.Transacciones[0].CodigoTransaccion)
+	}},
+	{"ListarDesplazamiento", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		orden, _ := ParseOrden("emisor")
+
+		result, err := repo.Listar(Consulta{Filtro: Filtro{Monedas: []string{"MXN", "USD"}}, Orden: orden, Limite: 1, Desplazamiento: 1})
+		fuera, errFuera := repo.Listar(Consulta{Limite: 1, Desplazamiento: 5})
+		_, errVacio := repo.Listar(Consulta{Filtro: Filtro{Monedas: []string{"EUR"}}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, result.Total)
+		assert.Equal(t, transaccionesConformidad[1:], result.Transacciones)
+		assert.Nil(t, errFuera)
+		assert.Equal(t, 2, fuera.Total)
+		assert.Empty(t, fuera.Transacciones)
+		assert.NotNil(t, errVacio)
+	}},
+	{"Store", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		expected := Transaccion{
+			Id:                3,
+			CodigoTransaccion: "ctr",
+			Moneda:            "MXN",
+			Monto:             dinero.DebeParsear("100"),
+			Emisor:            "Banamex",
+			EmisorId:          4,
+			Receptor:          "Bancomer",
+			ReceptorId:        7,
+			FechaTransaccion:  fechaPrueba("21/02/2022"),
+			Estado:            ESTADO_PENDIENTE,
+		}
+
+		result, err := repo.Store(expected.CodigoTransaccion, expected.Moneda, expected.Monto,
+			emisorDe(expected), receptorDe(expected), expected.FechaTransaccion, nil)
+		all, errAll := repo.GetAll()
+
+		assert.Nil(t, err)
+		assert.Nil(t, errAll)
+		assert.Equal(t, expected, result)
+		assert.Equal(t, expected, all[2])
+	}},
+	{"Update", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		expected := Transaccion{
+			Id:                1,
+			CodigoTransaccion: "After Update",
+			Moneda:            "USD",
+			Monto:             dinero.DebeParsear("200"),
+			Emisor:            "Banregio",
+			EmisorId:          5,
+			Receptor:          "Visa",
+			ReceptorId:        6,
+			FechaTransaccion:  fechaPrueba("22/02/2022"),
+			Estado:            ESTADO_PENDIENTE,
+		}
+
+		result, err := repo.Update(expected.Id, expected.CodigoTransaccion, expected.Moneda,
+			expected.Monto, emisorDe(expected), receptorDe(expected), expected.FechaTransaccion, nil)
+		all, errAll := repo.GetAll()
+
+		assert.Nil(t, err)
+		assert.Nil(t, errAll)
+		assert.Equal(t, expected, result)
+		assert.Equal(t, expected, all[0])
+	}},
+	{"UpdateNotFound", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.Update(9, "After Update", "USD", dinero.DebeParsear("200"), parte("Banregio"), parte("Visa"), fechaPrueba("22/02/2022"), nil)
+		all, errAll := repo.GetAll()
+
+		assert.NotNil(t, err)
+		assert.Empty(t, result)
+		assert.Nil(t, errAll)
+		assert.Equal(t, transaccionesConformidad, all)
+	}},
+	{"Patch", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		expected := transaccionesConformidad[0]
+		expected.CodigoTransaccion = "After Update"
+		expected.Monto = dinero.DebeParsear("250")
+
+		result, err := repo.Patch(expected.Id, expected.CodigoTransaccion, expected.Monto, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expected, result)
+	}},
+	{"PatchNotFound", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.Patch(9, "After Update", dinero.DebeParsear("200"), nil)
+		all, errAll := repo.GetAll()
+
+		assert.NotNil(t, err)
+		assert.Empty(t, result)
+		assert.Nil(t, errAll)
+		assert.Equal(t, transaccionesConformidad, all)
+	}},
+	{"Delete", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		err := repo.Delete(1)
+		all, errAll := repo.GetAll()
+
+		assert.Nil(t, err)
+		assert.Nil(t, errAll)
+		assert.Equal(t, transaccionesConformidad[1:], all)
+	}},
+	{"DeleteNotFound", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		err := repo.Delete(9)
+		all, errAll := repo.GetAll()
+
+		assert.NotNil(t, err)
+		assert.Nil(t, errAll)
+		assert.Equal(t, transaccionesConformidad, all)
+	}},
+	{"LastID", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.LastID()
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, result)
+	}},
+	{"CodigoDuplicado", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		original := transaccionesConformidad[0]
+
+		_, errStore := repo.Store("ctr2", "MXN", dinero.DebeParsear("100"), parte("Banamex"), parte("Bancomer"), fechaPrueba("21/02/2022"), nil)
+		_, errUpdate := repo.Update(1, "ctr2", "MXN", dinero.DebeParsear("100"), parte("Banamex"), parte("Bancomer"), fechaPrueba("21/02/2022"), nil)
+		_, errPatch := repo.Patch(1, "ctr2", dinero.DebeParsear("100"), nil)
+		mismo, errMismo := repo.Patch(1, original.CodigoTransaccion, dinero.DebeParsear("100"), nil)
+		all, errAll := repo.GetAll()
+
+		assert.ErrorIs(t, errStore, ErrCodigoDuplicado)
+		assert.ErrorIs(t, errUpdate, ErrCodigoDuplicado)
+		assert.ErrorIs(t, errPatch, ErrCodigoDuplicado)
+		assert.Nil(t, errMismo)
+		assert.Equal(t, original.CodigoTransaccion, mismo.CodigoTransaccion)
+		assert.Nil(t, errAll)
+		assert.Len(t, all, 2)
+	}},
+	{"CambiarEstado", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+
+		result, err := repo.CambiarEstado(1, ESTADO_PENDIENTE, ESTADO_AUTORIZADA)
+		_, errActual := repo.CambiarEstado(1, ESTADO_PENDIENTE, ESTADO_RECHAZADA)
+		_, errNoExiste := repo.CambiarEstado(9, ESTADO_PENDIENTE, ESTADO_AUTORIZADA)
+		_, errUpdate := repo.Update(1, "ctr1", "MXN", dinero.DebeParsear("1"), parte("Brandon"), parte("Juan"), fechaPrueba("21/04/2022"), nil)
+		_, errPatch := repo.Patch(1, "ctr1", dinero.DebeParsear("1"), nil)
+		autorizadas, errFiltro := repo.GetTransaccionFiltrada(Filtro{Estados: []Estado{ESTADO_AUTORIZADA, ESTADO_LIQUIDADA}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, ESTADO_AUTORIZADA, result.Estado)
+		assert.Equal(t, transaccionesConformidad[0].Monto, result.Monto)
+		assert.ErrorIs(t, errActual, ErrTransicionNoValida)
+		assert.NotNil(t, errNoExiste)
+		assert.NotErrorIs(t, errNoExiste, ErrTransicionNoValida)
+		assert.ErrorIs(t, errUpdate, ErrTransaccionNoEditable)
+		assert.ErrorIs(t, errPatch, ErrTransaccionNoEditable)
+		assert.Nil(t, errFiltro)
+		assert.Equal(t, []Transaccion{result}, autorizadas)
+	}},
+	{"StoreReversa", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		_, errPendiente := repo.StoreReversa(2, Reversa{FechaTransaccion: fechaPrueba("22/04/2022")})
+		_, _ = repo.CambiarEstado(2, ESTADO_PENDIENTE, ESTADO_AUTORIZADA)
+		_, _ = repo.CambiarEstado(2, ESTADO_AUTORIZADA, ESTADO_LIQUIDADA)
+
+		parcial, errParcial := repo.StoreReversa(2, Reversa{Monto: montoFiltro("50.00"), FechaTransaccion: fechaPrueba("22/04/2022")})
+		_, errExcedida := repo.StoreReversa(2, Reversa{Monto: montoFiltro("150.01"), FechaTransaccion: fechaPrueba("22/04/2022")})
+		_, errDeReversa := repo.StoreReversa(parcial.Id, Reversa{FechaTransaccion: fechaPrueba("22/04/2022")})
+		resto, errResto := repo.StoreReversa(2, Reversa{CodigoTransaccion: "dev-2", FechaTransaccion: fechaPrueba("23/04/2022")})
+		_, errRevertida := repo.StoreReversa(2, Reversa{Monto: montoFiltro("0.01"), FechaTransaccion: fechaPrueba("23/04/2022")})
+		reversas, errReversas := repo.GetReversas(2)
+		sinReversas, errSinReversas := repo.GetReversas(1)
+		original, _ := repo.GetByCodigo("ctr2")
+
+		assert.ErrorIs(t, errPendiente, ErrReversaNoValida)
+		assert.Nil(t, errParcial)
+		assert.Equal(t, Transaccion{
+			Id:                3,
+			CodigoTransaccion: "ctr2-R1",
+			Moneda:            "USD",
+			Monto:             dinero.DebeParsear("50.00"),
+			Emisor:            "Brandon",
+			Receptor:          "Juan",
+			FechaTransaccion:  fechaPrueba("22/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
+			Referencia:        &original.Id,
+		}, parcial)
+		assert.ErrorIs(t, errExcedida, ErrReversaExcedida)
+		assert.ErrorIs(t, errDeReversa, ErrReversaNoValida)
+		assert.Nil(t, errResto)
+		assert.Equal(t, "150.00", resto.Monto.String())
+		assert.ErrorIs(t, errRevertida, ErrReversaNoValida)
+		assert.Nil(t, errReversas)
+		assert.Equal(t, []Transaccion{parcial, resto}, reversas)
+		assert.Nil(t, errSinReversas)
+		assert.Empty(t, sinReversas)
+		assert.Equal(t, ESTADO_REVERTIDA, original.Estado)
+	}},
+	{"RechazarReversaCompleta", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		_, _ = repo.CambiarEstado(2, ESTADO_PENDIENTE, ESTADO_AUTORIZADA)
+		_, _ = repo.CambiarEstado(2, ESTADO_AUTORIZADA, ESTADO_LIQUIDADA)
+		reversa, errReversa := repo.StoreReversa(2, Reversa{FechaTransaccion: fechaPrueba("22/04/2022")})
+		revertida, _ := repo.GetByCodigo("ctr2")
+
+		rechazada, errRechazo := repo.CambiarEstado(reversa.Id, ESTADO_PENDIENTE, ESTADO_RECHAZADA)
+		original, _ := repo.GetByCodigo("ctr2")
+		nueva, errNueva := repo.StoreReversa(2, Reversa{FechaTransaccion: fechaPrueba("23/04/2022")})
+
+		assert.Nil(t, errReversa)
+		assert.Equal(t, ESTADO_REVERTIDA, revertida.Estado)
+		assert.Nil(t, errRechazo)
+		assert.Equal(t, ESTADO_RECHAZADA, rechazada.Estado)
+		assert.Equal(t, ESTADO_LIQUIDADA, original.Estado)
+		assert.Nil(t, errNueva)
+		assert.Equal(t, transaccionesConformidad[1].Monto, nueva.Monto)
+	}},
+	{"UpdateReversa", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		_, _ = repo.CambiarEstado(2, ESTADO_PENDIENTE, ESTADO_AUTORIZADA)
+		_, _ = repo.CambiarEstado(2, ESTADO_AUTORIZADA, ESTADO_LIQUIDADA)
+		reversa, _ := repo.StoreReversa(2, Reversa{Monto: montoFiltro("50.00"), FechaTransaccion: fechaPrueba("22/04/2022")})
+
+		_, errUpdate := repo.Update(reversa.Id, "ctr2-R1", "USD", dinero.DebeParsear("500.00"), parte("Brandon"), parte("Juan"), fechaPrueba("22/04/2022"), nil)
+		_, errPatch := repo.Patch(reversa.Id, "ctr2-R1", dinero.DebeParsear("500.00"), nil)
+		reversas, errReversas := repo.GetReversas(2)
+
+		assert.ErrorIs(t, errUpdate, ErrReversaNoEditable)
+		assert.ErrorIs(t, errUpdate, ErrTransaccionNoEditable)
+		assert.ErrorIs(t, errPatch, ErrReversaNoEditable)
+		assert.Nil(t, errReversas)
+		assert.Equal(t, []Transaccion{reversa}, reversas)
+	}},
+	{"DeleteReversaVinculada", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		_, _ = repo.CambiarEstado(2, ESTADO_PENDIENTE, ESTADO_AUTORIZADA)
+		_, _ = repo.CambiarEstado(2, ESTADO_AUTORIZADA, ESTADO_LIQUIDADA)
+		reversa, errReversa := repo.StoreReversa(2, Reversa{Monto: montoFiltro("50.00"), FechaTransaccion: fechaPrueba("22/04/2022")})
+
+		errOriginal := repo.Delete(2)
+		errDeReversa := repo.Delete(reversa.Id)
+		errSinVinculo := repo.Delete(1)
+		reversas, errReversas := repo.GetReversas(2)
+
+		assert.Nil(t, errReversa)
+		assert.ErrorIs(t, errOriginal, ErrReversaVinculada)
+		assert.ErrorIs(t, errDeReversa, ErrReversaVinculada)
+		assert.Nil(t, errSinVinculo)
+		assert.Nil(t, errReversas)
+		assert.Equal(t, []Transaccion{reversa}, reversas)
+	}},
+	{"CambiarRiesgo", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		marcada := &Riesgo{Evaluacion: riesgo.Evaluacion{Accion: riesgo.ACCION_MARCAR, Puntaje: 60, Reglas: []string{"usd_alto"}}}
+
+		result, err := repo.CambiarRiesgo(2, marcada)
+		actualizada, errUpdate := repo.Update(2, "ctr2", "USD", dinero.DebeParsear("300"), parte("Brandon"), parte("Juan"), fechaPrueba("22/04/2022"), nil)
+		leida, _ := repo.GetByCodigo("ctr2")
+		_, errNoExiste := repo.CambiarRiesgo(99, marcada)
+
+		assert.Nil(t, err)
+		assert.Equal(t, marcada, result.Riesgo)
+		assert.Nil(t, errUpdate)
+		assert.Equal(t, marcada, actualizada.Riesgo)
+		assert.Equal(t, marcada, leida.Riesgo)
+		assert.True(t, leida.EnRevision())
+		assert.NotNil(t, errNoExiste)
+	}},
+	{"GuardarRiesgo", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		marcada := &Riesgo{Evaluacion: riesgo.Evaluacion{Accion: riesgo.ACCION_MARCAR, Puntaje: 60, Reglas: []string{"usd_alto"}}}
+		permitida := &Riesgo{Evaluacion: riesgo.Evaluacion{Accion: riesgo.ACCION_PERMITIR}}
+
+		nueva, errStore := repo.Store("ctr9", "USD", dinero.DebeParsear("20000"), parte("Brandon"), parte("Juan"), fechaPrueba("22/04/2022"), marcada)
+		actualizada, errUpdate := repo.Update(2, "ctr2", "USD", dinero.DebeParsear("20000"), parte("Brandon"), parte("Juan"), fechaPrueba("22/04/2022"), marcada)
+		parcial, errPatch := repo.Patch(2, "ctr2", dinero.DebeParsear("300"), permitida)
+		conservada, errConservada := repo.Patch(2, "ctr2", dinero.DebeParsear("400"), nil)
+		leidaNueva, _ := repo.GetByCodigo("ctr9")
+		leida, _ := repo.GetByCodigo("ctr2")
+
+		assert.Nil(t, errStore)
+		assert.Equal(t, marcada, nueva.Riesgo)
+		assert.Equal(t, marcada, leidaNueva.Riesgo)
+		assert.Nil(t, errUpdate)
+		assert.Equal(t, marcada, actualizada.Riesgo)
+		assert.Nil(t, errPatch)
+		assert.Equal(t, permitida, parcial.Riesgo)
+		assert.Nil(t, errConservada)
+		assert.Equal(t, permitida, conservada.Riesgo)
+		assert.Equal(t, permitida, leida.Riesgo)
+		assert.Equal(t, "400", leida.Monto.String())
+	}},
+	{"StoreLote", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		marcada := &Riesgo{Evaluacion: riesgo.Evaluacion{Accion: riesgo.ACCION_MARCAR, Puntaje: 60, Reglas: []string{"usd_alto"}}}
+		nueva := func(codigo string) Transaccion {
+			return Transaccion{CodigoTransaccion: codigo, Moneda: "MXN", Monto: dinero.DebeParsear("10.00"), Emisor: "Ana", Receptor: "Luis",
+				FechaTransaccion: fechaPrueba("23/04/2022")}
+		}
+		marcadaNueva := nueva("ctr8")
+		marcadaNueva.Riesgo = marcada
+
+		result, err := repo.StoreLote([]Transaccion{nueva("ctr7"), marcadaNueva})
+		_, errOcupado := repo.StoreLote([]Transaccion{nueva("ctr9"), nueva("ctr1")})
+		_, errRepetido := repo.StoreLote([]Transaccion{nueva("ctr9"), nueva("ctr9")})
+		vacio, errVacio := repo.StoreLote(nil)
+		todas, _ := repo.GetAll()
+
+		assert.Nil(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, len(transaccionesConformidad)+1, result[0].Id)
+		assert.Equal(t, len(transaccionesConformidad)+2, result[1].Id)
+		assert.Equal(t, ESTADO_PENDIENTE, result[0].Estado)
+		assert.Nil(t, result[0].Riesgo)
+		assert.Equal(t, marcada, result[1].Riesgo)
+		assert.ErrorIs(t, errOcupado, ErrCodigoDuplicado)
+		assert.ErrorIs(t, errRepetido, ErrCodigoDuplicado)
+		assert.Nil(t, errVacio)
+		assert.Empty(t, vacio)
+		assert.Equal(t, append(append([]Transaccion{}, transaccionesConformidad...), result...), todas)
+	}},
+	{"GetByCodigo", func(t *testing.T, repo Repository) {
+		sembrarRepository(t, repo)
+		_, errPatch := repo.Patch(2, "ctr2-nuevo", dinero.DebeParsear("200"), nil)
+
+		result, err := repo.GetByCodigo("ctr2-nuevo")
+		_, errAnterior := repo.GetByCodigo("ctr2")
+		_, errNoExiste := repo.GetByCodigo("ctr9")
+
+		assert.Nil(t, errPatch)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, result.Id)
+		assert.NotNil(t, errAnterior)
+		assert.NotNil(t, errNoExiste)
+	}},
+}
 
-	// Assert
-	assert.NotNil(t, err)
-	assert.True(t, spyStore.readWasCalled)
-	assert.False(t, spyStore.writeWasCalled)
-	assert.Empty(t, result)
+func TestRepository(t *testing.T) {
+	for _, implementacion := range implementacionesRepository {
+		for _, caso := range casosConformidadRepository {
+			implementacion, caso := implementacion, caso
+			t.Run(implementacion.nombre+"/"+caso.nombre, func(t *testing.T) {
+				caso.probar(t, implementacion.nuevo(t))
+			})
+		}
+	}
 }
 
-func TestRepositoryDelete(t *testing.T) {
+func TestMigrarSQLMontosExactos(t *testing.T) {
 	// Arrange
-	mockStore := &MockStore{
-		Data: []Transaccion{
-			{
-				Id:                1,
-				CodigoTransaccion: "ctr",
-				Moneda:            "MXN",
-				Monto:             0,
-				Emisor:            "Brandon",
-				Receptor:          "Juan",
-				FechaTransaccion:  "21/04/2022",
-			},
-		}}
-	repo := NewRepository(mockStore)
-	id := 1
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "transacciones.db"))
+	assert.Nil(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY)`)
+	assert.Nil(t, err)
+	for index, migracion := range migraciones(time.UTC)[:2] {
+		assert.Nil(t, aplicarMigracion(db, index+1, migracion))
+	}
+	_, err = db.Exec(`INSERT INTO transacciones (codigo_transaccion, moneda, monto, emisor, receptor, fecha_transaccion)
+		VALUES ('ctr1', 'MXN', 0.30000000000000004, 'Banamex', 'Bancomer', '21/02/2022'),
+		       ('ctr2', 'JPY', 1500, 'Bancomer', 'Banamex', '22/02/2022')`)
+	assert.Nil(t, err)
 
 	// Act
-	err := repo.Delete(id)
+	errMigrar := MigrarSQL(db, time.UTC)
+	result, errFiltro := NewSQLRepository(db).GetTransaccionFiltrada(Filtro{Monto: montoFiltro("0.3")})
+	all, errAll := NewSQLRepository(db).GetAll()
 
 	// Assert
-	assert.Nil(t, err)
-	assert.True(t, mockStore.readWasCalled)
-	assert.True(t, mockStore.writeWasCalled)
+	assert.Nil(t, errMigrar)
+	assert.Nil(t, errFiltro)
+	assert.Nil(t, errAll)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "0.30", result[0].Monto.String())
+	assert.Equal(t, "1500", all[1].Monto.String())
 }
 
-func TestRepositoryLastID(t *testing.T) {
+func TestMigrarSQLFechas(t *testing.T) {
 	// Arrange
-	mockStore := &MockStore{
-		Data: []Transaccion{
-			{
-				Id:                10,
-				CodigoTransaccion: "ctr",
-				Moneda:            "MXN",
-				Monto:             0,
-				Emisor:            "Brandon",
-				Receptor:          "Juan",
-				FechaTransaccion:  "21/04/2022",
-			},
-		}}
-	repo := NewRepository(mockStore)
-	expected := 10
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "transacciones.db"))
+	assert.Nil(t, err)
+	defer db.Close()
+	zona, _ := fecha.CargarZona("America/Mexico_City")
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY)`)
+	assert.Nil(t, err)
+	for index, migracion := range migraciones(zona)[:3] {
+		assert.Nil(t, aplicarMigracion(db, index+1, migracion))
+	}
+	_, err = db.Exec(`INSERT INTO transacciones (codigo_transaccion, moneda, monto, emisor, receptor, fecha_transaccion)
+		VALUES ('ctr1', 'MXN', '10.00', 'Banamex', 'Bancomer', '01/04/2022'),
+		       ('ctr2', 'MXN', '20.00', 'Bancomer', 'Banamex', '04/04/2022')`)
+	assert.Nil(t, err)
 
 	// Act
-	result, err := repo.LastID()
+	errMigrar := MigrarSQL(db, zona)
+	all, errAll := NewSQLRepository(db).GetAll()
+	delDia, errDia := NewSQLRepository(db).GetTransaccionFiltrada(Filtro{
+		FechaDesde: fechaFiltro(time.Date(2022, 4, 4, 0, 0, 0, 0, zona)),
+		FechaHasta: fechaFiltro(time.Date(2022, 4, 4, 23, 59, 59, 0, zona)),
+	})
 
 	// Assert
-	assert.Nil(t, err)
-	assert.True(t, mockStore.readWasCalled)
-	assert.False(t, mockStore.writeWasCalled)
-	assert.Equal(t, expected, result)
+	assert.Nil(t, errMigrar)
+	assert.Nil(t, errAll)
+	assert.Equal(t, "2022-04-01T00:00:00-06:00", all[0].FechaTransaccion.Format(time.RFC3339))
+	assert.Equal(t, "2022-04-04T00:00:00-05:00", all[1].FechaTransaccion.Format(time.RFC3339))
+	assert.Nil(t, errDia)
+	assert.Len(t, delDia, 1)
+	assert.Equal(t, "ctr2", delDia[0].CodigoTransaccion)
 }
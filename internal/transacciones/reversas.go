@@ -0,0 +1,102 @@
+package transacciones
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+)
+
+var (
+	ErrReversaNoValida = errors.New("la transaccion no se puede revertir")
+	ErrReversaExcedida = errors.New("el monto a revertir excede lo que falta por revertir")
+	// ErrReversaVinculada impide eliminar una transaccion con reversas o una
+	// reversa, para no dejar referencias sin original ni cambiar lo revertido.
+	ErrReversaVinculada = errors.New("la transaccion esta vinculada a una reversa y no se puede eliminar")
+	// ErrReversaNoEditable impide cambiar una reversa pendiente con Update o
+	// Patch, que no revisan lo que falta por revertir de la original.
+	ErrReversaNoEditable = fmt.Errorf("%w: las reversas no se pueden modificar", ErrTransaccionNoEditable)
+)
+
+// Reversa pide devolver total o parcialmente una transaccion liquidada. Un
+// Monto nil devuelve todo lo que falte por revertir y un CodigoTransaccion
+// vacio se genera a partir del codigo de la original.
+type Reversa struct {
+	CodigoTransaccion string
+	Monto             *dinero.Monto
+	FechaTransaccion  time.Time
+}
+
+// cuentaComoRevertido indica si una reversa en el estado dado ya descuenta del
+// monto original; las rechazadas o revertidas no movieron dinero.
+func cuentaComoRevertido(estado Estado) bool {
+	return estado != ESTADO_RECHAZADA && estado != ESTADO_REVERTIDA
+}
+
+// MontoRevertido suma las reversas que descuentan del monto original.
+func MontoRevertido(reversas []Transaccion, escala int) (dinero.Monto, error) {
+	total := dinero.Nuevo(0, escala)
+	for _, reversa := range reversas {
+		if !cuentaComoRevertido(reversa.Estado) {
+			continue
+		}
+		var err error
+		if total, err = total.Sumar(reversa.Monto); err != nil {
+			return dinero.Monto{}, err
+		}
+	}
+	return total, nil
+}
+
+// planearReversa arma la transaccion compensatoria de la original, que ya tiene
+// las reversas indicadas. completa indica que con ella se revierte todo el
+// monto y la original debe pasar a revertida; si despues se rechaza alguna de
+// sus reversas, CambiarEstado la regresa a liquidada. El id lo asigna el
+// repositorio.
+func planearReversa(original Transaccion, reversas []Transaccion, solicitud Reversa) (reversa Transaccion, completa bool, err error) {
+	if original.Referencia != nil {
+		return Transaccion{}, false, fmt.Errorf("%w: la transaccion %d ya es una reversa", ErrReversaNoValida, original.Id)
+	}
+	if original.Estado != ESTADO_LIQUIDADA {
+		return Transaccion{}, false, fmt.Errorf("%w: la transaccion esta %s", ErrReversaNoValida, original.Estado)
+	}
+
+	revertido, err := MontoRevertido(reversas, original.Monto.Escala())
+	if err != nil {
+		return Transaccion{}, false, err
+	}
+	restante, err := original.Monto.Restar(revertido)
+	if err != nil {
+		return Transaccion{}, false, err
+	}
+
+	monto := restante
+	if solicitud.Monto != nil {
+		monto = *solicitud.Monto
+	}
+	if !monto.EsPositivo() {
+		return Transaccion{}, false, fmt.Errorf("%w: no queda monto por revertir", ErrReversaExcedida)
+	}
+	if monto.Cmp(restante) > 0 {
+		return Transaccion{}, false, fmt.Errorf("%w: quedan %s por revertir", ErrReversaExcedida, restante)
+	}
+
+	codigo := solicitud.CodigoTransaccion
+	if codigo == STRING_EMPTY {
+		codigo = fmt.Sprintf("%s-R%d", original.CodigoTransaccion, len(reversas)+1)
+	}
+	referencia := original.Id
+	return Transaccion{
+		CodigoTransaccion: codigo,
+		Moneda:            original.Moneda,
+		Monto:             monto,
+		Emisor:            original.Receptor,
+		EmisorId:          original.ReceptorId,
+		Receptor:          original.Emisor,
+		ReceptorId:        original.EmisorId,
+		FechaTransaccion:  solicitud.FechaTransaccion,
+		Estado:            ESTADO_PENDIENTE,
+		Referencia:        &referencia,
+	}, monto.Igual(restante), nil
+}
@@ -0,0 +1,25 @@
+package transacciones
+
+import (
+	"errors"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/riesgo"
+)
+
+var (
+	ErrEnRevision  = errors.New("la transaccion esta en revision y debe aprobarse antes de autorizarla")
+	ErrSinRevision = errors.New("la transaccion no esta en revision")
+)
+
+// Riesgo es la evaluacion de las reglas de riesgo que se guarda con la
+// transaccion. Aprobada indica que una transaccion marcada ya se reviso.
+type Riesgo struct {
+	riesgo.Evaluacion
+	Aprobada bool `json:"aprobada,omitempty"`
+}
+
+// EnRevision indica si la transaccion fue marcada por las reglas de riesgo y
+// sigue pendiente de aprobarse.
+func (t Transaccion) EnRevision() bool {
+	return t.Estado == ESTADO_PENDIENTE && t.Riesgo != nil && t.Riesgo.Accion == riesgo.ACCION_MARCAR && !t.Riesgo.Aprobada
+}
@@ -2,6 +2,17 @@ package transacciones
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/limites"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/riesgo"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
 )
 
 const (
@@ -9,54 +20,226 @@ const (
 	INT_ZERO     = 0
 )
 
+var ErrFechaNoValida = errors.New("la fecha de la transaccion es requerida")
+
 type Service interface {
 	GetAll() ([]Transaccion, error)
-	GetTransaccionFiltrada(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) ([]Transaccion, error)
+	GetTransaccionFiltrada(filtro Filtro) ([]Transaccion, error)
+	Listar(consulta Consulta) (Pagina, error)
 	GetTransaccion(id int) (Transaccion, error)
-	Store(codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error)
-	Update(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error)
-	Patch(id int, codigoTransaccion string, monto float64) (Transaccion, error)
+	GetByCodigo(codigoTransaccion string) (Transaccion, error)
+	Store(codigoTransaccion, moneda string, monto dinero.Monto, emisorId, receptorId int, fechaTransaccion time.Time) (Transaccion, error)
+	Update(id int, codigoTransaccion, moneda string, monto dinero.Monto, emisorId, receptorId int, fechaTransaccion time.Time) (Transaccion, error)
+	StoreLote(nuevas []Nueva, completo bool) ([]ResultadoLote, error)
+	Patch(id int, codigoTransaccion string, monto dinero.Monto) (Transaccion, error)
+	Transicionar(id int, nuevo Estado) (Transaccion, error)
+	EnRevision() ([]Transaccion, error)
+	AprobarRevision(id int) (Transaccion, error)
+	StoreReversa(id int, reversa Reversa) (Transaccion, error)
+	GetReversas(id int) ([]Transaccion, error)
 	Delete(id int) error
 }
 
+// Observador recibe cada transaccion despues de darse de alta o modificarse,
+// y el id de cada una que se elimina. Un error del observador no revierte el
+// cambio, por lo que debe poder reconstruirse a partir de las transacciones.
+type Observador interface {
+	Actualizada(transaccion Transaccion) error
+	Eliminada(id int) error
+}
+
+// service notifica a los observadores con escritura tomado, de modo que los
+// reciben en el mismo orden en que se aplicaron los cambios.
 type service struct {
-	repository Repository
+	repository   Repository
+	monedas      monedas.Service
+	partes       partes.Service
+	limites      limites.Service
+	riesgo       riesgo.Service
+	observadores []Observador
+	escritura    sync.Mutex
 }
 
-func NewService(r Repository) Service {
-	return &service{repository: r}
+// Opcion configura una dependencia opcional del servicio.
+type Opcion func(*service)
+
+// ConMonedas indica el catalogo contra el que se validan las monedas. Por
+// defecto se usa el catalogo ISO 4217.
+func ConMonedas(m monedas.Service) Opcion {
+	return func(s *service) {
+		s.monedas = m
+	}
 }
 
-func (s *service) GetAll() ([]Transaccion, error) {
-	return s.repository.GetAll()
+// ConPartes indica el registro en el que se buscan el emisor y el receptor.
+// Sin registro no se puede dar de alta ni actualizar ninguna transaccion.
+func ConPartes(p partes.Service) Opcion {
+	return func(s *service) {
+		s.partes = p
+	}
 }
 
-func (s *service) GetTransaccionFiltrada(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) ([]Transaccion, error) {
-	transacciones, err := s.repository.GetAll()
+// ConLimites indica los limites por emisor que deben respetar las
+// transacciones nuevas y las modificadas.
+func ConLimites(l limites.Service) Opcion {
+	return func(s *service) {
+		s.limites = l
+	}
+}
+
+// ConReglas indica las reglas de riesgo con que se evaluan las transacciones
+// nuevas y las modificadas.
+func ConReglas(r riesgo.Service) Opcion {
+	return func(s *service) {
+		s.riesgo = r
+	}
+}
 
+// ConObservador agrega un observador de los cambios a las transacciones.
+func ConObservador(o Observador) Opcion {
+	return func(s *service) {
+		s.observadores = append(s.observadores, o)
+	}
+}
+
+func NewService(r Repository, opciones ...Opcion) Service {
+	s := &service{repository: r, monedas: monedas.NewService(monedas.NewRepository())}
+	for _, opcion := range opciones {
+		opcion(s)
+	}
+	return s
+}
+
+// escribir aplica cambio y notifica la transaccion resultante.
+func (s *service) escribir(cambio func() (Transaccion, error)) (Transaccion, error) {
+	s.escritura.Lock()
+	defer s.escritura.Unlock()
+
+	transaccion, err := cambio()
 	if err != nil {
-		return []Transaccion{}, err
+		return Transaccion{}, err
 	}
+	s.notificar(transaccion)
+	return transaccion, nil
+}
 
-	var transaccionesFiltradas []Transaccion
+func (s *service) notificar(transaccion Transaccion) {
+	for _, observador := range s.observadores {
+		if err := observador.Actualizada(transaccion); err != nil {
+			log.Printf("no se logro notificar la transaccion %d: %v", transaccion.Id, err)
+		}
+	}
+}
 
-	for _, transaccion := range transacciones {
-		if (id == INT_ZERO || transaccion.Id == id) &&
-			(codigoTransaccion == STRING_EMPTY || transaccion.CodigoTransaccion == codigoTransaccion) &&
-			(moneda == STRING_EMPTY || transaccion.Moneda == moneda) &&
-			(monto == INT_ZERO || transaccion.Monto == monto) &&
-			(emisor == STRING_EMPTY || transaccion.Emisor == emisor) &&
-			(receptor == STRING_EMPTY || transaccion.Receptor == receptor) &&
-			(fechaTransaccion == STRING_EMPTY || transaccion.FechaTransaccion == fechaTransaccion) {
-			transaccionesFiltradas = append(transaccionesFiltradas, transaccion)
+// prepararMonto valida la moneda y expresa el monto con sus decimales. El
+// monto debe ser positivo: uno negativo restaria del acumulado de los limites.
+// Regresa el codigo normalizado de la moneda.
+func (s *service) prepararMonto(codigoMoneda string, monto dinero.Monto) (string, dinero.Monto, error) {
+	moneda, err := s.monedas.Validar(codigoMoneda)
+	if err != nil {
+		return "", dinero.Monto{}, err
+	}
+	if !monto.EsPositivo() {
+		return "", dinero.Monto{}, fmt.Errorf("%w: el monto debe ser positivo", ErrMontoNoValido)
+	}
+	monto, err = normalizarMonto(monto, moneda)
+	if err != nil {
+		return "", dinero.Monto{}, err
+	}
+	return moneda.Codigo, monto, nil
+}
+
+// resolverPartes regresa el emisor y el receptor, que deben estar registrados
+// y activos.
+func (s *service) resolverPartes(emisorId, receptorId int) (partes.Parte, partes.Parte, error) {
+	if s.partes == nil {
+		return partes.Parte{}, partes.Parte{}, fmt.Errorf("%w: no hay un registro de partes", partes.ErrParteNoEncontrada)
+	}
+	emisor, err := s.partes.Activa(emisorId)
+	if err != nil {
+		return partes.Parte{}, partes.Parte{}, fmt.Errorf("emisor: %w", err)
+	}
+	receptor, err := s.partes.Activa(receptorId)
+	if err != nil {
+		return partes.Parte{}, partes.Parte{}, fmt.Errorf("receptor: %w", err)
+	}
+	return emisor, receptor, nil
+}
+
+// verificarLimites revisa los limites del emisor contra sus demas
+// transacciones en la moneda, sin contar la transaccion excluir ni las
+// rechazadas. lote son transacciones aun no guardadas que tambien cuentan.
+// Las transacciones anteriores al registro de partes no tienen emisor y no se
+// revisan.
+func (s *service) verificarLimites(excluir int, lote []Transaccion, emisorId int, moneda string, monto dinero.Monto, fechaTransaccion time.Time) error {
+	if s.limites == nil || emisorId == INT_ZERO {
+		return nil
+	}
+	previos := func(desde, hasta time.Time) ([]limites.Movimiento, error) {
+		pagina, err := s.repository.Listar(Consulta{Filtro: Filtro{Monedas: []string{moneda}, FechaDesde: &desde, FechaHasta: &hasta}})
+		if err != nil && !errors.Is(err, ErrSinResultados) {
+			return nil, err
+		}
+		cuenta := func(transaccion Transaccion) bool {
+			return transaccion.EmisorId == emisorId && transaccion.Estado != ESTADO_RECHAZADA &&
+				!transaccion.FechaTransaccion.Before(desde) && transaccion.FechaTransaccion.Before(hasta)
 		}
+		var movimientos []limites.Movimiento
+		for _, transaccion := range pagina.Transacciones {
+			if transaccion.Id != excluir && cuenta(transaccion) {
+				movimientos = append(movimientos, limites.Movimiento{Monto: transaccion.Monto, Fecha: transaccion.FechaTransaccion})
+			}
+		}
+		// Listar ya filtro la moneda de las guardadas.
+		for _, transaccion := range lote {
+			if transaccion.Moneda == moneda && cuenta(transaccion) {
+				movimientos = append(movimientos, limites.Movimiento{Monto: transaccion.Monto, Fecha: transaccion.FechaTransaccion})
+			}
+		}
+		return movimientos, nil
 	}
+	return s.limites.Verificar(emisorId, moneda, monto, fechaTransaccion, previos)
+}
 
-	if len(transaccionesFiltradas) == INT_ZERO {
-		return []Transaccion{}, errors.New("ninguna transaccion fue encontrada")
+// evaluarRiesgo aplica las reglas de riesgo a la transaccion. Sin reglas
+// regresa nil; si una regla la rechaza regresa un *riesgo.Rechazo.
+func (s *service) evaluarRiesgo(transaccion Transaccion) (*Riesgo, error) {
+	if s.riesgo == nil {
+		return nil, nil
 	}
+	evaluacion, err := s.riesgo.Evaluar(riesgo.Datos{
+		CodigoTransaccion: transaccion.CodigoTransaccion,
+		Moneda:            transaccion.Moneda,
+		Monto:             transaccion.Monto,
+		Emisor:            transaccion.Emisor,
+		EmisorId:          transaccion.EmisorId,
+		Receptor:          transaccion.Receptor,
+		ReceptorId:        transaccion.ReceptorId,
+		Fecha:             transaccion.FechaTransaccion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if evaluacion.Accion == riesgo.ACCION_RECHAZAR {
+		return nil, &riesgo.Rechazo{Evaluacion: evaluacion}
+	}
+	return &Riesgo{Evaluacion: evaluacion}, nil
+}
 
-	return transaccionesFiltradas, nil
+func (s *service) GetAll() ([]Transaccion, error) {
+	return s.repository.GetAll()
+}
+
+func (s *service) GetTransaccionFiltrada(filtro Filtro) ([]Transaccion, error) {
+	return s.repository.GetTransaccionFiltrada(filtro)
+}
+
+func (s *service) Listar(consulta Consulta) (Pagina, error) {
+	consulta, err := consulta.validar()
+	if err != nil {
+		return Pagina{}, err
+	}
+	return s.repository.Listar(consulta)
 }
 
 func (s *service) GetTransaccion(id int) (Transaccion, error) {
@@ -75,27 +258,204 @@ func (s *service) GetTransaccion(id int) (Transaccion, error) {
 	return Transaccion{}, errors.New("no se enconto la transaccion")
 }
 
-func (s *service) Store(codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error) {
-	id, err := s.repository.LastID()
+func (s *service) GetByCodigo(codigoTransaccion string) (Transaccion, error) {
+	return s.repository.GetByCodigo(codigoTransaccion)
+}
+
+func (s *service) Store(codigoTransaccion, moneda string, monto dinero.Monto, emisorId, receptorId int, fechaTransaccion time.Time) (Transaccion, error) {
+	if fechaTransaccion.IsZero() {
+		return Transaccion{}, ErrFechaNoValida
+	}
+	moneda, monto, err := s.prepararMonto(moneda, monto)
 	if err != nil {
 		return Transaccion{}, err
 	}
-	id++
-	transaccion, err := s.repository.Store(id, codigoTransaccion, moneda, monto, emisor, receptor, fechaTransaccion)
+	emisor, receptor, err := s.resolverPartes(emisorId, receptorId)
 	if err != nil {
 		return Transaccion{}, err
 	}
-	return transaccion, nil
+	return s.escribir(func() (Transaccion, error) {
+		if err := s.verificarLimites(INT_ZERO, nil, emisor.Id, moneda, monto, fechaTransaccion); err != nil {
+			return Transaccion{}, err
+		}
+		evaluacion, err := s.evaluarRiesgo(Transaccion{CodigoTransaccion: codigoTransaccion, Moneda: moneda, Monto: monto, Emisor: emisor.Nombre,
+			EmisorId: emisor.Id, Receptor: receptor.Nombre, ReceptorId: receptor.Id, FechaTransaccion: fechaTransaccion})
+		if err != nil {
+			return Transaccion{}, err
+		}
+		return s.repository.Store(codigoTransaccion, moneda, monto, emisor, receptor, fechaTransaccion, evaluacion)
+	})
 }
 
-func (s *service) Update(id int, codigoTransaccion, moneda string, monto float64, emisor, receptor, fechaTransaccion string) (Transaccion, error) {
-	return s.repository.Update(id, codigoTransaccion, moneda, monto, emisor, receptor, fechaTransaccion)
+func (s *service) Update(id int, codigoTransaccion, moneda string, monto dinero.Monto, emisorId, receptorId int, fechaTransaccion time.Time) (Transaccion, error) {
+	if fechaTransaccion.IsZero() {
+		return Transaccion{}, ErrFechaNoValida
+	}
+	moneda, monto, err := s.prepararMonto(moneda, monto)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	emisor, receptor, err := s.resolverPartes(emisorId, receptorId)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	return s.escribir(func() (Transaccion, error) {
+		if err := s.verificarLimites(id, nil, emisor.Id, moneda, monto, fechaTransaccion); err != nil {
+			return Transaccion{}, err
+		}
+		evaluacion, err := s.evaluarRiesgo(Transaccion{CodigoTransaccion: codigoTransaccion, Moneda: moneda, Monto: monto, Emisor: emisor.Nombre,
+			EmisorId: emisor.Id, Receptor: receptor.Nombre, ReceptorId: receptor.Id, FechaTransaccion: fechaTransaccion})
+		if err != nil {
+			return Transaccion{}, err
+		}
+		// Una modificacion se vuelve a revisar aunque antes se hubiera aprobado.
+		return s.repository.Update(id, codigoTransaccion, moneda, monto, emisor, receptor, fechaTransaccion, evaluacion)
+	})
 }
 
-func (s *service) Patch(id int, codigoTransaccion string, monto float64) (Transaccion, error) {
-	return s.repository.Patch(id, codigoTransaccion, monto)
+func (s *service) Patch(id int, codigoTransaccion string, monto dinero.Monto) (Transaccion, error) {
+	transaccion, err := s.GetTransaccion(id)
+	if err != nil {
+		return Transaccion{}, errors.New("no se encontro la transaccion a actualizar")
+	}
+	_, monto, err = s.prepararMonto(transaccion.Moneda, monto)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	return s.escribir(func() (Transaccion, error) {
+		if err := s.verificarLimites(id, nil, transaccion.EmisorId, transaccion.Moneda, monto, transaccion.FechaTransaccion); err != nil {
+			return Transaccion{}, err
+		}
+		modificada := transaccion
+		modificada.CodigoTransaccion, modificada.Monto = codigoTransaccion, monto
+		evaluacion, err := s.evaluarRiesgo(modificada)
+		if err != nil {
+			return Transaccion{}, err
+		}
+		return s.repository.Patch(id, codigoTransaccion, monto, evaluacion)
+	})
+}
+
+// Transicionar pasa la transaccion a nuevo si la maquina de estados lo permite
+// desde su estado actual.
+func (s *service) Transicionar(id int, nuevo Estado) (Transaccion, error) {
+	transaccion, err := s.GetTransaccion(id)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	if !transaccion.Estado.PuedePasarA(nuevo) {
+		return Transaccion{}, fmt.Errorf("%w: de %s a %s", ErrTransicionNoValida, transaccion.Estado, nuevo)
+	}
+	return s.escribir(func() (Transaccion, error) {
+		// La revision se consulta con escritura tomada para no autorizar una
+		// transaccion que se acaba de modificar y volvio a marcarse.
+		if nuevo == ESTADO_AUTORIZADA {
+			actual, err := s.GetTransaccion(id)
+			if err != nil {
+				return Transaccion{}, err
+			}
+			if actual.EnRevision() {
+				return Transaccion{}, ErrEnRevision
+			}
+		}
+		cambiada, err := s.repository.CambiarEstado(id, transaccion.Estado, nuevo)
+		if err != nil {
+			return Transaccion{}, err
+		}
+		// Rechazar una reversa puede regresar su original a liquidada.
+		if cambiada.Referencia != nil && nuevo == ESTADO_RECHAZADA {
+			if original, err := s.GetTransaccion(*cambiada.Referencia); err == nil {
+				s.notificar(original)
+			}
+		}
+		return cambiada, nil
+	})
+}
+
+// EnRevision regresa las transacciones marcadas por las reglas de riesgo que
+// esperan aprobarse, de la de mayor puntaje a la de menor.
+func (s *service) EnRevision() ([]Transaccion, error) {
+	transacciones, err := s.repository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	revision := []Transaccion{}
+	for _, transaccion := range transacciones {
+		if transaccion.EnRevision() {
+			revision = append(revision, transaccion)
+		}
+	}
+	sort.SliceStable(revision, func(i, j int) bool {
+		if revision[i].Riesgo.Puntaje != revision[j].Riesgo.Puntaje {
+			return revision[i].Riesgo.Puntaje > revision[j].Riesgo.Puntaje
+		}
+		return revision[i].Id < revision[j].Id
+	})
+	return revision, nil
+}
+
+// AprobarRevision saca de revision una transaccion marcada para que pueda
+// autorizarse. Para rechazarla se usa Transicionar.
+func (s *service) AprobarRevision(id int) (Transaccion, error) {
+	return s.escribir(func() (Transaccion, error) {
+		transaccion, err := s.GetTransaccion(id)
+		if err != nil {
+			return Transaccion{}, err
+		}
+		if !transaccion.EnRevision() {
+			return Transaccion{}, ErrSinRevision
+		}
+		aprobado := *transaccion.Riesgo
+		aprobado.Aprobada = true
+		return s.repository.CambiarRiesgo(id, &aprobado)
+	})
+}
+
+// StoreReversa devuelve total o parcialmente la transaccion id con una nueva
+// transaccion que la referencia.
+func (s *service) StoreReversa(id int, reversa Reversa) (Transaccion, error) {
+	if reversa.FechaTransaccion.IsZero() {
+		return Transaccion{}, ErrFechaNoValida
+	}
+	if reversa.Monto != nil {
+		original, err := s.GetTransaccion(id)
+		if err != nil {
+			return Transaccion{}, err
+		}
+		_, monto, err := s.prepararMonto(original.Moneda, *reversa.Monto)
+		if err != nil {
+			return Transaccion{}, err
+		}
+		reversa.Monto = &monto
+	}
+	return s.escribir(func() (Transaccion, error) {
+		transaccion, err := s.repository.StoreReversa(id, reversa)
+		if err != nil {
+			return Transaccion{}, err
+		}
+		// Una reversa completa tambien cambia el estado de la original.
+		if original, err := s.GetTransaccion(id); err == nil {
+			s.notificar(original)
+		}
+		return transaccion, nil
+	})
+}
+
+func (s *service) GetReversas(id int) ([]Transaccion, error) {
+	return s.repository.GetReversas(id)
 }
 
 func (s *service) Delete(id int) error {
-	return s.repository.Delete(id)
+	s.escritura.Lock()
+	defer s.escritura.Unlock()
+
+	if err := s.repository.Delete(id); err != nil {
+		return err
+	}
+	for _, observador := range s.observadores {
+		if err := observador.Eliminada(id); err != nil {
+			log.Printf("no se logro notificar la baja de la transaccion %d: %v", id, err)
+		}
+	}
+	return nil
 }
@@ -1,29 +1,54 @@
 package transacciones
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/limites"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/monedas"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/riesgo"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/store"
 	"github.com/stretchr/testify/assert"
 )
 
+// registroPartes crea un registro con Juan (1) y Pedro (2) activos y Brandon
+// (3) inactivo.
+func registroPartes(t *testing.T) partes.Service {
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "partes.json")}
+	if err := db.Write([]partes.Parte{
+		{Id: 1, Nombre: "Juan", Tipo: partes.TIPO_PERSONA, Estado: partes.ESTADO_ACTIVA},
+		{Id: 2, Nombre: "Pedro", Tipo: partes.TIPO_PERSONA, Estado: partes.ESTADO_ACTIVA},
+		{Id: 3, Nombre: "Brandon", Tipo: partes.TIPO_PERSONA, Estado: partes.ESTADO_INACTIVA},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return partes.NewService(partes.NewRepository(db))
+}
+
 func TestServiceGetAll(t *testing.T) {
 	// Arrange
 	expected := []Transaccion{{
 		Id:                1,
 		CodigoTransaccion: "ctr1",
 		Moneda:            "MXN",
-		Monto:             0,
+		Monto:             dinero.DebeParsear("0"),
 		Emisor:            "Bancomer",
 		Receptor:          "Banamex",
-		FechaTransaccion:  "21/04/2022",
+		FechaTransaccion:  fechaPrueba("21/04/2022"),
+		Estado:            ESTADO_PENDIENTE,
 	}, {
 		Id:                2,
 		CodigoTransaccion: "ctr2",
 		Moneda:            "USD",
-		Monto:             100,
+		Monto:             dinero.DebeParsear("100"),
 		Emisor:            "Banamex",
 		Receptor:          "Bancomer",
-		FechaTransaccion:  "22/04/2022",
+		FechaTransaccion:  fechaPrueba("22/04/2022"),
+		Estado:            ESTADO_PENDIENTE,
 	}}
 	mock := MockStore{
 		Data: expected,
@@ -48,32 +73,31 @@ func TestServiceGetTransaccionFiltrada(t *testing.T) {
 		Id:                2,
 		CodigoTransaccion: "ctr2",
 		Moneda:            "USD",
-		Monto:             100,
+		Monto:             dinero.DebeParsear("100"),
 		Emisor:            "Banamex",
 		Receptor:          "Bancomer",
-		FechaTransaccion:  "22/04/2022",
+		FechaTransaccion:  fechaPrueba("22/04/2022"),
+		Estado:            ESTADO_PENDIENTE,
 	}}
-	filter := Transaccion{
-		CodigoTransaccion: "ctr2",
-	}
+	filter := Filtro{CodigoTransaccion: textoFiltro("ctr2")}
 
 	mock := MockStore{
 		Data: []Transaccion{{
 			Id:                1,
 			CodigoTransaccion: "ctr1",
 			Moneda:            "MXN",
-			Monto:             0,
+			Monto:             dinero.DebeParsear("0"),
 			Emisor:            "Bancomer",
 			Receptor:          "Banamex",
-			FechaTransaccion:  "21/04/2022",
+			FechaTransaccion:  fechaPrueba("21/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}, resultExpected[0]},
 	}
 	repo := NewRepository(&mock)
 	service := NewService(repo)
 
 	// Act
-	result, err := service.GetTransaccionFiltrada(filter.Id, filter.CodigoTransaccion, filter.Moneda,
-		filter.Monto, filter.Emisor, filter.Receptor, filter.FechaTransaccion)
+	result, err := service.GetTransaccionFiltrada(filter)
 
 	// Assert
 	assert.Nil(t, err)
@@ -90,10 +114,11 @@ func TestServiceGetTransaccion(t *testing.T) {
 			Id:                1,
 			CodigoTransaccion: "ctr1",
 			Moneda:            "MXN",
-			Monto:             0,
+			Monto:             dinero.DebeParsear("0"),
 			Emisor:            "Brandon",
 			Receptor:          "Juan",
-			FechaTransaccion:  "21/04/2022",
+			FechaTransaccion:  fechaPrueba("21/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}},
 	}
 	repo := NewRepository(&mock)
@@ -117,27 +142,31 @@ func TestServiceStore(t *testing.T) {
 			Id:                100,
 			CodigoTransaccion: "Before Update",
 			Moneda:            "MXN",
-			Monto:             0,
+			Monto:             dinero.DebeParsear("0"),
 			Emisor:            "Brandon",
 			Receptor:          "Juan",
-			FechaTransaccion:  "21/04/2022",
+			FechaTransaccion:  fechaPrueba("21/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}},
 	}
 	repo := NewRepository(&mock)
-	service := NewService(repo)
+	service := NewService(repo, ConPartes(registroPartes(t)))
 	expected := Transaccion{
 		Id:                101,
 		CodigoTransaccion: "After Update",
 		Moneda:            "USD",
-		Monto:             100,
+		Monto:             dinero.DebeParsear("100.00"),
 		Emisor:            "Juan",
+		EmisorId:          1,
 		Receptor:          "Pedro",
-		FechaTransaccion:  "22/04/2022",
+		ReceptorId:        2,
+		FechaTransaccion:  fechaPrueba("22/04/2022"),
+		Estado:            ESTADO_PENDIENTE,
 	}
 
 	// Act
 	result, err := service.Store(expected.CodigoTransaccion, expected.Moneda,
-		expected.Monto, expected.Emisor, expected.Receptor, expected.FechaTransaccion)
+		expected.Monto, expected.EmisorId, expected.ReceptorId, expected.FechaTransaccion)
 
 	// Assert
 	assert.Nil(t, err)
@@ -153,27 +182,31 @@ func TestServiceUpdate(t *testing.T) {
 			Id:                1,
 			CodigoTransaccion: "Before Update",
 			Moneda:            "MXN",
-			Monto:             0,
+			Monto:             dinero.DebeParsear("0"),
 			Emisor:            "Brandon",
 			Receptor:          "Juan",
-			FechaTransaccion:  "21/04/2022",
+			FechaTransaccion:  fechaPrueba("21/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}},
 	}
 	repo := NewRepository(&mock)
-	service := NewService(repo)
+	service := NewService(repo, ConPartes(registroPartes(t)))
 	expected := Transaccion{
 		Id:                1,
 		CodigoTransaccion: "After Update",
 		Moneda:            "USD",
-		Monto:             100,
+		Monto:             dinero.DebeParsear("100.00"),
 		Emisor:            "Juan",
+		EmisorId:          1,
 		Receptor:          "Pedro",
-		FechaTransaccion:  "22/04/2022",
+		ReceptorId:        2,
+		FechaTransaccion:  fechaPrueba("22/04/2022"),
+		Estado:            ESTADO_PENDIENTE,
 	}
 
 	// Act
 	result, err := service.Update(expected.Id, expected.CodigoTransaccion, expected.Moneda,
-		expected.Monto, expected.Emisor, expected.Receptor, expected.FechaTransaccion)
+		expected.Monto, expected.EmisorId, expected.ReceptorId, expected.FechaTransaccion)
 
 	// Assert
 	assert.Nil(t, err)
@@ -189,10 +222,11 @@ func TestServicePatch(t *testing.T) {
 			Id:                1,
 			CodigoTransaccion: "Before Update",
 			Moneda:            "MXN",
-			Monto:             0,
+			Monto:             dinero.DebeParsear("0"),
 			Emisor:            "Brandon",
 			Receptor:          "Juan",
-			FechaTransaccion:  "21/04/2022",
+			FechaTransaccion:  fechaPrueba("21/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}},
 	}
 	repo := NewRepository(&mock)
@@ -200,7 +234,7 @@ func TestServicePatch(t *testing.T) {
 
 	id := 1
 	codigoTransaccion := "After Update"
-	monto := 200.0
+	monto := dinero.DebeParsear("200.00")
 
 	// Act
 	result, err := service.Patch(id, codigoTransaccion, monto)
@@ -221,26 +255,29 @@ func TestServiceDelete(t *testing.T) {
 			Id:                1,
 			CodigoTransaccion: "ctr1",
 			Moneda:            "MXN",
-			Monto:             100,
+			Monto:             dinero.DebeParsear("100"),
 			Emisor:            "Banxico",
 			Receptor:          "Banamex",
-			FechaTransaccion:  "21/04/2022",
+			FechaTransaccion:  fechaPrueba("21/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}, {
 			Id:                2,
 			CodigoTransaccion: "ctr2",
 			Moneda:            "MXN",
-			Monto:             200,
+			Monto:             dinero.DebeParsear("200"),
 			Emisor:            "Bancomer",
 			Receptor:          "Banxico",
-			FechaTransaccion:  "22/04/2022",
+			FechaTransaccion:  fechaPrueba("22/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}, {
 			Id:                3,
 			CodigoTransaccion: "ctr3",
 			Moneda:            "MXN",
-			Monto:             300,
+			Monto:             dinero.DebeParsear("300"),
 			Emisor:            "Banamex",
 			Receptor:          "Bancomer",
-			FechaTransaccion:  "23/04/2022",
+			FechaTransaccion:  fechaPrueba("23/04/2022"),
+			Estado:            ESTADO_PENDIENTE,
 		}},
 	}
 	repo := NewRepository(&mock)
@@ -259,3 +296,337 @@ func TestServiceDelete(t *testing.T) {
 	assert.NotNil(t, err2)
 	assert.Len(t, mock.Data, lenExpected)
 }
+
+func TestServiceStoreMontoNoValido(t *testing.T) {
+	// Arrange
+	mock := MockStore{Data: []Transaccion{}}
+	repo := NewRepository(&mock)
+	service := NewService(repo)
+
+	// Act
+	result, err := service.Store("ctr1", "MXN", dinero.DebeParsear("100.555"), 1, 2, fechaPrueba("22/04/2022"))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrMontoNoValido)
+	assert.False(t, mock.writeWasCalled)
+	assert.Empty(t, result)
+}
+
+func TestServiceStoreMonedaNoValida(t *testing.T) {
+	// Arrange
+	mock := MockStore{Data: []Transaccion{}}
+	repo := NewRepository(&mock)
+	service := NewService(repo, ConMonedas(monedas.NewService(monedas.NewRepository())), ConPartes(registroPartes(t)))
+
+	// Act
+	result, err := service.Store("ctr1", "XYZ", dinero.DebeParsear("100"), 1, 2, fechaPrueba("22/04/2022"))
+	normalizada, errNormalizada := service.Store("ctr2", "jpy", dinero.DebeParsear("100"), 1, 2, fechaPrueba("22/04/2022"))
+
+	// Assert
+	assert.ErrorIs(t, err, monedas.ErrMonedaNoValida)
+	assert.Empty(t, result)
+	assert.Nil(t, errNormalizada)
+	assert.Equal(t, "JPY", normalizada.Moneda)
+	assert.Equal(t, "100", normalizada.Monto.String())
+}
+
+func TestServiceStoreFechaNoValida(t *testing.T) {
+	// Arrange
+	mock := MockStore{Data: []Transaccion{}}
+	service := NewService(NewRepository(&mock))
+
+	// Act
+	result, err := service.Store("ctr1", "MXN", dinero.DebeParsear("100"), 1, 2, time.Time{})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrFechaNoValida)
+	assert.Empty(t, result)
+}
+
+func TestServiceStoreParteNoValida(t *testing.T) {
+	// Arrange
+	mock := MockStore{Data: []Transaccion{}}
+	service := NewService(NewRepository(&mock), ConPartes(registroPartes(t)))
+	sinRegistro := NewService(NewRepository(&mock))
+
+	// Act
+	_, errNoExiste := service.Store("ctr1", "MXN", dinero.DebeParsear("100"), 1, 99, fechaPrueba("22/04/2022"))
+	_, errInactiva := service.Store("ctr1", "MXN", dinero.DebeParsear("100"), 3, 2, fechaPrueba("22/04/2022"))
+	_, errSinRegistro := sinRegistro.Store("ctr1", "MXN", dinero.DebeParsear("100"), 1, 2, fechaPrueba("22/04/2022"))
+
+	// Assert
+	assert.ErrorIs(t, errNoExiste, partes.ErrParteNoEncontrada)
+	assert.ErrorIs(t, errInactiva, partes.ErrParteInactiva)
+	assert.ErrorIs(t, errSinRegistro, partes.ErrParteNoEncontrada)
+	assert.False(t, mock.writeWasCalled)
+}
+
+func TestServiceStoreLimites(t *testing.T) {
+	// Arrange
+	db := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "limites.json")}
+	_ = db.Write([]limites.Limite{{EmisorId: 1, Moneda: "MXN", TotalDiario: montoFiltro("1000.00")}})
+	mock := MockStore{Data: []Transaccion{}}
+	service := NewService(NewRepository(&mock), ConPartes(registroPartes(t)), ConLimites(limites.NewService(limites.NewRepository(db))))
+	primera, _ := service.Store("ctr1", "MXN", dinero.DebeParsear("600"), 1, 2, fechaPrueba("21/04/2022"))
+	_, _ = service.Store("ctr2", "MXN", dinero.DebeParsear("400"), 1, 2, fechaPrueba("21/04/2022"))
+
+	// Act
+	_, errNegativo := service.Store("ctr7", "MXN", dinero.DebeParsear("-600"), 1, 2, fechaPrueba("21/04/2022"))
+	_, errUpdateNegativo := service.Update(primera.Id, "ctr1", "MXN", dinero.DebeParsear("-600"), 1, 2, fechaPrueba("21/04/2022"))
+	_, errExcedido := service.Store("ctr3", "MXN", dinero.DebeParsear("0.01"), 1, 2, fechaPrueba("21/04/2022"))
+	_, errOtroDia := service.Store("ctr4", "MXN", dinero.DebeParsear("0.01"), 1, 2, fechaPrueba("22/04/2022"))
+	_, errOtroEmisor := service.Store("ctr5", "MXN", dinero.DebeParsear("5000"), 2, 1, fechaPrueba("21/04/2022"))
+	_, errPatch := service.Patch(primera.Id, "ctr1", dinero.DebeParsear("600.01"))
+	_, errUpdate := service.Update(primera.Id, "ctr1", "MXN", dinero.DebeParsear("500"), 1, 2, fechaPrueba("21/04/2022"))
+	_, _ = service.Transicionar(primera.Id, ESTADO_RECHAZADA)
+	_, errRechazada := service.Store("ctr6", "MXN", dinero.DebeParsear("600"), 1, 2, fechaPrueba("21/04/2022"))
+
+	// Assert
+	assert.ErrorIs(t, errNegativo, ErrMontoNoValido)
+	assert.ErrorIs(t, errUpdateNegativo, ErrMontoNoValido)
+	assert.ErrorIs(t, errExcedido, limites.ErrLimiteExcedido)
+	assert.Nil(t, errOtroDia)
+	assert.Nil(t, errOtroEmisor)
+	assert.ErrorIs(t, errPatch, limites.ErrLimiteExcedido)
+	assert.Nil(t, errUpdate)
+	assert.Nil(t, errRechazada)
+}
+
+func TestServiceStoreReglasRiesgo(t *testing.T) {
+	// Arrange
+	reglas := filepath.Join(t.TempDir(), "reglas.txt")
+	_ = os.WriteFile(reglas, []byte("usd_alto: monto > 10000 and moneda == USD -> flag 60\nmonto > 1000000 -> reject\n"), 0666)
+	mock := MockStore{Data: []Transaccion{}}
+	service := NewService(NewRepository(&mock), ConPartes(registroPartes(t)), ConReglas(riesgo.NewService(riesgo.NewRepository(reglas))))
+	normal, _ := service.Store("ctr1", "USD", dinero.DebeParsear("100"), 1, 2, fechaPrueba("21/04/2022"))
+
+	// Act
+	marcada, errMarcada := service.Store("ctr2", "USD", dinero.DebeParsear("20000"), 1, 2, fechaPrueba("21/04/2022"))
+	_, errRechazada := service.Store("ctr3", "USD", dinero.DebeParsear("2000000"), 1, 2, fechaPrueba("21/04/2022"))
+	revision, errRevision := service.EnRevision()
+	_, errAutorizar := service.Transicionar(marcada.Id, ESTADO_AUTORIZADA)
+	_, errSinRevision := service.AprobarRevision(normal.Id)
+	aprobada, errAprobar := service.AprobarRevision(marcada.Id)
+	_, errAutorizarAprobada := service.Transicionar(marcada.Id, ESTADO_AUTORIZADA)
+	_, errPatch := service.Patch(normal.Id, "ctr1", dinero.DebeParsear("10001"))
+	revisionFinal, _ := service.EnRevision()
+
+	// Assert
+	assert.Equal(t, &Riesgo{Evaluacion: riesgo.Evaluacion{Accion: riesgo.ACCION_PERMITIR}}, normal.Riesgo)
+	assert.Nil(t, errMarcada)
+	assert.Equal(t, &Riesgo{Evaluacion: riesgo.Evaluacion{Accion: riesgo.ACCION_MARCAR, Puntaje: 60, Reglas: []string{"usd_alto"}}}, marcada.Riesgo)
+	assert.ErrorIs(t, errRechazada, riesgo.ErrRechazadaPorRiesgo)
+	assert.Nil(t, errRevision)
+	assert.Equal(t, []Transaccion{marcada}, revision)
+	assert.ErrorIs(t, errAutorizar, ErrEnRevision)
+	assert.ErrorIs(t, errSinRevision, ErrSinRevision)
+	assert.Nil(t, errAprobar)
+	assert.True(t, aprobada.Riesgo.Aprobada)
+	assert.Nil(t, errAutorizarAprobada)
+	assert.Nil(t, errPatch)
+	assert.Len(t, revisionFinal, 1)
+	assert.Equal(t, normal.Id, revisionFinal[0].Id)
+}
+
+func TestServiceRiesgoEnUnaEscritura(t *testing.T) {
+	// Arrange
+	reglas := filepath.Join(t.TempDir(), "reglas.txt")
+	_ = os.WriteFile(reglas, []byte("usd_alto: monto > 10000 and moneda == USD -> flag 60\n"), 0666)
+	db := &CountingStore{JsonFileStore: &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "transacciones.json")}}
+	service := NewService(NewRepository(db), ConPartes(registroPartes(t)), ConReglas(riesgo.NewService(riesgo.NewRepository(reglas))))
+	marcada, _ := service.Store("ctr1", "USD", dinero.DebeParsear("20000"), 1, 2, fechaPrueba("21/04/2022"))
+	aprobada, _ := service.AprobarRevision(marcada.Id)
+	antes := db.writes
+
+	// Act
+	actualizada, errUpdate := service.Update(marcada.Id, "ctr1", "USD", dinero.DebeParsear("30000"), 1, 2, fechaPrueba("21/04/2022"))
+	escriturasUpdate := db.writes - antes
+	parcial, errPatch := service.Patch(marcada.Id, "ctr1", dinero.DebeParsear("100"))
+	escriturasPatch := db.writes - antes - escriturasUpdate
+
+	// Assert
+	assert.True(t, aprobada.Riesgo.Aprobada)
+	assert.Nil(t, errUpdate)
+	assert.Equal(t, 1, escriturasUpdate)
+	assert.True(t, actualizada.EnRevision())
+	assert.Nil(t, errPatch)
+	assert.Equal(t, 1, escriturasPatch)
+	assert.False(t, parcial.EnRevision())
+}
+
+func TestServiceStoreLote(t *testing.T) {
+	// Arrange
+	nuevas := []Nueva{
+		{CodigoTransaccion: "ctr1", Moneda: "MXN", Monto: dinero.DebeParsear("100"), EmisorId: 1, ReceptorId: 2, FechaTransaccion: fechaPrueba("21/04/2022")},
+		{CodigoTransaccion: "ctr1", Moneda: "MXN", Monto: dinero.DebeParsear("200"), EmisorId: 1, ReceptorId: 2, FechaTransaccion: fechaPrueba("21/04/2022")},
+		{CodigoTransaccion: "ctr3", Moneda: "MXN", Monto: dinero.DebeParsear("300"), EmisorId: 2, ReceptorId: 1, FechaTransaccion: fechaPrueba("21/04/2022")},
+		{CodigoTransaccion: "ctr4", Moneda: "MXN", Monto: dinero.DebeParsear("0"), EmisorId: 2, ReceptorId: 1, FechaTransaccion: fechaPrueba("21/04/2022")},
+	}
+	completo := NewService(NewRepository(&MockStore{Data: []Transaccion{}}), ConPartes(registroPartes(t)))
+	parcial := NewService(NewRepository(&MockStore{Data: []Transaccion{}}), ConPartes(registroPartes(t)))
+
+	// Act
+	resultadosCompleto, errCompleto := completo.StoreLote(nuevas, true)
+	guardadasCompleto, _ := completo.GetAll()
+	resultadosParcial, errParcial := parcial.StoreLote(nuevas, false)
+	guardadasParcial, _ := parcial.GetAll()
+
+	// Assert
+	assert.Nil(t, errCompleto)
+	assert.Nil(t, errParcial)
+	assert.ErrorIs(t, resultadosCompleto[0].Err, ErrLoteCancelado)
+	assert.ErrorIs(t, resultadosCompleto[1].Err, ErrCodigoDuplicado)
+	assert.ErrorIs(t, resultadosCompleto[2].Err, ErrLoteCancelado)
+	assert.ErrorIs(t, resultadosCompleto[3].Err, ErrMontoNoValido)
+	assert.Empty(t, guardadasCompleto)
+	assert.Nil(t, resultadosParcial[0].Err)
+	assert.ErrorIs(t, resultadosParcial[1].Err, ErrCodigoDuplicado)
+	assert.Nil(t, resultadosParcial[2].Err)
+	assert.ErrorIs(t, resultadosParcial[3].Err, ErrMontoNoValido)
+	assert.Equal(t, []Transaccion{resultadosParcial[0].Transaccion, resultadosParcial[2].Transaccion}, guardadasParcial)
+}
+
+func TestServiceStoreLoteErrorEscritura(t *testing.T) {
+	// Arrange
+	nuevas := []Nueva{
+		{CodigoTransaccion: "ctr1", Moneda: "MXN", Monto: dinero.DebeParsear("100"), EmisorId: 1, ReceptorId: 2, FechaTransaccion: fechaPrueba("21/04/2022")},
+		{CodigoTransaccion: "ctr2", Moneda: "MXN", Monto: dinero.DebeParsear("0"), EmisorId: 1, ReceptorId: 2, FechaTransaccion: fechaPrueba("21/04/2022")},
+	}
+	mock := &ErrorWriteMockStore{MockStore{Data: []Transaccion{}}}
+	service := NewService(NewRepository(mock), ConPartes(registroPartes(t)))
+
+	// Act
+	resultados, err := service.StoreLote(nuevas, false)
+
+	// Assert
+	assert.NotNil(t, err)
+	assert.Nil(t, resultados)
+	assert.True(t, mock.writeWasCalled)
+}
+
+// ObservadorSpy registra los ids que recibe.
+type ObservadorSpy struct {
+	actualizadas []int
+	eliminadas   []int
+}
+
+func (o *ObservadorSpy) Actualizada(transaccion Transaccion) error {
+	o.actualizadas = append(o.actualizadas, transaccion.Id)
+	return nil
+}
+
+func (o *ObservadorSpy) Eliminada(id int) error {
+	o.eliminadas = append(o.eliminadas, id)
+	return nil
+}
+
+func TestServiceStoreLoteLimites(t *testing.T) {
+	// Arrange
+	limitesDb := &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "limites.json")}
+	_ = limitesDb.Write([]limites.Limite{{EmisorId: 1, Moneda: "MXN", TotalDiario: montoFiltro("1000.00")}})
+	nuevas := []Nueva{
+		{CodigoTransaccion: "ctr1", Moneda: "MXN", Monto: dinero.DebeParsear("600"), EmisorId: 1, ReceptorId: 2, FechaTransaccion: fechaPrueba("21/04/2022")},
+		{CodigoTransaccion: "ctr2", Moneda: "MXN", Monto: dinero.DebeParsear("500"), EmisorId: 1, ReceptorId: 2, FechaTransaccion: fechaPrueba("21/04/2022")},
+		{CodigoTransaccion: "ctr3", Moneda: "MXN", Monto: dinero.DebeParsear("300"), EmisorId: 2, ReceptorId: 1, FechaTransaccion: fechaPrueba("21/04/2022")},
+		{CodigoTransaccion: "ctr4", Moneda: "MXN", Monto: dinero.DebeParsear("400"), EmisorId: 1, ReceptorId: 2, FechaTransaccion: fechaPrueba("21/04/2022")},
+	}
+	nuevoServicio := func() (Service, *CountingStore, *ObservadorSpy) {
+		db := &CountingStore{JsonFileStore: &store.JsonFileStore{FileName: filepath.Join(t.TempDir(), "transacciones.json")}}
+		observador := &ObservadorSpy{}
+		return NewService(NewRepository(db), ConPartes(registroPartes(t)), ConLimites(limites.NewService(limites.NewRepository(limitesDb))),
+			ConObservador(observador)), db, observador
+	}
+	completo, dbCompleto, observadorCompleto := nuevoServicio()
+	parcial, dbParcial, observadorParcial := nuevoServicio()
+
+	// Act
+	resultadosCompleto, errCompleto := completo.StoreLote(nuevas, true)
+	resultadosParcial, errParcial := parcial.StoreLote(nuevas, false)
+	guardadasParcial, _ := parcial.GetAll()
+
+	// Assert
+	assert.Nil(t, errCompleto)
+	assert.Nil(t, errParcial)
+	assert.ErrorIs(t, resultadosCompleto[0].Err, ErrLoteCancelado)
+	assert.ErrorIs(t, resultadosCompleto[1].Err, limites.ErrLimiteExcedido)
+	assert.ErrorIs(t, resultadosCompleto[2].Err, ErrLoteCancelado)
+	assert.ErrorIs(t, resultadosCompleto[3].Err, ErrLoteCancelado)
+	assert.Equal(t, 0, dbCompleto.writes)
+	assert.Empty(t, observadorCompleto.actualizadas)
+	assert.Empty(t, observadorCompleto.eliminadas)
+	assert.Nil(t, resultadosParcial[0].Err)
+	assert.ErrorIs(t, resultadosParcial[1].Err, limites.ErrLimiteExcedido)
+	assert.Nil(t, resultadosParcial[2].Err)
+	assert.Nil(t, resultadosParcial[3].Err)
+	assert.Equal(t, []int{1, 2, 3}, []int{resultadosParcial[0].Transaccion.Id, resultadosParcial[2].Transaccion.Id, resultadosParcial[3].Transaccion.Id})
+	assert.Len(t, guardadasParcial, 3)
+	assert.Equal(t, 1, dbParcial.writes)
+	assert.Equal(t, []int{1, 2, 3}, observadorParcial.actualizadas)
+	assert.Empty(t, observadorParcial.eliminadas)
+}
+
+func TestServiceListarConsultaNoValida(t *testing.T) {
+	// Arrange
+	mock := MockStore{Data: []Transaccion{}}
+	service := NewService(NewRepository(&mock))
+
+	// Act
+	_, errLimite := service.Listar(Consulta{Limite: -1})
+	_, errCursor := service.Listar(Consulta{Limite: 1, Desplazamiento: 1, Cursor: "abc"})
+	_, errOrden := ParseOrden("-monto,saldo")
+
+	// Assert
+	assert.ErrorIs(t, errLimite, ErrConsultaNoValida)
+	assert.ErrorIs(t, errCursor, ErrConsultaNoValida)
+	assert.ErrorIs(t, errOrden, ErrConsultaNoValida)
+	assert.False(t, mock.readWasCalled)
+}
+
+func TestServiceTransicionar(t *testing.T) {
+	// Arrange
+	mock := MockStore{Data: []Transaccion{}}
+	service := NewService(NewRepository(&mock), ConPartes(registroPartes(t)))
+	transaccion, _ := service.Store("ctr1", "MXN", dinero.DebeParsear("100"), 1, 2, fechaPrueba("21/04/2022"))
+
+	// Act
+	_, errSaltada := service.Transicionar(transaccion.Id, ESTADO_LIQUIDADA)
+	autorizada, errAutorizar := service.Transicionar(transaccion.Id, ESTADO_AUTORIZADA)
+	liquidada, errLiquidar := service.Transicionar(transaccion.Id, ESTADO_LIQUIDADA)
+	_, errRechazar := service.Transicionar(transaccion.Id, ESTADO_RECHAZADA)
+	_, errPatch := service.Patch(transaccion.Id, "ctr1", dinero.DebeParsear("1"))
+	_, errNoExiste := service.Transicionar(99, ESTADO_AUTORIZADA)
+
+	// Assert
+	assert.ErrorIs(t, errSaltada, ErrTransicionNoValida)
+	assert.Nil(t, errAutorizar)
+	assert.Equal(t, ESTADO_AUTORIZADA, autorizada.Estado)
+	assert.Nil(t, errLiquidar)
+	assert.Equal(t, ESTADO_LIQUIDADA, liquidada.Estado)
+	assert.ErrorIs(t, errRechazar, ErrTransicionNoValida)
+	assert.ErrorIs(t, errPatch, ErrTransaccionNoEditable)
+	assert.NotNil(t, errNoExiste)
+	assert.NotErrorIs(t, errNoExiste, ErrTransicionNoValida)
+}
+
+func TestServiceStoreReversa(t *testing.T) {
+	// Arrange
+	mock := MockStore{Data: []Transaccion{}}
+	service := NewService(NewRepository(&mock), ConPartes(registroPartes(t)))
+	transaccion, _ := service.Store("ctr1", "JPY", dinero.DebeParsear("1000"), 1, 2, fechaPrueba("21/04/2022"))
+	_, _ = service.Transicionar(transaccion.Id, ESTADO_AUTORIZADA)
+	_, _ = service.Transicionar(transaccion.Id, ESTADO_LIQUIDADA)
+
+	// Act
+	_, errDecimales := service.StoreReversa(transaccion.Id, Reversa{Monto: montoFiltro("10.5"), FechaTransaccion: fechaPrueba("22/04/2022")})
+	_, errNegativo := service.StoreReversa(transaccion.Id, Reversa{Monto: montoFiltro("-10"), FechaTransaccion: fechaPrueba("22/04/2022")})
+	_, errFecha := service.StoreReversa(transaccion.Id, Reversa{Monto: montoFiltro("10")})
+	reversa, err := service.StoreReversa(transaccion.Id, Reversa{Monto: montoFiltro("10"), FechaTransaccion: fechaPrueba("22/04/2022")})
+
+	// Assert
+	assert.ErrorIs(t, errDecimales, ErrMontoNoValido)
+	assert.ErrorIs(t, errNegativo, ErrMontoNoValido)
+	assert.ErrorIs(t, errFecha, ErrFechaNoValida)
+	assert.Nil(t, err)
+	assert.Equal(t, "10", reversa.Monto.String())
+	assert.Equal(t, transaccion.Id, *reversa.Referencia)
+}
@@ -0,0 +1,221 @@
+package transacciones
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/fecha"
+)
+
+type migracion func(tx *sql.Tx) error
+
+func sentencia(query string) migracion {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// migraciones contiene, en orden, los cambios de esquema de la base sql. Una
+// migracion ya publicada no se modifica; los cambios nuevos se agregan al
+// final. zona es la zona horaria en que se interpretan las fechas sin zona.
+func migraciones(zona *time.Location) []migracion {
+	return []migracion{
+		sentencia(`CREATE TABLE transacciones (
+			id                 INTEGER PRIMARY KEY,
+			codigo_transaccion TEXT    NOT NULL,
+			moneda             TEXT    NOT NULL,
+			monto              REAL    NOT NULL,
+			emisor             TEXT    NOT NULL,
+			receptor           TEXT    NOT NULL,
+			fecha_transaccion  TEXT    NOT NULL
+		)`),
+		sentencia(`CREATE UNIQUE INDEX idx_transacciones_codigo_transaccion ON transacciones (codigo_transaccion)`),
+		migrarMontosExactos,
+		migrarFechas(zona),
+		sentencia(`CREATE INDEX idx_transacciones_fecha_transaccion_unix ON transacciones (fecha_transaccion_unix)`),
+		sentencia(`ALTER TABLE transacciones ADD COLUMN estado TEXT NOT NULL DEFAULT 'pendiente'`),
+		sentencia(`CREATE INDEX idx_transacciones_estado ON transacciones (estado)`),
+		sentencia(`ALTER TABLE transacciones ADD COLUMN referencia INTEGER REFERENCES transacciones (id)`),
+		sentencia(`CREATE INDEX idx_transacciones_referencia ON transacciones (referencia)`),
+		sentencia(`ALTER TABLE transacciones ADD COLUMN emisor_id INTEGER`),
+		sentencia(`ALTER TABLE transacciones ADD COLUMN receptor_id INTEGER`),
+		sentencia(`ALTER TABLE transacciones ADD COLUMN riesgo TEXT`),
+		migrarFechasNano,
+		sentencia(`CREATE INDEX idx_transacciones_fecha_transaccion_unix_nano ON transacciones (fecha_transaccion_unix_nano)`),
+	}
+}
+
+// migrarMontosExactos reemplaza la columna monto REAL por el texto exacto del
+// monto, redondeado a los decimales de su moneda, y agrega monto_diezmilesimas
+// para poder comparar y ordenar montos en sql.
+func migrarMontosExactos(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ALTER TABLE transacciones ADD COLUMN monto_texto TEXT NOT NULL DEFAULT '0'`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`ALTER TABLE transacciones ADD COLUMN monto_diezmilesimas INTEGER NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, moneda, monto FROM transacciones`)
+	if err != nil {
+		return err
+	}
+	type fila struct {
+		id     int
+		moneda string
+		monto  float64
+	}
+	var filas []fila
+	for rows.Next() {
+		var f fila
+		if err := rows.Scan(&f.id, &f.moneda, &f.monto); err != nil {
+			rows.Close()
+			return err
+		}
+		filas = append(filas, f)
+	}
+	rows.Close()
+
+	for _, f := range filas {
+		monto, err := dinero.Parse(strconv.FormatFloat(f.monto, 'f', -1, 64))
+		if err != nil {
+			return err
+		}
+		if monto, err = monto.Redondear(escalaMoneda(f.moneda)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE transacciones SET monto_texto = ?, monto_diezmilesimas = ? WHERE id = ?`,
+			monto.String(), montoComparable(monto), f.id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`ALTER TABLE transacciones DROP COLUMN monto`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`ALTER TABLE transacciones RENAME COLUMN monto_texto TO monto`)
+	return err
+}
+
+// migrarFechas reescribe las fechas dd/mm/aaaa como RFC 3339 en la zona
+// indicada y agrega fecha_transaccion_unix.
+func migrarFechas(zona *time.Location) migracion {
+	return func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`ALTER TABLE transacciones ADD COLUMN fecha_transaccion_unix INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(`SELECT id, fecha_transaccion FROM transacciones`)
+		if err != nil {
+			return err
+		}
+		fechas := map[int]string{}
+		for rows.Next() {
+			var id int
+			var texto string
+			if err := rows.Scan(&id, &texto); err != nil {
+				rows.Close()
+				return err
+			}
+			fechas[id] = texto
+		}
+		rows.Close()
+
+		for id, texto := range fechas {
+			valor, err := fecha.Parse(texto, zona)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE transacciones SET fecha_transaccion = ?, fecha_transaccion_unix = ? WHERE id = ?`,
+				textoFecha(valor), valor.Unix(), id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// migrarFechasNano reemplaza fecha_transaccion_unix, que solo guarda
+// segundos, por fecha_transaccion_unix_nano, para que el orden y los cursores
+// distingan fracciones de segundo como los stores de archivo.
+func migrarFechasNano(tx *sql.Tx) error {
+	if _, err := tx.Exec(`ALTER TABLE transacciones ADD COLUMN fecha_transaccion_unix_nano INTEGER NOT NULL DEFAULT 0`); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, fecha_transaccion FROM transacciones`)
+	if err != nil {
+		return err
+	}
+	fechas := map[int]string{}
+	for rows.Next() {
+		var id int
+		var texto string
+		if err := rows.Scan(&id, &texto); err != nil {
+			rows.Close()
+			return err
+		}
+		fechas[id] = texto
+	}
+	rows.Close()
+
+	for id, texto := range fechas {
+		valor, err := time.Parse(time.RFC3339Nano, texto)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE transacciones SET fecha_transaccion_unix_nano = ? WHERE id = ?`, valor.UnixNano(), id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DROP INDEX idx_transacciones_fecha_transaccion_unix`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`ALTER TABLE transacciones DROP COLUMN fecha_transaccion_unix`)
+	return err
+}
+
+// MigrarSQL aplica sobre db las migraciones que aun no se han ejecutado. Las
+// fechas que se guardaron sin zona horaria se interpretan en zona.
+func MigrarSQL(db *sql.DB, zona *time.Location) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return errors.New("error al crear la tabla de migraciones")
+	}
+
+	for index, migracion := range migraciones(zona) {
+		version := index + 1
+		if err := aplicarMigracion(db, version, migracion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func aplicarMigracion(db *sql.DB, version int, migracion migracion) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.New("error al iniciar la migracion")
+	}
+	defer tx.Rollback()
+
+	var aplicada int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&aplicada); err != nil {
+		return errors.New("error al consultar las migraciones")
+	}
+	if aplicada > INT_ZERO {
+		return nil
+	}
+
+	if err := migracion(tx); err != nil {
+		return errors.New("error al aplicar la migracion " + strconv.Itoa(version))
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return errors.New("error al registrar la migracion")
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,674 @@
+package transacciones
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BrandonICR/web_cl2_050422_8am/internal/partes"
+	"github.com/BrandonICR/web_cl2_050422_8am/pkg/dinero"
+	"github.com/mattn/go-sqlite3"
+)
+
+const columnasTransaccion = `id, codigo_transaccion, moneda, monto, emisor, receptor, fecha_transaccion, estado, referencia, emisor_id, receptor_id, riesgo`
+
+type sqlRepository struct {
+	db *sql.DB
+}
+
+// NewSQLRepository crea un Repository sobre una base sqlite a la que ya se le
+// aplicaron las migraciones con MigrarSQL.
+func NewSQLRepository(db *sql.DB) Repository {
+	return &sqlRepository{db: db}
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaccion(row scanner) (Transaccion, error) {
+	var transaccion Transaccion
+	var monto, fechaTransaccion, estado string
+	var referencia, emisorId, receptorId sql.NullInt64
+	var textoRiesgo sql.NullString
+	if err := row.Scan(&transaccion.Id, &transaccion.CodigoTransaccion, &transaccion.Moneda, &monto,
+		&transaccion.Emisor, &transaccion.Receptor, &fechaTransaccion, &estado, &referencia, &emisorId, &receptorId, &textoRiesgo); err != nil {
+		return Transaccion{}, err
+	}
+	if textoRiesgo.Valid {
+		if err := json.Unmarshal([]byte(textoRiesgo.String), &transaccion.Riesgo); err != nil {
+			return Transaccion{}, err
+		}
+	}
+	transaccion.EmisorId = int(emisorId.Int64)
+	transaccion.ReceptorId = int(receptorId.Int64)
+	transaccion.Estado = Estado(estado)
+	if referencia.Valid {
+		id := int(referencia.Int64)
+		transaccion.Referencia = &id
+	}
+	var err error
+	if transaccion.Monto, err = dinero.Parse(monto); err != nil {
+		return Transaccion{}, err
+	}
+	transaccion.FechaTransaccion, err = time.Parse(time.RFC3339Nano, fechaTransaccion)
+	return transaccion, err
+}
+
+// idParte guarda NULL para las transacciones anteriores al registro de partes.
+func idParte(id int) interface{} {
+	if id == INT_ZERO {
+		return nil
+	}
+	return id
+}
+
+// La columna fecha_transaccion guarda la fecha en RFC 3339 con su
+// desplazamiento original, y fecha_transaccion_unix_nano los nanosegundos
+// desde epoch para poder comparar y ordenar fechas en sql.
+func textoFecha(valor time.Time) string {
+	return valor.Format(time.RFC3339Nano)
+}
+
+// ESCALA_COMPARABLE es la escala de la columna monto_diezmilesimas, suficiente
+// para cualquier moneda ISO 4217.
+const ESCALA_COMPARABLE = 4
+
+// montoComparable regresa el valor que se guarda en monto_diezmilesimas.
+func montoComparable(monto dinero.Monto) int64 {
+	comparable, err := monto.Redondear(ESCALA_COMPARABLE)
+	if err != nil {
+		return 0
+	}
+	return comparable.Unidades()
+}
+
+func (r *sqlRepository) consultar(query string, args ...interface{}) ([]Transaccion, error) {
+	transacciones, err := r.leer(query, args...)
+	if err != nil {
+		return []Transaccion{}, err
+	}
+	if len(transacciones) == INT_ZERO {
+		return []Transaccion{}, ErrSinResultados
+	}
+	return transacciones, nil
+}
+
+// leer regresa las transacciones de la consulta, aunque no haya ninguna.
+func (r *sqlRepository) leer(query string, args ...interface{}) ([]Transaccion, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return []Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+	defer rows.Close()
+
+	transacciones := []Transaccion{}
+	for rows.Next() {
+		transaccion, err := scanTransaccion(rows)
+		if err != nil {
+			return []Transaccion{}, errors.New("error al leer de la base de datos")
+		}
+		transacciones = append(transacciones, transaccion)
+	}
+	if err := rows.Err(); err != nil {
+		return []Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+	return transacciones, nil
+}
+
+func (r *sqlRepository) GetAll() ([]Transaccion, error) {
+	return r.consultar(`SELECT ` + columnasTransaccion + ` FROM transacciones ORDER BY id`)
+}
+
+// limiteComparable expresa un limite de rango en diezmilesimas. Si el monto
+// trae mas decimales se redondea hacia dentro del rango: hacia arriba para un
+// minimo y hacia abajo para un maximo.
+func limiteComparable(monto dinero.Monto, minimo bool) int64 {
+	limite, err := monto.Redondear(ESCALA_COMPARABLE)
+	if err != nil {
+		return 0
+	}
+	unidades := limite.Unidades()
+	if minimo && limite.Cmp(monto) < 0 {
+		unidades++
+	}
+	if !minimo && limite.Cmp(monto) > 0 {
+		unidades--
+	}
+	return unidades
+}
+
+// escaparLike escapa los comodines de LIKE para buscar el texto literal.
+func escaparLike(texto string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(texto)
+}
+
+// condicionesSQL traduce el filtro a una clausula WHERE y sus argumentos.
+func condicionesSQL(filtro Filtro) (string, []interface{}) {
+	var condiciones []string
+	var args []interface{}
+	agregar := func(condicion string, valores ...interface{}) {
+		condiciones = append(condiciones, condicion)
+		args = append(args, valores...)
+	}
+
+	if filtro.Id != nil {
+		agregar("id = ?", *filtro.Id)
+	}
+	if filtro.CodigoTransaccion != nil {
+		agregar("codigo_transaccion = ?", *filtro.CodigoTransaccion)
+	}
+	if len(filtro.Monedas) > INT_ZERO {
+		marcas := strings.TrimSuffix(strings.Repeat("?, ", len(filtro.Monedas)), ", ")
+		valores := make([]interface{}, len(filtro.Monedas))
+		for index, moneda := range filtro.Monedas {
+			valores[index] = moneda
+		}
+		agregar("moneda IN ("+marcas+")", valores...)
+	}
+	if len(filtro.Estados) > INT_ZERO {
+		marcas := strings.TrimSuffix(strings.Repeat("?, ", len(filtro.Estados)), ", ")
+		valores := make([]interface{}, len(filtro.Estados))
+		for index, estado := range filtro.Estados {
+			valores[index] = string(estado)
+		}
+		agregar("estado IN ("+marcas+")", valores...)
+	}
+	if filtro.Monto != nil {
+		// Un monto con mas decimales que la columna no puede ser igual a ninguno.
+		comparable, err := filtro.Monto.Escalar(ESCALA_COMPARABLE)
+		if err != nil {
+			agregar("0")
+		} else {
+			agregar("monto_diezmilesimas = ?", comparable.Unidades())
+		}
+	}
+	if filtro.MontoMin != nil {
+		agregar("monto_diezmilesimas >= ?", limiteComparable(*filtro.MontoMin, true))
+	}
+	if filtro.MontoMax != nil {
+		agregar("monto_diezmilesimas <= ?", limiteComparable(*filtro.MontoMax, false))
+	}
+	if filtro.Emisor != nil {
+		switch filtro.Emisor.Coincidencia {
+		case COINCIDENCIA_PREFIJO:
+			agregar(`emisor LIKE ? ESCAPE '\'`, escaparLike(filtro.Emisor.Valor)+"%")
+		case COINCIDENCIA_CONTIENE:
+			agregar(`emisor LIKE ? ESCAPE '\'`, "%"+escaparLike(filtro.Emisor.Valor)+"%")
+		default:
+			agregar("emisor = ?", filtro.Emisor.Valor)
+		}
+	}
+	if filtro.Receptor != nil {
+		agregar("receptor = ?", *filtro.Receptor)
+	}
+	if filtro.FechaDesde != nil {
+		agregar("fecha_transaccion_unix_nano >= ?", filtro.FechaDesde.UnixNano())
+	}
+	if filtro.FechaHasta != nil {
+		agregar("fecha_transaccion_unix_nano <= ?", filtro.FechaHasta.UnixNano())
+	}
+
+	if len(condiciones) == INT_ZERO {
+		return "", nil
+	}
+	return ` WHERE ` + strings.Join(condiciones, " AND "), args
+}
+
+func (r *sqlRepository) GetTransaccionFiltrada(filtro Filtro) ([]Transaccion, error) {
+	condiciones, args := condicionesSQL(filtro)
+	return r.consultar(`SELECT `+columnasTransaccion+` FROM transacciones`+condiciones+` ORDER BY id`, args...)
+}
+
+// condicionCursor traduce la posicion del cursor a una condicion que deja
+// solo las filas que van despues de ella en el orden indicado.
+func condicionCursor(orden []Orden, ultima Transaccion) (string, []interface{}) {
+	var alternativas []string
+	var args []interface{}
+	for index, campo := range orden {
+		var partes []string
+		for _, anterior := range orden[:index] {
+			definicion := camposOrden[anterior.Campo]
+			partes = append(partes, definicion.columna+" = ?")
+			args = append(args, definicion.valorSQL(ultima))
+		}
+		definicion := camposOrden[campo.Campo]
+		operador := " > ?"
+		if campo.Descendente {
+			operador = " < ?"
+		}
+		partes = append(partes, definicion.columna+operador)
+		args = append(args, definicion.valorSQL(ultima))
+		alternativas = append(alternativas, "("+strings.Join(partes, " AND ")+")")
+	}
+	return "(" + strings.Join(alternativas, " OR ") + ")", args
+}
+
+func ordenSQL(orden []Orden) string {
+	partes := make([]string, len(orden))
+	for index, campo := range orden {
+		partes[index] = camposOrden[campo.Campo].columna
+		if campo.Descendente {
+			partes[index] += " DESC"
+		}
+	}
+	return ` ORDER BY ` + strings.Join(partes, ", ")
+}
+
+// Listar cuenta y pagina en sql; solo se leen las filas de la pagina, mas
+// una para saber si hay otra despues.
+func (r *sqlRepository) Listar(consulta Consulta) (Pagina, error) {
+	orden := consulta.ordenCompleto()
+	condiciones, args := condicionesSQL(consulta.Filtro)
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM transacciones`+condiciones, args...).Scan(&total); err != nil {
+		return Pagina{}, errors.New("error al leer de la base de datos")
+	}
+	if total == INT_ZERO {
+		return Pagina{}, ErrSinResultados
+	}
+
+	if consulta.Cursor != STRING_EMPTY {
+		ultima, err := decodificarCursor(consulta.Cursor, orden)
+		if err != nil {
+			return Pagina{}, err
+		}
+		condicion, argsCursor := condicionCursor(orden, ultima)
+		if condiciones == STRING_EMPTY {
+			condiciones = ` WHERE ` + condicion
+		} else {
+			condiciones += ` AND ` + condicion
+		}
+		args = append(args, argsCursor...)
+	}
+
+	query := `SELECT ` + columnasTransaccion + ` FROM transacciones` + condiciones + ordenSQL(orden)
+	if consulta.Limite > INT_ZERO {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, consulta.Limite+1, consulta.Desplazamiento)
+	} else if consulta.Desplazamiento > INT_ZERO {
+		query += ` LIMIT -1 OFFSET ?`
+		args = append(args, consulta.Desplazamiento)
+	}
+
+	transacciones, err := r.leer(query, args...)
+	if err != nil {
+		return Pagina{}, err
+	}
+
+	pagina := Pagina{Transacciones: transacciones, Total: total}
+	if consulta.Limite > INT_ZERO && len(transacciones) > consulta.Limite {
+		pagina.Transacciones = transacciones[:consulta.Limite]
+		pagina.SiguienteCursor = codificarCursor(orden, pagina.Transacciones[consulta.Limite-1])
+	}
+	return pagina, nil
+}
+
+// GetByCodigo busca por el indice unico de codigo_transaccion.
+func (r *sqlRepository) GetByCodigo(codigoTransaccion string) (Transaccion, error) {
+	transaccion, err := scanTransaccion(r.db.QueryRow(`SELECT `+columnasTransaccion+` FROM transacciones WHERE codigo_transaccion = ?`, codigoTransaccion))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Transaccion{}, errors.New("no se encontro la transaccion")
+	}
+	if err != nil {
+		return Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+	return transaccion, nil
+}
+
+func (r *sqlRepository) Store(codigoTransaccion, moneda string, monto dinero.Monto, emisor, receptor partes.Parte, fechaTransaccion time.Time, riesgo *Riesgo) (Transaccion, error) {
+	texto, err := textoRiesgo(riesgo)
+	if err != nil {
+		return Transaccion{}, err
+	}
+	result, err := r.db.Exec(`INSERT INTO transacciones (codigo_transaccion, moneda, monto, monto_diezmilesimas, emisor, receptor, emisor_id, receptor_id, fecha_transaccion, fecha_transaccion_unix_nano, riesgo)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, codigoTransaccion, moneda, monto.String(), montoComparable(monto), emisor.Nombre, receptor.Nombre,
+		idParte(emisor.Id), idParte(receptor.Id), textoFecha(fechaTransaccion), fechaTransaccion.UnixNano(), texto)
+	if err != nil {
+		return Transaccion{}, errorEscritura(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+
+	return Transaccion{
+		Id:                int(id),
+		CodigoTransaccion: codigoTransaccion,
+		Moneda:            moneda,
+		Monto:             monto,
+		Emisor:            emisor.Nombre,
+		EmisorId:          emisor.Id,
+		Receptor:          receptor.Nombre,
+		ReceptorId:        receptor.Id,
+		FechaTransaccion:  fechaTransaccion,
+		Estado:            ESTADO_PENDIENTE,
+		Riesgo:            riesgo,
+	}, nil
+}
+
+// StoreLote da de alta las transacciones dentro de una transaccion sql; si
+// alguna falla no se guarda ninguna.
+func (r *sqlRepository) StoreLote(nuevas []Transaccion) ([]Transaccion, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, errors.New("error al escribir en la base de datos")
+	}
+	defer tx.Rollback()
+
+	guardadas := make([]Transaccion, len(nuevas))
+	for index, nueva := range nuevas {
+		texto, err := textoRiesgo(nueva.Riesgo)
+		if err != nil {
+			return nil, err
+		}
+		result, err := tx.Exec(`INSERT INTO transacciones (codigo_transaccion, moneda, monto, monto_diezmilesimas, emisor, receptor, emisor_id, receptor_id, fecha_transaccion, fecha_transaccion_unix_nano, riesgo)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, nueva.CodigoTransaccion, nueva.Moneda, nueva.Monto.String(), montoComparable(nueva.Monto),
+			nueva.Emisor, nueva.Receptor, idParte(nueva.EmisorId), idParte(nueva.ReceptorId), textoFecha(nueva.FechaTransaccion), nueva.FechaTransaccion.UnixNano(), texto)
+		if err != nil {
+			if err = errorEscritura(err); errors.Is(err, ErrCodigoDuplicado) {
+				return nil, fmt.Errorf("%w: %s", ErrCodigoDuplicado, nueva.CodigoTransaccion)
+			}
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, errors.New("error al escribir en la base de datos")
+		}
+		nueva.Id, nueva.Estado, nueva.Referencia = int(id), ESTADO_PENDIENTE, nil
+		guardadas[index] = nueva
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.New("error al escribir en la base de datos")
+	}
+	return guardadas, nil
+}
+
+// Update reemplaza los datos de una transaccion pendiente. Un riesgo nil
+// conserva la evaluacion guardada.
+func (r *sqlRepository) Update(id int, codigoTransaccion, moneda string, monto dinero.Monto, emisor, receptor partes.Parte, fechaTransaccion time.Time, riesgo *Riesgo) (Transaccion, error) {
+	texto, err := textoRiesgo(riesgo)
+	if err != nil {
+		return Transaccion{}, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE transacciones SET codigo_transaccion = ?, moneda = ?, monto = ?, monto_diezmilesimas = ?, emisor = ?, receptor = ?,
+		emisor_id = ?, receptor_id = ?, fecha_transaccion = ?, fecha_transaccion_unix_nano = ?, riesgo = COALESCE(?, riesgo) WHERE id = ? AND estado = ? AND referencia IS NULL`,
+		codigoTransaccion, moneda, monto.String(), montoComparable(monto), emisor.Nombre, receptor.Nombre, idParte(emisor.Id), idParte(receptor.Id),
+		textoFecha(fechaTransaccion), fechaTransaccion.UnixNano(), texto, id, ESTADO_PENDIENTE)
+	if err != nil {
+		return Transaccion{}, errorEscritura(err)
+	}
+	if afectadas, err := result.RowsAffected(); err != nil || afectadas == INT_ZERO {
+		return Transaccion{}, errorSinActualizar(tx.QueryRow(`SELECT estado, referencia FROM transacciones WHERE id = ?`, id))
+	}
+
+	transaccion, err := scanTransaccion(tx.QueryRow(`SELECT `+columnasTransaccion+` FROM transacciones WHERE id = ?`, id))
+	if err != nil {
+		return Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	return transaccion, nil
+}
+
+// Patch cambia el codigo y el monto de una transaccion pendiente. Un riesgo
+// nil conserva la evaluacion guardada.
+func (r *sqlRepository) Patch(id int, codigoTransaccion string, monto dinero.Monto, riesgo *Riesgo) (Transaccion, error) {
+	texto, err := textoRiesgo(riesgo)
+	if err != nil {
+		return Transaccion{}, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE transacciones SET codigo_transaccion = ?, monto = ?, monto_diezmilesimas = ?, riesgo = COALESCE(?, riesgo) WHERE id = ? AND estado = ? AND referencia IS NULL`,
+		codigoTransaccion, monto.String(), montoComparable(monto), texto, id, ESTADO_PENDIENTE)
+	if err != nil {
+		return Transaccion{}, errorEscritura(err)
+	}
+	if afectadas, err := result.RowsAffected(); err != nil || afectadas == INT_ZERO {
+		return Transaccion{}, errorSinActualizar(tx.QueryRow(`SELECT estado, referencia FROM transacciones WHERE id = ?`, id))
+	}
+
+	transaccion, err := scanTransaccion(tx.QueryRow(`SELECT `+columnasTransaccion+` FROM transacciones WHERE id = ?`, id))
+	if err != nil {
+		return Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	return transaccion, nil
+}
+
+// textoRiesgo expresa la evaluacion como el json de la columna riesgo; nil
+// se guarda como NULL.
+func textoRiesgo(riesgo *Riesgo) (interface{}, error) {
+	if riesgo == nil {
+		return nil, nil
+	}
+	contenido, err := json.Marshal(riesgo)
+	if err != nil {
+		return nil, err
+	}
+	return string(contenido), nil
+}
+
+// errorSinActualizar explica por que un UPDATE sobre una transaccion
+// pendiente no afecto ninguna fila; fila es la consulta de su estado y su
+// referencia.
+func errorSinActualizar(fila *sql.Row) error {
+	var estado string
+	var referencia sql.NullInt64
+	if err := fila.Scan(&estado, &referencia); err != nil {
+		return errors.New("no se encontro la transaccion a actualizar")
+	}
+	if referencia.Valid {
+		return ErrReversaNoEditable
+	}
+	return ErrTransaccionNoEditable
+}
+
+// CambiarEstado pasa la transaccion a nuevo solo si sigue en actual. Rechazar
+// una reversa regresa a liquidada su original si estaba revertida.
+func (r *sqlRepository) CambiarEstado(id int, actual, nuevo Estado) (Transaccion, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE transacciones SET estado = ? WHERE id = ? AND estado = ?`, nuevo, id, actual)
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	if afectadas, err := result.RowsAffected(); err != nil || afectadas == INT_ZERO {
+		var estado string
+		if err := tx.QueryRow(`SELECT estado FROM transacciones WHERE id = ?`, id).Scan(&estado); err != nil {
+			return Transaccion{}, errors.New("no se encontro la transaccion")
+		}
+		return Transaccion{}, fmt.Errorf("%w: la transaccion esta %s", ErrTransicionNoValida, estado)
+	}
+
+	transaccion, err := scanTransaccion(tx.QueryRow(`SELECT `+columnasTransaccion+` FROM transacciones WHERE id = ?`, id))
+	if err != nil {
+		return Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+	if nuevo == ESTADO_RECHAZADA && transaccion.Referencia != nil {
+		if _, err := tx.Exec(`UPDATE transacciones SET estado = ? WHERE id = ? AND estado = ?`,
+			ESTADO_LIQUIDADA, *transaccion.Referencia, ESTADO_REVERTIDA); err != nil {
+			return Transaccion{}, errors.New("error al escribir en la base de datos")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	return transaccion, nil
+}
+
+// CambiarRiesgo guarda la evaluacion de riesgo de la transaccion como json.
+func (r *sqlRepository) CambiarRiesgo(id int, riesgo *Riesgo) (Transaccion, error) {
+	texto, err := textoRiesgo(riesgo)
+	if err != nil {
+		return Transaccion{}, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE transacciones SET riesgo = ? WHERE id = ?`, texto, id)
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	if afectadas, err := result.RowsAffected(); err != nil || afectadas == INT_ZERO {
+		return Transaccion{}, errors.New("no se encontro la transaccion")
+	}
+
+	transaccion, err := scanTransaccion(tx.QueryRow(`SELECT `+columnasTransaccion+` FROM transacciones WHERE id = ?`, id))
+	if err != nil {
+		return Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	return transaccion, nil
+}
+
+// StoreReversa agrega la reversa de la transaccion id dentro de una
+// transaccion sql, de modo que la suma de reversas se revisa sobre los mismos
+// datos que se escriben.
+func (r *sqlRepository) StoreReversa(id int, solicitud Reversa) (Transaccion, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	defer tx.Rollback()
+
+	original, err := scanTransaccion(tx.QueryRow(`SELECT `+columnasTransaccion+` FROM transacciones WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Transaccion{}, errors.New("no se encontro la transaccion")
+	}
+	if err != nil {
+		return Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+
+	rows, err := tx.Query(`SELECT `+columnasTransaccion+` FROM transacciones WHERE referencia = ? ORDER BY id`, id)
+	if err != nil {
+		return Transaccion{}, errors.New("error al leer de la base de datos")
+	}
+	reversas := []Transaccion{}
+	for rows.Next() {
+		reversa, err := scanTransaccion(rows)
+		if err != nil {
+			rows.Close()
+			return Transaccion{}, errors.New("error al leer de la base de datos")
+		}
+		reversas = append(reversas, reversa)
+	}
+	rows.Close()
+
+	reversa, completa, err := planearReversa(original, reversas, solicitud)
+	if err != nil {
+		return Transaccion{}, err
+	}
+
+	result, err := tx.Exec(`INSERT INTO transacciones (codigo_transaccion, moneda, monto, monto_diezmilesimas, emisor, receptor, emisor_id, receptor_id, fecha_transaccion, fecha_transaccion_unix_nano, estado, referencia)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, reversa.CodigoTransaccion, reversa.Moneda, reversa.Monto.String(), montoComparable(reversa.Monto),
+		reversa.Emisor, reversa.Receptor, idParte(reversa.EmisorId), idParte(reversa.ReceptorId), textoFecha(reversa.FechaTransaccion), reversa.FechaTransaccion.UnixNano(), reversa.Estado, id)
+	if err != nil {
+		return Transaccion{}, errorEscritura(err)
+	}
+	nuevoId, err := result.LastInsertId()
+	if err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	reversa.Id = int(nuevoId)
+
+	if completa {
+		if _, err := tx.Exec(`UPDATE transacciones SET estado = ? WHERE id = ?`, ESTADO_REVERTIDA, id); err != nil {
+			return Transaccion{}, errors.New("error al escribir en la base de datos")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Transaccion{}, errors.New("error al escribir en la base de datos")
+	}
+	return reversa, nil
+}
+
+// GetReversas regresa las reversas de la transaccion id, aunque no tenga ninguna.
+func (r *sqlRepository) GetReversas(id int) ([]Transaccion, error) {
+	return r.leer(`SELECT `+columnasTransaccion+` FROM transacciones WHERE referencia = ? ORDER BY id`, id)
+}
+
+// Delete revisa los vinculos con reversas en la misma transaccion sql que
+// borra la fila.
+func (r *sqlRepository) Delete(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return errors.New("error al escribir en la base de datos")
+	}
+	defer tx.Rollback()
+
+	var referencia sql.NullInt64
+	var reversas int
+	err = tx.QueryRow(`SELECT referencia, (SELECT COUNT(*) FROM transacciones WHERE referencia = ?) FROM transacciones WHERE id = ?`, id, id).
+		Scan(&referencia, &reversas)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("la transaccion a eliminar no existe")
+	}
+	if err != nil {
+		return errors.New("error al leer de la base de datos")
+	}
+	if referencia.Valid || reversas > INT_ZERO {
+		return ErrReversaVinculada
+	}
+
+	if _, err := tx.Exec(`DELETE FROM transacciones WHERE id = ?`, id); err != nil {
+		return errors.New("error al escribir en la base de datos")
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.New("error al escribir en la base de datos")
+	}
+	return nil
+}
+
+func (r *sqlRepository) LastID() (int, error) {
+	var id int
+	if err := r.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM transacciones`).Scan(&id); err != nil {
+		return 0, errors.New("error al leer de la base de datos")
+	}
+	return id, nil
+}
+
+func errorEscritura(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return ErrCodigoDuplicado
+	}
+	return errors.New("error al escribir en la base de datos")
+}
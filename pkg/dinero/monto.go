@@ -0,0 +1,293 @@
+package dinero
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MAX_ESCALA es el maximo numero de decimales que puede representar un Monto.
+const MAX_ESCALA = 18
+
+var (
+	ErrFormato   = errors.New("el monto no tiene un formato decimal valido")
+	ErrDesborde  = errors.New("el monto excede el rango soportado")
+	ErrPrecision = errors.New("el monto tiene mas decimales de los permitidos")
+
+	ErrDivisionEntreCero = errors.New("no es posible dividir entre cero")
+)
+
+// Monto es una cantidad decimal exacta igual a unidades / 10^escala. La
+// escala se conserva, de modo que "4000.50" se emite igual a como se recibio.
+type Monto struct {
+	unidades int64
+	escala   int
+}
+
+// Nuevo crea un Monto a partir de unidades menores, p. ej. Nuevo(400050, 2) es 4000.50.
+func Nuevo(unidades int64, escala int) Monto {
+	return Monto{unidades: unidades, escala: escala}
+}
+
+// Parse lee un decimal como "4000.50", "-12" o "0.125" sin pasar por float64.
+func Parse(texto string) (Monto, error) {
+	texto = strings.TrimSpace(texto)
+	if texto == "" {
+		return Monto{}, ErrFormato
+	}
+
+	negativo := false
+	switch texto[0] {
+	case '-':
+		negativo = true
+		texto = texto[1:]
+	case '+':
+		texto = texto[1:]
+	}
+
+	entero, decimales := texto, ""
+	if punto := strings.IndexByte(texto, '.'); punto >= 0 {
+		entero, decimales = texto[:punto], texto[punto+1:]
+	}
+	if (entero == "" && decimales == "") || !soloDigitos(entero) || !soloDigitos(decimales) {
+		return Monto{}, ErrFormato
+	}
+	if len(decimales) > MAX_ESCALA {
+		return Monto{}, ErrPrecision
+	}
+
+	digitos := strings.TrimLeft(entero+decimales, "0")
+	if digitos == "" {
+		return Monto{escala: len(decimales)}, nil
+	}
+	unidades, err := strconv.ParseInt(digitos, 10, 64)
+	if err != nil {
+		return Monto{}, ErrDesborde
+	}
+	if negativo {
+		unidades = -unidades
+	}
+	return Monto{unidades: unidades, escala: len(decimales)}, nil
+}
+
+// DebeParsear es como Parse pero entra en panico si el texto no es valido.
+// Esta pensado para literales en pruebas y tablas fijas.
+func DebeParsear(texto string) Monto {
+	monto, err := Parse(texto)
+	if err != nil {
+		panic(err)
+	}
+	return monto
+}
+
+func soloDigitos(texto string) bool {
+	for _, caracter := range texto {
+		if caracter < '0' || caracter > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Monto) Unidades() int64 {
+	return m.unidades
+}
+
+func (m Monto) Escala() int {
+	return m.escala
+}
+
+func (m Monto) EsCero() bool {
+	return m.unidades == 0
+}
+
+func (m Monto) EsNegativo() bool {
+	return m.unidades < 0
+}
+
+func (m Monto) EsPositivo() bool {
+	return m.unidades > 0
+}
+
+func (m Monto) String() string {
+	texto := strconv.FormatInt(m.unidades, 10)
+	if m.escala == 0 {
+		return texto
+	}
+
+	signo := ""
+	if m.unidades < 0 {
+		signo, texto = "-", texto[1:]
+	}
+	if len(texto) <= m.escala {
+		texto = strings.Repeat("0", m.escala-len(texto)+1) + texto
+	}
+	corte := len(texto) - m.escala
+	return signo + texto[:corte] + "." + texto[corte:]
+}
+
+// Float64 regresa una aproximacion del monto. Solo debe usarse para
+// presentacion, nunca para calculos.
+func (m Monto) Float64() float64 {
+	valor, _ := strconv.ParseFloat(m.String(), 64)
+	return valor
+}
+
+func (m Monto) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON acepta tanto cadenas ("4000.50") como numeros json (4000.5);
+// en ambos casos el texto se interpreta de forma exacta.
+func (m *Monto) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	texto := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		var err error
+		if texto, err = strconv.Unquote(texto); err != nil {
+			return ErrFormato
+		}
+	} else if strings.ContainsAny(texto, "eE") {
+		return ErrFormato
+	}
+
+	monto, err := Parse(texto)
+	if err != nil {
+		return err
+	}
+	*m = monto
+	return nil
+}
+
+func (m Monto) grande() *big.Int {
+	return big.NewInt(m.unidades)
+}
+
+func potencia(exponente int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponente)), nil)
+}
+
+func desdeGrande(valor *big.Int, escala int) (Monto, error) {
+	if !valor.IsInt64() {
+		return Monto{}, ErrDesborde
+	}
+	return Monto{unidades: valor.Int64(), escala: escala}, nil
+}
+
+// Escalar expresa el monto con la escala indicada. Falla si para hacerlo
+// habria que descartar decimales distintos de cero.
+func (m Monto) Escalar(escala int) (Monto, error) {
+	if escala < 0 || escala > MAX_ESCALA {
+		return Monto{}, ErrPrecision
+	}
+	if escala >= m.escala {
+		return desdeGrande(new(big.Int).Mul(m.grande(), potencia(escala-m.escala)), escala)
+	}
+
+	cociente, residuo := new(big.Int).QuoRem(m.grande(), potencia(m.escala-escala), new(big.Int))
+	if residuo.Sign() != 0 {
+		return Monto{}, ErrPrecision
+	}
+	return desdeGrande(cociente, escala)
+}
+
+// Redondear expresa el monto con la escala indicada redondeando al par mas
+// cercano cuando hay que descartar decimales.
+func (m Monto) Redondear(escala int) (Monto, error) {
+	if escala >= m.escala {
+		return m.Escalar(escala)
+	}
+	if escala < 0 {
+		return Monto{}, ErrPrecision
+	}
+	return desdeGrande(dividirRedondeando(m.grande(), potencia(m.escala-escala)), escala)
+}
+
+// dividirRedondeando calcula dividendo / divisor redondeando al par mas cercano.
+func dividirRedondeando(dividendo, divisor *big.Int) *big.Int {
+	cociente, residuo := new(big.Int).QuoRem(dividendo, divisor, new(big.Int))
+	doble := new(big.Int).Mul(new(big.Int).Abs(residuo), big.NewInt(2))
+	comparacion := doble.Cmp(new(big.Int).Abs(divisor))
+	if comparacion > 0 || (comparacion == 0 && cociente.Bit(0) == 1) {
+		if (dividendo.Sign() < 0) != (divisor.Sign() < 0) {
+			cociente.Sub(cociente, big.NewInt(1))
+		} else {
+			cociente.Add(cociente, big.NewInt(1))
+		}
+	}
+	return cociente
+}
+
+// alinear regresa ambos montos como enteros con la misma escala.
+func alinear(a, b Monto) (*big.Int, *big.Int, int) {
+	if a.escala >= b.escala {
+		return a.grande(), new(big.Int).Mul(b.grande(), potencia(a.escala-b.escala)), a.escala
+	}
+	return new(big.Int).Mul(a.grande(), potencia(b.escala-a.escala)), b.grande(), b.escala
+}
+
+// Cmp regresa -1, 0 o 1 segun m sea menor, igual o mayor que otro, sin
+// importar la escala de cada uno.
+func (m Monto) Cmp(otro Monto) int {
+	a, b, _ := alinear(m, otro)
+	return a.Cmp(b)
+}
+
+func (m Monto) Igual(otro Monto) bool {
+	return m.Cmp(otro) == 0
+}
+
+// Sumar regresa m + otro con la mayor de las dos escalas.
+func (m Monto) Sumar(otro Monto) (Monto, error) {
+	a, b, escala := alinear(m, otro)
+	return desdeGrande(a.Add(a, b), escala)
+}
+
+// Restar regresa m - otro con la mayor de las dos escalas.
+func (m Monto) Restar(otro Monto) (Monto, error) {
+	a, b, escala := alinear(m, otro)
+	return desdeGrande(a.Sub(a, b), escala)
+}
+
+func (m Monto) Negar() Monto {
+	return Monto{unidades: -m.unidades, escala: m.escala}
+}
+
+// reescalar expresa un entero con escala desde en la escala hasta,
+// redondeando al par mas cercano si se pierden decimales.
+func reescalar(valor *big.Int, desde, hasta int) *big.Int {
+	if hasta >= desde {
+		return valor.Mul(valor, potencia(hasta-desde))
+	}
+	return dividirRedondeando(valor, potencia(desde-hasta))
+}
+
+// Multiplicar regresa m * factor con la escala indicada, redondeando al par
+// mas cercano.
+func (m Monto) Multiplicar(factor Monto, escala int) (Monto, error) {
+	if escala < 0 || escala > MAX_ESCALA {
+		return Monto{}, ErrPrecision
+	}
+	producto := new(big.Int).Mul(m.grande(), factor.grande())
+	return desdeGrande(reescalar(producto, m.escala+factor.escala, escala), escala)
+}
+
+// Dividir regresa m / divisor con la escala indicada, redondeando al par mas
+// cercano.
+func (m Monto) Dividir(divisor Monto, escala int) (Monto, error) {
+	if divisor.EsCero() {
+		return Monto{}, ErrDivisionEntreCero
+	}
+	if escala < 0 || escala > MAX_ESCALA {
+		return Monto{}, ErrPrecision
+	}
+	numerador := new(big.Int).Mul(m.grande(), potencia(divisor.escala+escala))
+	denominador := new(big.Int).Mul(divisor.grande(), potencia(m.escala))
+	return desdeGrande(dividirRedondeando(numerador, denominador), escala)
+}
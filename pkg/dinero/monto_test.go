@@ -0,0 +1,124 @@
+package dinero
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	casos := []struct {
+		texto     string
+		unidades  int64
+		escala    int
+		esperado  string
+		conFallas bool
+	}{
+		{texto: "4000.50", unidades: 400050, escala: 2, esperado: "4000.50"},
+		{texto: "-0.05", unidades: -5, escala: 2, esperado: "-0.05"},
+		{texto: "12", unidades: 12, escala: 0, esperado: "12"},
+		{texto: "0.000", unidades: 0, escala: 3, esperado: "0.000"},
+		{texto: "1e3", conFallas: true},
+		{texto: "", conFallas: true},
+		{texto: "12.3.4", conFallas: true},
+		{texto: "99999999999999999999", conFallas: true},
+	}
+
+	for _, caso := range casos {
+		// Act
+		result, err := Parse(caso.texto)
+
+		// Assert
+		if caso.conFallas {
+			assert.NotNil(t, err, caso.texto)
+			continue
+		}
+		assert.Nil(t, err, caso.texto)
+		assert.Equal(t, caso.unidades, result.Unidades(), caso.texto)
+		assert.Equal(t, caso.escala, result.Escala(), caso.texto)
+		assert.Equal(t, caso.esperado, result.String(), caso.texto)
+	}
+}
+
+func TestMontoJSON(t *testing.T) {
+	// Arrange
+	var data struct {
+		Cadena Monto `json:"cadena"`
+		Numero Monto `json:"numero"`
+	}
+
+	// Act
+	err := json.Unmarshal([]byte(`{"cadena":"4000.50","numero":0.1}`), &data)
+	result, errMarshal := json.Marshal(data)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, errMarshal)
+	assert.Equal(t, `{"cadena":"4000.50","numero":"0.1"}`, string(result))
+}
+
+func TestMontoEscalar(t *testing.T) {
+	// Act
+	ampliado, errAmpliado := DebeParsear("10.5").Escalar(2)
+	reducido, errReducido := DebeParsear("10.50").Escalar(1)
+	_, errPrecision := DebeParsear("10.55").Escalar(1)
+
+	// Assert
+	assert.Nil(t, errAmpliado)
+	assert.Equal(t, "10.50", ampliado.String())
+	assert.Nil(t, errReducido)
+	assert.Equal(t, "10.5", reducido.String())
+	assert.ErrorIs(t, errPrecision, ErrPrecision)
+}
+
+func TestMontoRedondear(t *testing.T) {
+	casos := map[string]string{
+		"0.30000000000000004": "0.30",
+		"2.345":               "2.34",
+		"2.355":               "2.36",
+		"-2.355":              "-2.36",
+		"-2.341":              "-2.34",
+	}
+
+	for texto, esperado := range casos {
+		// Act
+		result, err := DebeParsear(texto).Redondear(2)
+
+		// Assert
+		assert.Nil(t, err, texto)
+		assert.Equal(t, esperado, result.String(), texto)
+	}
+}
+
+func TestMontoAritmetica(t *testing.T) {
+	// Arrange
+	a := DebeParsear("0.10")
+	b := DebeParsear("0.2")
+
+	// Act
+	suma, errSuma := a.Sumar(b)
+	resta, errResta := a.Restar(b)
+
+	// Assert
+	assert.Nil(t, errSuma)
+	assert.Nil(t, errResta)
+	assert.Equal(t, "0.30", suma.String())
+	assert.Equal(t, "-0.10", resta.String())
+	assert.True(t, DebeParsear("0.30").Igual(DebeParsear("0.3")))
+	assert.Equal(t, -1, a.Cmp(b))
+}
+
+func TestMontoMultiplicarDividir(t *testing.T) {
+	// Act
+	producto, errProducto := DebeParsear("4000.50").Multiplicar(DebeParsear("0.054321"), 2)
+	cociente, errCociente := DebeParsear("1").Dividir(DebeParsear("18.4500"), 6)
+	_, errCero := DebeParsear("1").Dividir(DebeParsear("0.00"), 2)
+
+	// Assert
+	assert.Nil(t, errProducto)
+	assert.Equal(t, "217.31", producto.String())
+	assert.Nil(t, errCociente)
+	assert.Equal(t, "0.054201", cociente.String())
+	assert.ErrorIs(t, errCero, ErrDivisionEntreCero)
+}
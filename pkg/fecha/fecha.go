@@ -0,0 +1,89 @@
+// Package fecha interpreta las fechas que reciben y guardan las transacciones.
+package fecha
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	// Incluye la base de zonas horarias para no depender de la del sistema.
+	_ "time/tzdata"
+)
+
+// FORMATO_LEGADO es el formato dd/mm/aaaa en que se guardaban las fechas y en
+// que se siguen mostrando a los clientes de la version 1 de la api.
+const FORMATO_LEGADO = "02/01/2006"
+
+var ErrFecha = errors.New("la fecha no es valida, se espera dd/mm/aaaa, aaaa-mm-dd o RFC 3339")
+
+// formatosConZona indican su propio desplazamiento respecto a UTC.
+var formatosConZona = []string{
+	time.RFC3339Nano,
+}
+
+// formatosLocales se interpretan en la zona horaria configurada.
+var formatosLocales = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2/1/2006 15:04:05",
+	"2/1/2006 15:04",
+}
+
+// formatosDia solo indican el dia y se interpretan a medianoche.
+var formatosDia = []string{
+	"2006-01-02",
+	"2/1/2006",
+}
+
+// Parse interpreta texto en cualquiera de los formatos aceptados. Las fechas
+// sin zona se ubican en zona; las que solo traen el dia, a medianoche. Fechas
+// imposibles como 31/02/2022 se rechazan.
+func Parse(texto string, zona *time.Location) (time.Time, error) {
+	valor, _, err := parse(texto, zona)
+	return valor, err
+}
+
+// ParseHasta es como Parse, pero si texto solo indica el dia regresa el
+// ultimo instante de ese dia, para usarlo como limite superior inclusivo.
+func ParseHasta(texto string, zona *time.Location) (time.Time, error) {
+	valor, soloDia, err := parse(texto, zona)
+	if err != nil || !soloDia {
+		return valor, err
+	}
+	_, fin := Dia(valor)
+	return fin.Add(-time.Nanosecond), nil
+}
+
+func parse(texto string, zona *time.Location) (time.Time, bool, error) {
+	texto = strings.TrimSpace(texto)
+	for _, formato := range formatosConZona {
+		if valor, err := time.Parse(formato, texto); err == nil {
+			return valor, false, nil
+		}
+	}
+	for _, formato := range formatosLocales {
+		if valor, err := time.ParseInLocation(formato, texto, zona); err == nil {
+			return valor, false, nil
+		}
+	}
+	for _, formato := range formatosDia {
+		if valor, err := time.ParseInLocation(formato, texto, zona); err == nil {
+			return valor, true, nil
+		}
+	}
+	return time.Time{}, false, ErrFecha
+}
+
+// CargarZona regresa la zona horaria IANA indicada, o UTC si nombre esta vacio.
+func CargarZona(nombre string) (*time.Location, error) {
+	if nombre == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(nombre)
+}
+
+// Dia regresa el inicio del dia de valor, en su propia zona, y el del dia siguiente.
+func Dia(valor time.Time) (time.Time, time.Time) {
+	inicio := time.Date(valor.Year(), valor.Month(), valor.Day(), 0, 0, 0, 0, valor.Location())
+	return inicio, inicio.AddDate(0, 0, 1)
+}
@@ -0,0 +1,84 @@
+package fecha
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	// Arrange
+	zona, _ := CargarZona("America/Mexico_City")
+	casos := []struct {
+		texto    string
+		esperada time.Time
+	}{
+		{"04/04/2022", time.Date(2022, 4, 4, 0, 0, 0, 0, zona)},
+		{"4/4/2022 13:45", time.Date(2022, 4, 4, 13, 45, 0, 0, zona)},
+		{"2022-04-04", time.Date(2022, 4, 4, 0, 0, 0, 0, zona)},
+		{"2022-04-04T13:45:10", time.Date(2022, 4, 4, 13, 45, 10, 0, zona)},
+		{"2022-04-04T13:45:10Z", time.Date(2022, 4, 4, 13, 45, 10, 0, time.UTC)},
+		{"2022-04-04T13:45:10.5+02:00", time.Date(2022, 4, 4, 11, 45, 10, 500000000, time.UTC)},
+	}
+
+	for _, caso := range casos {
+		// Act
+		result, err := Parse(caso.texto, zona)
+
+		// Assert
+		assert.Nil(t, err, caso.texto)
+		assert.True(t, caso.esperada.Equal(result), "%s: %s", caso.texto, result)
+	}
+}
+
+func TestParseConservaZona(t *testing.T) {
+	// Arrange
+	zona, _ := CargarZona("America/Mexico_City")
+
+	// Act
+	local, _ := Parse("04/04/2022", zona)
+	conOffset, _ := Parse("2022-04-04T00:00:00+09:00", zona)
+
+	// Assert
+	assert.Equal(t, "2022-04-04T00:00:00-05:00", local.Format(time.RFC3339))
+	assert.Equal(t, "2022-04-04T00:00:00+09:00", conOffset.Format(time.RFC3339))
+}
+
+func TestParseNoValida(t *testing.T) {
+	for _, texto := range []string{"", "31/02/2022", "2022-13-01", "29/02/2023", "04-04-2022", "ayer", "2022-04-04T25:00:00Z"} {
+		// Act
+		_, err := Parse(texto, time.UTC)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrFecha, texto)
+	}
+}
+
+func TestParseHasta(t *testing.T) {
+	// Arrange
+	zona, _ := CargarZona("America/Mexico_City")
+
+	// Act
+	dia, errDia := ParseHasta("04/04/2022", zona)
+	instante, errInstante := ParseHasta("2022-04-04T10:00:00-05:00", zona)
+
+	// Assert
+	assert.Nil(t, errDia)
+	assert.Equal(t, time.Date(2022, 4, 4, 23, 59, 59, 999999999, zona), dia)
+	assert.Nil(t, errInstante)
+	assert.Equal(t, "2022-04-04T10:00:00-05:00", instante.Format(time.RFC3339))
+}
+
+func TestDia(t *testing.T) {
+	// Arrange
+	zona, _ := CargarZona("America/Mexico_City")
+	valor := time.Date(2022, 4, 4, 18, 30, 0, 0, zona)
+
+	// Act
+	inicio, fin := Dia(valor)
+
+	// Assert
+	assert.Equal(t, time.Date(2022, 4, 4, 0, 0, 0, 0, zona), inicio)
+	assert.Equal(t, time.Date(2022, 4, 5, 0, 0, 0, 0, zona), fin)
+}
@@ -0,0 +1,435 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	journalSuffix       = ".journal"
+	DefaultCompactEvery = 1000
+)
+
+const (
+	journalPut    = "put"
+	journalDelete = "delete"
+)
+
+// JournalFileStore guarda un snapshot con formato de arreglo json en FileName
+// y agrega cada cambio posterior como una linea en FileName.journal. El
+// estado se reconstruye leyendo el snapshot y aplicando el journal, y cuando
+// este acumula CompactEvery entradas se compacta en un nuevo snapshot.
+//
+// Cada registro se identifica por su campo "id", por lo que el snapshot es
+// compatible con el formato de JsonFileStore.
+type JournalFileStore struct {
+	FileName     string
+	CompactEvery int
+
+	lock fileLock
+
+	mu          sync.Mutex
+	loaded      bool
+	order       []int
+	records     map[int]json.RawMessage
+	deleted     int
+	entries     int
+	offset      int64
+	snapshotSig fileSignature
+	version     uint64
+}
+
+type journalEntry struct {
+	Op     string          `json:"op"`
+	Id     int             `json:"id"`
+	Record json.RawMessage `json:"record,omitempty"`
+}
+
+type fileSignature struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func signatureOf(fileName string) (fileSignature, error) {
+	info, err := os.Stat(fileName)
+	if os.IsNotExist(err) {
+		return fileSignature{}, nil
+	}
+	if err != nil {
+		return fileSignature{}, err
+	}
+	return fileSignature{exists: true, size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+func (s *JournalFileStore) journalName() string {
+	return s.FileName + journalSuffix
+}
+
+func (s *JournalFileStore) compactEvery() int {
+	if s.CompactEvery <= 0 {
+		return DefaultCompactEvery
+	}
+	return s.CompactEvery
+}
+
+func (s *JournalFileStore) Read(data interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(s.snapshot(), data); err != nil {
+		return errors.New("archivo con formato no valido")
+	}
+	return nil
+}
+
+// Write reemplaza todo el contenido del store escribiendo un nuevo snapshot.
+func (s *JournalFileStore) Write(data interface{}) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return errors.New("error al almacenar la nueva transaccion")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, records, err := parseSnapshot(content)
+	if err != nil {
+		return errors.New("error al almacenar la nueva transaccion")
+	}
+	s.order, s.records, s.deleted = order, records, 0
+
+	if err := s.compact(); err != nil {
+		s.loaded = false
+		return err
+	}
+	return nil
+}
+
+// Put agrega o reemplaza el registro con el id indicado.
+func (s *JournalFileStore) Put(id int, record interface{}) error {
+	content, err := json.Marshal(record)
+	if err != nil {
+		return errors.New("error al almacenar la nueva transaccion")
+	}
+	return s.append(journalEntry{Op: journalPut, Id: id, Record: content})
+}
+
+// Delete elimina el registro con el id indicado.
+func (s *JournalFileStore) Delete(id int) error {
+	return s.append(journalEntry{Op: journalDelete, Id: id})
+}
+
+// Version cambia cada vez que cambia el contenido del store, incluso si el
+// cambio lo hizo otro proceso.
+func (s *JournalFileStore) Version() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return 0, err
+	}
+	return s.version, nil
+}
+
+func (s *JournalFileStore) Lock() error {
+	if err := s.lock.acquire(s.FileName + lockSuffix); err != nil {
+		return errors.New("error al bloquear el journal")
+	}
+	return nil
+}
+
+func (s *JournalFileStore) Unlock() error {
+	if err := s.lock.release(); err != nil {
+		return errors.New("error al desbloquear el journal")
+	}
+	return nil
+}
+
+// Recover restaura el snapshot desde su copia si quedo corrupto y descarta la
+// ultima linea del journal si una escritura la dejo incompleta.
+func (s *JournalFileStore) Recover() error {
+	if err := s.Lock(); err != nil {
+		return err
+	}
+	defer s.Unlock()
+
+	removeTempFiles(s.FileName)
+
+	if _, err := os.Stat(s.FileName); err == nil && !isValidJsonFile(s.FileName) {
+		backup, err := os.ReadFile(s.FileName + backupSuffix)
+		if err != nil || !json.Valid(backup) {
+			return errors.New("el snapshot esta corrupto y no existe una copia valida")
+		}
+		if err := writeFileAtomic(s.FileName, backup, false); err != nil {
+			return errors.New("error al restaurar el snapshot")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loaded = false
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	return s.truncateTornTail()
+}
+
+func (s *JournalFileStore) append(entry journalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.New("error al almacenar la nueva transaccion")
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	if err := s.truncateTornTail(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.journalName(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.New("error al abrir el journal")
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		s.loaded = false
+		return errors.New("error al escribir en el journal")
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		s.loaded = false
+		return errors.New("error al escribir en el journal")
+	}
+	if err := file.Close(); err != nil {
+		s.loaded = false
+		return errors.New("error al escribir en el journal")
+	}
+
+	s.apply(entry)
+	s.offset += int64(len(line))
+	s.entries++
+	s.version++
+
+	if s.entries >= s.compactEvery() {
+		return s.compact()
+	}
+	return nil
+}
+
+// refresh sincroniza el estado en memoria con los archivos. Si el snapshot
+// cambio se recarga todo; si no, solo se aplican las lineas nuevas del journal.
+func (s *JournalFileStore) refresh() error {
+	snapshotSig, err := signatureOf(s.FileName)
+	if err != nil {
+		return errors.New("archivo no encontrado")
+	}
+
+	journalSig, err := signatureOf(s.journalName())
+	if err != nil {
+		return errors.New("archivo no encontrado")
+	}
+
+	if !s.loaded || snapshotSig != s.snapshotSig || journalSig.size < s.offset {
+		if err := s.loadSnapshot(); err != nil {
+			return err
+		}
+		s.snapshotSig = snapshotSig
+	}
+
+	if journalSig.size == s.offset {
+		return nil
+	}
+	return s.replay()
+}
+
+func (s *JournalFileStore) loadSnapshot() error {
+	s.order, s.records, s.deleted = nil, map[int]json.RawMessage{}, 0
+	s.entries, s.offset = 0, 0
+	s.loaded = false
+
+	content, err := os.ReadFile(s.FileName)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.New("archivo no encontrado")
+	}
+	if err == nil {
+		order, records, err := parseSnapshot(content)
+		if err != nil {
+			return errors.New("archivo con formato no valido")
+		}
+		s.order, s.records = order, records
+	}
+
+	s.loaded = true
+	s.version++
+	return nil
+}
+
+// replay aplica las lineas completas del journal a partir de offset. Una
+// ultima linea sin salto de linea es una escritura interrumpida y se ignora.
+func (s *JournalFileStore) replay() error {
+	file, err := os.Open(s.journalName())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.New("error al abrir el journal")
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(s.offset, io.SeekStart); err != nil {
+		return errors.New("error al leer el journal")
+	}
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.New("error al leer el journal")
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			s.loaded = false
+			return errors.New("journal con formato no valido")
+		}
+		s.apply(entry)
+		s.offset += int64(len(line))
+		s.entries++
+		s.version++
+	}
+}
+
+func (s *JournalFileStore) truncateTornTail() error {
+	journalSig, err := signatureOf(s.journalName())
+	if err != nil {
+		return errors.New("archivo no encontrado")
+	}
+	if journalSig.size <= s.offset {
+		return nil
+	}
+	if err := os.Truncate(s.journalName(), s.offset); err != nil {
+		return errors.New("error al reparar el journal")
+	}
+	return nil
+}
+
+func (s *JournalFileStore) apply(entry journalEntry) {
+	switch entry.Op {
+	case journalPut:
+		if _, ok := s.records[entry.Id]; !ok {
+			s.order = append(s.order, entry.Id)
+		}
+		s.records[entry.Id] = entry.Record
+	case journalDelete:
+		if _, ok := s.records[entry.Id]; ok {
+			delete(s.records, entry.Id)
+			s.deleted++
+		}
+	}
+
+	if s.deleted > len(s.order)/2 {
+		s.compactOrder()
+	}
+}
+
+// compactOrder quita de order los ids eliminados. Un id eliminado y vuelto a
+// agregar mientras sigue en order conserva su posicion original.
+func (s *JournalFileStore) compactOrder() {
+	order := make([]int, 0, len(s.records))
+	seen := make(map[int]bool, len(s.records))
+	for _, id := range s.order {
+		if _, ok := s.records[id]; ok && !seen[id] {
+			order = append(order, id)
+			seen[id] = true
+		}
+	}
+	s.order = order
+	s.deleted = 0
+}
+
+func (s *JournalFileStore) snapshot() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteByte('[')
+	seen := make(map[int]bool, len(s.records))
+	for _, id := range s.order {
+		record, ok := s.records[id]
+		if !ok || seen[id] {
+			continue
+		}
+		if len(seen) > 0 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(record)
+		seen[id] = true
+	}
+	buffer.WriteByte(']')
+	return buffer.Bytes()
+}
+
+// compact escribe el estado actual como snapshot y vacia el journal. Si el
+// proceso termina entre ambos pasos, volver a aplicar el journal sobre el
+// nuevo snapshot produce el mismo estado.
+func (s *JournalFileStore) compact() error {
+	s.compactOrder()
+	if err := writeFileAtomic(s.FileName, s.snapshot(), true); err != nil {
+		return errors.New("error al escribir el snapshot")
+	}
+	if err := os.Truncate(s.journalName(), 0); err != nil && !os.IsNotExist(err) {
+		s.loaded = false
+		return errors.New("error al vaciar el journal")
+	}
+
+	snapshotSig, err := signatureOf(s.FileName)
+	if err != nil {
+		s.loaded = false
+		return errors.New("archivo no encontrado")
+	}
+	s.snapshotSig = snapshotSig
+	s.entries, s.offset = 0, 0
+	s.loaded = true
+	s.version++
+	return nil
+}
+
+func parseSnapshot(content []byte) ([]int, map[int]json.RawMessage, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	order := make([]int, 0, len(raw))
+	records := make(map[int]json.RawMessage, len(raw))
+	for _, record := range raw {
+		var key struct {
+			Id int `json:"id"`
+		}
+		if err := json.Unmarshal(record, &key); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := records[key.Id]; !ok {
+			order = append(order, key.Id)
+		}
+		records[key.Id] = record
+	}
+	return order, records, nil
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalFileStorePutAndDelete(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	db := &JournalFileStore{FileName: fileName, CompactEvery: 100}
+	expected := []registro{{Id: 1, Nombre: "primero actualizado"}, {Id: 3, Nombre: "tercero"}}
+
+	// Act
+	assert.Nil(t, db.Put(1, registro{Id: 1, Nombre: "primero"}))
+	assert.Nil(t, db.Put(2, registro{Id: 2, Nombre: "segundo"}))
+	assert.Nil(t, db.Put(3, registro{Id: 3, Nombre: "tercero"}))
+	assert.Nil(t, db.Put(1, registro{Id: 1, Nombre: "primero actualizado"}))
+	assert.Nil(t, db.Delete(2))
+	var result []registro
+	err := db.Read(&result)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+	assert.NoFileExists(t, fileName)
+}
+
+func TestJournalFileStoreRebuildsOnOpen(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	assert.Nil(t, os.WriteFile(fileName, []byte(`[{"id":1,"nombre":"snapshot"}]`), 0644))
+	first := &JournalFileStore{FileName: fileName, CompactEvery: 100}
+	assert.Nil(t, first.Put(2, registro{Id: 2, Nombre: "journal"}))
+	assert.Nil(t, first.Delete(1))
+	expected := []registro{{Id: 2, Nombre: "journal"}}
+
+	// Act
+	second := &JournalFileStore{FileName: fileName, CompactEvery: 100}
+	var result []registro
+	err := second.Read(&result)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestJournalFileStoreCompacts(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	db := &JournalFileStore{FileName: fileName, CompactEvery: 3}
+
+	// Act
+	assert.Nil(t, db.Put(1, registro{Id: 1, Nombre: "primero"}))
+	assert.Nil(t, db.Put(2, registro{Id: 2, Nombre: "segundo"}))
+	assert.Nil(t, db.Put(3, registro{Id: 3, Nombre: "tercero"}))
+	assert.Nil(t, db.Put(4, registro{Id: 4, Nombre: "cuarto"}))
+	var snapshot []registro
+	errSnapshot := (&JsonFileStore{FileName: fileName}).Read(&snapshot)
+	journal, errJournal := os.ReadFile(fileName + journalSuffix)
+
+	// Assert
+	assert.Nil(t, errSnapshot)
+	assert.Nil(t, errJournal)
+	assert.Len(t, snapshot, 3)
+	assert.Equal(t, 1, strings.Count(string(journal), "\n"))
+}
+
+func TestJournalFileStoreSeesOtherWriters(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	reader := &JournalFileStore{FileName: fileName, CompactEvery: 100}
+	writer := &JournalFileStore{FileName: fileName, CompactEvery: 100}
+	assert.Nil(t, writer.Put(1, registro{Id: 1, Nombre: "primero"}))
+	before, errBefore := reader.Version()
+
+	// Act
+	assert.Nil(t, writer.Put(2, registro{Id: 2, Nombre: "segundo"}))
+	after, errAfter := reader.Version()
+	var result []registro
+	err := reader.Read(&result)
+
+	// Assert
+	assert.Nil(t, errBefore)
+	assert.Nil(t, errAfter)
+	assert.NotEqual(t, before, after)
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+}
+
+func TestJournalFileStoreRecoverTornTail(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	db := &JournalFileStore{FileName: fileName, CompactEvery: 100}
+	assert.Nil(t, db.Put(1, registro{Id: 1, Nombre: "primero"}))
+	journal, err := os.OpenFile(fileName+journalSuffix, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	journal.WriteString(`{"op":"put","id":2,"rec`)
+	journal.Close()
+	expected := []registro{{Id: 1, Nombre: "primero"}, {Id: 3, Nombre: "tercero"}}
+
+	// Act
+	reopened := &JournalFileStore{FileName: fileName, CompactEvery: 100}
+	errRecover := reopened.Recover()
+	errPut := reopened.Put(3, registro{Id: 3, Nombre: "tercero"})
+	var result []registro
+	errRead := (&JournalFileStore{FileName: fileName, CompactEvery: 100}).Read(&result)
+
+	// Assert
+	assert.Nil(t, errRecover)
+	assert.Nil(t, errPut)
+	assert.Nil(t, errRead)
+	assert.Equal(t, expected, result)
+}
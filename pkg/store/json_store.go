@@ -3,17 +3,31 @@ package store
 import (
 	"encoding/json"
 	"errors"
+	"hash/fnv"
 	"os"
+	"path/filepath"
+	"sync"
 )
 
+const (
+	backupSuffix = ".bak"
+	lockSuffix   = ".lock"
+	tempPattern  = ".tmp-*"
+)
+
+// ErrFileNotFound indica que el archivo del store aun no existe.
+var ErrFileNotFound = errors.New("archivo no encontrado")
+
 type JsonFileStore struct {
 	FileName string
+
+	lock fileLock
 }
 
 func (s *JsonFileStore) Read(data interface{}) error {
 	jsonData, err := os.ReadFile(s.FileName)
 	if err != nil {
-		return errors.New("archivo no encontrado")
+		return ErrFileNotFound
 	}
 	serr := json.Unmarshal((jsonData), data)
 	if serr != nil {
@@ -27,8 +41,174 @@ func (s *JsonFileStore) Write(data interface{}) error {
 	if err != nil {
 		return errors.New("error al almacenar la nueva transaccion")
 	}
-	if err := os.WriteFile(s.FileName, content, 0644); err != nil {
+	if err := writeFileAtomic(s.FileName, content, true); err != nil {
 		return errors.New("error al escribir en el archivo json")
 	}
 	return nil
 }
+
+// Version cambia cada vez que cambia el contenido del archivo, incluso si lo
+// reemplazo otro proceso. Se calcula sobre el contenido y no sobre el tamano
+// y la fecha de modificacion, porque otra escritura del mismo tamano dentro de
+// la resolucion del sistema de archivos no cambiaria ninguno de los dos.
+func (s *JsonFileStore) Version() (uint64, error) {
+	content, err := os.ReadFile(s.FileName)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.New("error al leer el archivo json")
+	}
+	hash := fnv.New64a()
+	hash.Write(content)
+	return hash.Sum64(), nil
+}
+
+// Lock toma el bloqueo exclusivo del store.
+func (s *JsonFileStore) Lock() error {
+	if err := s.lock.acquire(s.FileName + lockSuffix); err != nil {
+		return errors.New("error al bloquear el archivo json")
+	}
+	return nil
+}
+
+func (s *JsonFileStore) Unlock() error {
+	if err := s.lock.release(); err != nil {
+		return errors.New("error al desbloquear el archivo json")
+	}
+	return nil
+}
+
+// Recover elimina los temporales que dejo una escritura interrumpida y, si el
+// archivo no contiene json valido, lo restaura desde la ultima copia buena. Un
+// archivo que aun no existe se considera vacio.
+func (s *JsonFileStore) Recover() error {
+	if err := s.Lock(); err != nil {
+		return err
+	}
+	defer s.Unlock()
+
+	removeTempFiles(s.FileName)
+
+	if _, err := os.Stat(s.FileName); os.IsNotExist(err) {
+		return nil
+	}
+	if isValidJsonFile(s.FileName) {
+		return nil
+	}
+
+	backup, err := os.ReadFile(s.FileName + backupSuffix)
+	if err != nil || !json.Valid(backup) {
+		return errors.New("el archivo json esta corrupto y no existe una copia valida")
+	}
+
+	if err := writeFileAtomic(s.FileName, backup, false); err != nil {
+		return errors.New("error al restaurar el archivo json")
+	}
+	return nil
+}
+
+func removeTempFiles(fileName string) {
+	temps, _ := filepath.Glob(fileName + tempPattern)
+	for _, temp := range temps {
+		os.Remove(temp)
+	}
+}
+
+func isValidJsonFile(fileName string) bool {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return false
+	}
+	return json.Valid(content)
+}
+
+// writeFileAtomic escribe el contenido en un temporal del mismo directorio, lo
+// sincroniza a disco y lo renombra sobre el archivo destino, de modo que un
+// lector nunca ve un archivo a medias. Si backup es verdadero, el contenido
+// anterior se conserva como copia .bak.
+func writeFileAtomic(fileName string, content []byte, backup bool) error {
+	dir := filepath.Dir(fileName)
+	temp, err := os.CreateTemp(dir, filepath.Base(fileName)+tempPattern)
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		os.Remove(tempName)
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		os.Remove(tempName)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	if err := os.Chmod(tempName, 0644); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+
+	if backup && isValidJsonFile(fileName) {
+		os.Remove(fileName + backupSuffix)
+		if err := os.Link(fileName, fileName+backupSuffix); err != nil {
+			copyFile(fileName, fileName+backupSuffix)
+		}
+	}
+
+	if err := os.Rename(tempName, fileName); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func copyFile(source, destination string) error {
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(destination, content, 0644)
+}
+
+// fileLock combina un mutex, que serializa a los llamadores del mismo
+// proceso, con un bloqueo sobre un archivo auxiliar, que serializa a los
+// procesos que comparten el archivo. Se usa un archivo aparte porque el de
+// datos se reemplaza en cada escritura.
+type fileLock struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (l *fileLock) acquire(lockName string) error {
+	l.mu.Lock()
+	file, err := os.OpenFile(lockName, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		l.mu.Unlock()
+		return err
+	}
+	l.file = file
+	return nil
+}
+
+func (l *fileLock) release() error {
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type registro struct {
+	Id     int    `json:"id"`
+	Nombre string `json:"nombre"`
+}
+
+func TestJsonFileStoreWriteKeepsBackup(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	db := &JsonFileStore{FileName: fileName}
+	first := []registro{{Id: 1, Nombre: "primero"}}
+	second := []registro{{Id: 1, Nombre: "primero"}, {Id: 2, Nombre: "segundo"}}
+
+	// Act
+	errFirst := db.Write(first)
+	errSecond := db.Write(second)
+	var result []registro
+	errRead := db.Read(&result)
+	var backup []registro
+	errBackup := (&JsonFileStore{FileName: fileName + backupSuffix}).Read(&backup)
+
+	// Assert
+	assert.Nil(t, errFirst)
+	assert.Nil(t, errSecond)
+	assert.Nil(t, errRead)
+	assert.Nil(t, errBackup)
+	assert.Equal(t, second, result)
+	assert.Equal(t, first, backup)
+}
+
+func TestJsonFileStoreRecoverFromBackup(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	db := &JsonFileStore{FileName: fileName}
+	expected := []registro{{Id: 1, Nombre: "primero"}}
+	assert.Nil(t, db.Write(expected))
+	assert.Nil(t, db.Write(append(expected, registro{Id: 2, Nombre: "segundo"})))
+	assert.Nil(t, os.WriteFile(fileName, []byte(`[{"id":1,"nom`), 0644))
+	assert.Nil(t, os.WriteFile(fileName+".tmp-123", []byte(`[`), 0644))
+
+	// Act
+	err := db.Recover()
+	var result []registro
+	errRead := db.Read(&result)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Nil(t, errRead)
+	assert.Equal(t, expected, result)
+	assert.NoFileExists(t, fileName+".tmp-123")
+}
+
+func TestJsonFileStoreRecoverWithoutBackup(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	assert.Nil(t, os.WriteFile(fileName, []byte(`[{"id":1`), 0644))
+	db := &JsonFileStore{FileName: fileName}
+
+	// Act
+	err := db.Recover()
+
+	// Assert
+	assert.NotNil(t, err)
+}
+
+func TestJsonFileStoreVersion(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	db := &JsonFileStore{FileName: fileName}
+	other := &JsonFileStore{FileName: fileName}
+
+	// Act
+	missing, errMissing := db.Version()
+	assert.Nil(t, db.Write([]registro{{Id: 1, Nombre: "primero"}}))
+	first, errFirst := db.Version()
+	same, errSame := db.Version()
+	assert.Nil(t, other.Write([]registro{{Id: 1, Nombre: "segundo"}, {Id: 2, Nombre: "tercero"}}))
+	changed, errChanged := db.Version()
+	// Mismo tamano y misma fecha de modificacion, otro contenido.
+	info, _ := os.Stat(fileName)
+	assert.Nil(t, other.Write([]registro{{Id: 1, Nombre: "segundX"}, {Id: 2, Nombre: "tercero"}}))
+	assert.Nil(t, os.Chtimes(fileName, info.ModTime(), info.ModTime()))
+	sameSize, errSameSize := db.Version()
+
+	// Assert
+	assert.Nil(t, errMissing)
+	assert.Nil(t, errSameSize)
+	assert.NotEqual(t, changed, sameSize)
+	assert.Nil(t, errFirst)
+	assert.Nil(t, errSame)
+	assert.Nil(t, errChanged)
+	assert.NotEqual(t, missing, first)
+	assert.Equal(t, first, same)
+	assert.NotEqual(t, first, changed)
+}
+
+func TestJsonFileStoreLockIsExclusive(t *testing.T) {
+	// Arrange
+	fileName := filepath.Join(t.TempDir(), "data.json")
+	first := &JsonFileStore{FileName: fileName}
+	second := &JsonFileStore{FileName: fileName}
+	acquired := make(chan struct{})
+
+	// Act
+	assert.Nil(t, first.Lock())
+	go func() {
+		second.Lock()
+		close(acquired)
+		second.Unlock()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	// Assert
+	select {
+	case <-acquired:
+		t.Fatal("el segundo store obtuvo el bloqueo mientras el primero lo tenia")
+	default:
+	}
+	assert.Nil(t, first.Unlock())
+	<-acquired
+}
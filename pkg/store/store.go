@@ -5,16 +5,66 @@ type Store interface {
 	Write(data interface{}) error
 }
 
+// Locker es implementado por los stores que pueden bloquearse de forma
+// exclusiva durante un ciclo de lectura, modificacion y escritura.
+type Locker interface {
+	Lock() error
+	Unlock() error
+}
+
+// Recoverer es implementado por los stores capaces de reparar su archivo
+// cuando una escritura anterior quedo a medias.
+type Recoverer interface {
+	Recover() error
+}
+
+// RecordStore es implementado por los stores que pueden guardar o eliminar
+// un solo registro sin reescribir toda la lista.
+type RecordStore interface {
+	Store
+	Put(id int, record interface{}) error
+	Delete(id int) error
+}
+
+// Versioner es implementado por los stores que pueden indicar si su
+// contenido cambio desde la ultima lectura sin tener que leerlo completo.
+type Versioner interface {
+	Version() (uint64, error)
+}
+
 type StoreType string
 
 const (
-	JsonFileType StoreType = "jsonFile"
+	JsonFileType    StoreType = "jsonFile"
+	JournalFileType StoreType = "journalFile"
 )
 
 func NewStore(storeType StoreType, filename string) Store {
 	switch storeType {
 	case JsonFileType:
 		return &JsonFileStore{FileName: filename}
+	case JournalFileType:
+		return &JournalFileStore{FileName: filename, CompactEvery: DefaultCompactEvery}
+	}
+	return nil
+}
+
+// Lock bloquea el store si este lo soporta y regresa la funcion que lo libera.
+func Lock(s Store) (func(), error) {
+	locker, ok := s.(Locker)
+	if !ok {
+		return func() {}, nil
+	}
+	if err := locker.Lock(); err != nil {
+		return func() {}, err
+	}
+	return func() { _ = locker.Unlock() }, nil
+}
+
+// Recover repara el store si este lo soporta.
+func Recover(s Store) error {
+	if recoverer, ok := s.(Recoverer); ok {
+		return recoverer.Recover()
 	}
 	return nil
 }
@@ -3,15 +3,34 @@ package web
 import "fmt"
 
 type Response struct {
-	Code    string      `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Code       string      `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data,omitempty"`
+	Total      *int        `json:"total,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Error      string      `json:"error,omitempty"`
 }
 
 func NewResponse(code int, message string, data interface{}, err string) Response {
 	if code < 400 {
-		return Response{fmt.Sprint(code), message, data, ""}
+		return Response{Code: fmt.Sprint(code), Message: message, Data: data}
 	}
-	return Response{fmt.Sprint(code), message, nil, err}
+	return Response{Code: fmt.Sprint(code), Message: message, Error: err}
+}
+
+// NewPageResponse responde una pagina de resultados. total cuenta todos los
+// resultados y nextCursor, si no esta vacio, pide la pagina siguiente.
+func NewPageResponse(code int, message string, data interface{}, total int, nextCursor string) Response {
+	response := NewResponse(code, message, data, "")
+	response.Total = &total
+	response.NextCursor = nextCursor
+	return response
+}
+
+// NewErrorResponse responde un error junto con data, que describe el error
+// para que el cliente pueda actuar sin interpretar el mensaje.
+func NewErrorResponse(code int, message string, data interface{}, err string) Response {
+	response := NewResponse(code, message, nil, err)
+	response.Data = data
+	return response
 }
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/BrandonICR/web_cl2_050422_8am/cmd/server/engine"
@@ -16,13 +19,22 @@ import (
 const FILE_STORE = "transacciones.json"
 
 type transaccion struct {
-	Id                int     `json:"id"`
-	CodigoTransaccion string  `json:"codigo_transaccion"`
-	Moneda            string  `json:"moneda"`
-	Monto             float64 `json:"monto"`
-	Emisor            string  `json:"emisor"`
-	Receptor          string  `json:"receptor"`
-	FechaTransaccion  string  `json:"fecha_transaccion"`
+	Id                int    `json:"id"`
+	CodigoTransaccion string `json:"codigo_transaccion"`
+	Moneda            string `json:"moneda"`
+	Monto             string `json:"monto"`
+	Emisor            string `json:"emisor"`
+	EmisorId          int    `json:"emisor_id"`
+	Receptor          string `json:"receptor"`
+	ReceptorId        int    `json:"receptor_id"`
+	FechaTransaccion  string `json:"fecha_transaccion"`
+}
+
+func removeFileStore(fileName string) {
+	os.Remove(fileName)
+	os.Remove(fileName + ".bak")
+	os.Remove(fileName + ".lock")
+	os.Remove(fileName + ".journal")
 }
 
 func TestUpdate(t *testing.T) {
@@ -41,9 +53,11 @@ func TestUpdate(t *testing.T) {
 		Id:                2,
 		CodigoTransaccion: "ctr new",
 		Moneda:            "USD",
-		Monto:             900,
+		Monto:             "900.00",
 		Emisor:            "Banamex",
-		Receptor:          "Banxico",
+		EmisorId:          4,
+		Receptor:          "Paco",
+		ReceptorId:        5,
 		FechaTransaccion:  "23/04/2022",
 	}
 
@@ -63,7 +77,7 @@ func TestUpdate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, reqBody, resBody.Data)
 
-	os.Remove(tempFileName)
+	removeFileStore(tempFileName)
 }
 
 func TestDelete(t *testing.T) {
@@ -93,5 +107,1340 @@ func TestDelete(t *testing.T) {
 	err := json.Unmarshal(res.Body.Bytes(), &resBody)
 	assert.Nil(t, err)
 
-	os.Remove(tempFileName)
+	removeFileStore(tempFileName)
+}
+
+func TestStoreConcurrente(t *testing.T) {
+	tempFileName := "transacciones_store_concurrente_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type response struct {
+		Code    string      `json:"code"`
+		Message string      `json:"message"`
+		Data    transaccion `json:"data,omitempty"`
+		Error   string      `json:"error,omitempty"`
+	}
+
+	const peticiones = 200
+	ids := make(chan int, peticiones)
+	var wg sync.WaitGroup
+
+	for i := 0; i < peticiones; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reqBody := transaccion{
+				CodigoTransaccion: fmt.Sprintf("ctr concurrente %d", i),
+				Moneda:            "MXN",
+				Monto:             "100.00",
+				Emisor:            "Banamex",
+				EmisorId:          4,
+				Receptor:          "Paco",
+				ReceptorId:        5,
+				FechaTransaccion:  "23/04/2022",
+			}
+			reqBytesBody, _ := json.Marshal(reqBody)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transacciones/0", bytes.NewBuffer(reqBytesBody))
+			req.Header.Add("Content-Type", "application/json")
+			req.Header.Add("authorization", "12345")
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			var resBody response
+			if res.Code == http.StatusOK && json.Unmarshal(res.Body.Bytes(), &resBody) == nil {
+				ids <- resBody.Data.Id
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	unicos := map[int]bool{}
+	for id := range ids {
+		unicos[id] = true
+	}
+	assert.Len(t, unicos, peticiones)
+
+	type listResponse struct {
+		Data []transaccion `json:"data"`
+	}
+	var almacenadas listResponse
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/transacciones", nil)
+	req.Header.Add("authorization", "12345")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &almacenadas))
+
+	var original []transaccion
+	data, err := os.ReadFile(FILE_STORE)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &original))
+	assert.Len(t, almacenadas.Data, len(original)+peticiones)
+}
+
+func TestStoreMoneda(t *testing.T) {
+	tempFileName := "transacciones_store_moneda_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type response struct {
+		Code    string      `json:"code"`
+		Message string      `json:"message"`
+		Data    transaccion `json:"data,omitempty"`
+		Error   string      `json:"error,omitempty"`
+	}
+
+	casos := []struct {
+		moneda   string
+		status   int
+		esperada string
+	}{
+		{moneda: " usd", status: http.StatusOK, esperada: "USD"},
+		{moneda: "XYZ", status: http.StatusBadRequest},
+		{moneda: "VEF", status: http.StatusBadRequest},
+	}
+
+	for index, caso := range casos {
+		var resBody response
+		reqBody := transaccion{
+			CodigoTransaccion: fmt.Sprintf("ctr moneda %d", index),
+			Moneda:            caso.moneda,
+			Monto:             "100.00",
+			Emisor:            "Banamex",
+			EmisorId:          4,
+			Receptor:          "Paco",
+			ReceptorId:        5,
+			FechaTransaccion:  "23/04/2022",
+		}
+		reqBytesBody, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transacciones/0", bytes.NewBuffer(reqBytesBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assert.Equal(t, caso.status, res.Code, caso.moneda)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		assert.Equal(t, caso.esperada, resBody.Data.Moneda)
+	}
+}
+
+func TestGetMonedas(t *testing.T) {
+	tempFileName := "transacciones_monedas_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type moneda struct {
+		Codigo    string `json:"codigo"`
+		Decimales int    `json:"decimales"`
+		Activa    bool   `json:"activa"`
+	}
+	type response struct {
+		Code string   `json:"code"`
+		Data []moneda `json:"data"`
+	}
+	var resBody response
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/monedas?activas=true", nil)
+	req.Header.Add("authorization", "12345")
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	assert.Contains(t, resBody.Data, moneda{Codigo: "MXN", Decimales: 2, Activa: true})
+	for _, m := range resBody.Data {
+		assert.True(t, m.Activa, m.Codigo)
+	}
+}
+
+func TestGetAllConvertido(t *testing.T) {
+	tempFileName := "transacciones_convertir_temp.json"
+	tiposCambioFileName := "./tipos_cambio.json"
+	removeFileStore(tiposCambioFileName)
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+	defer removeFileStore(tiposCambioFileName)
+
+	type conversion struct {
+		Moneda    string `json:"moneda"`
+		Monto     string `json:"monto"`
+		Tasa      string `json:"tasa"`
+		FechaTasa string `json:"fecha_tasa"`
+	}
+	type transaccionConvertida struct {
+		transaccion
+		Conversion conversion `json:"conversion"`
+	}
+	type response struct {
+		Code  string                  `json:"code"`
+		Data  []transaccionConvertida `json:"data"`
+		Error string                  `json:"error"`
+	}
+
+	get := func() (*httptest.ResponseRecorder, response) {
+		var resBody response
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/transacciones?convertir_a=usd", nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		return res, resBody
+	}
+
+	res, _ := get()
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+
+	reqBody := []byte(`{"origen":"MXN","destino":"USD","fecha":"01/04/2022","tasa":"0.05"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tipos-cambio", bytes.NewBuffer(reqBody))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("authorization", "12345")
+	resStore := httptest.NewRecorder()
+	router.ServeHTTP(resStore, req)
+	assert.Equal(t, http.StatusOK, resStore.Code)
+
+	res, resBody := get()
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Len(t, resBody.Data, 5)
+	for _, item := range resBody.Data {
+		if item.Id == 3 {
+			assert.Equal(t, "500.00", item.Monto)
+			assert.Equal(t, conversion{Moneda: "USD", Monto: "25.00", Tasa: "0.05", FechaTasa: "01/04/2022"}, item.Conversion)
+		}
+	}
+}
+
+func TestFechaTransaccionVersiones(t *testing.T) {
+	tempFileName := "transacciones_fechas_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type response struct {
+		Code  string      `json:"code"`
+		Data  transaccion `json:"data"`
+		Error string      `json:"error"`
+	}
+
+	casos := []struct {
+		fecha    string
+		accept   string
+		status   int
+		esperada string
+	}{
+		{"2022-04-23T10:30:00-05:00", "", http.StatusOK, "23/04/2022"},
+		{"23/04/2022", "application/vnd.transacciones.v2+json", http.StatusOK, "2022-04-23T00:00:00-05:00"},
+		{"2022-04-23 22:15:00", "application/vnd.transacciones.v2+json", http.StatusOK, "2022-04-23T22:15:00-05:00"},
+		{"31/02/2022", "", http.StatusBadRequest, ""},
+		{"23-04-2022", "", http.StatusBadRequest, ""},
+	}
+
+	for index, caso := range casos {
+		var resBody response
+		reqBody, _ := json.Marshal(transaccion{
+			CodigoTransaccion: fmt.Sprintf("fecha-%d", index),
+			Moneda:            "MXN",
+			Monto:             "100.00",
+			Emisor:            "Banamex",
+			EmisorId:          4,
+			Receptor:          "Paco",
+			ReceptorId:        5,
+			FechaTransaccion:  caso.fecha,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transacciones/0", bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		if caso.accept != "" {
+			req.Header.Add("Accept", caso.accept)
+		}
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assert.Equal(t, caso.status, res.Code, caso.fecha)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		assert.Equal(t, caso.esperada, resBody.Data.FechaTransaccion, caso.fecha)
+	}
+}
+
+func TestVersionNoValida(t *testing.T) {
+	tempFileName := "transacciones_version_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	casos := []struct {
+		metodo string
+		url    string
+		accept string
+		status int
+	}{
+		{http.MethodGet, "/api/v1/transacciones/2?version=2", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/transacciones/2", "application/json, application/vnd.transacciones.v1+json", http.StatusOK},
+		{http.MethodGet, "/api/v1/transacciones/2?version=3", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/transacciones/2", "application/vnd.transacciones.v3+json", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/revision?version=dos", "", http.StatusBadRequest},
+		// La version se revisa antes de atender la peticion: la transaccion no se borra.
+		{http.MethodDelete, "/api/v1/transacciones/6?version=3", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/transacciones/6", "", http.StatusOK},
+	}
+
+	for _, caso := range casos {
+		req := httptest.NewRequest(caso.metodo, caso.url, nil)
+		req.Header.Add("authorization", "12345")
+		if caso.accept != "" {
+			req.Header.Add("Accept", caso.accept)
+		}
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assert.Equal(t, caso.status, res.Code, caso.metodo+" "+caso.url)
+		if caso.status == http.StatusBadRequest {
+			assert.Contains(t, res.Body.String(), "las versiones soportadas son 1 y 2")
+		}
+	}
+}
+
+func TestGetTransaccionFiltradaRangos(t *testing.T) {
+	tempFileName := "transacciones_filtro_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type response struct {
+		Code string        `json:"code"`
+		Data []transaccion `json:"data"`
+	}
+
+	casos := []struct {
+		query  string
+		status int
+		ids    []int
+	}{
+		{"moneda=mxn,USD&monto_min=500.01&fecha_desde=04/04/2022&fecha_hasta=2022-04-12&emisor=ban&emisor_coincidencia=prefijo", http.StatusOK, []int{2, 4}},
+		{"monto_max=500&moneda=MXN&moneda=EUR", http.StatusOK, []int{3, 6}},
+		{"emisor=regio&emisor_coincidencia=contiene&fecha_transaccion=20/04/2022", http.StatusOK, []int{5, 6}},
+		{"monto=0", http.StatusNotFound, nil},
+		{"monto=abc", http.StatusBadRequest, nil},
+		{"fecha_desde=31/04/2022", http.StatusBadRequest, nil},
+		{"emisor=ban&emisor_coincidencia=parecido", http.StatusBadRequest, nil},
+	}
+
+	for _, caso := range casos {
+		var resBody response
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/transacciones/?"+caso.query, nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assert.Equal(t, caso.status, res.Code, caso.query)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		var ids []int
+		for _, item := range resBody.Data {
+			ids = append(ids, item.Id)
+		}
+		assert.Equal(t, caso.ids, ids, caso.query)
+	}
+}
+
+func TestGetAllPaginado(t *testing.T) {
+	tempFileName := "transacciones_paginado_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type response struct {
+		Code       string        `json:"code"`
+		Data       []transaccion `json:"data"`
+		Total      int           `json:"total"`
+		NextCursor string        `json:"next_cursor"`
+	}
+
+	obtener := func(query string) (*httptest.ResponseRecorder, response) {
+		var resBody response
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/transacciones?"+query, nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		return res, resBody
+	}
+
+	var ids []int
+	cursor := ""
+	for paginas := 0; paginas < 3; paginas++ {
+		res, resBody := obtener("limit=2&sort=-monto&cursor=" + cursor)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 5, resBody.Total)
+		assert.Contains(t, res.Header().Get("Link"), `rel="first"`)
+		for _, item := range resBody.Data {
+			ids = append(ids, item.Id)
+		}
+		cursor = resBody.NextCursor
+		if cursor == "" {
+			break
+		}
+		assert.Contains(t, res.Header().Get("Link"), `rel="next"`)
+	}
+	assert.Equal(t, []int{2, 5, 4, 3, 6}, ids)
+	assert.Equal(t, "", cursor)
+
+	res, resBody := obtener("limit=2&offset=2&sort=fecha_transaccion")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, 2, len(resBody.Data))
+	assert.Equal(t, 4, resBody.Data[0].Id)
+	assert.Contains(t, res.Header().Get("Link"), `offset=0&sort=fecha_transaccion>; rel="prev"`)
+	assert.Contains(t, res.Header().Get("Link"), `offset=4&sort=fecha_transaccion>; rel="next"`)
+
+	res, _ = obtener("sort=color")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	res, _ = obtener("limit=-1")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestStoreIdempotente(t *testing.T) {
+	tempFileName := "transacciones_idempotencia_temp.json"
+	clavesFileName := "idempotencia_temp.json"
+	os.Setenv("IDEMPOTENCIA_FILE", clavesFileName)
+	defer os.Unsetenv("IDEMPOTENCIA_FILE")
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+	defer removeFileStore(clavesFileName)
+
+	type response struct {
+		Code string      `json:"code"`
+		Data transaccion `json:"data"`
+	}
+
+	enviar := func(clave, accept string, body transaccion) (*httptest.ResponseRecorder, response) {
+		var resBody response
+		reqBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transacciones/0", bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		req.Header.Add("Idempotency-Key", clave)
+		req.Header.Add("Accept", accept)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		return res, resBody
+	}
+
+	body := transaccion{
+		CodigoTransaccion: "ctr-idem",
+		Moneda:            "MXN",
+		Monto:             "150.00",
+		Emisor:            "Banamex",
+		EmisorId:          4,
+		Receptor:          "Paco",
+		ReceptorId:        5,
+		FechaTransaccion:  "23/04/2022",
+	}
+
+	primera, primeraBody := enviar("movil-1", "application/json", body)
+	reintento, reintentoBody := enviar("movil-1", "application/json", body)
+	otroAccept, _ := enviar("movil-1", "*/*", body)
+	body.CodigoTransaccion, body.Monto = "ctr-idem-2", "151.00"
+	distinta, _ := enviar("movil-1", "application/json", body)
+	otra, otraBody := enviar("movil-2", "application/json", body)
+
+	assert.Equal(t, http.StatusOK, primera.Code)
+	assert.Equal(t, http.StatusOK, reintento.Code)
+	assert.Equal(t, "true", reintento.Header().Get("Idempotency-Replayed"))
+	assert.Equal(t, primeraBody.Data, reintentoBody.Data)
+	assert.Equal(t, http.StatusUnprocessableEntity, otroAccept.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, distinta.Code)
+	assert.Equal(t, http.StatusOK, otra.Code)
+	assert.NotEqual(t, primeraBody.Data.Id, otraBody.Data.Id)
+
+	var transacciones []transaccion
+	data, _ := os.ReadFile(tempFileName)
+	assert.Nil(t, json.Unmarshal(data, &transacciones))
+	assert.Equal(t, 7, len(transacciones))
+}
+
+func TestCodigoTransaccionUnico(t *testing.T) {
+	tempFileName := "transacciones_codigo_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type response struct {
+		Code  string      `json:"code"`
+		Data  transaccion `json:"data"`
+		Error string      `json:"error"`
+	}
+
+	enviar := func(method, url string, body interface{}) (*httptest.ResponseRecorder, response) {
+		var resBody response
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		return res, resBody
+	}
+
+	nueva := transaccion{
+		CodigoTransaccion: "ct3",
+		Moneda:            "MXN",
+		Monto:             "100.00",
+		Emisor:            "Banamex",
+		EmisorId:          4,
+		Receptor:          "Paco",
+		ReceptorId:        5,
+		FechaTransaccion:  "23/04/2022",
+	}
+
+	store, _ := enviar(http.MethodPost, "/api/v1/transacciones/0", nueva)
+	update, _ := enviar(http.MethodPut, "/api/v1/transacciones/2", nueva)
+	patch, _ := enviar(http.MethodPatch, "/api/v1/transacciones/2", map[string]string{"codigo_transaccion": "ct3", "monto": "10"})
+	encontrada, encontradaBody := enviar(http.MethodGet, "/api/v1/transacciones/codigo/ct3", nil)
+	noEncontrada, _ := enviar(http.MethodGet, "/api/v1/transacciones/codigo/ct99", nil)
+	porId, porIdBody := enviar(http.MethodGet, "/api/v1/transacciones/2", nil)
+
+	assert.Equal(t, http.StatusConflict, store.Code)
+	assert.Equal(t, http.StatusConflict, update.Code)
+	assert.Equal(t, http.StatusConflict, patch.Code)
+	assert.Equal(t, http.StatusOK, encontrada.Code)
+	assert.Equal(t, 3, encontradaBody.Data.Id)
+	assert.Equal(t, http.StatusNotFound, noEncontrada.Code)
+	assert.Equal(t, http.StatusOK, porId.Code)
+	assert.Equal(t, "ctr2", porIdBody.Data.CodigoTransaccion)
+}
+
+func TestCicloDeVida(t *testing.T) {
+	tempFileName := "transacciones_estados_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type transaccionConEstado struct {
+		Id     int    `json:"id"`
+		Estado string `json:"estado"`
+	}
+	type response struct {
+		Code string               `json:"code"`
+		Data transaccionConEstado `json:"data"`
+	}
+
+	enviar := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	casos := []struct {
+		accion string
+		status int
+		estado string
+	}{
+		{"liquidar", http.StatusConflict, ""},
+		{"autorizar", http.StatusOK, "autorizada"},
+		{"liquidar", http.StatusOK, "liquidada"},
+		{"rechazar", http.StatusConflict, ""},
+	}
+	for _, caso := range casos {
+		var resBody response
+		res := enviar(http.MethodPost, "/api/v1/transacciones/2/"+caso.accion, nil)
+
+		assert.Equal(t, caso.status, res.Code, caso.accion)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		assert.Equal(t, caso.estado, resBody.Data.Estado, caso.accion)
+	}
+
+	// Una liquidada solo se revierte registrando la devolucion.
+	revertir := enviar(http.MethodPost, "/api/v1/transacciones/2/revertir", nil)
+	reversa := enviar(http.MethodPost, "/api/v1/transacciones/2/reversa", map[string]string{"fecha_transaccion": "2022-04-05"})
+	assert.Equal(t, http.StatusNotFound, revertir.Code)
+	assert.Equal(t, http.StatusOK, reversa.Code)
+
+	patch := enviar(http.MethodPatch, "/api/v1/transacciones/2", map[string]string{"codigo_transaccion": "ctr2", "monto": "10"})
+	noExiste := enviar(http.MethodPost, "/api/v1/transacciones/99/autorizar", nil)
+	assert.Equal(t, http.StatusConflict, patch.Code)
+	assert.Equal(t, http.StatusNotFound, noExiste.Code)
+
+	var filtradas struct {
+		Data []transaccionConEstado `json:"data"`
+	}
+	filtro := enviar(http.MethodGet, "/api/v1/transacciones/?estado=revertida,liquidada", nil)
+	assert.Equal(t, http.StatusOK, filtro.Code)
+	assert.Nil(t, json.Unmarshal(filtro.Body.Bytes(), &filtradas))
+	assert.Equal(t, []transaccionConEstado{{Id: 2, Estado: "revertida"}}, filtradas.Data)
+	assert.Equal(t, http.StatusBadRequest, enviar(http.MethodGet, "/api/v1/transacciones/?estado=borrada", nil).Code)
+}
+
+func TestReversa(t *testing.T) {
+	tempFileName := "transacciones_reversa_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type reversa struct {
+		Id         int    `json:"id"`
+		Monto      string `json:"monto"`
+		Emisor     string `json:"emisor"`
+		Referencia int    `json:"referencia"`
+	}
+	type detalle struct {
+		Estado         string    `json:"estado"`
+		Reversas       []reversa `json:"reversas"`
+		MontoRevertido string    `json:"monto_revertido"`
+	}
+
+	enviar := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	pendiente := enviar(http.MethodPost, "/api/v1/transacciones/3/reversa", nil)
+	enviar(http.MethodPost, "/api/v1/transacciones/3/autorizar", nil)
+	enviar(http.MethodPost, "/api/v1/transacciones/3/liquidar", nil)
+	parcial := enviar(http.MethodPost, "/api/v1/transacciones/3/reversa", map[string]string{"monto": "200.00", "fecha_transaccion": "2022-04-02"})
+	excedida := enviar(http.MethodPost, "/api/v1/transacciones/3/reversa", map[string]string{"monto": "300.01"})
+	resto := enviar(http.MethodPost, "/api/v1/transacciones/3/reversa", nil)
+
+	assert.Equal(t, http.StatusConflict, pendiente.Code)
+	assert.Equal(t, http.StatusOK, parcial.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, excedida.Code)
+	assert.Equal(t, http.StatusOK, resto.Code)
+
+	var resBody struct {
+		Data detalle `json:"data"`
+	}
+	res := enviar(http.MethodGet, "/api/v1/transacciones/3", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	assert.Equal(t, "revertida", resBody.Data.Estado)
+	assert.Equal(t, "500.00", resBody.Data.MontoRevertido)
+	assert.Equal(t, []reversa{
+		{Id: 7, Monto: "200.00", Emisor: "Pablo", Referencia: 3},
+		{Id: 8, Monto: "300.00", Emisor: "Pablo", Referencia: 3},
+	}, resBody.Data.Reversas)
+
+	assert.Equal(t, http.StatusConflict, enviar(http.MethodDelete, "/api/v1/transacciones/3", nil).Code)
+	assert.Equal(t, http.StatusConflict, enviar(http.MethodDelete, "/api/v1/transacciones/7", nil).Code)
+}
+
+func TestLibroMayor(t *testing.T) {
+	tempFileName := "transacciones_libro_temp.json"
+	libroFileName := "libro_temp.json"
+	t.Setenv("LIBRO_FILE", libroFileName)
+	removeFileStore(libroFileName)
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+	defer removeFileStore(libroFileName)
+
+	type saldo struct {
+		Moneda string `json:"moneda"`
+		Saldo  string `json:"saldo"`
+	}
+	type movimiento struct {
+		TransaccionId int    `json:"transaccion_id"`
+		Monto         string `json:"monto"`
+		Concepto      string `json:"concepto"`
+		Saldo         string `json:"saldo"`
+	}
+
+	enviar := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	saldoDe := func(cuenta string) []saldo {
+		var resBody struct {
+			Data []saldo `json:"data"`
+		}
+		res := enviar(http.MethodGet, "/api/v1/cuentas/"+cuenta+"/saldo", nil)
+		assert.Equal(t, http.StatusOK, res.Code, cuenta)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		return resBody.Data
+	}
+
+	assert.Equal(t, []saldo{{"MXN", "-4500.00"}}, saldoDe("Bancomer"))
+	assert.Equal(t, []saldo{{"MXN", "500.00"}}, saldoDe("Pablo"))
+
+	enviar(http.MethodPatch, "/api/v1/transacciones/3", map[string]string{"codigo_transaccion": "ct3", "monto": "100"})
+	assert.Equal(t, []saldo{{"MXN", "-4100.00"}}, saldoDe("Bancomer"))
+
+	enviar(http.MethodPost, "/api/v1/transacciones/2/rechazar", nil)
+	assert.Equal(t, []saldo{{"MXN", "-100.00"}}, saldoDe("Bancomer"))
+	assert.Equal(t, []saldo{{"MXN", "0.00"}}, saldoDe("Pedrito"))
+
+	enviar(http.MethodDelete, "/api/v1/transacciones/3", nil)
+	assert.Equal(t, []saldo{{"MXN", "0.00"}}, saldoDe("Bancomer"))
+
+	var resBody struct {
+		Data []movimiento `json:"data"`
+	}
+	res := enviar(http.MethodGet, "/api/v1/cuentas/Pablo/movimientos?moneda=mxn", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	assert.Equal(t, []movimiento{
+		{TransaccionId: 3, Monto: "500.00", Concepto: "registro", Saldo: "500.00"},
+		{TransaccionId: 3, Monto: "-500.00", Concepto: "ajuste", Saldo: "0.00"},
+		{TransaccionId: 3, Monto: "100.00", Concepto: "registro", Saldo: "100.00"},
+		{TransaccionId: 3, Monto: "-100.00", Concepto: "ajuste", Saldo: "0.00"},
+	}, resBody.Data)
+	assert.Equal(t, http.StatusNotFound, enviar(http.MethodGet, "/api/v1/cuentas/Nadie/saldo", nil).Code)
+}
+
+func TestLibroMayorSinTransacciones(t *testing.T) {
+	tempFileName := "transacciones_libro_vacio_temp.json"
+	vacioFileName := "transacciones_vacio_temp.json"
+	libroFileName := "libro_vacio_temp.json"
+	t.Setenv("LIBRO_FILE", libroFileName)
+	removeFileStore(libroFileName)
+	defer removeFileStore(tempFileName)
+	defer removeFileStore(vacioFileName)
+	defer removeFileStore(libroFileName)
+	assert.Nil(t, os.WriteFile(vacioFileName, []byte("[]"), 0644))
+
+	saldoDe := func(router http.Handler, cuenta string) string {
+		var resBody struct {
+			Data []struct {
+				Saldo string `json:"saldo"`
+			} `json:"data"`
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/cuentas/"+cuenta+"/saldo", nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+		assert.Equal(t, 1, len(resBody.Data))
+		return resBody.Data[0].Saldo
+	}
+
+	conTransacciones := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	saldoInicial := saldoDe(conTransacciones, "Bancomer")
+	sinTransacciones := engine.GetEngine(vacioFileName, tempFileName, "./../.env")
+
+	assert.Equal(t, "-4500.00", saldoInicial)
+	assert.Equal(t, "0.00", saldoDe(sinTransacciones, "Bancomer"))
+}
+
+func TestPartes(t *testing.T) {
+	tempFileName := "transacciones_partes_temp.json"
+	partesFileName := "partes_temp.json"
+	contenido, _ := os.ReadFile("partes.json")
+	assert.Nil(t, os.WriteFile(partesFileName, contenido, 0666))
+	t.Setenv("PARTES_FILE", partesFileName)
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+	defer removeFileStore(partesFileName)
+
+	type parte struct {
+		Id     int    `json:"id"`
+		Nombre string `json:"nombre"`
+		Tipo   string `json:"tipo"`
+		Estado string `json:"estado"`
+	}
+	type response struct {
+		Data parte `json:"data"`
+	}
+
+	enviar := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	var lista struct {
+		Data []parte `json:"data"`
+	}
+	res := enviar(http.MethodGet, "/api/v1/partes", nil)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &lista))
+	assert.Len(t, lista.Data, 7)
+
+	duplicada := enviar(http.MethodPost, "/api/v1/partes", map[string]string{"nombre": " BANCOMER ", "tipo": "banco"})
+	tipoNoValido := enviar(http.MethodPost, "/api/v1/partes", map[string]string{"nombre": "Oxxo", "tipo": "tienda"})
+	assert.Equal(t, http.StatusConflict, duplicada.Code)
+	assert.Equal(t, http.StatusBadRequest, tipoNoValido.Code)
+
+	var nueva response
+	res = enviar(http.MethodPost, "/api/v1/partes", map[string]string{"nombre": "  Oxxo  Centro", "tipo": "comercio"})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &nueva))
+	assert.Equal(t, parte{Id: 8, Nombre: "Oxxo Centro", Tipo: "comercio", Estado: "activa"}, nueva.Data)
+
+	transaccionNueva := func(codigo string, emisorId, receptorId int) transaccion {
+		return transaccion{CodigoTransaccion: codigo, Moneda: "MXN", Monto: "10.00", EmisorId: emisorId, ReceptorId: receptorId, FechaTransaccion: "23/04/2022"}
+	}
+	var almacenada struct {
+		Data transaccion `json:"data"`
+	}
+	res = enviar(http.MethodPost, "/api/v1/transacciones/0", transaccionNueva("ctr-oxxo", 2, 8))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &almacenada))
+	assert.Equal(t, "Pedrito", almacenada.Data.Emisor)
+	assert.Equal(t, "Oxxo Centro", almacenada.Data.Receptor)
+
+	res = enviar(http.MethodPut, "/api/v1/partes/8", map[string]string{"nombre": "Oxxo Centro", "tipo": "comercio", "estado": "inactiva"})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, http.StatusNotFound, enviar(http.MethodGet, "/api/v1/partes/99", nil).Code)
+
+	inactiva := enviar(http.MethodPost, "/api/v1/transacciones/0", transaccionNueva("ctr-inactiva", 2, 8))
+	noExiste := enviar(http.MethodPost, "/api/v1/transacciones/0", transaccionNueva("ctr-no-existe", 99, 2))
+	sinEmisor := enviar(http.MethodPost, "/api/v1/transacciones/0", transaccionNueva("ctr-sin-emisor", 0, 2))
+	assert.Equal(t, http.StatusUnprocessableEntity, inactiva.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, noExiste.Code)
+	assert.Equal(t, http.StatusBadRequest, sinEmisor.Code)
+}
+
+func TestLimites(t *testing.T) {
+	tempFileName := "transacciones_limites_temp.json"
+	limitesFileName := "limites_temp.json"
+	limites := `[{"emisor_id": 1, "moneda": "MXN", "monto_maximo": "1000.00", "total_diario": "1500.00"}]`
+	assert.Nil(t, os.WriteFile(limitesFileName, []byte(limites), 0666))
+	t.Setenv("LIMITES_FILE", limitesFileName)
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+	defer removeFileStore(limitesFileName)
+
+	type excedido struct {
+		Limite     string `json:"limite"`
+		EmisorId   int    `json:"emisor_id"`
+		Permitido  string `json:"permitido"`
+		Acumulado  string `json:"acumulado"`
+		Disponible string `json:"disponible"`
+	}
+	type response struct {
+		Data  excedido `json:"data"`
+		Error string   `json:"error"`
+	}
+
+	enviar := func(codigo, monto, fechaTransaccion string) (*httptest.ResponseRecorder, response) {
+		reqBody, _ := json.Marshal(transaccion{CodigoTransaccion: codigo, Moneda: "MXN", Monto: monto, EmisorId: 1, ReceptorId: 3, FechaTransaccion: fechaTransaccion})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transacciones/0", bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		var resBody response
+		_ = json.Unmarshal(res.Body.Bytes(), &resBody)
+		return res, resBody
+	}
+
+	maximo, maximoBody := enviar("ctr-maximo", "1000.01", "23/04/2022")
+	assert.Equal(t, http.StatusUnprocessableEntity, maximo.Code)
+	assert.Equal(t, excedido{Limite: "monto_maximo", EmisorId: 1, Permitido: "1000.00", Acumulado: "0.00", Disponible: "1000.00"}, maximoBody.Data)
+	assert.NotEmpty(t, maximoBody.Error)
+
+	permitida, _ := enviar("ctr-permitida", "1000.00", "01/04/2022")
+	assert.Equal(t, http.StatusOK, permitida.Code)
+
+	diario, diarioBody := enviar("ctr-diario", "0.01", "01/04/2022")
+	assert.Equal(t, http.StatusUnprocessableEntity, diario.Code)
+	assert.Equal(t, excedido{Limite: "total_diario", EmisorId: 1, Permitido: "1500.00", Acumulado: "1500.00", Disponible: "0.00"}, diarioBody.Data)
+
+	otroDia, _ := enviar("ctr-otro-dia", "0.01", "02/04/2022")
+	assert.Equal(t, http.StatusOK, otroDia.Code)
+}
+
+func TestRevisionRiesgo(t *testing.T) {
+	tempFileName := "transacciones_riesgo_temp.json"
+	reglasFileName := "reglas_temp.txt"
+	reglas := "# reglas de prueba\nusd_alto: monto > 10000 and moneda == USD -> review 60\nmonto > 1000000 -> reject\n"
+	assert.Nil(t, os.WriteFile(reglasFileName, []byte(reglas), 0666))
+	t.Setenv("REGLAS_FILE", reglasFileName)
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+	defer removeFileStore(reglasFileName)
+
+	type riesgo struct {
+		Accion   string   `json:"accion"`
+		Puntaje  int      `json:"puntaje"`
+		Reglas   []string `json:"reglas"`
+		Aprobada bool     `json:"aprobada"`
+	}
+	type conRiesgo struct {
+		Id     int    `json:"id"`
+		Riesgo riesgo `json:"riesgo"`
+	}
+
+	enviar := func(metodo, url string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(metodo, url, bytes.NewBuffer(reqBody))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	nueva := func(codigo, monto string) transaccion {
+		return transaccion{CodigoTransaccion: codigo, Moneda: "USD", Monto: monto, EmisorId: 1, ReceptorId: 3, FechaTransaccion: "23/04/2022"}
+	}
+
+	rechazada := enviar(http.MethodPost, "/api/v1/transacciones/0", nueva("ctr-rechazada", "2000000.00"))
+	assert.Equal(t, http.StatusUnprocessableEntity, rechazada.Code)
+	var rechazadaBody struct {
+		Data riesgo `json:"data"`
+	}
+	_ = json.Unmarshal(rechazada.Body.Bytes(), &rechazadaBody)
+	assert.Equal(t, riesgo{Accion: "reject", Puntaje: 100, Reglas: []string{"usd_alto", "linea_3"}}, rechazadaBody.Data)
+
+	marcada := enviar(http.MethodPost, "/api/v1/transacciones/0", nueva("ctr-marcada", "20000.00"))
+	assert.Equal(t, http.StatusOK, marcada.Code)
+	var marcadaBody struct {
+		Data conRiesgo `json:"data"`
+	}
+	_ = json.Unmarshal(marcada.Body.Bytes(), &marcadaBody)
+	assert.Equal(t, riesgo{Accion: "flag", Puntaje: 60, Reglas: []string{"usd_alto"}}, marcadaBody.Data.Riesgo)
+	url := fmt.Sprintf("/api/v1/transacciones/%d/autorizar", marcadaBody.Data.Id)
+
+	revision := enviar(http.MethodGet, "/api/v1/revision", nil)
+	var revisionBody struct {
+		Data []conRiesgo `json:"data"`
+	}
+	_ = json.Unmarshal(revision.Body.Bytes(), &revisionBody)
+	assert.Equal(t, http.StatusOK, revision.Code)
+	assert.Equal(t, []conRiesgo{marcadaBody.Data}, revisionBody.Data)
+
+	assert.Equal(t, http.StatusConflict, enviar(http.MethodPost, url, nil).Code)
+	assert.Equal(t, http.StatusConflict, enviar(http.MethodPost, "/api/v1/revision/2/aprobar", nil).Code)
+	assert.Equal(t, http.StatusOK, enviar(http.MethodPost, fmt.Sprintf("/api/v1/revision/%d/aprobar", marcadaBody.Data.Id), nil).Code)
+	assert.Equal(t, http.StatusOK, enviar(http.MethodPost, url, nil).Code)
+
+	vacia := enviar(http.MethodGet, "/api/v1/revision", nil)
+	_ = json.Unmarshal(vacia.Body.Bytes(), &revisionBody)
+	assert.Empty(t, revisionBody.Data)
+}
+
+func TestImportar(t *testing.T) {
+	tempFileName := "transacciones_importar_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type filaReporte struct {
+		Linea  int    `json:"linea"`
+		Estado string `json:"estado"`
+		Id     int    `json:"id"`
+		Error  string `json:"error"`
+	}
+	type reporte struct {
+		Aceptadas  int           `json:"aceptadas"`
+		Rechazadas int           `json:"rechazadas"`
+		Canceladas int           `json:"canceladas"`
+		Filas      []filaReporte `json:"filas"`
+	}
+	importar := func(url, contentType string, body *bytes.Buffer) (*httptest.ResponseRecorder, reporte) {
+		req := httptest.NewRequest(http.MethodPost, url, body)
+		req.Header.Add("Content-Type", contentType)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		var resBody struct {
+			Data reporte `json:"data"`
+		}
+		_ = json.Unmarshal(res.Body.Bytes(), &resBody)
+		return res, resBody.Data
+	}
+	contar := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/transacciones", nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		var resBody struct {
+			Data []transaccion `json:"data"`
+		}
+		_ = json.Unmarshal(res.Body.Bytes(), &resBody)
+		return len(resBody.Data)
+	}
+	previas := contar()
+
+	archivoCSV := "codigo_transaccion,moneda,monto,emisor_id,receptor_id,fecha_transaccion\n" +
+		"imp-1,MXN,100.00,1,3,23/04/2022\n" +
+		"imp-2,MXN,,1,3,23/04/2022\n" +
+		"imp-3,MXN,300.00,1,99,23/04/2022\n" +
+		"imp-4,MXN,400.00,4,5,2022-04-23T10:00:00-05:00\n"
+
+	completo, completoBody := importar("/api/v1/transacciones/importar", "text/csv", bytes.NewBufferString(archivoCSV))
+	assert.Equal(t, http.StatusUnprocessableEntity, completo.Code)
+	assert.Equal(t, 1, completoBody.Rechazadas)
+	assert.Equal(t, 3, completoBody.Canceladas)
+	assert.Equal(t, previas, contar())
+
+	parcial, parcialBody := importar("/api/v1/transacciones/importar?modo=parcial", "text/csv", bytes.NewBufferString(archivoCSV))
+	assert.Equal(t, http.StatusOK, parcial.Code)
+	assert.Equal(t, 2, parcialBody.Aceptadas)
+	assert.Equal(t, 2, parcialBody.Rechazadas)
+	assert.Equal(t, []int{2, 3, 4, 5}, []int{parcialBody.Filas[0].Linea, parcialBody.Filas[1].Linea, parcialBody.Filas[2].Linea, parcialBody.Filas[3].Linea})
+	assert.Equal(t, "rechazada", parcialBody.Filas[1].Estado)
+	assert.Contains(t, parcialBody.Filas[1].Error, "monto")
+	assert.Equal(t, "rechazada", parcialBody.Filas[2].Estado)
+	assert.Equal(t, previas+2, contar())
+
+	archivoNDJSON := `{"referencia": "imp-5", "divisa": "USD", "importe": 10.5, "emisor_id": 4, "receptor_id": 5, "fecha_transaccion": "24/04/2022"}` + "\n\n" +
+		`{"referencia": "imp-6", "divisa": "USD", "importe": "20.00", "emisor_id": 4, "receptor_id": 5, "fecha_transaccion": "24/04/2022"}` + "\n"
+	body := &bytes.Buffer{}
+	formulario := multipart.NewWriter(body)
+	parte, _ := formulario.CreateFormFile("archivo", "lote.ndjson")
+	_, _ = parte.Write([]byte(archivoNDJSON))
+	_ = formulario.Close()
+	ndjson, ndjsonBody := importar("/api/v1/transacciones/importar?mapeo=codigo_transaccion:referencia,moneda:divisa,monto:importe", formulario.FormDataContentType(), body)
+	assert.Equal(t, http.StatusOK, ndjson.Code)
+	assert.Equal(t, 2, ndjsonBody.Aceptadas)
+	assert.Equal(t, 3, ndjsonBody.Filas[1].Linea)
+	assert.Equal(t, previas+4, contar())
+
+	sinFormato, _ := importar("/api/v1/transacciones/importar", "application/octet-stream", bytes.NewBufferString(archivoCSV))
+	assert.Equal(t, http.StatusBadRequest, sinFormato.Code)
+	malMapeo, _ := importar("/api/v1/transacciones/importar?mapeo=pais:country", "text/csv", bytes.NewBufferString(archivoCSV))
+	assert.Equal(t, http.StatusBadRequest, malMapeo.Code)
+}
+
+func TestExportar(t *testing.T) {
+	tempFileName := "transacciones_exportar_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	exportar := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/transacciones/exportar"+query, nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	csv := exportar("?receptor=Lestat&columnas=id,codigo_transaccion,monto,emisor&locale=es-ES&sort=-monto")
+	assert.Equal(t, http.StatusOK, csv.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", csv.Header().Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename="transacciones_\d{8}-\d{6}\.csv"$`, csv.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id;codigo_transaccion;monto;emisor\n5;ctr5;800,00;Banregio\n6;ctr;230,00;Banregio\n", csv.Body.String())
+
+	ndjson := exportar("?formato=ndjson&columnas=id,monto&moneda=USD")
+	assert.Equal(t, http.StatusOK, ndjson.Code)
+	assert.Equal(t, "application/x-ndjson", ndjson.Header().Get("Content-Type"))
+	assert.Empty(t, ndjson.Body.String())
+
+	xlsx := exportar("?formato=xlsx")
+	assert.Equal(t, http.StatusOK, xlsx.Code)
+	assert.Contains(t, xlsx.Header().Get("Content-Disposition"), ".xlsx")
+	assert.Equal(t, "PK", xlsx.Body.String()[:2])
+
+	assert.Equal(t, http.StatusBadRequest, exportar("?formato=pdf").Code)
+	assert.Equal(t, http.StatusBadRequest, exportar("?columnas=saldo").Code)
+	assert.Equal(t, http.StatusBadRequest, exportar("?monto_min=abc").Code)
+}
+
+func TestISO20022(t *testing.T) {
+	tempFileName := "transacciones_iso20022_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	enviar := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Add("Content-Type", "application/xml")
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	pain := enviar(http.MethodGet, "/api/v1/transacciones/iso20022/pain001?emisor=Banregio&iniciador=Tesoreria", "")
+	assert.Equal(t, http.StatusOK, pain.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", pain.Header().Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename="pain001_\d{8}-\d{6}\.xml"$`, pain.Header().Get("Content-Disposition"))
+	assert.Contains(t, pain.Body.String(), "<NbOfTxs>2</NbOfTxs>\n      <CtrlSum>1030.00</CtrlSum>")
+	assert.Contains(t, pain.Body.String(), "<EndToEndId>ctr5</EndToEndId>")
+
+	// El mismo documento con otros codigos da de alta dos transacciones.
+	documento := strings.NewReplacer("<EndToEndId>ctr5<", "<EndToEndId>iso-1<", "<EndToEndId>ctr<", "<EndToEndId>iso-2<").Replace(pain.Body.String())
+	importado := enviar(http.MethodPost, "/api/v1/transacciones/iso20022/pain001", documento)
+	assert.Equal(t, http.StatusOK, importado.Code)
+	assert.Contains(t, importado.Body.String(), `"aceptadas":2`)
+
+	repetido := enviar(http.MethodPost, "/api/v1/transacciones/iso20022/pain001", documento)
+	assert.Equal(t, http.StatusUnprocessableEntity, repetido.Code)
+	assert.Contains(t, repetido.Body.String(), `"rechazadas":2,"canceladas":0`)
+
+	noValido := enviar(http.MethodPost, "/api/v1/transacciones/iso20022/pain001", strings.Replace(documento, "<CtrlSum>1030.00</CtrlSum>", "<CtrlSum>1.00</CtrlSum>", 1))
+	assert.Equal(t, http.StatusBadRequest, noValido.Code)
+	assert.Contains(t, noValido.Body.String(), "GrpHdr/CtrlSum")
+
+	camt := enviar(http.MethodGet, "/api/v1/transacciones/iso20022/camt053?parte=7&moneda=mxn", "")
+	assert.Equal(t, http.StatusOK, camt.Code)
+	assert.Contains(t, camt.Header().Get("Content-Disposition"), "camt053_7_MXN_")
+	assert.Equal(t, 4, strings.Count(camt.Body.String(), "<CdtDbtInd>CRDT</CdtDbtInd>\n        <Sts>BOOK</Sts>"))
+	// El saldo de cierre es el mismo que el de la cuenta en el libro.
+	assert.Contains(t, camt.Body.String(), "<Cd>CLBD</Cd>\n          </CdOrPrtry>\n        </Tp>\n        <Amt Ccy=\"MXN\">2060.00</Amt>\n        <CdtDbtInd>CRDT</CdtDbtInd>")
+	assert.Contains(t, enviar(http.MethodGet, "/api/v1/cuentas/Lestat/saldo", "").Body.String(), `"saldo":"2060.00"`)
+	assert.Contains(t, camt.Body.String(), "<Nm>Lestat</Nm>")
+
+	assert.Equal(t, http.StatusBadRequest, enviar(http.MethodGet, "/api/v1/transacciones/iso20022/camt053?moneda=MXN", "").Code)
+	assert.Equal(t, http.StatusNotFound, enviar(http.MethodGet, "/api/v1/transacciones/iso20022/camt053?parte=99&moneda=MXN", "").Code)
+	assert.Equal(t, http.StatusNotFound, enviar(http.MethodGet, "/api/v1/transacciones/iso20022/pain001?emisor=Nadie", "").Code)
+}
+
+func TestExtractoMT940(t *testing.T) {
+	tempFileName := "transacciones_mt940_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	enviar := func(contentType string, body *bytes.Buffer) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/extractos/mt940", body)
+		req.Header.Add("Content-Type", contentType)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	archivo, err := os.ReadFile("./../internal/mt940/testdata/swift.mt940")
+	assert.Nil(t, err)
+	var formulario bytes.Buffer
+	escritor := multipart.NewWriter(&formulario)
+	parte, _ := escritor.CreateFormFile("archivo", "swift.mt940")
+	_, _ = parte.Write(archivo)
+	_ = escritor.Close()
+
+	res := enviar(escritor.FormDataContentType(), &formulario)
+	assert.Equal(t, http.StatusOK, res.Code)
+	var resBody struct {
+		Data []struct {
+			Referencia string `json:"referencia"`
+			Lineas     []struct {
+				Monto      string `json:"monto"`
+				Referencia string `json:"referencia"`
+			} `json:"lineas"`
+		} `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	assert.Equal(t, 2, len(resBody.Data))
+	assert.Equal(t, "STMT220421", resBody.Data[0].Referencia)
+	assert.Equal(t, "-4000.00", resBody.Data[0].Lineas[0].Monto)
+	assert.Equal(t, "ctr2", resBody.Data[0].Lineas[0].Referencia)
+
+	noValido := enviar("text/plain", bytes.NewBufferString(":20:REF\n:60F:C220420MXN100,00\n:62F:C220421MXN100,00\n-\n"))
+	assert.Equal(t, http.StatusBadRequest, noValido.Code)
+	assert.Contains(t, noValido.Body.String(), "el extracto no tiene cuenta")
+}
+
+func TestConciliaciones(t *testing.T) {
+	tempFileName := "transacciones_conciliaciones_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type resumen struct {
+		Conciliadas      int `json:"conciliadas"`
+		DiferenciasMonto int `json:"diferencias_monto"`
+		SoloInternas     int `json:"solo_internas"`
+		SoloExternas     int `json:"solo_externas"`
+	}
+	type conciliacion struct {
+		Id      int     `json:"id"`
+		Fuente  string  `json:"fuente"`
+		Resumen resumen `json:"resumen"`
+	}
+	enviar := func(method, url, contentType, body string) (*httptest.ResponseRecorder, conciliacion) {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Add("Content-Type", contentType)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		var resBody struct {
+			Data conciliacion `json:"data"`
+		}
+		_ = json.Unmarshal(res.Body.Bytes(), &resBody)
+		return res, resBody.Data
+	}
+
+	// ctr5 se concilia por codigo y ctr, sin codigo en el extracto, por monto
+	// y fecha; la linea x-1 no es de ninguna transaccion de Banregio.
+	extracto := `{"parte_id": 6, "lineas": [
+		{"codigo_transaccion": "ctr5", "moneda": "MXN", "monto": "-800.00", "fecha": "2022-04-20"},
+		{"moneda": "MXN", "monto": "-230.00", "fecha": "20/04/2022"},
+		{"codigo_transaccion": "x-1", "moneda": "MXN", "monto": "50.00", "fecha": "2022-04-20"}]}`
+	res, desdeJSON := enviar(http.MethodPost, "/api/v1/conciliaciones", "application/json", extracto)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "json", desdeJSON.Fuente)
+	assert.Equal(t, resumen{Conciliadas: 2, SoloExternas: 1}, desdeJSON.Resumen)
+
+	res, guardada := enviar(http.MethodGet, fmt.Sprintf("/api/v1/conciliaciones/%d", desdeJSON.Id), "", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, desdeJSON, guardada)
+	assert.Contains(t, res.Body.String(), `"criterio":"monto_fecha"`)
+
+	lista, _ := enviar(http.MethodGet, "/api/v1/conciliaciones", "", "")
+	assert.Equal(t, http.StatusOK, lista.Code)
+	assert.Contains(t, lista.Body.String(), fmt.Sprintf(`{"id":%d,`, desdeJSON.Id))
+	assert.NotContains(t, lista.Body.String(), `"conciliadas":[`)
+
+	exportada, _ := enviar(http.MethodGet, fmt.Sprintf("/api/v1/conciliaciones/%d/exportar", desdeJSON.Id), "", "")
+	assert.Equal(t, http.StatusOK, exportada.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", exportada.Header().Get("Content-Type"))
+	assert.Contains(t, exportada.Body.String(), "\nconciliada,codigo,5,ctr5,MXN,800.00,")
+	assert.Contains(t, exportada.Body.String(), "\nsolo_externa,,,,MXN,,,3,x-1,50.00,2022-04-20,")
+
+	// ct3 aparece en el extracto con otro monto.
+	mt940 := ":20:BBVA0404\n:25:0123456789\n:60F:C220331MXN10000,00\n" +
+		":61:220401D500,50NTRFct3\n:61:220404D4000,00NTRFctr2\n:62F:C220404MXN5499,50\n-\n"
+	res, desdeMT940 := enviar(http.MethodPost, "/api/v1/conciliaciones?parte_id=1", "text/plain", mt940)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "mt940", desdeMT940.Fuente)
+	assert.Equal(t, resumen{Conciliadas: 1, DiferenciasMonto: 1}, desdeMT940.Resumen)
+	assert.Contains(t, res.Body.String(), `"diferencia":"0.50"`)
+
+	noValida, _ := enviar(http.MethodPost, "/api/v1/conciliaciones", "application/json", `{"lineas": []}`)
+	assert.Equal(t, http.StatusBadRequest, noValida.Code)
+	assert.Contains(t, noValida.Body.String(), "el extracto no tiene lineas")
+	sinCuenta, _ := enviar(http.MethodPost, "/api/v1/conciliaciones", "text/plain", strings.Replace(mt940, ":25:0123456789\n", "", 1))
+	assert.Equal(t, http.StatusBadRequest, sinCuenta.Code)
+	formato, _ := enviar(http.MethodGet, fmt.Sprintf("/api/v1/conciliaciones/%d/exportar?formato=xlsx", desdeJSON.Id), "", "")
+	assert.Equal(t, http.StatusBadRequest, formato.Code)
+	noExiste, _ := enviar(http.MethodGet, "/api/v1/conciliaciones/0", "", "")
+	assert.Equal(t, http.StatusNotFound, noExiste.Code)
+}
+
+func TestReporteResumen(t *testing.T) {
+	tempFileName := "transacciones_reporte_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	type grupo struct {
+		Claves   map[string]string `json:"claves"`
+		Cantidad int               `json:"cantidad"`
+		Suma     string            `json:"suma"`
+		Minimo   string            `json:"minimo"`
+		Maximo   string            `json:"maximo"`
+		Promedio string            `json:"promedio"`
+	}
+	resumir := func(query string) (*httptest.ResponseRecorder, []string, []grupo) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/reportes/resumen"+query, nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		var resBody struct {
+			Data struct {
+				Agrupar []string `json:"agrupar"`
+				Grupos  []grupo  `json:"grupos"`
+			} `json:"data"`
+		}
+		_ = json.Unmarshal(res.Body.Bytes(), &resBody)
+		return res, resBody.Data.Agrupar, resBody.Data.Grupos
+	}
+
+	res, agrupar, grupos := resumir("?agrupar=emisor")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, []string{"moneda", "emisor"}, agrupar)
+	assert.Equal(t, 3, len(grupos))
+	assert.Equal(t, grupo{Claves: map[string]string{"moneda": "MXN", "emisor": "Bancomer"}, Cantidad: 2,
+		Suma: "4500.00", Minimo: "500.00", Maximo: "4000.00", Promedio: "2250.00"}, grupos[1])
+
+	res, _, grupos = resumir("?agrupar=mes,dia&emisor=Banregio")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, 1, len(grupos))
+	assert.Equal(t, map[string]string{"moneda": "MXN", "mes": "2022-04", "dia": "2022-04-20"}, grupos[0].Claves)
+	assert.Equal(t, "1030.00", grupos[0].Suma)
+	assert.Equal(t, "515.00", grupos[0].Promedio)
+
+	res, _, grupos = resumir("?agrupar=semana&emisor=Nadie")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, 0, len(grupos))
+
+	res, _, _ = resumir("?agrupar=anio")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	res, _, _ = resumir("?monto_min=abc")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestEstadoCuenta(t *testing.T) {
+	tempFileName := "transacciones_estado_cuenta_temp.json"
+	router := engine.GetEngine(FILE_STORE, tempFileName, "./../.env")
+	defer removeFileStore(tempFileName)
+
+	consultar := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/estados-cuenta/"+query, nil)
+		req.Header.Add("authorization", "12345")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	// ct3 es del 1 de abril: queda en el saldo inicial.
+	res := consultar("bancomer?fecha_desde=2022-04-02&fecha_hasta=2022-04-30")
+	assert.Equal(t, http.StatusOK, res.Code)
+	var resBody struct {
+		Data struct {
+			Parte     string `json:"parte"`
+			Secciones []struct {
+				Moneda       string `json:"moneda"`
+				SaldoInicial string `json:"saldo_inicial"`
+				Cargos       string `json:"cargos"`
+				SaldoFinal   string `json:"saldo_final"`
+				Lineas       []struct {
+					CodigoTransaccion string `json:"codigo_transaccion"`
+					Contraparte       string `json:"contraparte"`
+					Monto             string `json:"monto"`
+					Saldo             string `json:"saldo"`
+				} `json:"lineas"`
+			} `json:"secciones"`
+		} `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	assert.Equal(t, "Bancomer", resBody.Data.Parte)
+	assert.Equal(t, 1, len(resBody.Data.Secciones))
+	mxn := resBody.Data.Secciones[0]
+	assert.Equal(t, "MXN", mxn.Moneda)
+	assert.Equal(t, "-500.00", mxn.SaldoInicial)
+	assert.Equal(t, "4000.00", mxn.Cargos)
+	assert.Equal(t, "-4500.00", mxn.SaldoFinal)
+	assert.Equal(t, 1, len(mxn.Lineas))
+	assert.Equal(t, "ctr2", mxn.Lineas[0].CodigoTransaccion)
+	assert.Equal(t, "Pedrito", mxn.Lineas[0].Contraparte)
+	assert.Equal(t, "-4000.00", mxn.Lineas[0].Monto)
+	assert.Equal(t, "-4500.00", mxn.Lineas[0].Saldo)
+
+	res = consultar("Lestat?fecha_hasta=2022-04-30&formato=csv")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="estado_cuenta_7.csv"`, res.Header().Get("Content-Disposition"))
+	filas := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	assert.Equal(t, 5, len(filas))
+	assert.Equal(t, "MXN,,saldo inicial,,,,,,,0.00", filas[1])
+	assert.Equal(t, "MXN,2022-04-30T23:59:59-05:00,saldo final,,,,,0.00,1030.00,1030.00", filas[4])
+
+	res = consultar("Pablo?formato=html")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Contains(t, res.Body.String(), "Estado de cuenta de Pablo")
+
+	res = consultar("Nadie")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = consultar("Pablo?formato=pdf")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = consultar("Pablo?fecha_desde=2022-04-30&fecha_hasta=2022-04-01")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
 }